@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// jsonSchema renders the docs as a JSON Schema document
+type jsonSchema struct{}
+
+// jsonSchemaGo renders the docs as a JSON Schema document
+// that is embedded in a Go source file
+type jsonSchemaGo struct {
+	pkg     string
+	varName string
+}
+
+type schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 interface{}        `json:"type"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Deprecated           bool               `json:"deprecated,omitempty"`
+}
+
+func (jsonSchema) render(docs []doc) {
+	b, err := buildJSONSchema(docs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error building JSON schema: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(b))
+}
+
+func (r jsonSchemaGo) render(docs []doc) {
+	b, err := buildJSONSchema(docs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error building JSON schema: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("// Code generated by gen-kismatic-ref-docs. DO NOT EDIT.")
+	fmt.Println()
+	fmt.Printf("package %s\n", r.pkg)
+	fmt.Println()
+	fmt.Printf("// %s is the JSON Schema of the plan file\n", r.varName)
+	// backticks cannot be escaped in a raw string literal
+	fmt.Printf("const %s = `%s\n`\n", r.varName, strings.Replace(string(b), "`", "` + \"`\" + `", -1))
+}
+
+// buildJSONSchema turns the flat list of docs into a tree of schemas.
+// The docs are expected to be in depth-first order, which means that
+// the parent of a property is always found before the property itself.
+func buildJSONSchema(docs []doc) ([]byte, error) {
+	root := &schema{
+		Schema:               "http://json-schema.org/draft-07/schema#",
+		Title:                "Kismatic Plan File",
+		Type:                 "object",
+		Properties:           map[string]*schema{},
+		AdditionalProperties: false,
+	}
+	// keep track of the object schema that holds the properties of each doc
+	objects := map[string]*schema{"": root}
+	for _, d := range docs {
+		parentPath, name := "", d.property
+		if i := strings.LastIndex(d.property, "."); i != -1 {
+			parentPath, name = d.property[:i], d.property[i+1:]
+		}
+		parent, ok := objects[parentPath]
+		if !ok {
+			return nil, fmt.Errorf("parent of property %q was not found", d.property)
+		}
+		s, err := schemaForDoc(d)
+		if err != nil {
+			return nil, err
+		}
+		parent.Properties[name] = s
+		if d.required {
+			parent.Required = append(parent.Required, name)
+		}
+		// record where the properties of this doc should be added
+		switch {
+		case s.Items != nil && s.Items.Properties != nil:
+			objects[d.property] = s.Items
+		case s.Properties != nil:
+			objects[d.property] = s
+		}
+	}
+	return json.MarshalIndent(root, "", "  ")
+}
+
+func schemaForDoc(d doc) (*schema, error) {
+	s := &schema{
+		Description: strings.TrimSpace(d.description),
+		Deprecated:  d.deprecated,
+	}
+	elemType := d.propertyType
+	isArray := strings.HasPrefix(d.propertyType, "[]")
+	if isArray {
+		elemType = strings.TrimPrefix(d.propertyType, "[]")
+	}
+	elem := s
+	if isArray {
+		elem = &schema{}
+		s.Type = []string{"array", "null"}
+		s.Items = elem
+	}
+
+	switch elemType {
+	case "string":
+		elem.Type = "string"
+	case "int":
+		elem.Type = "integer"
+	case "bool":
+		elem.Type = "boolean"
+	case "map[string]string":
+		elem.Type = []string{"object", "null"}
+		elem.AdditionalProperties = &schema{Type: "string"}
+	default:
+		elem.Type = "object"
+		if d.nullable {
+			elem.Type = []string{"object", "null"}
+		}
+		elem.Properties = map[string]*schema{}
+		elem.AdditionalProperties = false
+	}
+
+	for _, o := range d.options {
+		v, err := jsonValue(elemType, o)
+		if err != nil {
+			return nil, fmt.Errorf("invalid option for property %q: %v", d.property, err)
+		}
+		elem.Enum = append(elem.Enum, v)
+	}
+	// an empty string is the same as not setting an optional property
+	if len(elem.Enum) > 0 && elemType == "string" && !d.required {
+		elem.Enum = append(elem.Enum, "")
+	}
+	if d.defaultValue != "" && !isArray {
+		v, err := jsonValue(elemType, d.defaultValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default value for property %q: %v", d.property, err)
+		}
+		s.Default = v
+	}
+	return s, nil
+}
+
+// jsonValue converts the string found in the documentation markers to
+// a JSON value of the given type
+func jsonValue(typeName string, v string) (interface{}, error) {
+	switch typeName {
+	case "int":
+		return strconv.Atoi(v)
+	case "bool":
+		return strconv.ParseBool(v)
+	case "string":
+		// 'empty' is used to document that the default is the empty string
+		if v == "'empty'" {
+			return "", nil
+		}
+		return v, nil
+	default:
+		return nil, fmt.Errorf("values of type %q are not supported", typeName)
+	}
+}
@@ -22,6 +22,7 @@ type doc struct {
 	options      []string
 	required     bool
 	deprecated   bool
+	nullable     bool
 }
 
 func main() {
@@ -40,6 +41,10 @@ func main() {
 		r = markdown{}
 	case "markdown-table":
 		r = markdownTable{}
+	case "json-schema":
+		r = jsonSchema{}
+	case "json-schema-go":
+		// the renderer is created once the package name is known
 	default:
 		fmt.Fprintf(os.Stderr, "unknown output type: %s\n", *output)
 		os.Exit(1)
@@ -55,6 +60,9 @@ func main() {
 	}
 
 	m[file] = f
+	if *output == "json-schema-go" {
+		r = jsonSchemaGo{pkg: f.Name.Name, varName: typeName + "JSONSchema"}
+	}
 	apkg, _ := ast.NewPackage(fset, m, nil, nil) // error deliberately ignored
 	pkgDoc := godoc.New(apkg, "", 0)
 
@@ -105,6 +113,7 @@ func docForType(typeName string, allTypes []*godoc.Type, parentFieldName string)
 						if err != nil {
 							panic(err)
 						}
+						d.nullable = true
 						docs = append(docs, d)
 						if isStruct(typeName) {
 							docs = append(docs, docForType(typeName, allTypes, fieldName)...)
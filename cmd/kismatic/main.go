@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"math/rand"
@@ -8,7 +9,9 @@ import (
 
 	"github.com/apprenda/kismatic/pkg/cli"
 	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/ssh"
 	"github.com/apprenda/kismatic/pkg/util"
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 // Set via linker flag
@@ -21,6 +24,7 @@ func init() {
 
 func main() {
 	rand.Seed(time.Now().UnixNano())
+	ssh.SetPassphrasePrompt(promptForPassphrase)
 	cmd, err := cli.NewKismaticCommand(version, buildDate, os.Stdin, os.Stdout, os.Stderr)
 	if err != nil {
 		util.PrintColor(os.Stderr, util.Red, "Error initializing command: %v\n", err)
@@ -31,3 +35,18 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// promptForPassphrase reads the passphrase of the encrypted SSH key from the terminal
+func promptForPassphrase(keyFile string) ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		return nil, fmt.Errorf("SSH key %q is encrypted: set its passphrase in the %s environment variable", keyFile, ssh.PassphraseEnvVar)
+	}
+	fmt.Fprintf(os.Stderr, "Enter passphrase for key '%s': ", keyFile)
+	passphrase, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("error reading passphrase: %v", err)
+	}
+	return passphrase, nil
+}
@@ -6,11 +6,16 @@ import (
 	yaml "gopkg.in/yaml.v2"
 )
 
+// redactedSecret replaces the secrets of cluster catalogs that are recorded on disk
+const redactedSecret = "REDACTED"
+
 type ClusterCatalog struct {
 	Versions struct {
 		Kubernetes    string `yaml:"kubernetes"`
 		KubernetesYum string `yaml:"kubernetes_yum"`
 		KubernetesDeb string `yaml:"kubernetes_deb"`
+		// Images are the versions of the images that depend on the Kubernetes version
+		Images map[string]string `yaml:"images"`
 	}
 
 	ClusterName               string `yaml:"kubernetes_cluster_name"`
@@ -95,6 +100,10 @@ type ClusterCatalog struct {
 
 	CloudProvider string `yaml:"cloud_provider"`
 	CloudConfig   string `yaml:"cloud_config_local"`
+	// CloudConfigContents is the cloud provider configuration that was resolved
+	// from a secret reference. The runner writes it to a file of the run directory,
+	// which is removed once the playbook exits, and sets CloudConfig to the file.
+	CloudConfigContents string `yaml:"-"`
 
 	DNS struct {
 		Enabled  bool
@@ -197,6 +206,50 @@ func (c *ClusterCatalog) EnableRestart() {
 	c.ForceDockerRestart = true
 }
 
+// RestartedServices returns the services that are forced to restart
+func (c ClusterCatalog) RestartedServices() []string {
+	services := []string{}
+	for _, s := range []struct {
+		name    string
+		restart bool
+	}{
+		{"etcd", c.ForceEtcdRestart},
+		{"apiserver", c.ForceAPIServerRestart},
+		{"controller-manager", c.ForceControllerManagerRestart},
+		{"scheduler", c.ForceSchedulerRestart},
+		{"proxy", c.ForceProxyRestart},
+		{"kubelet", c.ForceKubeletRestart},
+		{"calico-node", c.ForceCalicoNodeRestart},
+		{"docker", c.ForceDockerRestart},
+	} {
+		if s.restart {
+			services = append(services, s.name)
+		}
+	}
+	return services
+}
+
+// Redacted returns a copy of the cluster catalog that does not contain
+// any secrets, which is safe to record on disk.
+func (c ClusterCatalog) Redacted() ClusterCatalog {
+	if c.AdminPassword != "" {
+		c.AdminPassword = redactedSecret
+	}
+	if c.DockerRegistryPassword != "" {
+		c.DockerRegistryPassword = redactedSecret
+	}
+	if c.CNI.Options.Weave.Password != "" {
+		c.CNI.Options.Weave.Password = redactedSecret
+	}
+	if c.CloudConfigContents != "" {
+		c.CloudConfigContents = ""
+		if c.CloudConfig == "" {
+			c.CloudConfig = redactedSecret
+		}
+	}
+	return c
+}
+
 func (c *ClusterCatalog) ToYAML() ([]byte, error) {
 	bytez, marshalErr := yaml.Marshal(c)
 	if marshalErr != nil {
@@ -10,7 +10,8 @@ type namedEvent struct {
 	Name string
 }
 
-type runnerResult struct {
+// RunnerResult is the result of running a task on a host
+type RunnerResult struct {
 	// Command is the command that was run
 	Command []string `json:"cmd"`
 	// Stdout captured when the command was run
@@ -28,9 +29,11 @@ type runnerResult struct {
 	MaxRetries int `json:"retries"`
 }
 
-type runnerResultEvent struct {
+// RunnerResultEvent is the result of running a task on a host, embedded in
+// the events of runners
+type RunnerResultEvent struct {
 	Host         string
-	Result       runnerResult
+	Result       RunnerResult
 	IgnoreErrors bool
 }
 
@@ -82,7 +85,7 @@ func (e *HandlerTaskStartEvent) Type() string {
 
 // RunnerOKEvent signals the successful completion of a runner
 type RunnerOKEvent struct {
-	runnerResultEvent
+	RunnerResultEvent
 }
 
 func (e *RunnerOKEvent) Type() string {
@@ -91,7 +94,7 @@ func (e *RunnerOKEvent) Type() string {
 
 // RunnerFailedEvent signals a failure when executing a runner
 type RunnerFailedEvent struct {
-	runnerResultEvent
+	RunnerResultEvent
 }
 
 func (e *RunnerFailedEvent) Type() string {
@@ -100,7 +103,7 @@ func (e *RunnerFailedEvent) Type() string {
 
 // RunnerItemOKEvent signals the successful completion of a runner item
 type RunnerItemOKEvent struct {
-	runnerResultEvent
+	RunnerResultEvent
 }
 
 func (e *RunnerItemOKEvent) Type() string {
@@ -109,7 +112,7 @@ func (e *RunnerItemOKEvent) Type() string {
 
 // RunnerItemFailedEvent signals the failure of a task with a specific item
 type RunnerItemFailedEvent struct {
-	runnerResultEvent
+	RunnerResultEvent
 }
 
 func (e *RunnerItemFailedEvent) Type() string {
@@ -118,7 +121,7 @@ func (e *RunnerItemFailedEvent) Type() string {
 
 // RunnerItemRetryEvent signals the retry of a runner item
 type RunnerItemRetryEvent struct {
-	runnerResultEvent
+	RunnerResultEvent
 }
 
 func (e *RunnerItemRetryEvent) Type() string {
@@ -127,7 +130,7 @@ func (e *RunnerItemRetryEvent) Type() string {
 
 // RunnerSkippedEvent is raised when a runner is skipped
 type RunnerSkippedEvent struct {
-	runnerResultEvent
+	RunnerResultEvent
 }
 
 func (e *RunnerSkippedEvent) Type() string {
@@ -136,7 +139,7 @@ func (e *RunnerSkippedEvent) Type() string {
 
 // RunnerUnreachableEvent is raised when the target host is not reachable via SSH
 type RunnerUnreachableEvent struct {
-	runnerResultEvent
+	RunnerResultEvent
 }
 
 func (e *RunnerUnreachableEvent) Type() string {
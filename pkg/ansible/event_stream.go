@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 
 	"github.com/apprenda/kismatic/pkg/util"
 )
@@ -28,7 +29,8 @@ func EventStream(in io.Reader) <-chan Event {
 			}
 			out <- event
 		}
-		if err != io.EOF {
+		// the stream is closed when the playbook is cancelled
+		if err != io.EOF && !isClosedError(err) {
 			fmt.Printf("Error reading ansible event stream: %v", err)
 		}
 		// Close the channel, as the stream is done
@@ -37,6 +39,11 @@ func EventStream(in io.Reader) <-chan Event {
 	return out
 }
 
+func isClosedError(err error) bool {
+	pe, ok := err.(*os.PathError)
+	return ok && pe.Err == os.ErrClosed
+}
+
 // eventEnvelope contains event data for a specific event type
 type eventEnvelope struct {
 	Type string      `json:"eventType"`
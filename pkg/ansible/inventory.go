@@ -26,12 +26,16 @@ type Node struct {
 	PublicIP string
 	// InternalIP is the internal IP, if different from PublicIP.
 	InternalIP string
-	// SSHPrivateKey is the private key to be used for SSH authentication
+	// SSHPrivateKey is the private key to be used for SSH authentication.
+	// The keys of the ssh-agent are used when empty.
 	SSHPrivateKey string
 	// SSHPort is the SSH port number for connecting to the node
 	SSHPort int
 	// SSHUser is the SSH user for logging into the node
 	SSHUser string
+	// SSHCommonArgs are the extra arguments passed to ssh, sftp and scp
+	// when connecting to the node, such as the bastion's ProxyCommand
+	SSHCommonArgs string
 }
 
 // ToINI converts the inventory into INI format
@@ -44,7 +48,15 @@ func (i Inventory) ToINI() []byte {
 			if n.InternalIP != "" {
 				internalIP = n.InternalIP
 			}
-			fmt.Fprintf(w, "%q ansible_host=%q internal_ipv4=%q ansible_ssh_private_key_file=%q ansible_port=%d ansible_user=%q\n", n.Host, n.PublicIP, internalIP, n.SSHPrivateKey, n.SSHPort, n.SSHUser)
+			fmt.Fprintf(w, "%q ansible_host=%q internal_ipv4=%q", n.Host, n.PublicIP, internalIP)
+			if n.SSHPrivateKey != "" {
+				fmt.Fprintf(w, " ansible_ssh_private_key_file=%q", n.SSHPrivateKey)
+			}
+			fmt.Fprintf(w, " ansible_port=%d ansible_user=%q", n.SSHPort, n.SSHUser)
+			if n.SSHCommonArgs != "" {
+				fmt.Fprintf(w, " ansible_ssh_common_args=%q", n.SSHCommonArgs)
+			}
+			fmt.Fprintln(w)
 		}
 	}
 
@@ -1,11 +1,10 @@
 package ansible
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
-	"math/rand"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -24,18 +23,29 @@ const (
 // OutputFormat is used for controlling the STDOUT format of the Ansible runner
 type OutputFormat string
 
+// defaultStopGracePeriod is how long Ansible is given to stop once it is
+// interrupted, before it is killed
+const defaultStopGracePeriod = 30 * time.Second
+
+// eventStreamGracePeriod is how long the events that are left in the named pipe
+// are read once Ansible exits. The processes started by Ansible may keep the
+// pipe open after it exits, in which case the stream is closed after this period.
+const eventStreamGracePeriod = 5 * time.Second
+
 // Runner for running Ansible playbooks
 type Runner interface {
 	// StartPlaybook runs the playbook asynchronously with the given inventory and extra vars.
+	// The playbook file is relative to the playbooks directory, unless it is an absolute path.
 	// It returns a read-only channel that must be consumed for the playbook execution to proceed.
-	StartPlaybook(playbookFile string, inventory Inventory, cc ClusterCatalog) (<-chan Event, error)
+	// Ansible is interrupted when the context is cancelled, and killed if it does not stop in time.
+	StartPlaybook(ctx context.Context, playbookFile string, inventory Inventory, cc ClusterCatalog) (<-chan Event, error)
 	// WaitPlaybook blocks until the execution of the playbook is complete. If an error occurred,
 	// it is returned. Otherwise, returns nil to signal the completion of the playbook.
 	WaitPlaybook() error
 	// StartPlaybookOnNode runs the playbook asynchronously with the given inventory and extra vars
 	// against the specific node.
 	// It returns a read-only channel that must be consumed for the playbook execution to proceed.
-	StartPlaybookOnNode(playbookFile string, inventory Inventory, cc ClusterCatalog, node ...string) (<-chan Event, error)
+	StartPlaybookOnNode(ctx context.Context, playbookFile string, inventory Inventory, cc ClusterCatalog, node ...string) (<-chan Event, error)
 }
 
 type runner struct {
@@ -47,12 +57,25 @@ type runner struct {
 	pythonPath   string
 	ansibleDir   string
 	runDir       string
+	env          []string
 	waitPlaybook func() error
 	namedPipe    string
+	// the cluster catalog with its secrets, and the cloud provider configuration
+	// resolved from a secret reference, which are removed once the playbook exits
+	extraVarsFile   string
+	cloudConfigFile string
+	// how long Ansible is given to stop once its context is cancelled
+	stopGracePeriod time.Duration
+	// closed when the ansible process exits
+	exited chan struct{}
 }
 
 // NewRunner returns a new runner for running Ansible playbooks.
-func NewRunner(out, errOut io.Writer, ansibleDir string, runDir string) (Runner, error) {
+// The inventory and the cluster catalog of the playbooks are written to the run
+// directory, so that runners of different run directories can be used concurrently.
+// The env variables are set on the Ansible process, in addition to the environment
+// of kismatic.
+func NewRunner(out, errOut io.Writer, ansibleDir string, runDir string, env ...string) (Runner, error) {
 	// Ansible depends on python 2.7 being installed and on the path as "python".
 	// Validate that it is available
 	if _, err := exec.LookPath("python"); err != nil {
@@ -70,6 +93,9 @@ func NewRunner(out, errOut io.Writer, ansibleDir string, runDir string) (Runner,
 		pythonPath: ppath,
 		ansibleDir: ansibleDir,
 		runDir:     runDir,
+		env:        env,
+
+		stopGracePeriod: defaultStopGracePeriod,
 	}, nil
 }
 
@@ -80,8 +106,9 @@ func (r *runner) WaitPlaybook() error {
 		return fmt.Errorf("wait called, but playbook not started")
 	}
 	execErr := r.waitPlaybook()
-	// Process exited, we can clean up named pipe
-	removeErr := os.Remove(r.namedPipe)
+	// Process exited, we can clean up the secrets and the named pipe
+	r.removeSecrets()
+	removeErr := os.RemoveAll(filepath.Dir(r.namedPipe))
 	if removeErr != nil && execErr != nil {
 		return fmt.Errorf("an error occurred running ansible: %v. Removing named pipe at %q failed: %v", execErr, r.namedPipe, removeErr)
 	}
@@ -95,51 +122,76 @@ func (r *runner) WaitPlaybook() error {
 }
 
 // RunPlaybook with the given inventory and extra vars
-func (r *runner) StartPlaybook(playbookFile string, inv Inventory, cc ClusterCatalog) (<-chan Event, error) {
-	return r.startPlaybook(playbookFile, inv, cc) // Don't set the --limit arg
+func (r *runner) StartPlaybook(ctx context.Context, playbookFile string, inv Inventory, cc ClusterCatalog) (<-chan Event, error) {
+	return r.startPlaybook(ctx, playbookFile, inv, cc) // Don't set the --limit arg
 }
 
 // StartPlaybookOnNode runs the playbook asynchronously with the given inventory and extra vars
 // against the specific node.
 // It returns a read-only channel that must be consumed for the playbook execution to proceed.
-func (r *runner) StartPlaybookOnNode(playbookFile string, inv Inventory, cc ClusterCatalog, nodes ...string) (<-chan Event, error) {
+func (r *runner) StartPlaybookOnNode(ctx context.Context, playbookFile string, inv Inventory, cc ClusterCatalog, nodes ...string) (<-chan Event, error) {
 	// set the --limit arg to the node we want to target
-	return r.startPlaybook(playbookFile, inv, cc, nodes...)
+	return r.startPlaybook(ctx, playbookFile, inv, cc, nodes...)
 }
 
-func (r *runner) startPlaybook(playbookFile string, inv Inventory, cc ClusterCatalog, nodes ...string) (<-chan Event, error) {
-	playbook := filepath.Join(r.ansibleDir, "playbooks", playbookFile)
+func (r *runner) startPlaybook(ctx context.Context, playbookFile string, inv Inventory, cc ClusterCatalog, nodes ...string) (<-chan Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("playbook %q was not started: %v", playbookFile, err)
+	}
+	playbook := playbookFile
+	if !filepath.IsAbs(playbook) {
+		playbook = filepath.Join(r.ansibleDir, "playbooks", playbookFile)
+	}
 	if _, err := os.Stat(playbook); os.IsNotExist(err) {
 		return nil, fmt.Errorf("playbook %q does not exist", playbook)
 	}
 
+	// the cluster catalog and the cloud provider configuration contain secrets,
+	// so only the owner may read them, and they are removed once the playbook exits
+	var err error
+	if cc.CloudConfigContents != "" {
+		r.cloudConfigFile, err = filepath.Abs(filepath.Join(r.runDir, "cloud-config"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine absolute path to the cloud provider configuration: %v", err)
+		}
+		if err = ioutil.WriteFile(r.cloudConfigFile, []byte(cc.CloudConfigContents), 0600); err != nil {
+			return nil, fmt.Errorf("error writing the cloud provider configuration to %q: %v", r.cloudConfigFile, err)
+		}
+		cc.CloudConfig = r.cloudConfigFile
+	}
 	yamlBytes, err := cc.ToYAML()
 	if err != nil {
+		r.removeSecrets()
 		return nil, fmt.Errorf("error writing cluster catalog data to yaml: %v", err)
 	}
-	clusterCatalogFile := filepath.Join(r.ansibleDir, "clustercatalog.yaml")
-	if err = ioutil.WriteFile(clusterCatalogFile, yamlBytes, 0644); err != nil {
-		return nil, fmt.Errorf("error writing cluster catalog file to %q: %v", clusterCatalogFile, err)
+	r.extraVarsFile = filepath.Join(r.runDir, "extra-vars.yaml")
+	if err = ioutil.WriteFile(r.extraVarsFile, yamlBytes, 0600); err != nil {
+		r.removeSecrets()
+		return nil, fmt.Errorf("error writing cluster catalog file to %q: %v", r.extraVarsFile, err)
 	}
 
-	inventoryFile := filepath.Join(r.ansibleDir, "inventory.ini")
-	if err := ioutil.WriteFile(inventoryFile, inv.ToINI(), 0644); err != nil {
+	inventoryFile := filepath.Join(r.runDir, "inventory.ini")
+	if err = ioutil.WriteFile(inventoryFile, inv.ToINI(), 0644); err != nil {
+		r.removeSecrets()
 		return nil, fmt.Errorf("error writing inventory file to %q: %v", inventoryFile, err)
 	}
 
-	if err := copyFileContents(clusterCatalogFile, filepath.Join(r.runDir, "clustercatalog.yaml")); err != nil {
-		return nil, fmt.Errorf("error copying clustercatalog.yaml to %q: %v", r.runDir, err)
+	// record the cluster catalog in the run directory, without its secrets
+	redacted := cc.Redacted()
+	redactedBytes, err := redacted.ToYAML()
+	if err != nil {
+		r.removeSecrets()
+		return nil, fmt.Errorf("error writing cluster catalog data to yaml: %v", err)
 	}
-	if err := copyFileContents(inventoryFile, filepath.Join(r.runDir, "inventory.ini")); err != nil {
-		return nil, fmt.Errorf("error copying inventory.ini to %q: %v", r.runDir, err)
+	if err = ioutil.WriteFile(filepath.Join(r.runDir, "clustercatalog.yaml"), redactedBytes, 0644); err != nil {
+		r.removeSecrets()
+		return nil, fmt.Errorf("error writing clustercatalog.yaml to %q: %v", r.runDir, err)
 	}
 
-	cmd := exec.Command(filepath.Join(r.ansibleDir, "bin", "ansible-playbook"), "-i", inventoryFile, "-s", playbook, "--extra-vars", "@"+clusterCatalogFile)
+	cmd := exec.Command(filepath.Join(r.ansibleDir, "bin", "ansible-playbook"), "-i", inventoryFile, "-s", playbook, "--extra-vars", "@"+r.extraVarsFile)
 	cmd.Stdout = r.out
 	cmd.Stderr = r.errOut
 
-	log.SetOutput(r.out)
-
 	limitArg := strings.Join(nodes, ",")
 	if limitArg != "" {
 		cmd.Args = append(cmd.Args, "--limit", limitArg)
@@ -152,47 +204,165 @@ func (r *runner) startPlaybook(playbookFile string, inv Inventory, cc ClusterCat
 	// Create named pipe
 	np, err := createTempNamedPipe()
 	if err != nil {
+		r.removeSecrets()
 		return nil, err
 	}
 	r.namedPipe = np
 
-	os.Setenv("PYTHONPATH", r.pythonPath)
-	os.Setenv("ANSIBLE_CALLBACK_PLUGINS", filepath.Join(r.ansibleDir, "playbooks", "callback"))
-	os.Setenv("ANSIBLE_CALLBACK_WHITELIST", "json_lines")
-	os.Setenv("ANSIBLE_CONFIG", filepath.Join(r.ansibleDir, "playbooks", "ansible.cfg"))
-	os.Setenv("ANSIBLE_JSON_LINES_PIPE", r.namedPipe)
+	// The environment is set on the process instead of kismatic, so that
+	// playbooks can run concurrently
+	env := r.ansibleEnv()
+	cmd.Env = append(os.Environ(), env...)
 
 	// Print Ansible command
-	fmt.Fprintf(r.out, "export PYTHONPATH=%v\n", os.Getenv("PYTHONPATH"))
-	fmt.Fprintf(r.out, "export ANSIBLE_CALLBACK_PLUGINS=%v\n", os.Getenv("ANSIBLE_CALLBACK_PLUGINS"))
-	fmt.Fprintf(r.out, "export ANSIBLE_CALLBACK_WHITELIST=%v\n", os.Getenv("ANSIBLE_CALLBACK_WHITELIST"))
-	fmt.Fprintf(r.out, "export ANSIBLE_CONFIG=%v\n", os.Getenv("ANSIBLE_CONFIG"))
-	fmt.Fprintf(r.out, "export ANSIBLE_JSON_LINES_PIPE=%v\n", os.Getenv("ANSIBLE_JSON_LINES_PIPE"))
+	for _, v := range env {
+		fmt.Fprintf(r.out, "export %v\n", v)
+	}
 	fmt.Fprintln(r.out, strings.Join(cmd.Args, " "))
 
+	// Ansible runs in its own process group, so that it is only interrupted by
+	// kismatic, and so that the processes it starts are interrupted with it
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	// Create the event stream out of the named pipe. It is opened before
+	// ansible is started, so that ansible is not left running when it fails.
+	// The pipe is also opened for writing, so that the stream does not end
+	// before ansible opens it. It is closed once ansible exits, so that the
+	// stream ends once the events written by ansible are read.
+	eventStreamFile, err := os.OpenFile(r.namedPipe, os.O_RDONLY|syscall.O_NONBLOCK, os.ModeNamedPipe)
+	if err != nil {
+		r.removeSecrets()
+		os.RemoveAll(filepath.Dir(r.namedPipe))
+		return nil, fmt.Errorf("error openning event stream pipe: %v", err)
+	}
+	eventStreamWriter, err := os.OpenFile(r.namedPipe, os.O_WRONLY, os.ModeNamedPipe)
+	if err != nil {
+		eventStreamFile.Close()
+		r.removeSecrets()
+		os.RemoveAll(filepath.Dir(r.namedPipe))
+		return nil, fmt.Errorf("error openning event stream pipe: %v", err)
+	}
+
 	// Starts async execution of ansible, which will block until
 	// we start reading from the named pipe
 	err = cmd.Start()
 	if err != nil {
+		eventStreamFile.Close()
+		eventStreamWriter.Close()
+		r.removeSecrets()
+		os.RemoveAll(filepath.Dir(r.namedPipe))
 		return nil, fmt.Errorf("error running playbook: %v", err)
 	}
-	r.waitPlaybook = cmd.Wait
+	r.exited = make(chan struct{})
+	go r.stopOnCancel(ctx, cmd.Process)
 
-	// Create the event stream out of the named pipe
-	eventStreamFile, err := os.OpenFile(r.namedPipe, os.O_RDWR, os.ModeNamedPipe)
-	if err != nil {
-		return nil, fmt.Errorf("error openning event stream pipe: %v", err)
+	r.waitPlaybook = func() error {
+		err := cmd.Wait()
+		close(r.exited)
+		eventStreamWriter.Close()
+		// the events that were not read once the playbook was cancelled are dropped,
+		// so that reading the event stream does not block. A playbook that exited
+		// successfully before it was cancelled succeeded.
+		if err != nil && ctx.Err() != nil {
+			eventStreamFile.Close()
+			return fmt.Errorf("%v (%v)", err, ctx.Err())
+		}
+		time.AfterFunc(eventStreamGracePeriod, func() { eventStreamFile.Close() })
+		return err
 	}
-	eventStream := EventStream(eventStreamFile)
+	eventStream := EventStream(eventPipe{eventStreamFile})
 	return eventStream, nil
 }
 
-// create a named pipe for getting json events out of ansible.
-// add random int to file name to avoid collision.
+// eventPipe is the read end of the named pipe of the events, which is closed
+// once it was read to the end
+type eventPipe struct {
+	f *os.File
+}
+
+func (p eventPipe) Read(b []byte) (int, error) {
+	n, err := p.f.Read(b)
+	if err == io.EOF {
+		p.f.Close()
+	}
+	return n, err
+}
+
+// removeSecrets removes the files of the run that contain secrets
+func (r *runner) removeSecrets() {
+	if r.extraVarsFile != "" {
+		os.Remove(r.extraVarsFile)
+	}
+	if r.cloudConfigFile != "" {
+		os.Remove(r.cloudConfigFile)
+	}
+}
+
+// stopOnCancel interrupts Ansible when the context is cancelled, as if Ctrl-C was
+// pressed, and kills it if it did not exit within the grace period
+func (r *runner) stopOnCancel(ctx context.Context, p *os.Process) {
+	select {
+	case <-r.exited:
+		return
+	case <-ctx.Done():
+	}
+	// signal the process group of ansible
+	syscall.Kill(-p.Pid, syscall.SIGINT)
+	timer := time.NewTimer(r.stopGracePeriod)
+	defer timer.Stop()
+	select {
+	case <-r.exited:
+	case <-timer.C:
+		syscall.Kill(-p.Pid, syscall.SIGKILL)
+	}
+}
+
+// ansibleEnv returns the environment variables of the Ansible process.
+// The variables of the runner take precedence over the environment of kismatic,
+// as the last value of a variable is the one used by the process.
+func (r *runner) ansibleEnv() []string {
+	env := append(playbookEnv(r.pythonPath, r.ansibleDir),
+		"ANSIBLE_CALLBACK_PLUGINS="+filepath.Join(r.ansibleDir, "playbooks", "callback"),
+		"ANSIBLE_CALLBACK_WHITELIST=json_lines",
+		"ANSIBLE_JSON_LINES_PIPE="+r.namedPipe,
+		// the ssh connections are only shared by the playbook, so that the
+		// connections to the hosts of different clusters are never mixed up
+		"ANSIBLE_SSH_ARGS=-o ControlMaster=auto -o ControlPersist=60s -o ControlPath="+filepath.Join(filepath.Dir(r.namedPipe), "ssh-%C"),
+	)
+	return append(env, r.env...)
+}
+
+// Environment returns the environment variables that are set on the Ansible
+// process to run the playbooks of the ansibleDir, without the variables that
+// stream the events of the playbook to kismatic.
+func Environment(ansibleDir string) ([]string, error) {
+	ppath, err := getPythonPath()
+	if err != nil {
+		return nil, err
+	}
+	return playbookEnv(ppath, ansibleDir), nil
+}
+
+// playbookEnv returns the environment variables that the playbooks require
+func playbookEnv(pythonPath string, ansibleDir string) []string {
+	return []string{
+		"PYTHONPATH=" + pythonPath,
+		"ANSIBLE_CONFIG=" + filepath.Join(ansibleDir, "playbooks", "ansible.cfg"),
+	}
+}
+
+// create a named pipe for getting json events out of ansible,
+// in a temporary directory of its own to avoid collisions. The directory
+// also holds the sockets of the ssh connections of the playbook. It is created
+// in /tmp, as the path of the sockets is limited to about 100 characters.
 func createTempNamedPipe() (string, error) {
-	start := time.Now()
-	np := filepath.Join(os.TempDir(), fmt.Sprintf("ansible-pipe-%d-%s", rand.Int(), start.Format("2006-01-02-15-04-05.99999")))
+	dir, err := ioutil.TempDir("/tmp", "ansible-pipe")
+	if err != nil {
+		return "", fmt.Errorf("error creating directory for named pipe: %v", err)
+	}
+	np := filepath.Join(dir, "events")
 	if err := syscall.Mkfifo(np, 0644); err != nil {
+		os.RemoveAll(dir)
 		return "", fmt.Errorf("error creating named pipe %q: %v", np, err)
 	}
 	return np, nil
@@ -207,20 +377,3 @@ func getPythonPath() (string, error) {
 	lib64 := filepath.Join(wd, "ansible", "lib64", "python2.7", "site-packages")
 	return fmt.Sprintf("%s:%s", lib, lib64), nil
 }
-
-func copyFileContents(src, dst string) (err error) {
-	in, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer in.Close()
-	out, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-	if _, err = io.Copy(out, in); err != nil {
-		return err
-	}
-	return out.Sync()
-}
@@ -1,8 +1,15 @@
 package ansible
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestWaitPlaybook(t *testing.T) {
@@ -15,3 +22,236 @@ func TestWaitPlaybook(t *testing.T) {
 		t.Error("Did not get the expected error when calling WaitPlaybook")
 	}
 }
+
+func TestStartPlaybookIsScopedToRunDirectory(t *testing.T) {
+	ansibleDir, err := ioutil.TempDir("", "ansible-runner-test")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(ansibleDir)
+	os.MkdirAll(filepath.Join(ansibleDir, "bin"), 0755)
+	os.MkdirAll(filepath.Join(ansibleDir, "playbooks"), 0755)
+	ioutil.WriteFile(filepath.Join(ansibleDir, "playbooks", "test.yaml"), []byte{}, 0644)
+	// the fake ansible-playbook prints its environment and arguments
+	script := "#!/bin/sh\necho \"sock=$SSH_AUTH_SOCK config=$ANSIBLE_CONFIG args=$@\"\necho \"ssh=$ANSIBLE_SSH_ARGS\"\n"
+	ioutil.WriteFile(filepath.Join(ansibleDir, "bin", "ansible-playbook"), []byte(script), 0755)
+
+	defer os.Setenv("ANSIBLE_CONFIG", os.Getenv("ANSIBLE_CONFIG"))
+	os.Setenv("ANSIBLE_CONFIG", "/etc/ansible.cfg")
+	runDirs := []string{filepath.Join(ansibleDir, "run1"), filepath.Join(ansibleDir, "run2")}
+	for i, runDir := range runDirs {
+		os.MkdirAll(runDir, 0755)
+		out := &bytes.Buffer{}
+		r := &runner{out: out, errOut: out, ansibleDir: ansibleDir, runDir: runDir, env: []string{"SSH_AUTH_SOCK=/tmp/agent.sock"}}
+		cc := ClusterCatalog{AdminPassword: "secret"}
+		if _, err = r.StartPlaybook(context.Background(), "test.yaml", Inventory{}, cc); err != nil {
+			t.Fatalf("run %d: unexpected error: %v", i, err)
+		}
+		if err = r.WaitPlaybook(); err != nil {
+			t.Fatalf("run %d: unexpected error: %v", i, err)
+		}
+		config := filepath.Join(ansibleDir, "playbooks", "ansible.cfg")
+		expected := fmt.Sprintf("sock=/tmp/agent.sock config=%s args=-i %s", config, filepath.Join(runDir, "inventory.ini"))
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("run %d: expected the playbook to run with %q, but got:\n%s", i, expected, out.String())
+		}
+		// the ssh connections are not shared with other playbooks
+		controlPath := "ControlPath=" + filepath.Join(filepath.Dir(r.namedPipe), "ssh-%C")
+		if !strings.Contains(out.String(), controlPath) {
+			t.Errorf("run %d: expected the ssh connections to be shared with %q, but got:\n%s", i, controlPath, out.String())
+		}
+		for _, f := range []string{"inventory.ini", "clustercatalog.yaml"} {
+			if _, err = os.Stat(filepath.Join(runDir, f)); err != nil {
+				t.Errorf("run %d: expected %s in the run directory: %v", i, f, err)
+			}
+		}
+		if _, err = os.Stat(r.extraVarsFile); !os.IsNotExist(err) {
+			t.Errorf("run %d: expected the secrets of the cluster catalog to be removed once the playbook exited", i)
+		}
+	}
+	if os.Getenv("ANSIBLE_CONFIG") != "/etc/ansible.cfg" || os.Getenv("SSH_AUTH_SOCK") == "/tmp/agent.sock" {
+		t.Errorf("expected the environment of the process to be left unchanged")
+	}
+	if _, err = os.Stat(filepath.Join(ansibleDir, "inventory.ini")); !os.IsNotExist(err) {
+		t.Errorf("expected no inventory to be written to the ansible directory")
+	}
+}
+
+func TestStartPlaybookWritesCloudConfigToRunDirectory(t *testing.T) {
+	ansibleDir, err := ioutil.TempDir("", "ansible-runner-test")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(ansibleDir)
+	os.MkdirAll(filepath.Join(ansibleDir, "bin"), 0755)
+	os.MkdirAll(filepath.Join(ansibleDir, "playbooks"), 0755)
+	ioutil.WriteFile(filepath.Join(ansibleDir, "playbooks", "test.yaml"), []byte{}, 0644)
+	// the fake ansible-playbook prints the cloud config and its mode
+	cloudConfig := filepath.Join(ansibleDir, "cloud-config")
+	script := fmt.Sprintf("#!/bin/sh\nstat -c 'mode=%%a' %[1]s\ncat %[1]s\n", cloudConfig)
+	ioutil.WriteFile(filepath.Join(ansibleDir, "bin", "ansible-playbook"), []byte(script), 0755)
+
+	out := &bytes.Buffer{}
+	r := &runner{out: out, errOut: out, ansibleDir: ansibleDir, runDir: ansibleDir}
+	cc := ClusterCatalog{CloudProvider: "aws", CloudConfigContents: "[Global]\nzone=secret"}
+	if _, err = r.StartPlaybook(context.Background(), "test.yaml", Inventory{}, cc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	extraVars, err := ioutil.ReadFile(r.extraVarsFile)
+	if err != nil {
+		t.Fatalf("error reading extra vars: %v", err)
+	}
+	if err = r.WaitPlaybook(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "mode=600\n[Global]\nzone=secret") {
+		t.Errorf("expected the cloud config to be readable only by its owner while the playbook runs, but got:\n%s", out.String())
+	}
+	if !strings.Contains(string(extraVars), "cloud_config_local: "+cloudConfig) {
+		t.Errorf("expected the cluster catalog to point to the cloud config, but got:\n%s", extraVars)
+	}
+	if _, err = os.Stat(cloudConfig); !os.IsNotExist(err) {
+		t.Errorf("expected the cloud config to be removed once the playbook exited")
+	}
+	recorded, err := ioutil.ReadFile(filepath.Join(ansibleDir, "clustercatalog.yaml"))
+	if err != nil || strings.Contains(string(recorded), "zone=secret") {
+		t.Errorf("expected the recorded cluster catalog not to contain the cloud config, but got %v:\n%s", err, recorded)
+	}
+}
+
+func TestEventStreamEndsWhenAnsibleExits(t *testing.T) {
+	ansibleDir, err := ioutil.TempDir("", "ansible-runner-test")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(ansibleDir)
+	os.MkdirAll(filepath.Join(ansibleDir, "bin"), 0755)
+	os.MkdirAll(filepath.Join(ansibleDir, "playbooks"), 0755)
+	ioutil.WriteFile(filepath.Join(ansibleDir, "playbooks", "test.yaml"), []byte{}, 0644)
+	// the fake ansible-playbook writes an event before exiting
+	script := "#!/bin/sh\necho '{\"eventType\":\"PLAYBOOK_END\",\"eventData\":{}}' > \"$ANSIBLE_JSON_LINES_PIPE\"\n"
+	ioutil.WriteFile(filepath.Join(ansibleDir, "bin", "ansible-playbook"), []byte(script), 0755)
+
+	r := &runner{out: ioutil.Discard, errOut: ioutil.Discard, ansibleDir: ansibleDir, runDir: ansibleDir}
+	events, err := r.StartPlaybook(context.Background(), "test.yaml", Inventory{}, ClusterCatalog{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ended := make(chan []Event)
+	go func() {
+		received := []Event{}
+		for e := range events {
+			received = append(received, e)
+		}
+		ended <- received
+	}()
+	if err = r.WaitPlaybook(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case received := <-ended:
+		if len(received) != 1 {
+			t.Errorf("expected the event written by ansible, but got %v", received)
+		}
+	case <-time.After(eventStreamGracePeriod / 2):
+		t.Errorf("expected the event stream to end once ansible exited")
+	}
+}
+
+func TestWaitPlaybookSucceedsWhenCancelledAfterAnsibleExited(t *testing.T) {
+	ansibleDir, err := ioutil.TempDir("", "ansible-runner-test")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(ansibleDir)
+	os.MkdirAll(filepath.Join(ansibleDir, "bin"), 0755)
+	os.MkdirAll(filepath.Join(ansibleDir, "playbooks"), 0755)
+	ioutil.WriteFile(filepath.Join(ansibleDir, "playbooks", "test.yaml"), []byte{}, 0644)
+	ioutil.WriteFile(filepath.Join(ansibleDir, "bin", "ansible-playbook"), []byte("#!/bin/sh\nexit 0\n"), 0755)
+
+	r := &runner{out: ioutil.Discard, errOut: ioutil.Discard, ansibleDir: ansibleDir, runDir: ansibleDir}
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := r.StartPlaybook(ctx, "test.yaml", Inventory{}, ClusterCatalog{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// give ansible time to exit
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	if err = r.WaitPlaybook(); err != nil {
+		t.Errorf("expected ansible that exited successfully to succeed, but got %v", err)
+	}
+	for range events {
+	}
+}
+
+func TestStartPlaybookCleansUpWhenAnsibleDoesNotStart(t *testing.T) {
+	ansibleDir, err := ioutil.TempDir("", "ansible-runner-test")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(ansibleDir)
+	os.MkdirAll(filepath.Join(ansibleDir, "playbooks"), 0755)
+	ioutil.WriteFile(filepath.Join(ansibleDir, "playbooks", "test.yaml"), []byte{}, 0644)
+	// there is no ansible-playbook to run
+	r := &runner{out: ioutil.Discard, errOut: ioutil.Discard, ansibleDir: ansibleDir, runDir: ansibleDir}
+	if _, err = r.StartPlaybook(context.Background(), "test.yaml", Inventory{}, ClusterCatalog{AdminPassword: "secret"}); err == nil {
+		t.Fatalf("expected an error starting the playbook")
+	}
+	if r.namedPipe == "" {
+		t.Fatalf("expected the playbook to fail once the named pipe was created")
+	}
+	if _, err = os.Stat(r.extraVarsFile); !os.IsNotExist(err) {
+		t.Errorf("expected the secrets of the cluster catalog to be removed")
+	}
+	if _, err = os.Stat(filepath.Dir(r.namedPipe)); !os.IsNotExist(err) {
+		t.Errorf("expected the named pipe to be removed")
+	}
+}
+
+func TestStartPlaybookIsStoppedWhenCancelled(t *testing.T) {
+	tests := []struct {
+		// the fake ansible-playbook
+		script string
+		// whether it must be killed
+		killed bool
+	}{
+		{script: "#!/bin/sh\ntrap 'exit 130' INT\nwhile true; do sleep 0.1; done\n"},
+		{script: "#!/bin/sh\ntrap '' INT\nsleep 30\n", killed: true},
+	}
+	for i, test := range tests {
+		ansibleDir, err := ioutil.TempDir("", "ansible-runner-test")
+		if err != nil {
+			t.Fatalf("error creating temp dir: %v", err)
+		}
+		defer os.RemoveAll(ansibleDir)
+		os.MkdirAll(filepath.Join(ansibleDir, "bin"), 0755)
+		os.MkdirAll(filepath.Join(ansibleDir, "playbooks"), 0755)
+		ioutil.WriteFile(filepath.Join(ansibleDir, "playbooks", "test.yaml"), []byte{}, 0644)
+		ioutil.WriteFile(filepath.Join(ansibleDir, "bin", "ansible-playbook"), []byte(test.script), 0755)
+
+		r := &runner{out: ioutil.Discard, errOut: ioutil.Discard, ansibleDir: ansibleDir, runDir: ansibleDir, stopGracePeriod: 2 * time.Second}
+		ctx, cancel := context.WithCancel(context.Background())
+		events, err := r.StartPlaybook(ctx, "test.yaml", Inventory{}, ClusterCatalog{})
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i, err)
+		}
+		// give the shell time to trap the signal
+		time.Sleep(200 * time.Millisecond)
+		start := time.Now()
+		cancel()
+		err = r.WaitPlaybook()
+		if err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+			t.Errorf("test %d: expected an error caused by the cancellation, but got %v", i, err)
+		}
+		if took := time.Since(start); (took >= r.stopGracePeriod) != test.killed {
+			t.Errorf("test %d: ansible stopped after %v, with a grace period of %v", i, took, r.stopGracePeriod)
+		}
+		if _, err = os.Stat(r.namedPipe); !os.IsNotExist(err) {
+			t.Errorf("test %d: expected the named pipe to be removed", i)
+		}
+		// the event stream is closed
+		for range events {
+		}
+	}
+}
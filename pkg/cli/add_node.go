@@ -20,6 +20,7 @@ type addNodeOpts struct {
 	OutputFormat             string
 	Verbose                  bool
 	SkipPreFlight            bool
+	DryRun                   bool
 }
 
 var validRoles = []string{"worker", "ingress", "storage"}
@@ -61,6 +62,11 @@ func NewCmdAddNode(out io.Writer, installOpts *installOpts) *cobra.Command {
 					newNode.Labels[pair[0]] = pair[1]
 				}
 			}
+			// the new node is written to the plan file, which can't be done
+			// when the plan is the result of merging overlays
+			if len(installOpts.planOverlays) > 0 {
+				return errors.New("nodes cannot be added when using plan file overlays")
+			}
 			return doAddNode(out, installOpts.planFilename, opts, newNode)
 		},
 	}
@@ -69,22 +75,34 @@ func NewCmdAddNode(out io.Writer, installOpts *installOpts) *cobra.Command {
 	cmd.Flags().StringVar(&opts.GeneratedAssetsDirectory, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	cmd.Flags().BoolVar(&opts.RestartServices, "restart-services", false, "force restart clusters services (Use with care)")
 	cmd.Flags().BoolVar(&opts.Verbose, "verbose", false, "enable verbose logging from the installation")
-	cmd.Flags().StringVarP(&opts.OutputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\")")
+	cmd.Flags().StringVarP(&opts.OutputFormat, "output", "o", "simple", installOutputFormatUsage)
 	cmd.Flags().BoolVar(&opts.SkipPreFlight, "skip-preflight", false, "skip pre-flight checks, useful when rerunning kismatic")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, dryRunFlagUsage)
 	return cmd
 }
 
-func doAddNode(out io.Writer, planFile string, opts *addNodeOpts, newNode install.Node) error {
-	planner := &install.FilePlanner{File: planFile}
+func doAddNode(stdout io.Writer, planFile string, opts *addNodeOpts, newNode install.Node) error {
+	out := messagesOut(stdout, opts.OutputFormat)
+	planner := &install.FilePlanner{File: planFile, Log: out}
 	if !planner.PlanExists() {
 		return planFileNotFoundErr{filename: planFile}
 	}
+	if err := verifyHostKeys(opts.GeneratedAssetsDirectory); err != nil {
+		return err
+	}
 	execOpts := install.ExecutorOptions{
 		GeneratedAssetsDirectory: opts.GeneratedAssetsDirectory,
 		OutputFormat:             opts.OutputFormat,
 		Verbose:                  opts.Verbose,
+		DryRun:                   opts.DryRun,
 	}
-	executor, err := install.NewExecutor(out, os.Stderr, execOpts)
+	executor, err := install.NewExecutor(stdout, os.Stderr, execOpts)
+	if err != nil {
+		return err
+	}
+	preflightExecOpts := execOpts
+	preflightExecOpts.DryRun = false // the pre-flight checks are run during a dry run
+	preflightExec, err := install.NewExecutor(stdout, os.Stderr, preflightExecOpts)
 	if err != nil {
 		return err
 	}
@@ -92,37 +110,45 @@ func doAddNode(out io.Writer, planFile string, opts *addNodeOpts, newNode instal
 	if err != nil {
 		return fmt.Errorf("failed to read plan file: %v", err)
 	}
-	if _, errs := install.ValidateNode(&newNode); errs != nil {
+	if ok, errs := install.ValidateNode(&newNode); !ok {
 		util.PrintValidationErrors(out, errs)
 		return errors.New("information provided about the new node is invalid")
 	}
 	// add new node to the plan just for validation
 	validatePlan := install.AddNodeToPlan(*plan, newNode, opts.Roles)
-	if _, errs := install.ValidatePlan(&validatePlan); errs != nil {
+	if ok, errs := install.ValidatePlan(&validatePlan); !ok {
 		util.PrintValidationErrors(out, errs)
 		return errors.New("the plan file failed validation")
 	}
+	// the new node is accessed with the SSH configuration of its groups
+	sshConfig := validatePlan.NodeSSHConfig(newNode)
 	nodeSSHCon := &install.SSHConnection{
-		SSHConfig: &plan.Cluster.SSH,
+		SSHConfig: &sshConfig,
 		Node:      &newNode,
 	}
-	if _, errs := install.ValidateSSHConnection(nodeSSHCon, "New node"); errs != nil {
+	if ok, errs := install.ValidateSSHConnection(nodeSSHCon, "New node"); !ok {
 		util.PrintValidationErrors(out, errs)
 		return errors.New("could not establish SSH connection to the new node")
 	}
 	if err = ensureNodeIsNew(*plan, newNode); err != nil {
 		return err
 	}
+	ctx, stop := interruptibleContext(out)
+	defer stop()
 	if !opts.SkipPreFlight {
 		util.PrintHeader(out, "Running Pre-Flight Checks On New Node", '=')
-		if err = executor.RunNewNodePreFlightCheck(*plan, newNode); err != nil {
+		if err = preflightExec.RunNewNodePreFlightCheck(ctx, *plan, newNode); err != nil {
 			return err
 		}
 	}
-	updatedPlan, err := executor.AddNode(plan, newNode, opts.Roles, opts.RestartServices)
+	updatedPlan, err := executor.AddNode(ctx, plan, newNode, opts.Roles, opts.RestartServices)
 	if err != nil {
 		return err
 	}
+	if opts.DryRun {
+		printDryRunComplete(out, executor.DryRunDirectory())
+		return nil
+	}
 	if err := planner.Write(updatedPlan); err != nil {
 		return fmt.Errorf("error updating plan file to include the new node: %v", err)
 	}
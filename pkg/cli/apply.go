@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -12,6 +13,7 @@ import (
 
 type applyCmd struct {
 	out                io.Writer
+	eventsOut          io.Writer
 	planner            install.Planner
 	executor           install.Executor
 	planFile           string
@@ -21,6 +23,9 @@ type applyCmd struct {
 	skipPreFlight      bool
 	restartServices    bool
 	limit              []string
+	resume             bool
+	dryRun             bool
+	runsDirectory      string
 }
 
 type applyOpts struct {
@@ -30,6 +35,8 @@ type applyOpts struct {
 	outputFormat       string
 	skipPreFlight      bool
 	limit              []string
+	resume             bool
+	dryRun             bool
 }
 
 // NewCmdApply creates a cluter using the plan file
@@ -42,11 +49,19 @@ func NewCmdApply(out io.Writer, installOpts *installOpts) *cobra.Command {
 			if len(args) != 0 {
 				return fmt.Errorf("Unexpected args: %v", args)
 			}
-			planner := &install.FilePlanner{File: installOpts.planFilename}
+			if applyOpts.resume && len(applyOpts.limit) > 0 {
+				return fmt.Errorf("--limit cannot be used with --resume, the installation is resumed on the nodes it was limited to")
+			}
+			if err := verifyHostKeys(applyOpts.generatedAssetsDir); err != nil {
+				return err
+			}
+			msgOut := messagesOut(out, applyOpts.outputFormat)
+			planner := &install.FilePlanner{File: installOpts.planFilename, Overlays: installOpts.planOverlays, Log: msgOut}
 			executorOpts := install.ExecutorOptions{
 				GeneratedAssetsDirectory: applyOpts.generatedAssetsDir,
 				OutputFormat:             applyOpts.outputFormat,
 				Verbose:                  applyOpts.verbose,
+				DryRun:                   applyOpts.dryRun,
 			}
 			executor, err := install.NewExecutor(out, os.Stderr, executorOpts)
 			if err != nil {
@@ -54,7 +69,8 @@ func NewCmdApply(out io.Writer, installOpts *installOpts) *cobra.Command {
 			}
 
 			applyCmd := &applyCmd{
-				out:                out,
+				out:                msgOut,
+				eventsOut:          eventsOut(out, applyOpts.outputFormat),
 				planner:            planner,
 				executor:           executor,
 				planFile:           installOpts.planFilename,
@@ -64,8 +80,15 @@ func NewCmdApply(out io.Writer, installOpts *installOpts) *cobra.Command {
 				skipPreFlight:      applyOpts.skipPreFlight,
 				restartServices:    applyOpts.restartServices,
 				limit:              applyOpts.limit,
+				resume:             applyOpts.resume,
+				dryRun:             applyOpts.dryRun,
+				runsDirectory:      "runs",
 			}
-			return applyCmd.run()
+			ctx, stop := interruptibleContext(msgOut)
+			defer stop()
+			err = applyCmd.run(ctx)
+			printSlowestTasks(msgOut, executor.TaskTimings())
+			return err
 		},
 	}
 
@@ -74,13 +97,18 @@ func NewCmdApply(out io.Writer, installOpts *installOpts) *cobra.Command {
 	cmd.Flags().StringVar(&applyOpts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	cmd.Flags().BoolVar(&applyOpts.restartServices, "restart-services", false, "force restart cluster services (Use with care)")
 	cmd.Flags().BoolVar(&applyOpts.verbose, "verbose", false, "enable verbose logging from the installation")
-	cmd.Flags().StringVarP(&applyOpts.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\")")
+	cmd.Flags().StringVarP(&applyOpts.outputFormat, "output", "o", "simple", installOutputFormatUsage)
 	cmd.Flags().BoolVar(&applyOpts.skipPreFlight, "skip-preflight", false, "skip pre-flight checks, useful when rerunning kismatic")
+	cmd.Flags().BoolVar(&applyOpts.resume, "resume", false, "resume the last installation from the phase that failed, skipping pre-flight checks and the phases that completed")
+	cmd.Flags().BoolVar(&applyOpts.dryRun, "dry-run", false, dryRunFlagUsage)
 
 	return cmd
 }
 
-func (c *applyCmd) run() error {
+func (c *applyCmd) run(ctx context.Context) error {
+	if c.resume {
+		return c.resumeInstall(ctx)
+	}
 	// Validate and run pre-flight
 	opts := &validateOpts{
 		planFile:           c.planFile,
@@ -89,8 +117,9 @@ func (c *applyCmd) run() error {
 		skipPreFlight:      c.skipPreFlight,
 		generatedAssetsDir: c.generatedAssetsDir,
 		limit:              c.limit,
+		eventsOut:          c.eventsOut,
 	}
-	err := doValidate(c.out, c.planner, opts)
+	err := doValidate(ctx, c.out, c.planner, opts)
 	if err != nil {
 		return fmt.Errorf("error validating plan: %v", err)
 	}
@@ -99,31 +128,74 @@ func (c *applyCmd) run() error {
 		return fmt.Errorf("error reading plan file: %v", err)
 	}
 
-	// Generate certificates
-	if err := c.executor.GenerateCertificates(plan, false); err != nil {
+	if c.dryRun {
+		util.PrintHeader(c.out, "Configuring Certificates", '=')
+		util.PrettyPrintSkipped(c.out, "Dry run: the certificates and the kubeconfig file were not generated")
+	} else {
+		// Generate certificates
+		if err := c.executor.GenerateCertificates(plan, false); err != nil {
+			return fmt.Errorf("error installing: %v", err)
+		}
+
+		// Generate kubeconfig
+		util.PrintHeader(c.out, "Generating Kubeconfig File", '=')
+		err = install.GenerateKubeconfig(plan, c.generatedAssetsDir)
+		if err != nil {
+			return fmt.Errorf("error generating kubeconfig file: %v", err)
+		}
+		util.PrettyPrintOk(c.out, "Generated kubeconfig file in the %q directory", c.generatedAssetsDir)
+	}
+
+	// Perform the installation
+	if err := c.executor.Install(ctx, plan, c.restartServices, c.limit...); err != nil {
 		return fmt.Errorf("error installing: %v", err)
 	}
 
-	// Generate kubeconfig
-	util.PrintHeader(c.out, "Generating Kubeconfig File", '=')
-	err = install.GenerateKubeconfig(plan, c.generatedAssetsDir)
+	return c.completeInstall(ctx, plan)
+}
+
+// resumeInstall runs the phases of the last installation that did not complete.
+// The pre-flight checks are skipped, as they fail on nodes that are partially
+// installed, and the certificates and kubeconfig were generated by the last installation.
+func (c *applyCmd) resumeInstall(ctx context.Context) error {
+	opts := &validateOpts{
+		planFile:           c.planFile,
+		verbose:            c.verbose,
+		outputFormat:       c.outputFormat,
+		skipPreFlight:      true,
+		generatedAssetsDir: c.generatedAssetsDir,
+		eventsOut:          c.eventsOut,
+	}
+	if err := doValidate(ctx, c.out, c.planner, opts); err != nil {
+		return fmt.Errorf("error validating plan: %v", err)
+	}
+	plan, err := c.planner.Read()
 	if err != nil {
-		return fmt.Errorf("error generating kubeconfig file: %v", err)
+		return fmt.Errorf("error reading plan file: %v", err)
 	}
-	util.PrettyPrintOk(c.out, "Generated kubeconfig file in the %q directory", c.generatedAssetsDir)
-
-	// Perform the installation
-	if err := c.executor.Install(plan, c.restartServices, c.limit...); err != nil {
+	from, err := install.FindInstallResumePoint(c.runsDirectory, plan)
+	if err != nil {
+		return err
+	}
+	if err := c.executor.ResumeInstall(ctx, plan, *from, c.restartServices); err != nil {
 		return fmt.Errorf("error installing: %v", err)
 	}
+	return c.completeInstall(ctx, plan)
+}
 
+// completeInstall runs the smoke test once the cluster is installed
+func (c *applyCmd) completeInstall(ctx context.Context, plan *install.Plan) error {
 	// Run smoketest
 	// Don't run
 	if plan.NetworkConfigured() {
-		if err := c.executor.RunSmokeTest(plan); err != nil {
+		if err := c.executor.RunSmokeTest(ctx, plan); err != nil {
 			return fmt.Errorf("error running smoke test: %v", err)
 		}
 	}
+	if c.dryRun {
+		printDryRunComplete(c.out, c.executor.DryRunDirectory())
+		return nil
+	}
 
 	util.PrintColor(c.out, util.Green, "\nThe cluster was installed successfully!\n")
 	fmt.Fprintln(c.out)
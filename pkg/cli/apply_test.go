@@ -2,6 +2,7 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"testing"
 
 	"github.com/apprenda/kismatic/pkg/install"
@@ -21,7 +22,7 @@ func TestApplyCmdInvalidPlanFound(t *testing.T) {
 		executor: fe,
 	}
 
-	err := applyCmd.run()
+	err := applyCmd.run(context.Background())
 
 	// expect an error here... we don't care about testing validation
 	if err == nil {
@@ -1,8 +1,18 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"text/tabwriter"
 
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/ssh"
+	"github.com/apprenda/kismatic/pkg/util"
 	"github.com/spf13/pflag"
 )
 
@@ -10,6 +20,142 @@ func addPlanFileFlag(flagSet *pflag.FlagSet, p *string) {
 	flagSet.StringVarP(p, "plan-file", "f", "kismatic-cluster.yaml", "path to the installation plan file")
 }
 
+func addPlanOverlayFlag(flagSet *pflag.FlagSet, p *[]string) {
+	flagSet.StringSliceVar(p, "overlay", []string{}, "path to a plan file overlay that is merged into the plan file. Overlays are merged in the order they are provided")
+}
+
+// installOutputFormatUsage is the usage of the output flag of the commands
+// that run ansible playbooks
+const installOutputFormatUsage = `installation output format (options "simple"|"raw"|"json"). The json format writes a line of JSON to stdout for every event of the installation, and the other output to stderr`
+
+// messagesOut returns where the messages of a command are written. With the
+// json output format, the events are the only output written to stdout, and
+// the messages are written to stderr.
+func messagesOut(out io.Writer, outputFormat string) io.Writer {
+	if outputFormat == "json" {
+		return os.Stderr
+	}
+	return out
+}
+
+// eventsOut returns where the events of a command are written. Events are
+// only written with the json output format.
+func eventsOut(out io.Writer, outputFormat string) io.Writer {
+	if outputFormat == "json" {
+		return out
+	}
+	return nil
+}
+
+const dryRunFlagUsage = "render the playbooks that would run, with their inventory and variables, in the dry-run directory, without changing the cluster"
+
+// printDryRunComplete prints the message shown at the end of a dry run, with the
+// directory the playbooks that would have run were rendered in, if any
+func printDryRunComplete(out io.Writer, dir string) {
+	if dir == "" {
+		util.PrintColor(out, util.Green, "\nDry run complete, the cluster was not changed. No playbook would have run.\n\n")
+		return
+	}
+	util.PrintColor(out, util.Green, "\nDry run complete, the cluster was not changed. The playbooks that would have run were rendered in %q.\n\n", dir)
+}
+
+// printSlowestTasks prints the tasks that took the longest to run, if any ran
+func printSlowestTasks(out io.Writer, timings []install.TaskTiming) {
+	slowest := install.SlowestTasks(timings, 10)
+	if len(slowest) == 0 {
+		return
+	}
+	util.PrintHeader(out, "Slowest Tasks", '=')
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprint(w, "Duration\tPhase\tTask\tSlowest Host\n")
+	for _, t := range slowest {
+		host := "-"
+		if h, ok := t.SlowestHost(); ok {
+			host = fmt.Sprintf("%s (%s)", h.Host, formatRunDuration(t.Start, h.End))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", formatRunDuration(t.Start, t.End), t.Phase, t.Task, host)
+	}
+	w.Flush()
+	fmt.Fprintf(out, "\nThe time spent on every task was recorded in the %q file of the run directories.\n\n", "timings.json")
+}
+
+// interruptibleContext returns a context that is cancelled when kismatic is
+// interrupted, so that the running playbook is stopped and its run is recorded
+// as cancelled. kismatic exits right away when it is interrupted again.
+// The returned func stops handling the interrupts.
+func interruptibleContext(out io.Writer) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-signals:
+		case <-done:
+			return
+		}
+		util.PrintColor(out, util.Orange, "\nInterrupted, stopping the running operation. Interrupt again to exit immediately.\n")
+		cancel()
+		select {
+		case <-signals:
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(signals)
+		close(done)
+		cancel()
+	}
+}
+
+// verifyHostKeys configures the SSH clients to verify the host keys of the nodes
+// against the known_hosts file of the generated assets directory
+func verifyHostKeys(generatedAssetsDir string) error {
+	file, err := install.KnownHostsFile(generatedAssetsDir)
+	if err != nil {
+		return err
+	}
+	ssh.SetKnownHostsFile(file)
+	return nil
+}
+
+// releaseResolutionOpts control how the Kubernetes releases are resolved
+type releaseResolutionOpts struct {
+	releaseCatalog      string
+	onlineReleaseLookup bool
+}
+
+func addReleaseResolutionFlags(flagSet *pflag.FlagSet, opts *releaseResolutionOpts) {
+	flagSet.StringVar(&opts.releaseCatalog, "release-catalog", "", fmt.Sprintf("path to the catalog of the latest stable Kubernetes releases, used to choose the version when the plan file does not set one (default %q next to the kismatic binary, if it exists)", install.ReleaseCatalogFile))
+	flagSet.BoolVar(&opts.onlineReleaseLookup, "online-release-lookup", false, "look up the latest stable Kubernetes releases that are not listed in the release catalog online")
+}
+
+func setReleaseResolution(opts *releaseResolutionOpts) error {
+	resolutionOpts := install.ReleaseResolutionOptions{
+		CatalogFile:  opts.releaseCatalog,
+		OnlineLookup: opts.onlineReleaseLookup,
+	}
+	if resolutionOpts.CatalogFile == "" {
+		// the catalog shipped with the binary is optional
+		resolutionOpts.CatalogFileOptional = true
+		resolutionOpts.CatalogFile = install.ReleaseCatalogFile
+		if ex, err := os.Executable(); err == nil {
+			resolutionOpts.CatalogFile = filepath.Join(filepath.Dir(ex), install.ReleaseCatalogFile)
+		}
+	}
+	if err := install.SetReleaseResolutionOptions(resolutionOpts); err != nil {
+		return fmt.Errorf("error reading the release catalog: %v", err)
+	}
+	return nil
+}
+
+// planFileOpts are the options shared by commands that read the plan file
+type planFileOpts struct {
+	planFilename string
+	planOverlays []string
+}
+
 type planFileNotFoundErr struct {
 	filename string
 }
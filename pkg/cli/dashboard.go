@@ -18,6 +18,7 @@ type dashboardOpts struct {
 	tokenOnly          bool
 	generatedAssetsDir string
 	planFilename       string
+	planOverlays       []string
 }
 
 const url = "http://localhost:8001/api/v1/namespaces/kube-system/services/https:kubernetes-dashboard:/proxy/#!/login"
@@ -65,6 +66,7 @@ func NewCmdDashboard(in io.Reader, out io.Writer) *cobra.Command {
 	cmd.Flags().BoolVar(&opts.dashboardURLMode, "url", false, "Display the kubernetes dashboard URL instead of opening it in the default browser")
 	cmd.Flags().BoolVar(&opts.tokenOnly, "token", false, "Do not open the dashboard, only generate a kubeconfig file with the admin token")
 	addPlanFileFlag(cmd.PersistentFlags(), &opts.planFilename)
+	addPlanOverlayFlag(cmd.PersistentFlags(), &opts.planOverlays)
 	return cmd
 }
 
@@ -83,7 +85,7 @@ func doDashboard(in io.Reader, out io.Writer, opts *dashboardOpts) error {
 	adminKubeconfig := filepath.Join(opts.generatedAssetsDir, "dashboard-admin-kubeconfig")
 	// Generate dashboard admin certificate if it does not exist
 	if _, err := os.Stat(adminKubeconfig); os.IsNotExist(err) {
-		planner := &install.FilePlanner{File: opts.planFilename}
+		planner := &install.FilePlanner{File: opts.planFilename, Overlays: opts.planOverlays}
 		plan, err := planner.Read()
 		if err != nil {
 			return fmt.Errorf("Error reading plan file: %v", err)
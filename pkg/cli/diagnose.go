@@ -11,9 +11,11 @@ import (
 )
 
 type diagsOpts struct {
-	planFilename string
-	verbose      bool
-	outputFormat string
+	planFilename       string
+	planOverlays       []string
+	generatedAssetsDir string
+	verbose            bool
+	outputFormat       string
 }
 
 // NewCmdDiagnostic collects diagnostic data on remote nodes
@@ -34,6 +36,8 @@ func NewCmdDiagnostic(out io.Writer) *cobra.Command {
 
 	// PersistentFlags
 	addPlanFileFlag(cmd.PersistentFlags(), &opts.planFilename)
+	addPlanOverlayFlag(cmd.PersistentFlags(), &opts.planOverlays)
+	cmd.Flags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "enable verbose logging from the installation")
 	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\")")
 
@@ -44,7 +48,7 @@ func doDiagnostics(out io.Writer, opts *diagsOpts) error {
 	util.PrintHeader(out, "Gathering Diagnostic Data", '=')
 
 	planFile := opts.planFilename
-	planner := install.FilePlanner{File: planFile}
+	planner := install.FilePlanner{File: planFile, Overlays: opts.planOverlays, Log: out}
 
 	// Read plan file
 	if !planner.PlanExists() {
@@ -52,6 +56,9 @@ func doDiagnostics(out io.Writer, opts *diagsOpts) error {
 		return planFileNotFoundErr{filename: planFile}
 	}
 	util.PrettyPrintOk(out, "Reading plan file")
+	if err := verifyHostKeys(opts.generatedAssetsDir); err != nil {
+		return err
+	}
 	plan, err := planner.Read()
 	if err != nil {
 		util.PrettyPrintErr(out, "Reading plan file")
@@ -68,15 +75,18 @@ func doDiagnostics(out io.Writer, opts *diagsOpts) error {
 
 	// Get diagnostics from nodes
 	options := install.ExecutorOptions{
-		OutputFormat: opts.outputFormat,
-		Verbose:      opts.verbose,
+		GeneratedAssetsDirectory: opts.generatedAssetsDir,
+		OutputFormat:             opts.outputFormat,
+		Verbose:                  opts.verbose,
 	}
 	executor, err := install.NewDiagnosticsExecutor(out, os.Stderr, options)
 	if err != nil {
 		return err
 	}
 
-	if err := executor.DiagnoseNodes(*plan); err != nil {
+	ctx, stop := interruptibleContext(out)
+	defer stop()
+	if err := executor.DiagnoseNodes(ctx, *plan); err != nil {
 		return err
 	}
 
@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"context"
+
 	"github.com/apprenda/kismatic/pkg/install"
 	"github.com/apprenda/kismatic/pkg/tls"
 )
@@ -31,7 +33,7 @@ type fakeExecutor struct {
 	err           error
 }
 
-func (fe *fakeExecutor) AddNode(p *install.Plan, newNode install.Node, roles []string, restartServices bool) (*install.Plan, error) {
+func (fe *fakeExecutor) AddNode(ctx context.Context, p *install.Plan, newNode install.Node, roles []string, restartServices bool) (*install.Plan, error) {
 	return nil, nil
 }
 
@@ -39,59 +41,72 @@ func (fe *fakeExecutor) GenerateCertificates(*install.Plan, bool) error {
 	return nil
 }
 
-func (fe *fakeExecutor) Install(p *install.Plan, restartServices bool, nodes ...string) error {
+func (fe *fakeExecutor) Install(ctx context.Context, p *install.Plan, restartServices bool, nodes ...string) error {
+	fe.installCalled = true
+	return fe.err
+}
+
+func (fe *fakeExecutor) ResumeInstall(ctx context.Context, p *install.Plan, from install.InstallResumePoint, restartServices bool) error {
 	fe.installCalled = true
 	return fe.err
 }
 
-func (fe *fakeExecutor) Reset(p *install.Plan, nodes ...string) error {
+func (fe *fakeExecutor) Reset(ctx context.Context, p *install.Plan, nodes ...string) error {
 	return nil
 }
 
-func (fe *fakeExecutor) RunPreFlightCheck(p *install.Plan, nodes ...string) error {
+func (fe *fakeExecutor) RunPreFlightCheck(ctx context.Context, p *install.Plan, nodes ...string) error {
 	return nil
 }
 
-func (fe *fakeExecutor) RunNewNodePreFlightCheck(install.Plan, install.Node) error {
+func (fe *fakeExecutor) RunNewNodePreFlightCheck(context.Context, install.Plan, install.Node) error {
 	return nil
 }
 
-func (fe *fakeExecutor) RunUpgradePreFlightCheck(*install.Plan, install.ListableNode) error {
+func (fe *fakeExecutor) RunUpgradePreFlightCheck(context.Context, *install.Plan, install.ListableNode) error {
 	return nil
 }
 
-func (fe *fakeExecutor) UpgradeNodes(install.Plan, []install.ListableNode, bool, int, bool) error {
+func (fe *fakeExecutor) UpgradeNodes(context.Context, install.Plan, []install.ListableNode, bool, int, bool) error {
 	return nil
 }
 
-func (fe *fakeExecutor) ValidateControlPlane(install.Plan) error {
+func (fe *fakeExecutor) ValidateControlPlane(context.Context, install.Plan) error {
 	return nil
 }
 
-func (fe *fakeExecutor) UpgradeDockerRegistry(install.Plan) error {
+func (fe *fakeExecutor) UpgradeDockerRegistry(context.Context, install.Plan) error {
 	return nil
 }
 
-func (fe *fakeExecutor) UpgradeClusterServices(install.Plan) error {
+func (fe *fakeExecutor) UpgradeClusterServices(context.Context, install.Plan) error {
 	return nil
 }
 
-func (fe *fakeExecutor) RunSmokeTest(p *install.Plan) error {
+func (fe *fakeExecutor) RunSmokeTest(ctx context.Context, p *install.Plan) error {
 	return nil
 }
 
-func (fe *fakeExecutor) RunPlay(string, *install.Plan, bool, ...string) error {
+func (fe *fakeExecutor) RunPlay(context.Context, string, *install.Plan, bool, ...string) error {
 	return nil
 }
 
-func (fe *fakeExecutor) AddVolume(*install.Plan, install.StorageVolume) error {
+func (fe *fakeExecutor) AddVolume(context.Context, *install.Plan, install.StorageVolume) error {
 	return nil
 }
 
-func (fe *fakeExecutor) DeleteVolume(*install.Plan, string) error {
+func (fe *fakeExecutor) DeleteVolume(context.Context, *install.Plan, string) error {
 	return nil
 }
 
+func (fe *fakeExecutor) TaskTimings() []install.TaskTiming {
+	return nil
+}
+
+func (fe *fakeExecutor) DryRunDirectory() string {
+	return ""
+}
+
 type fakePKI struct {
 	called              bool
 	generateCACalled    bool
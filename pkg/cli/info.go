@@ -13,8 +13,10 @@ import (
 )
 
 type infoOpts struct {
-	planFilename string
-	outputFormat string
+	planFilename       string
+	planOverlays       []string
+	generatedAssetsDir string
+	outputFormat       string
 }
 
 // NewCmdInfo returns the info command
@@ -31,13 +33,18 @@ This will be retrieved by connecting to each node via ssh`,
 		},
 	}
 	cmd.Flags().StringVarP(&opts.planFilename, "plan-file", "f", "kismatic-cluster.yaml", "path to the installation plan file")
+	addPlanOverlayFlag(cmd.Flags(), &opts.planOverlays)
+	cmd.Flags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", `output format (options "simple"|"json")`)
 	return cmd
 }
 
 func list(out io.Writer, opts *infoOpts) error {
+	if err := verifyHostKeys(opts.generatedAssetsDir); err != nil {
+		return err
+	}
 	// Check if plan file exists
-	planner := &install.FilePlanner{File: opts.planFilename}
+	planner := &install.FilePlanner{File: opts.planFilename, Overlays: opts.planOverlays, Log: messagesOut(out, opts.outputFormat)}
 	if !planner.PlanExists() {
 		return fmt.Errorf("plan does not exist")
 	}
@@ -52,16 +59,15 @@ func list(out io.Writer, opts *infoOpts) error {
 		return fmt.Errorf("error validating nodes")
 	}
 
-	// Validate SSH connections
-	if ok, errs := install.ValidatePlanSSHConnections(plan); !ok {
-		util.PrintValidationErrors(out, errs)
-		return fmt.Errorf("error getting info from cluster nodes")
-	}
-
+	// Nodes that cannot be reached are reported, instead of failing
 	lv, err := install.ListVersions(plan)
 	if err != nil {
 		return fmt.Errorf("error getting version: %v", err)
 	}
+	if len(lv.Nodes) == 0 {
+		printUnreachableNodes(out, lv.UnreachableNodes)
+		return fmt.Errorf("error getting info from cluster nodes: none of the nodes could be reached")
+	}
 
 	if opts.outputFormat == "json" {
 		b, err := json.MarshalIndent(lv, "", "  ")
@@ -85,5 +91,23 @@ func list(out io.Writer, opts *infoOpts) error {
 	for _, listNode := range lv.Nodes {
 		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", listNode.Node.Host, listNode.Node.IP, strings.Join(listNode.Roles, ","), listNode.Version)
 	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	if len(lv.UnreachableNodes) > 0 {
+		fmt.Fprintln(out)
+		printUnreachableNodes(out, lv.UnreachableNodes)
+	}
+	return nil
+}
+
+// printUnreachableNodes prints the nodes that could not be reached, along with the reason
+func printUnreachableNodes(out io.Writer, nodes []install.UnreachableNode) error {
+	fmt.Fprintf(out, "Unreachable Nodes:\n")
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprint(w, "Name\tIP\tRoles\tError\n")
+	for _, n := range nodes {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", n.Node.Host, n.Node.IP, strings.Join(n.Roles, ","), n.Error)
+	}
 	return w.Flush()
 }
@@ -8,6 +8,7 @@ import (
 
 type installOpts struct {
 	planFilename string
+	planOverlays []string
 }
 
 // NewCmdInstall creates a new install command
@@ -31,6 +32,7 @@ func NewCmdInstall(in io.Reader, out io.Writer) *cobra.Command {
 
 	// PersistentFlags
 	addPlanFileFlag(cmd.PersistentFlags(), &opts.planFilename)
+	addPlanOverlayFlag(cmd.PersistentFlags(), &opts.planOverlays)
 
 	return cmd
 }
@@ -11,6 +11,7 @@ import (
 
 type ipOpts struct {
 	planFilename string
+	planOverlays []string
 }
 
 // NewCmdIP prints the cluster's IP
@@ -24,13 +25,14 @@ func NewCmdIP(out io.Writer) *cobra.Command {
 			if len(args) != 0 {
 				return fmt.Errorf("Unexpected args: %v", args)
 			}
-			planner := &install.FilePlanner{File: opts.planFilename}
+			planner := &install.FilePlanner{File: opts.planFilename, Overlays: opts.planOverlays}
 			return doIP(out, planner, opts)
 		},
 	}
 
 	// PersistentFlags
 	cmd.PersistentFlags().StringVarP(&opts.planFilename, "plan-file", "f", "kismatic-cluster.yaml", "path to the installation plan file")
+	addPlanOverlayFlag(cmd.PersistentFlags(), &opts.planOverlays)
 
 	return cmd
 }
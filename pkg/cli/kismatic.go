@@ -8,6 +8,7 @@ import (
 
 // NewKismaticCommand creates the kismatic command
 func NewKismaticCommand(version string, buildDate string, in io.Reader, out, stderr io.Writer) (*cobra.Command, error) {
+	releaseOpts := &releaseResolutionOpts{}
 	cmd := &cobra.Command{
 		Use:   "kismatic",
 		Short: "kismatic is the main tool for managing your Kubernetes cluster",
@@ -16,18 +17,27 @@ more documentation is available at https://github.com/apprenda/kismatic`,
 		Run: func(cmd *cobra.Command, args []string) {
 			cmd.Help()
 		},
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return setReleaseResolution(releaseOpts)
+		},
 		SilenceUsage:  true,
 		SilenceErrors: true,
 	}
 
+	addReleaseResolutionFlags(cmd.PersistentFlags(), releaseOpts)
+
 	cmd.AddCommand(NewCmdVersion(buildDate, out))
+	cmd.AddCommand(NewCmdVersions(out))
 	cmd.AddCommand(NewCmdInstall(in, out))
+	cmd.AddCommand(NewCmdPlanFile(in, out))
 	cmd.AddCommand(NewCmdReset(in, out))
 	cmd.AddCommand(NewCmdVolume(in, out))
 	cmd.AddCommand(NewCmdIP(out))
 	cmd.AddCommand(NewCmdDashboard(in, out))
 	cmd.AddCommand(NewCmdSSH(out))
+	cmd.AddCommand(NewCmdSSHKeys(out))
 	cmd.AddCommand(NewCmdInfo(out))
+	cmd.AddCommand(NewCmdRuns(out))
 	cmd.AddCommand(NewCmdUpgrade(in, out))
 	cmd.AddCommand(NewCmdDiagnostic(out))
 	cmd.AddCommand(NewCmdCertificates(out))
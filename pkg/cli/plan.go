@@ -1,31 +1,174 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+
+	yaml "gopkg.in/yaml.v2"
 
 	"github.com/apprenda/kismatic/pkg/install"
 	"github.com/apprenda/kismatic/pkg/util"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
+type planOpts struct {
+	preset       string
+	answersFile  string
+	templateOpts install.PlanTemplateOptions
+}
+
+// planAnswers are the answers to the plan questions, read from a YAML file.
+// Answers that are not set are taken from the preset.
+type planAnswers struct {
+	Preset                string  `yaml:"preset"`
+	EtcdNodes             *int    `yaml:"etcd_nodes"`
+	MasterNodes           *int    `yaml:"master_nodes"`
+	WorkerNodes           *int    `yaml:"worker_nodes"`
+	IngressNodes          *int    `yaml:"ingress_nodes"`
+	StorageNodes          *int    `yaml:"storage_nodes"`
+	AdditionalFiles       *int    `yaml:"additional_files"`
+	AdminPassword         *string `yaml:"admin_password"`
+	CNIProvider           *string `yaml:"cni_provider"`
+	DNSProvider           *string `yaml:"dns_provider"`
+	DisableHeapster       *bool   `yaml:"disable_heapster"`
+	DisableDashboard      *bool   `yaml:"disable_dashboard"`
+	DisablePackageManager *bool   `yaml:"disable_package_manager"`
+}
+
 // NewCmdPlan creates a new install plan command
 func NewCmdPlan(in io.Reader, out io.Writer, options *installOpts) *cobra.Command {
+	opts := &planOpts{}
 	cmd := &cobra.Command{
 		Use:   "plan",
 		Short: "plan your Kubernetes cluster and generate a plan file",
+		Long: `Plan your Kubernetes cluster and generate a plan file.
+
+By default, the questions required to plan the cluster are asked interactively.
+The questions are not asked when a preset, an answers file or any of the plan
+flags are provided. In that case, the preset is applied first, followed by
+the answers file and the flags.`,
+		Example: `  # Generate a plan file for a small highly available cluster
+  kismatic install plan --preset ha-small
+
+  # Generate a plan file with 5 worker nodes, using weave as the CNI provider
+  kismatic install plan --worker-nodes 5 --cni-provider weave
+
+  # Generate a plan file using the answers found in answers.yaml
+  kismatic install plan --answers answers.yaml`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) != 0 {
 				return fmt.Errorf("Unexpected args: %v", args)
 			}
 			planner := &install.FilePlanner{File: options.planFilename}
-			return doPlan(in, out, planner, options.planFilename)
+			if !cmd.Flags().Changed("preset") && opts.answersFile == "" && !planFlagsChanged(cmd.Flags()) {
+				return doPlan(in, out, planner, options.planFilename)
+			}
+			templateOpts, err := planTemplateOptions(cmd.Flags(), opts)
+			if err != nil {
+				return err
+			}
+			return writePlanTemplate(out, planner, options.planFilename, templateOpts)
 		},
 	}
 
+	cmd.Flags().StringVar(&opts.preset, "preset", install.DefaultPlanTemplatePreset, fmt.Sprintf("preset to use for the cluster topology (options %v)", install.PlanTemplatePresets()))
+	cmd.Flags().StringVar(&opts.answersFile, "answers", "", "path to a YAML file with the answers to the plan questions")
+	cmd.Flags().IntVar(&opts.templateOpts.EtcdNodes, "etcd-nodes", 0, "number of etcd nodes")
+	cmd.Flags().IntVar(&opts.templateOpts.MasterNodes, "master-nodes", 0, "number of master nodes")
+	cmd.Flags().IntVar(&opts.templateOpts.WorkerNodes, "worker-nodes", 0, "number of worker nodes")
+	cmd.Flags().IntVar(&opts.templateOpts.IngressNodes, "ingress-nodes", 0, "number of ingress nodes")
+	cmd.Flags().IntVar(&opts.templateOpts.StorageNodes, "storage-nodes", 0, "number of storage nodes")
+	cmd.Flags().IntVar(&opts.templateOpts.AdditionalFiles, "additional-files", 0, "number of existing files or directories to be copied")
+	cmd.Flags().StringVar(&opts.templateOpts.AdminPassword, "admin-password", "", "password for the admin user")
+	cmd.Flags().StringVar(&opts.templateOpts.CNIProvider, "cni-provider", "", `CNI provider (options "calico"|"contiv"|"weave"|"custom")`)
+	cmd.Flags().StringVar(&opts.templateOpts.DNSProvider, "dns-provider", "", `DNS provider (options "kubedns"|"coredns")`)
+	cmd.Flags().BoolVar(&opts.templateOpts.DisableHeapster, "disable-heapster", false, "disable the heapster monitoring add-on")
+	cmd.Flags().BoolVar(&opts.templateOpts.DisableDashboard, "disable-dashboard", false, "disable the dashboard add-on")
+	cmd.Flags().BoolVar(&opts.templateOpts.DisablePackageManager, "disable-package-manager", false, "disable the package manager add-on")
+
 	return cmd
 }
 
+// the flags that map to a plan template option
+var planTemplateFlags = []string{"etcd-nodes", "master-nodes", "worker-nodes", "ingress-nodes", "storage-nodes", "additional-files", "admin-password", "cni-provider", "dns-provider", "disable-heapster", "disable-dashboard", "disable-package-manager"}
+
+func planFlagsChanged(flags *pflag.FlagSet) bool {
+	for _, f := range planTemplateFlags {
+		if flags.Changed(f) {
+			return true
+		}
+	}
+	return false
+}
+
+// planTemplateOptions returns the plan template options, starting from the preset
+// and applying the answers file and the flags that were set on top of it.
+func planTemplateOptions(flags *pflag.FlagSet, opts *planOpts) (install.PlanTemplateOptions, error) {
+	answers := planAnswers{}
+	if opts.answersFile != "" {
+		b, err := ioutil.ReadFile(opts.answersFile)
+		if err != nil {
+			return install.PlanTemplateOptions{}, fmt.Errorf("error reading answers file: %v", err)
+		}
+		// a misspelled answer would otherwise be ignored silently
+		if err = yaml.UnmarshalStrict(b, &answers); err != nil {
+			return install.PlanTemplateOptions{}, fmt.Errorf("error unmarshalling answers file: %v", err)
+		}
+	}
+
+	// the preset flag takes precedence over the preset in the answers file
+	preset := opts.preset
+	if answers.Preset != "" && !flags.Changed("preset") {
+		preset = answers.Preset
+	}
+	o, err := install.PlanTemplatePreset(preset)
+	if err != nil {
+		return install.PlanTemplateOptions{}, err
+	}
+
+	setInt := func(dst *int, answer *int, flag string, flagValue int) {
+		if answer != nil {
+			*dst = *answer
+		}
+		if flags.Changed(flag) {
+			*dst = flagValue
+		}
+	}
+	setString := func(dst *string, answer *string, flag string, flagValue string) {
+		if answer != nil {
+			*dst = *answer
+		}
+		if flags.Changed(flag) {
+			*dst = flagValue
+		}
+	}
+	setBool := func(dst *bool, answer *bool, flag string, flagValue bool) {
+		if answer != nil {
+			*dst = *answer
+		}
+		if flags.Changed(flag) {
+			*dst = flagValue
+		}
+	}
+	f := opts.templateOpts
+	setInt(&o.EtcdNodes, answers.EtcdNodes, "etcd-nodes", f.EtcdNodes)
+	setInt(&o.MasterNodes, answers.MasterNodes, "master-nodes", f.MasterNodes)
+	setInt(&o.WorkerNodes, answers.WorkerNodes, "worker-nodes", f.WorkerNodes)
+	setInt(&o.IngressNodes, answers.IngressNodes, "ingress-nodes", f.IngressNodes)
+	setInt(&o.StorageNodes, answers.StorageNodes, "storage-nodes", f.StorageNodes)
+	setInt(&o.AdditionalFiles, answers.AdditionalFiles, "additional-files", f.AdditionalFiles)
+	setString(&o.AdminPassword, answers.AdminPassword, "admin-password", f.AdminPassword)
+	setString(&o.CNIProvider, answers.CNIProvider, "cni-provider", f.CNIProvider)
+	setString(&o.DNSProvider, answers.DNSProvider, "dns-provider", f.DNSProvider)
+	setBool(&o.DisableHeapster, answers.DisableHeapster, "disable-heapster", f.DisableHeapster)
+	setBool(&o.DisableDashboard, answers.DisableDashboard, "disable-dashboard", f.DisableDashboard)
+	setBool(&o.DisablePackageManager, answers.DisablePackageManager, "disable-package-manager", f.DisablePackageManager)
+	return o, nil
+}
+
 func doPlan(in io.Reader, out io.Writer, planner install.Planner, planFile string) error {
 	fmt.Fprintln(out, "Plan your Kubernetes cluster:")
 
@@ -77,16 +220,6 @@ func doPlan(in io.Reader, out io.Writer, planner install.Planner, planFile strin
 		return fmt.Errorf("The number of files or directories must be greater than or equal to zero")
 	}
 
-	fmt.Fprintln(out)
-	fmt.Fprintf(out, "Generating installation plan file template with: \n")
-	fmt.Fprintf(out, "- %d etcd nodes\n", etcdNodes)
-	fmt.Fprintf(out, "- %d master nodes\n", masterNodes)
-	fmt.Fprintf(out, "- %d worker nodes\n", workerNodes)
-	fmt.Fprintf(out, "- %d ingress nodes\n", ingressNodes)
-	fmt.Fprintf(out, "- %d storage nodes\n", storageNodes)
-	fmt.Fprintf(out, "- %d files\n", files)
-	fmt.Fprintln(out)
-
 	planTemplate := install.PlanTemplateOptions{
 		EtcdNodes:       etcdNodes,
 		MasterNodes:     masterNodes,
@@ -95,7 +228,45 @@ func doPlan(in io.Reader, out io.Writer, planner install.Planner, planFile strin
 		StorageNodes:    storageNodes,
 		AdditionalFiles: files,
 	}
-	if err = install.WritePlanTemplate(planTemplate, planner); err != nil {
+	return writePlanTemplate(out, planner, planFile, planTemplate)
+}
+
+func writePlanTemplate(out io.Writer, planner install.Planner, planFile string, planTemplate install.PlanTemplateOptions) error {
+	if ok, errs := install.ValidatePlanTemplateOptions(&planTemplate); !ok {
+		util.PrintValidationErrors(out, errs)
+		return errors.New("the plan options are invalid")
+	}
+
+	cniProvider := planTemplate.CNIProvider
+	if cniProvider == "" {
+		cniProvider = "calico"
+	}
+	dnsProvider := planTemplate.DNSProvider
+	if dnsProvider == "" {
+		dnsProvider = "kubedns"
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Generating installation plan file template with: \n")
+	fmt.Fprintf(out, "- %d etcd nodes\n", planTemplate.EtcdNodes)
+	fmt.Fprintf(out, "- %d master nodes\n", planTemplate.MasterNodes)
+	fmt.Fprintf(out, "- %d worker nodes\n", planTemplate.WorkerNodes)
+	fmt.Fprintf(out, "- %d ingress nodes\n", planTemplate.IngressNodes)
+	fmt.Fprintf(out, "- %d storage nodes\n", planTemplate.StorageNodes)
+	fmt.Fprintf(out, "- %d files\n", planTemplate.AdditionalFiles)
+	fmt.Fprintf(out, "- %s CNI provider\n", cniProvider)
+	fmt.Fprintf(out, "- %s DNS provider\n", dnsProvider)
+	if planTemplate.DisableHeapster {
+		fmt.Fprintf(out, "- heapster monitoring disabled\n")
+	}
+	if planTemplate.DisableDashboard {
+		fmt.Fprintf(out, "- dashboard disabled\n")
+	}
+	if planTemplate.DisablePackageManager {
+		fmt.Fprintf(out, "- package manager disabled\n")
+	}
+	fmt.Fprintln(out)
+
+	if err := install.WritePlanTemplate(planTemplate, planner); err != nil {
 		return fmt.Errorf("error planning installation: %v", err)
 	}
 	fmt.Fprintf(out, "Wrote plan file template to %q\n", planFile)
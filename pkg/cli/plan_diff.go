@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+type planDiffOpts struct {
+	against       string
+	runsDirectory string
+}
+
+// NewCmdPlanDiff returns the command for comparing the plan file to the
+// plan that was last applied to the cluster
+func NewCmdPlanDiff(out io.Writer, planOpts *planFileOpts) *cobra.Command {
+	opts := &planDiffOpts{}
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "compare the plan file to the plan of the last successful run",
+		Long: `Compare the plan file to the plan of the last successful run.
+
+The changes made to the plan file since the last successful run are listed,
+along with the playbooks that would apply them and the cluster components that
+would be restarted. Secrets are not compared, as they are not recorded in the runs directory.`,
+		Example: `  # Review the changes made to the plan file
+  kismatic plan diff
+
+  # Compare the plan file to another plan file
+  kismatic plan diff --against runs/apply/2018-03-01-10-00-00/kismatic-cluster.yaml`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("Unexpected args: %v", args)
+			}
+			planner := &install.FilePlanner{File: planOpts.planFilename, Overlays: planOpts.planOverlays, Log: out}
+			return doPlanDiff(out, planner, *opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.against, "against", "", "plan file to compare against. Defaults to the plan file of the last successful run")
+	cmd.Flags().StringVar(&opts.runsDirectory, "runs-dir", "runs", "path to the directory where information about previous runs is kept")
+	return cmd
+}
+
+func doPlanDiff(out io.Writer, planner *install.FilePlanner, opts planDiffOpts) error {
+	if !planner.PlanExists() {
+		return planFileNotFoundErr{filename: planner.File}
+	}
+	against := opts.against
+	if against == "" {
+		var err error
+		if against, err = install.LastAppliedPlanFile(opts.runsDirectory); err != nil {
+			return err
+		}
+	}
+	applied, err := (&install.FilePlanner{File: against}).Read()
+	if err != nil {
+		return fmt.Errorf("error reading plan file %q: %v", against, err)
+	}
+	plan, err := planner.Read()
+	if err != nil {
+		return fmt.Errorf("error reading plan file: %v", err)
+	}
+	diff, err := install.DiffPlans(*applied, *plan)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Comparing %q to %q\n\n", planner.File, against)
+	if diff.Empty() {
+		util.PrettyPrintOk(out, "No changes found")
+		return nil
+	}
+	util.PrintHeader(out, "Changes", '=')
+	for _, c := range diff.Changes {
+		switch c.Type {
+		case install.PlanChangeAdded:
+			util.PrintColor(out, util.Green, "+ %s: %s\n", c.Path, c.New)
+		case install.PlanChangeRemoved:
+			util.PrintColor(out, util.Red, "- %s: %s\n", c.Path, c.Old)
+		default:
+			util.PrintColor(out, util.Orange, "~ %s: %s -> %s\n", c.Path, c.Old, c.New)
+		}
+	}
+	fmt.Fprintln(out)
+	util.PrintHeader(out, "Actions", '=')
+	if len(diff.Actions) == 0 {
+		fmt.Fprintln(out, "The changes do not require any action")
+		return nil
+	}
+	for _, a := range diff.Actions {
+		fmt.Fprintln(out, a.Description)
+		if a.Command != "" {
+			fmt.Fprintf(out, "  Command:  %s\n", a.Command)
+		}
+		if len(a.Restarts) > 0 {
+			fmt.Fprintf(out, "  Restarts: %s\n", strings.Join(a.Restarts, ", "))
+		}
+		fmt.Fprintf(out, "  Changes:  %s\n", strings.Join(a.Paths, ", "))
+	}
+	return nil
+}
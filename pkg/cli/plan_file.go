@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCmdPlanFile returns the command for managing the plan file
+func NewCmdPlanFile(in io.Reader, out io.Writer) *cobra.Command {
+	opts := &planFileOpts{}
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "manage your plan file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Usage()
+		},
+	}
+	addPlanFileFlag(cmd.PersistentFlags(), &opts.planFilename)
+	addPlanOverlayFlag(cmd.PersistentFlags(), &opts.planOverlays)
+	cmd.AddCommand(NewCmdPlanMigrate(out, opts))
+	cmd.AddCommand(NewCmdPlanSchema(out))
+	cmd.AddCommand(NewCmdPlanRender(out, opts))
+	cmd.AddCommand(NewCmdPlanNodes(out, opts))
+	cmd.AddCommand(NewCmdPlanDiff(out, opts))
+	return cmd
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdPlanMigrate returns the command for migrating a plan file to the
+// current schema version
+func NewCmdPlanMigrate(out io.Writer, opts *planFileOpts) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "upgrade the plan file to the latest schema version",
+		Long: `Upgrade the plan file to the latest schema version.
+
+The plan file is updated in place, and a backup of the original file is created
+next to it. The changes made to the plan file are printed once the migration is complete.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("Unexpected args: %v", args)
+			}
+			if len(opts.planOverlays) > 0 {
+				return errors.New("overlays cannot be migrated, only the plan file given by --plan-file is migrated")
+			}
+			planner := &install.FilePlanner{File: opts.planFilename}
+			return doPlanMigrate(out, planner)
+		},
+	}
+	return cmd
+}
+
+func doPlanMigrate(out io.Writer, planner *install.FilePlanner) error {
+	if !planner.PlanExists() {
+		return planFileNotFoundErr{filename: planner.File}
+	}
+	version, err := planner.SchemaVersion()
+	if err != nil {
+		return fmt.Errorf("error reading plan file: %v", err)
+	}
+	steps, err := install.PlanMigrationSteps(version)
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		fmt.Fprintf(out, "Plan file %q is already at schema version %q\n", planner.File, install.PlanAPIVersion)
+		return nil
+	}
+
+	original, err := ioutil.ReadFile(planner.File)
+	if err != nil {
+		return fmt.Errorf("error reading plan file: %v", err)
+	}
+	plan, err := planner.ReadWithoutDefaults()
+	if err != nil {
+		return fmt.Errorf("error reading plan file: %v", err)
+	}
+
+	backup := fmt.Sprintf("%s.%s.bak", planner.File, time.Now().Format("2006-01-02-15-04-05"))
+	if err = ioutil.WriteFile(backup, original, 0644); err != nil {
+		return fmt.Errorf("error backing up plan file: %v", err)
+	}
+	util.PrettyPrintOk(out, "Backed up plan file to %q", backup)
+
+	for _, s := range steps {
+		util.PrettyPrintOk(out, "Migrate from %s to %s: %s", s.From, s.To, s.Description)
+	}
+	if err = planner.Write(plan); err != nil {
+		return fmt.Errorf("error writing plan file: %v", err)
+	}
+	migrated, err := ioutil.ReadFile(planner.File)
+	if err != nil {
+		return fmt.Errorf("error reading plan file: %v", err)
+	}
+	util.PrettyPrintOk(out, "Migrated plan file %q to schema version %q", planner.File, install.PlanAPIVersion)
+
+	fmt.Fprintln(out)
+	util.PrintHeader(out, "Changes", '=')
+	util.PrintDiff(out, string(original), string(migrated), 2)
+	return nil
+}
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+type planNodesImportOpts struct {
+	format string
+	merge  bool
+}
+
+// NewCmdPlanNodes returns the command for managing the nodes of the plan file
+func NewCmdPlanNodes(out io.Writer, opts *planFileOpts) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "nodes",
+		Short: "manage the nodes of the plan file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Usage()
+		},
+	}
+	cmd.AddCommand(NewCmdPlanNodesImport(out, opts))
+	return cmd
+}
+
+// NewCmdPlanNodesImport returns the command for importing nodes into the plan file
+func NewCmdPlanNodesImport(out io.Writer, planOpts *planFileOpts) *cobra.Command {
+	opts := &planNodesImportOpts{}
+	cmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "import the nodes of the cluster from a CSV file, terraform output or ansible inventory",
+		Long: `Import the nodes of the cluster from a CSV file, terraform output or ansible inventory.
+
+The node groups found in the file replace the node groups of the plan file, and their
+expected count is updated. Node groups that are not found in the file, and the rest of
+the plan file, are left untouched. Use --merge to add the nodes to the existing node groups instead.
+
+CSV files must have a header row with the "roles", "host" and "ip" columns, and can
+optionally have the "internal_ip", "labels" and "taints" columns. Multiple roles
+are separated by spaces or semicolons:
+
+  roles,host,ip,internal_ip,labels,taints
+  etcd;master,master1,10.0.0.1,192.168.0.1,,
+  worker,worker1,10.0.0.2,192.168.0.2,"env=prod,tier=web",dedicated=web:NoSchedule
+
+Terraform output is the result of "terraform output -json", where the etcd, master,
+worker, ingress and storage outputs are lists of objects with the "host", "ip",
+"internal_ip", "labels" and "taints" keys.
+
+Ansible inventories define a group for each role, and the ansible_host, internal_ip,
+labels and taints variables for each host:
+
+  [worker]
+  worker1 ansible_host=10.0.0.2 internal_ip=192.168.0.2 labels="env=prod" taints="dedicated=web:NoSchedule"`,
+		Example: `  # Import the nodes created by terraform
+  terraform output -json > nodes.json
+  kismatic plan nodes import nodes.json
+
+  # Add the nodes found in a CMDB export to the existing nodes
+  kismatic plan nodes import --format csv --merge export.txt`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Usage()
+			}
+			if len(planOpts.planOverlays) > 0 {
+				return errors.New("nodes cannot be imported when using plan file overlays")
+			}
+			planner := &install.FilePlanner{File: planOpts.planFilename, Log: out}
+			return doPlanNodesImport(out, planner, args[0], *opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.format, "format", "", fmt.Sprintf("format of the file (options %v). Determined from the file extension if not set", install.NodeInventoryFormats()))
+	cmd.Flags().BoolVar(&opts.merge, "merge", false, "add the nodes to the existing nodes of the plan file, instead of replacing them")
+	return cmd
+}
+
+func doPlanNodesImport(out io.Writer, planner *install.FilePlanner, file string, opts planNodesImportOpts) error {
+	if !planner.PlanExists() {
+		return planFileNotFoundErr{filename: planner.File}
+	}
+	format := opts.format
+	if format == "" {
+		var err error
+		if format, err = install.NodeInventoryFormatFromFile(file); err != nil {
+			return err
+		}
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("error opening file: %v", err)
+	}
+	defer f.Close()
+	inv, err := install.ReadNodeInventory(f, format)
+	if err != nil {
+		return fmt.Errorf("error reading %q: %v", file, err)
+	}
+
+	original, err := ioutil.ReadFile(planner.File)
+	if err != nil {
+		return fmt.Errorf("error reading plan file: %v", err)
+	}
+	plan, err := planner.ReadWithoutDefaults()
+	if err != nil {
+		return fmt.Errorf("error reading plan file: %v", err)
+	}
+	plan.ImportNodes(inv, opts.merge)
+	if err = planner.Write(plan); err != nil {
+		return fmt.Errorf("error writing plan file: %v", err)
+	}
+	updated, err := ioutil.ReadFile(planner.File)
+	if err != nil {
+		return fmt.Errorf("error reading plan file: %v", err)
+	}
+
+	for _, role := range inv.Roles() {
+		util.PrettyPrintOk(out, "Imported %d %s nodes", len(inv[role]), role)
+	}
+	util.PrettyPrintOk(out, "Updated plan file %q", planner.File)
+	fmt.Fprintln(out)
+	util.PrintHeader(out, "Changes", '=')
+	util.PrintDiff(out, string(original), string(updated), 2)
+	return nil
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdPlanRender returns the command for printing the effective plan
+func NewCmdPlanRender(out io.Writer, opts *planFileOpts) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "print the effective plan, after merging all overlays into the plan file",
+		Long: `Print the effective plan, after merging all overlays into the plan file.
+
+Overlays are merged into the plan file in the order they are provided. Maps, such
+as option_overrides and node labels, are merged key by key. Any other value in an
+overlay, including the list of nodes of a node group, replaces the value found in
+the plan file.`,
+		Example: `  # Print the plan for the production environment
+  kismatic plan render -f base.yaml --overlay prod.yaml`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("Unexpected args: %v", args)
+			}
+			planner := &install.FilePlanner{File: opts.planFilename, Overlays: opts.planOverlays}
+			return doPlanRender(out, planner)
+		},
+	}
+	return cmd
+}
+
+func doPlanRender(out io.Writer, planner *install.FilePlanner) error {
+	if !planner.PlanExists() {
+		return planFileNotFoundErr{filename: planner.File}
+	}
+	plan, err := planner.Read()
+	if err != nil {
+		return fmt.Errorf("error reading plan file: %v", err)
+	}
+	return install.WritePlan(out, plan)
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdPlanSchema returns the command for printing the JSON Schema of the plan file
+func NewCmdPlanSchema(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "print the JSON Schema of the plan file",
+		Long: `Print the JSON Schema of the plan file.
+
+The schema can be used by editors that support JSON Schema to provide
+autocompletion and validation of the plan file, or to validate plan files
+without running kismatic.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("Unexpected args: %v", args)
+			}
+			fmt.Fprint(out, install.PlanJSONSchema)
+			return nil
+		},
+	}
+	return cmd
+}
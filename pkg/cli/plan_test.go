@@ -2,9 +2,15 @@ package cli
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/apprenda/kismatic/pkg/install"
 )
 
 func TestPlanCmdPlanNotFound(t *testing.T) {
@@ -101,3 +107,111 @@ func TestPlanCmdPlanNotFound(t *testing.T) {
 		}
 	}
 }
+
+func TestPlanCmdNonInteractive(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-plan-cmd")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	answersFile := filepath.Join(tmpDir, "answers.yaml")
+	answers := "preset: ha-large\nworker_nodes: 7\ncni_provider: weave\n"
+	if err = ioutil.WriteFile(answersFile, []byte(answers), 0644); err != nil {
+		t.Fatalf("error writing answers file: %v", err)
+	}
+	misspelledAnswersFile := filepath.Join(tmpDir, "misspelled-answers.yaml")
+	if err = ioutil.WriteFile(misspelledAnswersFile, []byte("worker_node: 5\n"), 0644); err != nil {
+		t.Fatalf("error writing answers file: %v", err)
+	}
+
+	tests := []struct {
+		args            []string
+		shouldError     bool
+		expectedEtcd    int
+		expectedWorker  int
+		expectedStorage int
+		expectedCNI     string
+		expectedDNS     string
+	}{
+		{
+			// default preset is used when only flags are set
+			args:           []string{"--worker-nodes", "5"},
+			expectedEtcd:   3,
+			expectedWorker: 5,
+			expectedCNI:    "calico",
+			expectedDNS:    "kubedns",
+		},
+		{
+			args:            []string{"--preset", "ha-large"},
+			expectedEtcd:    5,
+			expectedWorker:  10,
+			expectedStorage: 2,
+			expectedCNI:     "calico",
+			expectedDNS:     "coredns",
+		},
+		{
+			// answers file overrides the preset, flags override the answers file
+			args:            []string{"--answers", answersFile, "--cni-provider", "contiv"},
+			expectedEtcd:    5,
+			expectedWorker:  7,
+			expectedStorage: 2,
+			expectedCNI:     "contiv",
+			expectedDNS:     "coredns",
+		},
+		{
+			args:        []string{"--preset", "foo"},
+			shouldError: true,
+		},
+		{
+			args:        []string{"--etcd-nodes", "0"},
+			shouldError: true,
+		},
+		{
+			args:        []string{"--cni-provider", "foo"},
+			shouldError: true,
+		},
+		{
+			// unknown answers are rejected
+			args:        []string{"--answers", misspelledAnswersFile},
+			shouldError: true,
+		},
+	}
+	for i, test := range tests {
+		planFile := filepath.Join(tmpDir, fmt.Sprintf("plan-%d.yaml", i))
+		out := &bytes.Buffer{}
+		cmd := NewCmdPlan(strings.NewReader(""), out, &installOpts{planFilename: planFile})
+		cmd.SetArgs(test.args)
+		cmd.SetOutput(ioutil.Discard)
+		err := cmd.Execute()
+		if test.shouldError {
+			if err == nil {
+				t.Errorf("%v: expected an error, but didn't get one", test.args)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error running command: %v", test.args, err)
+			continue
+		}
+		fp := &install.FilePlanner{File: planFile}
+		p, err := fp.Read()
+		if err != nil {
+			t.Fatalf("%v: error reading plan file: %v", test.args, err)
+		}
+		if p.Etcd.ExpectedCount != test.expectedEtcd {
+			t.Errorf("%v: expected %d etcd nodes, got %d", test.args, test.expectedEtcd, p.Etcd.ExpectedCount)
+		}
+		if p.Worker.ExpectedCount != test.expectedWorker {
+			t.Errorf("%v: expected %d worker nodes, got %d", test.args, test.expectedWorker, p.Worker.ExpectedCount)
+		}
+		if p.Storage.ExpectedCount != test.expectedStorage {
+			t.Errorf("%v: expected %d storage nodes, got %d", test.args, test.expectedStorage, p.Storage.ExpectedCount)
+		}
+		if p.AddOns.CNI.Provider != test.expectedCNI {
+			t.Errorf("%v: expected CNI provider %q, got %q", test.args, test.expectedCNI, p.AddOns.CNI.Provider)
+		}
+		if p.AddOns.DNS.Provider != test.expectedDNS {
+			t.Errorf("%v: expected DNS provider %q, got %q", test.args, test.expectedDNS, p.AddOns.DNS.Provider)
+		}
+	}
+}
@@ -13,12 +13,14 @@ import (
 
 type resetOpts struct {
 	planFilename       string
+	planOverlays       []string
 	generatedAssetsDir string
 	verbose            bool
 	outputFormat       string
 	limit              []string
 	force              bool
 	removeAssets       bool
+	dryRun             bool
 }
 
 // NewCmdReset resets nodes
@@ -31,8 +33,8 @@ func NewCmdReset(in io.Reader, out io.Writer) *cobra.Command {
 			if len(args) != 0 {
 				return fmt.Errorf("Unexpected args: %v", args)
 			}
-			if opts.force == false {
-				ans, err := util.PromptForString(in, out, "Are you sure you want to reset the cluster? All data will be lost", "N", []string{"N", "y"})
+			if opts.force == false && !opts.dryRun {
+				ans, err := util.PromptForString(in, messagesOut(out, opts.outputFormat), "Are you sure you want to reset the cluster? All data will be lost", "N", []string{"N", "y"})
 				if err != nil {
 					return fmt.Errorf("error getting user response: %v", err)
 				}
@@ -47,20 +49,26 @@ func NewCmdReset(in io.Reader, out io.Writer) *cobra.Command {
 	cmd.Flags().StringSliceVar(&opts.limit, "limit", []string{}, "comma-separated list of hostnames to limit the execution to a subset of nodes")
 	cmd.Flags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "enable verbose logging from the installation")
-	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\")")
+	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", installOutputFormatUsage)
 	cmd.Flags().BoolVar(&opts.force, "force", false, `do not prompt`)
 	cmd.Flags().BoolVar(&opts.removeAssets, "remove-assets", false, "remove generated-assets-dir")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, dryRunFlagUsage)
 
 	addPlanFileFlag(cmd.PersistentFlags(), &opts.planFilename)
+	addPlanOverlayFlag(cmd.PersistentFlags(), &opts.planOverlays)
 
 	return cmd
 }
 
-func doReset(out io.Writer, opts *resetOpts) error {
-	planner := &install.FilePlanner{File: opts.planFilename}
+func doReset(stdout io.Writer, opts *resetOpts) error {
+	out := messagesOut(stdout, opts.outputFormat)
+	planner := &install.FilePlanner{File: opts.planFilename, Overlays: opts.planOverlays, Log: out}
 	if !planner.PlanExists() {
 		return planFileNotFoundErr{filename: opts.planFilename}
 	}
+	if err := verifyHostKeys(opts.generatedAssetsDir); err != nil {
+		return err
+	}
 	plan, err := planner.Read()
 	if err != nil {
 		return fmt.Errorf("failed to read plan file: %v", err)
@@ -69,14 +77,21 @@ func doReset(out io.Writer, opts *resetOpts) error {
 		GeneratedAssetsDirectory: opts.generatedAssetsDir,
 		OutputFormat:             opts.outputFormat,
 		Verbose:                  opts.verbose,
+		DryRun:                   opts.dryRun,
 	}
-	executor, err := install.NewExecutor(out, os.Stderr, executorOpts)
+	executor, err := install.NewExecutor(stdout, os.Stderr, executorOpts)
 	if err != nil {
 		return err
 	}
-	if err := executor.Reset(plan, opts.limit...); err != nil {
+	ctx, stop := interruptibleContext(out)
+	defer stop()
+	if err := executor.Reset(ctx, plan, opts.limit...); err != nil {
 		return fmt.Errorf("error running reset: %v", err)
 	}
+	if opts.dryRun {
+		printDryRunComplete(out, executor.DryRunDirectory())
+		return nil
+	}
 
 	if opts.removeAssets {
 		util.PrintHeader(out, "Removing Assets Directory", '=')
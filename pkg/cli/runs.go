@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type runsOpts struct {
+	runsDirectory string
+}
+
+// NewCmdRuns returns the command for browsing the runs of kismatic
+func NewCmdRuns(out io.Writer) *cobra.Command {
+	opts := &runsOpts{}
+	cmd := &cobra.Command{
+		Use:   "runs",
+		Short: "browse the operations that were performed on the cluster",
+		Long: `Browse the operations that were performed on the cluster.
+
+Each operation, such as an installation or an upgrade, records its plan file, Ansible log
+and inventory in a directory of the runs directory. The ID of a run is the path of its directory
+relative to the runs directory, such as "apply/2018-03-01-10-00-00".`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Usage()
+		},
+	}
+	cmd.PersistentFlags().StringVar(&opts.runsDirectory, "runs-dir", "runs", "path to the directory where information about previous runs is kept")
+	cmd.AddCommand(NewCmdRunsList(out, opts))
+	cmd.AddCommand(NewCmdRunsShow(out, opts))
+	cmd.AddCommand(NewCmdRunsPrune(out, opts))
+	return cmd
+}
+
+func formatRunTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+func formatRunDuration(start, end time.Time) string {
+	if start.IsZero() || end.IsZero() {
+		return "-"
+	}
+	return end.Sub(start).Truncate(time.Second).String()
+}
+
+func formatRunStatus(status string) string {
+	if status == "" {
+		return "unknown"
+	}
+	return status
+}
+
+func formatRunNodes(nodes []string) string {
+	if len(nodes) == 0 {
+		return "-"
+	}
+	return strings.Join(nodes, ",")
+}
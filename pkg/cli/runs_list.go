@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/spf13/cobra"
+)
+
+type runsListOpts struct {
+	outputFormat string
+}
+
+// NewCmdRunsList returns the command for listing runs
+func NewCmdRunsList(out io.Writer, runsOpts *runsOpts) *cobra.Command {
+	opts := runsListOpts{}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list the runs, from oldest to newest",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("Unexpected args: %v", args)
+			}
+			return doRunsList(out, runsOpts.runsDirectory, opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", `output format (options "simple"|"json")`)
+	return cmd
+}
+
+func doRunsList(out io.Writer, runsDirectory string, opts runsListOpts) error {
+	if opts.outputFormat != "simple" && opts.outputFormat != "json" {
+		return fmt.Errorf("output format %q is not supported", opts.outputFormat)
+	}
+	runs, err := install.ListRuns(runsDirectory)
+	if err != nil {
+		return err
+	}
+	if opts.outputFormat == "json" {
+		b, err := json.MarshalIndent(runs, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshalling runs: %v", err)
+		}
+		fmt.Fprintln(out, string(b))
+		return nil
+	}
+	if len(runs) == 0 {
+		fmt.Fprintf(out, "No runs were found in %q\n", runsDirectory)
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprint(w, "ID\tOPERATION\tSTART\tEND\tDURATION\tSTATUS\tNODES\tOPERATOR\n")
+	for _, r := range runs {
+		operator := r.Operator
+		if operator == "" {
+			operator = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.ID, r.Operation, formatRunTime(r.Start), formatRunTime(r.End), formatRunDuration(r.Start, r.End), formatRunStatus(r.Status), formatRunNodes(r.Nodes), operator)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+type runsPruneOpts struct {
+	keep int
+}
+
+// NewCmdRunsPrune returns the command for removing old runs
+func NewCmdRunsPrune(out io.Writer, runsOpts *runsOpts) *cobra.Command {
+	opts := runsPruneOpts{}
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "remove all but the most recent runs",
+		Long: `Remove all but the most recent runs from the runs directory.
+
+The most recent successful run that applied the plan file to the cluster is always kept,
+as "kismatic plan diff" compares the plan file to the plan file it recorded.`,
+		Example: `  # Keep the 10 most recent runs
+  kismatic runs prune --keep 10`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("Unexpected args: %v", args)
+			}
+			if !cmd.Flags().Changed("keep") {
+				return fmt.Errorf("the number of runs to keep must be set with --keep")
+			}
+			return doRunsPrune(out, runsOpts.runsDirectory, opts)
+		},
+	}
+	cmd.Flags().IntVar(&opts.keep, "keep", 0, "number of runs to keep")
+	return cmd
+}
+
+func doRunsPrune(out io.Writer, runsDirectory string, opts runsPruneOpts) error {
+	removed, err := install.PruneRuns(runsDirectory, opts.keep)
+	for _, r := range removed {
+		util.PrettyPrintOk(out, "Removed run %s", r.ID)
+	}
+	if err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		fmt.Fprintln(out, "No runs were removed")
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+type runsShowOpts struct {
+	outputFormat string
+}
+
+type runDetails struct {
+	install.Run
+	Files    []string
+	Failures []install.TaskFailure
+}
+
+// NewCmdRunsShow returns the command for showing the details of a run
+func NewCmdRunsShow(out io.Writer, runsOpts *runsOpts) *cobra.Command {
+	opts := runsShowOpts{}
+	cmd := &cobra.Command{
+		Use:   "show RUN_ID",
+		Short: "show the details of a run, and the tasks that failed during the run",
+		Example: `  # Show the details of a run
+  kismatic runs show apply/2018-03-01-10-00-00`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Usage()
+			}
+			return doRunsShow(out, runsOpts.runsDirectory, args[0], opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", `output format (options "simple"|"json")`)
+	return cmd
+}
+
+func doRunsShow(out io.Writer, runsDirectory string, id string, opts runsShowOpts) error {
+	if opts.outputFormat != "simple" && opts.outputFormat != "json" {
+		return fmt.Errorf("output format %q is not supported", opts.outputFormat)
+	}
+	r, err := install.GetRun(runsDirectory, id)
+	if err != nil {
+		return err
+	}
+	failures, err := install.RunFailures(*r)
+	if err != nil {
+		return err
+	}
+	fileInfos, err := ioutil.ReadDir(r.Directory)
+	if err != nil {
+		return fmt.Errorf("error listing the files of run %q: %v", id, err)
+	}
+	details := runDetails{Run: *r, Files: []string{}, Failures: failures}
+	for _, fi := range fileInfos {
+		details.Files = append(details.Files, fi.Name())
+	}
+
+	if opts.outputFormat == "json" {
+		b, err := json.MarshalIndent(details, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshalling run: %v", err)
+		}
+		fmt.Fprintln(out, string(b))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%s\n", r.ID)
+	fmt.Fprintf(w, "Operation:\t%s\n", r.Operation)
+	fmt.Fprintf(w, "Start:\t%s\n", formatRunTime(r.Start))
+	fmt.Fprintf(w, "End:\t%s\n", formatRunTime(r.End))
+	fmt.Fprintf(w, "Duration:\t%s\n", formatRunDuration(r.Start, r.End))
+	fmt.Fprintf(w, "Status:\t%s\n", formatRunStatus(r.Status))
+	fmt.Fprintf(w, "Nodes:\t%s\n", formatRunNodes(r.Nodes))
+	if r.Operator != "" {
+		fmt.Fprintf(w, "Operator:\t%s\n", r.Operator)
+	}
+	fmt.Fprintf(w, "Directory:\t%s\n", r.Directory)
+	fmt.Fprintf(w, "Files:\t%s\n", strings.Join(details.Files, ", "))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+	util.PrintHeader(out, "Failed Tasks", '=')
+	for _, f := range failures {
+		host := f.Host
+		if f.Item != "" {
+			host = fmt.Sprintf("%s (item=%s)", f.Host, f.Item)
+		}
+		if f.Unreachable {
+			util.PrettyPrintUnreachable(out, "%s on %s", f.Task, host)
+		} else {
+			util.PrettyPrintErr(out, "%s on %s", f.Task, host)
+		}
+		if f.Message != "" {
+			fmt.Fprintf(out, "  %s\n", strings.Replace(f.Message, "\n", "\n  ", -1))
+		}
+	}
+	return nil
+}
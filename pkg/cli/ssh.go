@@ -5,18 +5,18 @@ import (
 	"io"
 	"strings"
 
-	"github.com/apprenda/kismatic/pkg/ssh"
-
 	"github.com/apprenda/kismatic/pkg/install"
 	"github.com/apprenda/kismatic/pkg/util"
 	"github.com/spf13/cobra"
 )
 
 type sshOpts struct {
-	planFilename string
-	host         string
-	pty          bool
-	arguments    []string
+	planFilename       string
+	planOverlays       []string
+	generatedAssetsDir string
+	host               string
+	pty                bool
+	arguments          []string
 }
 
 // NewCmdSSH returns an ssh shell
@@ -42,7 +42,7 @@ HOST must be one of the following:
 
 			opts.host = args[0]
 
-			planner := &install.FilePlanner{File: opts.planFilename}
+			planner := &install.FilePlanner{File: opts.planFilename, Overlays: opts.planOverlays}
 			// Check if plan file exists
 			if !planner.PlanExists() {
 				return planFileNotFoundErr{filename: opts.planFilename}
@@ -58,12 +58,17 @@ HOST must be one of the following:
 	}
 
 	cmd.Flags().StringVarP(&opts.planFilename, "plan-file", "f", "kismatic-cluster.yaml", "path to the installation plan file")
+	addPlanOverlayFlag(cmd.Flags(), &opts.planOverlays)
+	cmd.Flags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	cmd.Flags().BoolVarP(&opts.pty, "pty", "t", false, "force PTY \"-t\" flag on the SSH connection")
 
 	return cmd
 }
 
 func doSSH(out io.Writer, planner install.Planner, opts *sshOpts) error {
+	if err := verifyHostKeys(opts.generatedAssetsDir); err != nil {
+		return err
+	}
 	plan, err := planner.Read()
 	if err != nil {
 		return fmt.Errorf("error reading plan file: %v", err)
@@ -82,7 +87,7 @@ func doSSH(out io.Writer, planner install.Planner, opts *sshOpts) error {
 		return fmt.Errorf("cannot validate SSH connection to node %q", opts.host)
 	}
 
-	client, err := ssh.NewClient(con.Node.IP, con.SSHConfig.Port, con.SSHConfig.User, con.SSHConfig.Key)
+	client, err := con.NewClient()
 	if err != nil {
 		return fmt.Errorf("error creating SSH client: %v", err)
 	}
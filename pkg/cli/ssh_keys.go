@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+type sshKeysRescanOpts struct {
+	planFilename       string
+	planOverlays       []string
+	generatedAssetsDir string
+	host               string
+}
+
+// NewCmdSSHKeys creates a new ssh-keys command
+func NewCmdSSHKeys(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ssh-keys",
+		Short: "Manage the SSH host keys of the nodes",
+		Long: `Manage the SSH host keys of the nodes.
+
+The host key of each node is recorded in the known_hosts file of the generated assets directory
+the first time the node is contacted. The connections that follow are refused if the node
+presents a different key.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(NewCmdSSHKeysRescan(out))
+
+	return cmd
+}
+
+// NewCmdSSHKeysRescan creates a new ssh-keys rescan command
+func NewCmdSSHKeysRescan(out io.Writer) *cobra.Command {
+	opts := &sshKeysRescanOpts{}
+	cmd := &cobra.Command{
+		Use:   "rescan HOST",
+		Short: "Accept the current SSH host key of a node",
+		Long: `Accept the current SSH host key of a node, replacing the key recorded for it.
+
+Only use this command when the host key of the node was changed deliberately, for example
+after the node was re-provisioned.
+
+HOST must be one of the following:
+- A hostname defined in the plan file
+- An alias: master, etcd, worker, ingress or storage. This will rescan the first defined node of that type.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Usage()
+			}
+			opts.host = args[0]
+			planner := &install.FilePlanner{File: opts.planFilename, Overlays: opts.planOverlays}
+			if !planner.PlanExists() {
+				return planFileNotFoundErr{filename: opts.planFilename}
+			}
+			return doSSHKeysRescan(out, planner, opts)
+		},
+	}
+
+	addPlanFileFlag(cmd.Flags(), &opts.planFilename)
+	addPlanOverlayFlag(cmd.Flags(), &opts.planOverlays)
+	cmd.Flags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
+
+	return cmd
+}
+
+func doSSHKeysRescan(out io.Writer, planner install.Planner, opts *sshKeysRescanOpts) error {
+	plan, err := planner.Read()
+	if err != nil {
+		return fmt.Errorf("error reading plan file: %v", err)
+	}
+	con, err := plan.GetSSHConnection(opts.host)
+	if err != nil {
+		return err
+	}
+	// the key of the bastion, if any, is still verified
+	if err = verifyHostKeys(opts.generatedAssetsDir); err != nil {
+		return err
+	}
+	knownHostsFile, err := install.KnownHostsFile(opts.generatedAssetsDir)
+	if err != nil {
+		return err
+	}
+	replaced, fingerprint, err := con.RescanHostKey(knownHostsFile)
+	if err != nil {
+		return err
+	}
+	for _, fp := range replaced {
+		if fp != fingerprint {
+			util.PrettyPrintWarn(out, "Removed host key %s of %q", fp, con.Node.Host)
+		}
+	}
+	util.PrettyPrintOk(out, "Recorded host key %s of %q in %q", fingerprint, con.Node.Host, knownHostsFile)
+	return nil
+}
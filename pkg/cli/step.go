@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -11,11 +12,12 @@ import (
 )
 
 type stepCmd struct {
-	out      io.Writer
-	planFile string
-	task     string
-	planner  install.Planner
-	executor install.Executor
+	out       io.Writer
+	eventsOut io.Writer
+	planFile  string
+	task      string
+	planner   install.Planner
+	executor  install.Executor
 
 	// Flags
 	generatedAssetsDir string
@@ -23,12 +25,12 @@ type stepCmd struct {
 	verbose            bool
 	outputFormat       string
 	limit              []string
+	dryRun             bool
 }
 
 // NewCmdStep returns the step command
 func NewCmdStep(out io.Writer, opts *installOpts) *cobra.Command {
 	stepCmd := &stepCmd{
-		out:      out,
 		planFile: opts.planFilename,
 	}
 	cmd := &cobra.Command{
@@ -38,31 +40,40 @@ func NewCmdStep(out io.Writer, opts *installOpts) *cobra.Command {
 			if len(args) != 1 {
 				return cmd.Usage()
 			}
+			if err := verifyHostKeys(stepCmd.generatedAssetsDir); err != nil {
+				return err
+			}
 			execOpts := install.ExecutorOptions{
 				GeneratedAssetsDirectory: stepCmd.generatedAssetsDir,
 				OutputFormat:             stepCmd.outputFormat,
 				Verbose:                  stepCmd.verbose,
+				DryRun:                   stepCmd.dryRun,
 			}
 			executor, err := install.NewExecutor(out, os.Stderr, execOpts)
 			if err != nil {
 				return err
 			}
+			stepCmd.out = messagesOut(out, stepCmd.outputFormat)
+			stepCmd.eventsOut = eventsOut(out, stepCmd.outputFormat)
 			stepCmd.task = args[0]
 			stepCmd.planFile = opts.planFilename
-			stepCmd.planner = &install.FilePlanner{File: stepCmd.planFile}
+			stepCmd.planner = &install.FilePlanner{File: stepCmd.planFile, Overlays: opts.planOverlays, Log: stepCmd.out}
 			stepCmd.executor = executor
-			return stepCmd.run()
+			ctx, stop := interruptibleContext(stepCmd.out)
+			defer stop()
+			return stepCmd.run(ctx)
 		},
 	}
 	cmd.Flags().StringSliceVar(&stepCmd.limit, "limit", []string{}, "comma-separated list of hostnames to limit the execution to a subset of nodes")
 	cmd.Flags().StringVar(&stepCmd.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	cmd.Flags().BoolVar(&stepCmd.restartServices, "restart-services", false, "force restart cluster services (Use with care)")
 	cmd.Flags().BoolVar(&stepCmd.verbose, "verbose", false, "enable verbose logging from the installation")
-	cmd.Flags().StringVarP(&stepCmd.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\")")
+	cmd.Flags().StringVarP(&stepCmd.outputFormat, "output", "o", "simple", installOutputFormatUsage)
+	cmd.Flags().BoolVar(&stepCmd.dryRun, "dry-run", false, dryRunFlagUsage)
 	return cmd
 }
 
-func (c stepCmd) run() error {
+func (c stepCmd) run(ctx context.Context) error {
 	valOpts := &validateOpts{
 		planFile:           c.planFile,
 		verbose:            c.verbose,
@@ -70,8 +81,9 @@ func (c stepCmd) run() error {
 		skipPreFlight:      true,
 		generatedAssetsDir: c.generatedAssetsDir,
 		limit:              c.limit,
+		eventsOut:          c.eventsOut,
 	}
-	if err := doValidate(c.out, c.planner, valOpts); err != nil {
+	if err := doValidate(ctx, c.out, c.planner, valOpts); err != nil {
 		return err
 	}
 	plan, err := c.planner.Read()
@@ -79,9 +91,13 @@ func (c stepCmd) run() error {
 		return fmt.Errorf("error reading plan file: %v", err)
 	}
 	util.PrintHeader(c.out, "Running Task", '=')
-	if err := c.executor.RunPlay(c.task, plan, c.restartServices, c.limit...); err != nil {
+	if err := c.executor.RunPlay(ctx, c.task, plan, c.restartServices, c.limit...); err != nil {
 		return err
 	}
+	if c.dryRun {
+		printDryRunComplete(c.out, c.executor.DryRunDirectory())
+		return nil
+	}
 	util.PrintColor(c.out, util.Green, "\nTask completed successfully\n\n")
 	return nil
 }
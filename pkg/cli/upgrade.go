@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -21,6 +22,7 @@ type upgradeOpts struct {
 	ignoreSafetyChecks bool
 	online             bool
 	planFile           string
+	planOverlays       []string
 	restartServices    bool
 	partialAllowed     bool
 	maxParallelWorkers int
@@ -52,12 +54,13 @@ Nodes in the cluster are upgraded in the following order:
 
 	cmd.PersistentFlags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	cmd.PersistentFlags().BoolVar(&opts.verbose, "verbose", false, "enable verbose logging from the installation")
-	cmd.PersistentFlags().StringVarP(&opts.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\")")
+	cmd.PersistentFlags().StringVarP(&opts.outputFormat, "output", "o", "simple", installOutputFormatUsage)
 	cmd.PersistentFlags().BoolVar(&opts.skipPreflight, "skip-preflight", false, "skip upgrade pre-flight checks")
 	cmd.PersistentFlags().BoolVar(&opts.restartServices, "restart-services", false, "force restart cluster services (Use with care)")
-	cmd.PersistentFlags().BoolVar(&opts.partialAllowed, "partial-ok", false, "allow the upgrade of ready nodes, and skip nodes that have been deemed unready for upgrade")
-	cmd.PersistentFlags().BoolVar(&opts.dryRun, "dry-run", false, "simulate the upgrade, but don't actually upgrade the cluster")
+	cmd.PersistentFlags().BoolVar(&opts.partialAllowed, "partial-ok", false, "allow the upgrade of ready nodes, and skip nodes that are unreachable or have been deemed unready for upgrade")
+	cmd.PersistentFlags().BoolVar(&opts.dryRun, "dry-run", false, dryRunFlagUsage)
 	addPlanFileFlag(cmd.PersistentFlags(), &opts.planFile)
+	addPlanOverlayFlag(cmd.PersistentFlags(), &opts.planOverlays)
 
 	// Subcommands
 	cmd.AddCommand(NewCmdUpgradeOffline(in, out, &opts))
@@ -111,29 +114,36 @@ before any changes are applied.
 	return &cmd
 }
 
-func doUpgrade(in io.Reader, out io.Writer, opts *upgradeOpts) error {
+func doUpgrade(in io.Reader, stdout io.Writer, opts *upgradeOpts) error {
+	out := messagesOut(stdout, opts.outputFormat)
 	if opts.maxParallelWorkers < 1 {
 		return fmt.Errorf("max-parallel-workers must be greater or equal to 1, got: %d", opts.maxParallelWorkers)
 	}
+	if err := verifyHostKeys(opts.generatedAssetsDir); err != nil {
+		return err
+	}
 
 	planFile := opts.planFile
-	planner := install.FilePlanner{File: planFile}
+	planner := install.FilePlanner{File: planFile, Overlays: opts.planOverlays, Log: out}
 	executorOpts := install.ExecutorOptions{
 		GeneratedAssetsDirectory: opts.generatedAssetsDir,
 		OutputFormat:             opts.outputFormat,
 		Verbose:                  opts.verbose,
 		DryRun:                   opts.dryRun,
 	}
-	executor, err := install.NewExecutor(out, os.Stderr, executorOpts)
+	executor, err := install.NewExecutor(stdout, os.Stderr, executorOpts)
 	if err != nil {
 		return err
 	}
+	defer func() { printSlowestTasks(out, executor.TaskTimings()) }()
 	preflightExecOpts := executorOpts
 	preflightExecOpts.DryRun = false // We always want to run preflight, even if doing a dry-run
-	preflightExec, err := install.NewPreFlightExecutor(out, os.Stderr, preflightExecOpts)
+	preflightExec, err := install.NewPreFlightExecutor(stdout, os.Stderr, preflightExecOpts)
 	if err != nil {
 		return err
 	}
+	ctx, stop := interruptibleContext(out)
+	defer stop()
 	util.PrintHeader(out, "Computing upgrade plan", '=')
 
 	// Read plan file
@@ -149,11 +159,27 @@ func doUpgrade(in io.Reader, out io.Writer, opts *upgradeOpts) error {
 	}
 
 	// Validate the plan file before we do anything
+	if events := eventsOut(stdout, opts.outputFormat); events != nil {
+		result := &validationResult{Errors: []install.ValidationError{}, Warnings: []install.ValidationError{}}
+		_, errs := install.ValidatePlan(plan)
+		result.add(errs)
+		if err = writeValidationEvent(events, result); err != nil {
+			return err
+		}
+	}
 	if err = validatePlan(out, plan); err != nil {
 		return err
 	}
 
-	if err = validateSSHConnectivity(out, plan); err != nil {
+	// Get the cluster and node versions
+	cv, err := install.ListVersions(plan)
+	if err != nil {
+		return fmt.Errorf("error listing cluster versions: %v", err)
+	}
+	if err = validateReachableNodes(out, cv, opts.partialAllowed); err != nil {
+		return err
+	}
+	if err = validateKubernetesUpgrade(out, cv, plan.Cluster.Version); err != nil {
 		return err
 	}
 
@@ -174,12 +200,6 @@ func doUpgrade(in io.Reader, out io.Writer, opts *upgradeOpts) error {
 		util.PrettyPrintOk(out, "Found existing kubeconfig file in %q", opts.generatedAssetsDir)
 	}
 
-	// Get the cluster and node versions
-	cv, err := install.ListVersions(plan)
-	if err != nil {
-		return fmt.Errorf("error listing cluster versions: %v", err)
-	}
-
 	// Figure out which nodes to upgrade
 	var toUpgrade []install.ListableNode
 	var toSkip []install.ListableNode
@@ -206,7 +226,7 @@ func doUpgrade(in io.Reader, out io.Writer, opts *upgradeOpts) error {
 	if len(toUpgrade) == 0 {
 		fmt.Fprintln(out, "All nodes are at the target version. Skipping node upgrades.")
 	} else {
-		if err = upgradeNodes(in, out, *plan, *opts, toUpgrade, executor, preflightExec); err != nil {
+		if err = upgradeNodes(ctx, in, out, *plan, *opts, toUpgrade, executor, preflightExec); err != nil {
 			return err
 		}
 	}
@@ -226,25 +246,81 @@ without the "--partial-ok" flag to perform a full upgrade.
 
 	// Upgrade the cluster services
 	util.PrintHeader(out, "Upgrade: Cluster Services", '=')
-	if err := executor.UpgradeClusterServices(*plan); err != nil {
+	if err := executor.UpgradeClusterServices(ctx, *plan); err != nil {
 		return fmt.Errorf("Failed to upgrade cluster services: %v", err)
 	}
 
 	if plan.NetworkConfigured() {
-		if err := executor.RunSmokeTest(plan); err != nil {
+		if err := executor.RunSmokeTest(ctx, plan); err != nil {
 			return fmt.Errorf("Smoke test failed: %v", err)
 		}
 	}
 
-	if !opts.dryRun {
-		fmt.Fprintln(out)
-		util.PrintColor(out, util.Green, "The cluster was upgraded successfully!\n")
-		fmt.Fprintln(out)
+	if opts.dryRun {
+		printDryRunComplete(out, executor.DryRunDirectory())
+		return nil
 	}
+	fmt.Fprintln(out)
+	util.PrintColor(out, util.Green, "The cluster was upgraded successfully!\n")
+	fmt.Fprintln(out)
 	return nil
 }
 
-func upgradeNodes(in io.Reader, out io.Writer, plan install.Plan, opts upgradeOpts, nodesNeedUpgrade []install.ListableNode, executor install.Executor, preflightExec install.PreFlightExecutor) error {
+// validateReachableNodes fails the upgrade if any of the nodes could not be reached,
+// unless doing a partial upgrade, in which case the worker nodes that could not be
+// reached are skipped
+func validateReachableNodes(out io.Writer, cv install.ClusterVersion, partialAllowed bool) error {
+	if len(cv.UnreachableNodes) == 0 {
+		util.PrettyPrintOk(out, "Validating SSH connectivity to nodes")
+		return nil
+	}
+	if !partialAllowed {
+		util.PrettyPrintErr(out, "Validating SSH connectivity to nodes")
+	} else {
+		util.PrettyPrintWarn(out, "Validating SSH connectivity to nodes")
+	}
+	for _, n := range cv.UnreachableNodes {
+		util.PrettyPrintUnreachable(out, "- %q is unreachable: %s", n.Node.Host, n.Error)
+	}
+	if !partialAllowed {
+		return fmt.Errorf("%d node(s) could not be reached. Use --partial-ok to upgrade the nodes that can be reached", len(cv.UnreachableNodes))
+	}
+	// Block the upgrade if partial is allowed but there is an etcd or master node
+	// that cannot be reached
+	for _, n := range cv.UnreachableNodes {
+		for _, r := range n.Roles {
+			if r == "master" || r == "etcd" {
+				return fmt.Errorf("node %q could not be reached: all etcd and master nodes must be reachable to perform a partial upgrade", n.Node.Host)
+			}
+		}
+	}
+	return nil
+}
+
+// validateKubernetesUpgrade verifies that the Kubernetes version of every node
+// can be upgraded to the version set in the plan
+func validateKubernetesUpgrade(out io.Writer, cv install.ClusterVersion, version string) error {
+	catalog := install.GetVersionCatalog()
+	errs := []error{}
+	for _, n := range cv.Nodes {
+		// nodes installed by older releases do not record their component versions
+		if n.ComponentVersions.Kubernetes == "" {
+			continue
+		}
+		if err := catalog.ValidateUpgrade(n.ComponentVersions.Kubernetes, version); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", n.Node.Host, err))
+		}
+	}
+	if len(errs) > 0 {
+		util.PrettyPrintErr(out, "Validating Kubernetes version %s", version)
+		util.PrintValidationErrors(out, errs)
+		return fmt.Errorf("the cluster cannot be upgraded to Kubernetes %s", version)
+	}
+	util.PrettyPrintOk(out, "Validating Kubernetes version %s", version)
+	return nil
+}
+
+func upgradeNodes(ctx context.Context, in io.Reader, out io.Writer, plan install.Plan, opts upgradeOpts, nodesNeedUpgrade []install.ListableNode, executor install.Executor, preflightExec install.PreFlightExecutor) error {
 	// Run safety checks if doing an online upgrade
 	unsafeNodes := []install.ListableNode{}
 	if opts.online {
@@ -266,7 +342,7 @@ func upgradeNodes(in io.Reader, out io.Writer, plan install.Plan, opts upgradeOp
 				}
 				fmt.Fprintln(out)
 				for _, err := range errs {
-					fmt.Println("-", err.Error())
+					fmt.Fprintln(out, "-", err.Error())
 				}
 				unsafeNodes = append(unsafeNodes, node)
 			} else {
@@ -319,7 +395,10 @@ func upgradeNodes(in io.Reader, out io.Writer, plan install.Plan, opts upgradeOp
 	if !opts.skipPreflight {
 		for _, node := range nodesNeedUpgrade {
 			util.PrintHeader(out, fmt.Sprintf("Preflight Checks: %s %s", node.Node.Host, node.Roles), '=')
-			if err := preflightExec.RunUpgradePreFlightCheck(&plan, node); err != nil {
+			if err := preflightExec.RunUpgradePreFlightCheck(ctx, &plan, node); err != nil {
+				if install.IsCancelled(err) {
+					return err
+				}
 				// return fmt.Errorf("Upgrade preflight check failed: %v", err)
 				unreadyNodes = append(unreadyNodes, node)
 			}
@@ -366,7 +445,7 @@ func upgradeNodes(in io.Reader, out io.Writer, plan install.Plan, opts upgradeOp
 	}
 
 	// Run the upgrade on the nodes that need it
-	if err := executor.UpgradeNodes(plan, toUpgrade, opts.online, opts.maxParallelWorkers, opts.restartServices); err != nil {
+	if err := executor.UpgradeNodes(ctx, plan, toUpgrade, opts.online, opts.maxParallelWorkers, opts.restartServices); err != nil {
 		return fmt.Errorf("Failed to upgrade nodes: %v", err)
 	}
 	return nil
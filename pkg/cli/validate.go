@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"path/filepath"
@@ -19,6 +21,9 @@ type validateOpts struct {
 	outputFormat       string
 	skipPreFlight      bool
 	limit              []string
+	// when set, the validation result and the pre-flight events are written
+	// to it as events, as done by the commands that use the json output format
+	eventsOut io.Writer
 }
 
 // NewCmdValidate creates a new install validate command
@@ -31,20 +36,33 @@ func NewCmdValidate(out io.Writer, installOpts *installOpts) *cobra.Command {
 			if len(args) != 0 {
 				return fmt.Errorf("Unexpected args: %v", args)
 			}
-			planner := &install.FilePlanner{File: installOpts.planFilename}
+			if err := verifyHostKeys(opts.generatedAssetsDir); err != nil {
+				return err
+			}
+			// only the validation results are written to stdout with the json output format
+			msgOut := messagesOut(out, opts.outputFormat)
+			planner := &install.FilePlanner{File: installOpts.planFilename, Overlays: installOpts.planOverlays, Log: msgOut}
 			opts.planFile = installOpts.planFilename
-			return doValidate(out, planner, opts)
+			ctx, stop := interruptibleContext(msgOut)
+			defer stop()
+			return doValidate(ctx, out, planner, opts)
 		},
 	}
 	cmd.Flags().StringSliceVar(&opts.limit, "limit", []string{}, "comma-separated list of hostnames to limit the execution to a subset of nodes")
 	cmd.Flags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "enable verbose logging from the installation")
-	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", "installation output format (options simple|raw)")
+	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", "installation output format (options simple|raw|json). The json format writes the validation results to stdout, and the pre-flight output to stderr")
 	cmd.Flags().BoolVar(&opts.skipPreFlight, "skip-preflight", false, "skip pre-flight checks")
 	return cmd
 }
 
-func doValidate(out io.Writer, planner install.Planner, opts *validateOpts) error {
+func doValidate(ctx context.Context, out io.Writer, planner install.Planner, opts *validateOpts) error {
+	if opts.eventsOut != nil {
+		return doValidateJSON(ctx, opts.eventsOut, out, planner, opts)
+	}
+	if opts.outputFormat == "json" {
+		return doValidateJSON(ctx, out, os.Stderr, planner, opts)
+	}
 	util.PrintHeader(out, "Validating", '=')
 	// Check if plan file exists
 	if !planner.PlanExists() {
@@ -92,14 +110,15 @@ func doValidate(out io.Writer, planner install.Planner, opts *validateOpts) erro
 	}
 	// Run pre-flight
 	options := install.ExecutorOptions{
-		OutputFormat: opts.outputFormat,
-		Verbose:      opts.verbose,
+		GeneratedAssetsDirectory: opts.generatedAssetsDir,
+		OutputFormat:             opts.outputFormat,
+		Verbose:                  opts.verbose,
 	}
 	e, err := install.NewPreFlightExecutor(out, os.Stderr, options)
 	if err != nil {
 		return err
 	}
-	return e.RunPreFlightCheck(plan, opts.limit...)
+	return e.RunPreFlightCheck(ctx, plan, opts.limit...)
 }
 
 // TODO this should really not be here
@@ -119,15 +138,129 @@ func newPKI(stdout io.Writer, options *validateOpts) (*install.LocalPKI, error)
 
 func validatePlan(out io.Writer, plan *install.Plan) error {
 	ok, errs := install.ValidatePlan(plan)
+	errs, warnings := install.SplitValidationWarnings(errs)
 	if !ok {
 		util.PrettyPrintErr(out, "Validating installation plan file")
 		util.PrintValidationErrors(out, errs)
+		util.PrintValidationWarnings(out, warnings)
 		return fmt.Errorf("Plan file validation error prevents installation from proceeding")
 	}
+	if len(warnings) > 0 {
+		util.PrettyPrintWarn(out, "Validating installation plan file")
+		util.PrintValidationWarnings(out, warnings)
+		return nil
+	}
 	util.PrettyPrintOk(out, "Validating installation plan file")
 	return nil
 }
 
+// validationResult is the result of the validation, written when
+// using the json output format
+type validationResult struct {
+	Valid    bool                      `json:"valid"`
+	Errors   []install.ValidationError `json:"errors"`
+	Warnings []install.ValidationError `json:"warnings"`
+}
+
+func (r *validationResult) add(errs []error) {
+	for _, verr := range install.ValidationErrors(errs) {
+		if verr.Severity == install.ValidationSeverityWarning {
+			r.Warnings = append(r.Warnings, verr)
+		} else {
+			r.Errors = append(r.Errors, verr)
+		}
+	}
+	r.Valid = len(r.Errors) == 0
+}
+
+// writeValidationEvent writes the validation result as an event
+func writeValidationEvent(out io.Writer, r *validationResult) error {
+	valid := r.Valid
+	return install.NewEventWriter(out).Write(install.Event{
+		Type:     install.EventValidationResult,
+		Valid:    &valid,
+		Errors:   r.Errors,
+		Warnings: r.Warnings,
+	})
+}
+
+// doValidateJSON runs the same validation as doValidate, writing the results
+// as a JSON document to out. The output of the pre-flight checks is written to errOut.
+// When writing events, the results and the events of the pre-flight checks
+// are written to out instead.
+func doValidateJSON(ctx context.Context, out io.Writer, errOut io.Writer, planner install.Planner, opts *validateOpts) error {
+	if !planner.PlanExists() {
+		return planFileNotFoundErr{filename: opts.planFile}
+	}
+	plan, err := planner.Read()
+	if err != nil {
+		return fmt.Errorf("error reading plan file: %v", err)
+	}
+	for _, host := range opts.limit {
+		if !plan.HostExists(host) {
+			return fmt.Errorf("host %q in '--limit' option does not match any hosts in the plan file", host)
+		}
+	}
+
+	result := &validationResult{Errors: []install.ValidationError{}, Warnings: []install.ValidationError{}}
+	_, errs := install.ValidatePlan(plan)
+	result.add(errs)
+	if result.Valid {
+		_, errs = install.ValidatePlanSSHConnections(plan)
+		result.add(errs)
+	}
+	if result.Valid {
+		pki, err := newPKI(errOut, opts)
+		if err != nil {
+			return err
+		}
+		_, errs = install.ValidateCertificates(plan, pki)
+		result.add(errs)
+	}
+	if result.Valid && !opts.skipPreFlight {
+		options := install.ExecutorOptions{
+			GeneratedAssetsDirectory: opts.generatedAssetsDir,
+			OutputFormat:             "simple",
+			Verbose:                  opts.verbose,
+		}
+		preflightOut := errOut
+		if opts.eventsOut != nil {
+			options.OutputFormat = "json"
+			preflightOut = out
+		}
+		e, err := install.NewPreFlightExecutor(preflightOut, errOut, options)
+		if err != nil {
+			return err
+		}
+		if err = e.RunPreFlightCheck(ctx, plan, opts.limit...); err != nil {
+			result.add([]error{&install.ValidationError{
+				Severity: install.ValidationSeverityError,
+				Code:     install.ValidationCodePreFlightFailed,
+				Message:  err.Error(),
+			}})
+		}
+	}
+
+	if opts.eventsOut != nil {
+		if err := writeValidationEvent(out, result); err != nil {
+			return err
+		}
+		if !result.Valid {
+			return fmt.Errorf("validation error prevents installation from proceeding")
+		}
+		return nil
+	}
+	b, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling validation results: %v", err)
+	}
+	fmt.Fprintln(out, string(b))
+	if !result.Valid {
+		return fmt.Errorf("validation error prevents installation from proceeding")
+	}
+	return nil
+}
+
 func validateSSHConnectivity(out io.Writer, plan *install.Plan) error {
 	ok, errs := install.ValidatePlanSSHConnections(plan)
 	if !ok {
@@ -2,6 +2,11 @@ package cli
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/apprenda/kismatic/pkg/install"
@@ -17,7 +22,7 @@ func TestValidateCmdPlanNotFound(t *testing.T) {
 		verbose:      false,
 		outputFormat: "table",
 	}
-	err := doValidate(out, fp, opts)
+	err := doValidate(context.Background(), out, fp, opts)
 	if err == nil {
 		t.Errorf("validate did not return an error when the plan does not exist")
 	}
@@ -38,7 +43,7 @@ func TestValidateCmdPlanInvalid(t *testing.T) {
 		verbose:      false,
 		outputFormat: "table",
 	}
-	err := doValidate(out, fp, opts)
+	err := doValidate(context.Background(), out, fp, opts)
 	if err == nil {
 		t.Errorf("did not return an error with an invalid plan")
 	}
@@ -47,3 +52,61 @@ func TestValidateCmdPlanInvalid(t *testing.T) {
 		t.Errorf("did not read the plan file")
 	}
 }
+
+func TestValidateCmdJSONOutput(t *testing.T) {
+	out := &bytes.Buffer{}
+	errOut := &bytes.Buffer{}
+	fp := &fakePlanner{
+		exists: true,
+		plan:   &install.Plan{},
+	}
+	opts := &validateOpts{
+		planFile:     "planFile",
+		outputFormat: "json",
+	}
+	if err := doValidateJSON(context.Background(), out, errOut, fp, opts); err == nil {
+		t.Errorf("did not return an error with an invalid plan")
+	}
+	result := validationResult{}
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out.String())
+	}
+	if result.Valid {
+		t.Errorf("expected the result to be invalid")
+	}
+	if len(result.Errors) == 0 {
+		t.Errorf("expected validation errors in the result")
+	}
+	for _, e := range result.Errors {
+		if e.Severity != install.ValidationSeverityError || e.Code == "" || e.Message == "" {
+			t.Errorf("incomplete validation error in the result: %+v", e)
+		}
+	}
+}
+
+func TestValidateCmdJSONOutputOfOlderPlan(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validate-cmd-test")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	// the plan does not have an apiVersion, so a warning is printed when it is read
+	planFile := filepath.Join(dir, "kismatic-cluster.yaml")
+	if err = ioutil.WriteFile(planFile, []byte(`{'cluster': {'name': 'test'}}`), 0644); err != nil {
+		t.Fatalf("error writing plan file: %v", err)
+	}
+	out := &bytes.Buffer{}
+	cmd := NewCmdValidate(out, &installOpts{planFilename: planFile})
+	cmd.SetOutput(ioutil.Discard)
+	cmd.SetArgs([]string{"-o", "json", "--skip-preflight", "--generated-assets-dir", filepath.Join(dir, "generated")})
+	if err = cmd.Execute(); err == nil {
+		t.Errorf("did not return an error with an invalid plan")
+	}
+	result := validationResult{}
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out.String())
+	}
+	if result.Valid {
+		t.Errorf("expected the result to be invalid")
+	}
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/spf13/cobra"
+)
+
+type versionsOpts struct {
+	outputFormat string
+}
+
+type kubernetesVersionOut struct {
+	Minor          string
+	Version        string
+	Default        bool
+	UpgradableFrom []string
+	Images         map[string]string
+}
+
+// NewCmdVersions returns the versions command
+func NewCmdVersions(out io.Writer) *cobra.Command {
+	opts := &versionsOpts{}
+	cmd := &cobra.Command{
+		Use:   "versions",
+		Short: "List the Kubernetes versions that can be installed or upgraded to",
+		Long: `List the Kubernetes minor versions supported by this release of Kismatic.
+
+Any patch release of a supported minor version can be set as the cluster version in the plan file.
+The listed version is the tested release, which is installed when the plan file does not set a version.
+A cluster can only be upgraded to a minor version from the same minor version, or from the previous one.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("Unexpected args: %v", args)
+			}
+			return doVersions(out, opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", `output format (options "simple"|"json")`)
+	return cmd
+}
+
+func doVersions(out io.Writer, opts *versionsOpts) error {
+	catalog := install.GetVersionCatalog()
+	versions := []kubernetesVersionOut{}
+	for _, r := range catalog.Kubernetes {
+		versions = append(versions, kubernetesVersionOut{
+			Minor:          r.Minor,
+			Version:        r.Version,
+			Default:        r.Default,
+			UpgradableFrom: catalog.UpgradableFrom(r),
+			Images:         r.Images,
+		})
+	}
+	switch opts.outputFormat {
+	case "json":
+		b, err := json.MarshalIndent(versions, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshalling versions: %v", err)
+		}
+		fmt.Fprintln(out, string(b))
+		return nil
+	case "simple":
+		w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+		fmt.Fprint(w, "Minor\tTested Version\tDefault\tUpgradable From\n")
+		for _, v := range versions {
+			def := ""
+			if v.Default {
+				def = "yes"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", v.Minor, v.Version, def, strings.Join(v.UpgradableFrom, ","))
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("output format %q is not supported", opts.outputFormat)
+	}
+}
@@ -8,7 +8,7 @@ import (
 
 // NewCmdVolume returns the storage command
 func NewCmdVolume(in io.Reader, out io.Writer) *cobra.Command {
-	var planFile string
+	opts := &planFileOpts{}
 	cmd := &cobra.Command{
 		Use:   "volume",
 		Short: "manage storage volumes on your Kubernetes cluster",
@@ -16,9 +16,10 @@ func NewCmdVolume(in io.Reader, out io.Writer) *cobra.Command {
 			return cmd.Usage()
 		},
 	}
-	addPlanFileFlag(cmd.PersistentFlags(), &planFile)
-	cmd.AddCommand(NewCmdVolumeAdd(out, &planFile))
-	cmd.AddCommand(NewCmdVolumeList(out, &planFile))
-	cmd.AddCommand(NewCmdVolumeDelete(in, out, &planFile))
+	addPlanFileFlag(cmd.PersistentFlags(), &opts.planFilename)
+	addPlanOverlayFlag(cmd.PersistentFlags(), &opts.planOverlays)
+	cmd.AddCommand(NewCmdVolumeAdd(out, opts))
+	cmd.AddCommand(NewCmdVolumeList(out, opts))
+	cmd.AddCommand(NewCmdVolumeDelete(in, out, opts))
 	return cmd
 }
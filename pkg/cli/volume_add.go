@@ -25,7 +25,7 @@ type volumeAddOptions struct {
 }
 
 // NewCmdVolumeAdd returns the command for adding storage volumes
-func NewCmdVolumeAdd(out io.Writer, planFile *string) *cobra.Command {
+func NewCmdVolumeAdd(out io.Writer, planOpts *planFileOpts) *cobra.Command {
 	opts := volumeAddOptions{}
 	cmd := &cobra.Command{
 		Use:   "add size_in_gigabytes [volume-name]",
@@ -34,7 +34,7 @@ func NewCmdVolumeAdd(out io.Writer, planFile *string) *cobra.Command {
 
 This function requires a target cluster that has storage nodes.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return doVolumeAdd(out, opts, *planFile, args)
+			return doVolumeAdd(out, opts, planOpts.planFilename, planOpts.planOverlays, args)
 		},
 		Example: `  # Create a 10GB distributed and replicated volume named "storage01"
   # with StorageClass "durable". Grant access to the volume to any client with an IP
@@ -54,7 +54,7 @@ This function requires a target cluster that has storage nodes.`,
 	return cmd
 }
 
-func doVolumeAdd(out io.Writer, opts volumeAddOptions, planFile string, args []string) error {
+func doVolumeAdd(out io.Writer, opts volumeAddOptions, planFile string, planOverlays []string, args []string) error {
 	// get volume name and size from arguments
 	var volumeName string
 	var volumeSizeStrGB string
@@ -76,10 +76,13 @@ func doVolumeAdd(out io.Writer, opts volumeAddOptions, planFile string, args []s
 	}
 
 	// setup ansible for execution
-	planner := &install.FilePlanner{File: planFile}
+	planner := &install.FilePlanner{File: planFile, Overlays: planOverlays}
 	if !planner.PlanExists() {
 		return planFileNotFoundErr{filename: planFile}
 	}
+	if err := verifyHostKeys(opts.generatedAssetsDir); err != nil {
+		return err
+	}
 	execOpts := install.ExecutorOptions{
 		OutputFormat: opts.outputFormat,
 		Verbose:      opts.verbose,
@@ -103,7 +106,9 @@ func doVolumeAdd(out io.Writer, opts volumeAddOptions, planFile string, args []s
 		skipPreFlight:      true,
 		generatedAssetsDir: opts.generatedAssetsDir,
 	}
-	if err := doValidate(out, planner, vopts); err != nil {
+	ctx, stop := interruptibleContext(out)
+	defer stop()
+	if err := doValidate(ctx, out, planner, vopts); err != nil {
 		return err
 	}
 
@@ -126,7 +131,7 @@ func doVolumeAdd(out io.Writer, opts volumeAddOptions, planFile string, args []s
 		}
 		return errors.New("storage volume validation failed")
 	}
-	if err := exec.AddVolume(plan, v); err != nil {
+	if err := exec.AddVolume(ctx, plan, v); err != nil {
 		return fmt.Errorf("error adding new volume: %v", err)
 	}
 
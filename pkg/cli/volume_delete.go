@@ -19,7 +19,7 @@ type volumeDeleteOptions struct {
 }
 
 // NewCmdVolumeDelete returns the command for deleting storage volumes
-func NewCmdVolumeDelete(in io.Reader, out io.Writer, planFile *string) *cobra.Command {
+func NewCmdVolumeDelete(in io.Reader, out io.Writer, planOpts *planFileOpts) *cobra.Command {
 	opts := volumeDeleteOptions{}
 	cmd := &cobra.Command{
 		Use:   "delete volume-name",
@@ -37,7 +37,7 @@ WARNING all data in the volume will be lost.`,
 					os.Exit(0)
 				}
 			}
-			return doVolumeDelete(out, opts, *planFile, args)
+			return doVolumeDelete(out, opts, planOpts.planFilename, planOpts.planOverlays, args)
 		},
 	}
 	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "enable verbose logging")
@@ -47,7 +47,7 @@ WARNING all data in the volume will be lost.`,
 	return cmd
 }
 
-func doVolumeDelete(out io.Writer, opts volumeDeleteOptions, planFile string, args []string) error {
+func doVolumeDelete(out io.Writer, opts volumeDeleteOptions, planFile string, planOverlays []string, args []string) error {
 	// get volume name and size from arguments
 	var volumeName string
 	switch len(args) {
@@ -58,10 +58,13 @@ func doVolumeDelete(out io.Writer, opts volumeDeleteOptions, planFile string, ar
 	}
 
 	// setup ansible for execution
-	planner := &install.FilePlanner{File: planFile}
+	planner := &install.FilePlanner{File: planFile, Overlays: planOverlays}
 	if !planner.PlanExists() {
 		return planFileNotFoundErr{filename: planFile}
 	}
+	if err := verifyHostKeys(opts.generatedAssetsDir); err != nil {
+		return err
+	}
 	execOpts := install.ExecutorOptions{
 		OutputFormat: opts.outputFormat,
 		Verbose:      opts.verbose,
@@ -85,11 +88,13 @@ func doVolumeDelete(out io.Writer, opts volumeDeleteOptions, planFile string, ar
 		skipPreFlight:      true,
 		generatedAssetsDir: opts.generatedAssetsDir,
 	}
-	if err := doValidate(out, planner, vopts); err != nil {
+	ctx, stop := interruptibleContext(out)
+	defer stop()
+	if err := doValidate(ctx, out, planner, vopts); err != nil {
 		return err
 	}
 
-	if err := exec.DeleteVolume(plan, volumeName); err != nil {
+	if err := exec.DeleteVolume(ctx, plan, volumeName); err != nil {
 		return fmt.Errorf("error deleting volume: %v", err)
 	}
 
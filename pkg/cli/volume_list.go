@@ -13,11 +13,12 @@ import (
 )
 
 type volumeListOptions struct {
-	outputFormat string
+	generatedAssetsDir string
+	outputFormat       string
 }
 
 // NewCmdVolumeList returns the command for listgin storage volumes
-func NewCmdVolumeList(out io.Writer, planFile *string) *cobra.Command {
+func NewCmdVolumeList(out io.Writer, planOpts *planFileOpts) *cobra.Command {
 	opts := volumeListOptions{}
 	cmd := &cobra.Command{
 		Use:   "list",
@@ -25,25 +26,29 @@ func NewCmdVolumeList(out io.Writer, planFile *string) *cobra.Command {
 		Long: `List storage volumes to the Kubernetes cluster.
 This function requires a target cluster that has storage nodes.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return doVolumeList(out, opts, *planFile, args)
+			return doVolumeList(out, opts, planOpts.planFilename, planOpts.planOverlays, args)
 		},
 	}
 
+	cmd.Flags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", `output format (options "simple"|"json")`)
 	return cmd
 }
 
-func doVolumeList(out io.Writer, opts volumeListOptions, planFile string, args []string) error {
+func doVolumeList(out io.Writer, opts volumeListOptions, planFile string, planOverlays []string, args []string) error {
 	// verify command
 	if opts.outputFormat != "simple" && opts.outputFormat != "json" {
 		return fmt.Errorf("output format %q is not supported", opts.outputFormat)
 	}
 
 	// Setup ansible
-	planner := &install.FilePlanner{File: planFile}
+	planner := &install.FilePlanner{File: planFile, Overlays: planOverlays}
 	if !planner.PlanExists() {
 		return planFileNotFoundErr{filename: planFile}
 	}
+	if err := verifyHostKeys(opts.generatedAssetsDir); err != nil {
+		return err
+	}
 
 	plan, err := planner.Read()
 	if err != nil {
@@ -3,6 +3,8 @@ package install
 import (
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/apprenda/kismatic/pkg/ssh"
 	"github.com/apprenda/kismatic/pkg/util"
@@ -17,6 +19,9 @@ type ClusterVersion struct {
 	LatestVersion   semver.Version
 	IsTransitioning bool
 	Nodes           []ListableNode
+	// The nodes that could not be reached. They are not considered when
+	// determining the versions of the cluster.
+	UnreachableNodes []UnreachableNode
 }
 
 // ListableNode contains version and role information about a given node
@@ -27,6 +32,15 @@ type ListableNode struct {
 	ComponentVersions ComponentVersions
 }
 
+// UnreachableNode is a node whose versions could not be determined,
+// as it could not be reached
+type UnreachableNode struct {
+	Node  Node
+	Roles []string
+	// The reason the node could not be reached
+	Error string
+}
+
 type ComponentVersions struct {
 	Kubernetes string
 }
@@ -59,74 +73,166 @@ func IsLessThanVersion(this semver.Version, that string) bool {
 	return this.LT(thatVersion)
 }
 
+// ListVersionsOptions control how the versions of the nodes are discovered
+type ListVersionsOptions struct {
+	// The maximum number of nodes that are queried concurrently
+	Concurrency int
+	// The maximum time to wait for the versions of a node, after which
+	// the node is recorded as unreachable. Nodes do not time out when zero.
+	NodeTimeout time.Duration
+	// newClient returns the SSH client of the node. Used in tests.
+	newClient func(p *Plan, node Node) (ssh.Client, error)
+}
+
+// DefaultListVersionsOptions are the options used by ListVersions
+var DefaultListVersionsOptions = ListVersionsOptions{
+	Concurrency: 50,
+	NodeTimeout: 30 * time.Second,
+}
+
 // ListVersions connects to the cluster described in the plan file and
 // gathers version information about it.
 func ListVersions(plan *Plan) (ClusterVersion, error) {
-	nodes := plan.GetUniqueNodes()
-	cv := ClusterVersion{
-		Nodes: []ListableNode{},
-	}
-
-	sshDeets := plan.Cluster.SSH
-	ketVerFile := "/etc/kismatic-version"
-	componentVerFile := "/etc/component-versions"
-	for i, node := range nodes {
-		client, err := ssh.NewClient(node.IP, sshDeets.Port, sshDeets.User, sshDeets.Key)
-		if err != nil {
-			return cv, fmt.Errorf("error creating SSH client: %v", err)
-		}
-
-		// get KET version
-		ketOutput, err := client.Output(false, fmt.Sprintf("cat %s", ketVerFile))
-		if err != nil {
-			// the output var contains the actual error message from the cat command, which has
-			// more meaningful info
-			return cv, fmt.Errorf("error getting KET version for node %q: %q", node.Host, ketOutput)
-		}
+	return ListVersionsWithOptions(plan, DefaultListVersionsOptions)
+}
 
-		thisVersion, err := parseVersion(ketOutput)
-		if err != nil {
-			return cv, fmt.Errorf("invalid version %q found in version file %q of node %s", ketOutput, ketVerFile, node.Host)
+// ListVersionsWithOptions connects to the nodes of the cluster described in the plan
+// file concurrently, and gathers version information about them. Nodes that cannot
+// be reached, or that do not respond in time, are recorded as unreachable.
+func ListVersionsWithOptions(plan *Plan, opts ListVersionsOptions) (ClusterVersion, error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultListVersionsOptions.Concurrency
+	}
+	if opts.newClient == nil {
+		opts.newClient = func(p *Plan, node Node) (ssh.Client, error) {
+			s := p.NodeSSHConfig(node)
+			return ssh.NewClientWithBastion(node.IP, s.Port, s.User, s.sshAuth(), s.sshBastion())
 		}
+	}
+	nodes := plan.GetUniqueNodes()
+	results := make([]nodeVersionResult, len(nodes))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, opts.Concurrency)
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node Node) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = nodeVersionWithTimeout(plan, node, opts)
+		}(i, node)
+	}
+	wg.Wait()
 
-		// get component versions
-		versionsOutput, err := client.Output(false, fmt.Sprintf("cat %s", componentVerFile))
-		// don't fail if the file is not found, will default to empty
-		// TODO remove
-		if err != nil && !strings.Contains(versionsOutput, "No such file or directory") {
-			// the output var contains the actual error message from the cat command, which has
-			// more meaningful info
-			return cv, fmt.Errorf("error getting component versions for node %q: %q", node.Host, versionsOutput)
+	cv := ClusterVersion{
+		Nodes:            []ListableNode{},
+		UnreachableNodes: []UnreachableNode{},
+	}
+	for i, r := range results {
+		if r.err != nil {
+			return cv, r.err
 		}
-		versions := ComponentVersions{}
-		if !strings.Contains(versionsOutput, "No such file or directory") {
-			err = yaml.Unmarshal([]byte(versionsOutput), &versions)
-			if err != nil {
-				return cv, fmt.Errorf("error unmarshalling component versions file: %q", componentVerFile)
-			}
+		if r.unreachable != nil {
+			cv.UnreachableNodes = append(cv.UnreachableNodes, UnreachableNode{
+				Node:  nodes[i],
+				Roles: plan.GetRolesForIP(nodes[i].IP),
+				Error: r.unreachable.Error(),
+			})
+			continue
 		}
-
-		cv.Nodes = append(cv.Nodes, ListableNode{node, plan.GetRolesForIP(node.IP), thisVersion, versions})
-
+		thisVersion := r.node.Version
 		// If looking at the first node, set the versions and move on
-		if i == 0 {
+		if len(cv.Nodes) == 0 {
 			cv.EarliestVersion = thisVersion
 			cv.LatestVersion = thisVersion
-			continue
 		}
-
 		if thisVersion.GT(cv.LatestVersion) {
 			cv.LatestVersion = thisVersion
 		}
 		if cv.EarliestVersion.GT(thisVersion) {
 			cv.EarliestVersion = thisVersion
 		}
+		cv.Nodes = append(cv.Nodes, r.node)
 	}
 
 	cv.IsTransitioning = cv.EarliestVersion.NE(cv.LatestVersion)
 	return cv, nil
 }
 
+type nodeVersionResult struct {
+	node ListableNode
+	// the reason the node could not be reached, if it could not
+	unreachable error
+	err         error
+}
+
+// nodeVersionWithTimeout gets the versions of the node, recording the node
+// as unreachable if it does not respond within the node timeout
+func nodeVersionWithTimeout(plan *Plan, node Node, opts ListVersionsOptions) nodeVersionResult {
+	if opts.NodeTimeout <= 0 {
+		return nodeVersion(plan, node, opts)
+	}
+	result := make(chan nodeVersionResult, 1)
+	go func() {
+		result <- nodeVersion(plan, node, opts)
+	}()
+	timer := time.NewTimer(opts.NodeTimeout)
+	defer timer.Stop()
+	select {
+	case r := <-result:
+		return r
+	case <-timer.C:
+		return nodeVersionResult{unreachable: fmt.Errorf("timed out after %v", opts.NodeTimeout)}
+	}
+}
+
+func nodeVersion(plan *Plan, node Node, opts ListVersionsOptions) nodeVersionResult {
+	ketVerFile := "/etc/kismatic-version"
+	componentVerFile := "/etc/component-versions"
+	client, err := opts.newClient(plan, node)
+	if err != nil {
+		return nodeVersionResult{err: fmt.Errorf("error creating SSH client: %v", err)}
+	}
+
+	// get KET version
+	ketOutput, err := client.Output(false, fmt.Sprintf("cat %s", ketVerFile))
+	if err != nil {
+		if !ssh.IsCommandError(err) {
+			return nodeVersionResult{unreachable: err}
+		}
+		// the output var contains the actual error message from the cat command, which has
+		// more meaningful info
+		return nodeVersionResult{err: fmt.Errorf("error getting KET version for node %q: %q", node.Host, ketOutput)}
+	}
+
+	thisVersion, err := parseVersion(ketOutput)
+	if err != nil {
+		return nodeVersionResult{err: fmt.Errorf("invalid version %q found in version file %q of node %s", ketOutput, ketVerFile, node.Host)}
+	}
+
+	// get component versions
+	versionsOutput, err := client.Output(false, fmt.Sprintf("cat %s", componentVerFile))
+	if err != nil && !ssh.IsCommandError(err) {
+		return nodeVersionResult{unreachable: err}
+	}
+	// don't fail if the file is not found, will default to empty
+	// TODO remove
+	if err != nil && !strings.Contains(versionsOutput, "No such file or directory") {
+		// the output var contains the actual error message from the cat command, which has
+		// more meaningful info
+		return nodeVersionResult{err: fmt.Errorf("error getting component versions for node %q: %q", node.Host, versionsOutput)}
+	}
+	versions := ComponentVersions{}
+	if !strings.Contains(versionsOutput, "No such file or directory") {
+		err = yaml.Unmarshal([]byte(versionsOutput), &versions)
+		if err != nil {
+			return nodeVersionResult{err: fmt.Errorf("error unmarshalling component versions file: %q", componentVerFile)}
+		}
+	}
+
+	return nodeVersionResult{node: ListableNode{node, plan.GetRolesForIP(node.IP), thisVersion, versions}}
+}
+
 // NodesWithRoles returns a filtered list of ListableNode slice based on the node's roles
 func NodesWithRoles(nodes []ListableNode, roles ...string) []ListableNode {
 	var subset []ListableNode
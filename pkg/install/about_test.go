@@ -1,8 +1,14 @@
 package install
 
 import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/apprenda/kismatic/pkg/ssh"
 	"github.com/blang/semver"
 )
 
@@ -124,3 +130,108 @@ func mustParseVersion(v string) semver.Version {
 	}
 	return ver
 }
+
+// fakeVersionClient returns the versions of a node
+type fakeVersionClient struct {
+	version string
+	delay   time.Duration
+	err     error
+
+	mu            *sync.Mutex
+	running, peak *int
+}
+
+func (c fakeVersionClient) Output(pty bool, args ...string) (string, error) {
+	// nodes that time out are given up on, and no longer count towards the concurrency
+	if c.delay > 0 {
+		time.Sleep(c.delay)
+		return c.version, nil
+	}
+	c.mu.Lock()
+	*c.running++
+	if *c.running > *c.peak {
+		*c.peak = *c.running
+	}
+	c.mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	c.mu.Lock()
+	*c.running--
+	c.mu.Unlock()
+	if c.err != nil {
+		return "", c.err
+	}
+	if strings.Contains(args[0], "/etc/component-versions") {
+		return "kubernetes: v1.10.0", nil
+	}
+	return c.version, nil
+}
+
+func (c fakeVersionClient) Shell(pty bool, args ...string) error {
+	return nil
+}
+
+func (c fakeVersionClient) Upload(localPath string, remotePath string) error {
+	return nil
+}
+
+func TestListVersionsWithOptions(t *testing.T) {
+	p := Plan{}
+	p.Etcd.Nodes = []Node{{Host: "etcd01", IP: "10.0.0.1"}}
+	p.Master.Nodes = []Node{{Host: "master01", IP: "10.0.0.2"}}
+	p.Worker.Nodes = []Node{
+		{Host: "worker01", IP: "10.0.0.3"},
+		{Host: "worker02", IP: "10.0.0.4"},
+		{Host: "worker03", IP: "10.0.0.5"},
+		{Host: "worker04", IP: "10.0.0.6"},
+	}
+	var (
+		mu            sync.Mutex
+		running, peak int
+	)
+	clients := map[string]fakeVersionClient{
+		"etcd01":   {version: "1.2.0"},
+		"master01": {version: "1.3.0"},
+		"worker01": {version: "1.3.0"},
+		"worker02": {err: errors.New("connection refused")},
+		"worker03": {version: "1.3.0", delay: time.Second},
+		"worker04": {version: "1.3.0"},
+	}
+	opts := ListVersionsOptions{
+		Concurrency: 2,
+		NodeTimeout: 200 * time.Millisecond,
+		newClient: func(p *Plan, node Node) (ssh.Client, error) {
+			c := clients[node.Host]
+			c.mu, c.running, c.peak = &mu, &running, &peak
+			return c, nil
+		},
+	}
+	cv, err := ListVersionsWithOptions(&p, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reachable := []string{}
+	for _, n := range cv.Nodes {
+		reachable = append(reachable, n.Node.Host)
+	}
+	if expected := []string{"etcd01", "master01", "worker01", "worker04"}; !reflect.DeepEqual(reachable, expected) {
+		t.Errorf("expected the reachable nodes to be %v, but got %v", expected, reachable)
+	}
+	unreachable := []string{}
+	for _, n := range cv.UnreachableNodes {
+		unreachable = append(unreachable, n.Node.Host)
+	}
+	if expected := []string{"worker02", "worker03"}; !reflect.DeepEqual(unreachable, expected) {
+		t.Errorf("expected the unreachable nodes to be %v, but got %v", expected, unreachable)
+	}
+	if !cv.IsTransitioning || cv.EarliestVersion.String() != "1.2.0" || cv.LatestVersion.String() != "1.3.0" {
+		t.Errorf("expected the cluster to be transitioning from 1.2.0 to 1.3.0, but got %+v", cv)
+	}
+	if cv.Nodes[1].ComponentVersions.Kubernetes != "v1.10.0" {
+		t.Errorf("expected the kubernetes version to be v1.10.0, but got %q", cv.Nodes[1].ComponentVersions.Kubernetes)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if peak > opts.Concurrency {
+		t.Errorf("expected at most %d nodes to be queried concurrently, but got %d", opts.Concurrency, peak)
+	}
+}
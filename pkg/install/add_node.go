@@ -1,6 +1,7 @@
 package install
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -12,7 +13,7 @@ var errMissingClusterCA = errors.New("The Certificate Authority's private key an
 
 // AddNode adds a worker node to the original cluster described in the plan.
 // If successful, the updated plan is returned.
-func (ae *ansibleExecutor) AddNode(originalPlan *Plan, newNode Node, roles []string, restartServices bool) (*Plan, error) {
+func (ae *ansibleExecutor) AddNode(ctx context.Context, originalPlan *Plan, newNode Node, roles []string, restartServices bool) (*Plan, error) {
 	if err := checkAddNodePrereqs(ae.pki, newNode); err != nil {
 		return nil, err
 	}
@@ -20,16 +21,20 @@ func (ae *ansibleExecutor) AddNode(originalPlan *Plan, newNode Node, roles []str
 
 	// Generate node certificates
 	util.PrintHeader(ae.stdout, "Generating Certificate For New Node", '=')
-	ca, err := ae.pki.GetClusterCA()
-	if err != nil {
-		return nil, err
-	}
-	if err = ae.pki.GenerateNodeCertificate(&updatedPlan, newNode, ca); err != nil {
-		return nil, fmt.Errorf("error generating certificate for new node: %v", err)
+	if ae.options.DryRun {
+		util.PrettyPrintSkipped(ae.stdout, "Dry run: the certificate of the new node was not generated")
+	} else {
+		ca, err := ae.pki.GetClusterCA()
+		if err != nil {
+			return nil, err
+		}
+		if err = ae.pki.GenerateNodeCertificate(&updatedPlan, newNode, ca); err != nil {
+			return nil, fmt.Errorf("error generating certificate for new node: %v", err)
+		}
 	}
 
 	// Run the playbook to add the node
-	inventory := buildInventoryFromPlan(&updatedPlan)
+	inventory := buildInventoryFromPlan(&updatedPlan, ae.knownHostsFile)
 	cc, err := ae.buildClusterCatalog(&updatedPlan)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate ansible vars: %v", err)
@@ -46,8 +51,8 @@ func (ae *ansibleExecutor) AddNode(originalPlan *Plan, newNode Node, roles []str
 			clusterCatalog: *cc,
 			explainer:      ae.defaultExplainer(),
 		}
-		if err = ae.execute(t); err != nil {
-			return nil, fmt.Errorf("error updating hosts files on all nodes: %v", err)
+		if err = ae.execute(ctx, t); err != nil {
+			return nil, taskError(err, "error updating hosts files on all nodes")
 		}
 	}
 
@@ -64,8 +69,8 @@ func (ae *ansibleExecutor) AddNode(originalPlan *Plan, newNode Node, roles []str
 		explainer:      ae.defaultExplainer(),
 		limit:          []string{newNode.Host},
 	}
-	if err = ae.execute(t); err != nil {
-		return nil, fmt.Errorf("error running playbook: %v", err)
+	if err = ae.execute(ctx, t); err != nil {
+		return nil, taskError(err, "error running playbook")
 	}
 
 	// Verify that the node registered with API server
@@ -80,8 +85,8 @@ func (ae *ansibleExecutor) AddNode(originalPlan *Plan, newNode Node, roles []str
 		explainer:      ae.defaultExplainer(),
 		limit:          []string{newNode.Host},
 	}
-	if err = ae.execute(t); err != nil {
-		return nil, fmt.Errorf("error running node smoke test: %v", err)
+	if err = ae.execute(ctx, t); err != nil {
+		return nil, taskError(err, "error running node smoke test")
 	}
 
 	// Allow access to new node to any storage volumes defined
@@ -95,8 +100,8 @@ func (ae *ansibleExecutor) AddNode(originalPlan *Plan, newNode Node, roles []str
 			clusterCatalog: *cc,
 			explainer:      ae.defaultExplainer(),
 		}
-		if err = ae.execute(t); err != nil {
-			return nil, fmt.Errorf("error adding new node to volume allow list: %v", err)
+		if err = ae.execute(ctx, t); err != nil {
+			return nil, taskError(err, "error adding new node to volume allow list")
 		}
 	}
 	return &updatedPlan, nil
@@ -1,6 +1,7 @@
 package install
 
 import (
+	"context"
 	"errors"
 	"io"
 	"io/ioutil"
@@ -33,7 +34,7 @@ func TestAddNodeCertMissingCAMissing(t *testing.T) {
 		},
 	}
 	newNode := Node{}
-	newPlan, err := e.AddNode(originalPlan, newNode, []string{"worker"}, true)
+	newPlan, err := e.AddNode(context.Background(), originalPlan, newNode, []string{"worker"}, true)
 	if newPlan != nil {
 		t.Errorf("add worker returned an updated plan")
 	}
@@ -70,7 +71,7 @@ func TestAddNodeCertMissingCAExists(t *testing.T) {
 		},
 	}
 	newNode := Node{}
-	_, err := e.AddNode(originalPlan, newNode, []string{"worker"}, true)
+	_, err := e.AddNode(context.Background(), originalPlan, newNode, []string{"worker"}, true)
 	if err != nil {
 		t.Errorf("unexpected error while adding worker: %v", err)
 	}
@@ -115,7 +116,7 @@ func TestAddNodePlanIsUpdated(t *testing.T) {
 	newNode := Node{
 		Host: "test",
 	}
-	updatedPlan, err := e.AddNode(originalPlan, newNode, []string{"worker"}, true)
+	updatedPlan, err := e.AddNode(context.Background(), originalPlan, newNode, []string{"worker"}, true)
 	if err != nil {
 		t.Errorf("unexpected error while adding worker: %v", err)
 	}
@@ -172,7 +173,7 @@ func TestAddIngressPlanIsUpdated(t *testing.T) {
 	newNode := Node{
 		Host: "test",
 	}
-	updatedPlan, err := e.AddNode(originalPlan, newNode, []string{"ingress"}, true)
+	updatedPlan, err := e.AddNode(context.Background(), originalPlan, newNode, []string{"ingress"}, true)
 	if err != nil {
 		t.Errorf("unexpected error while adding worker: %v", err)
 	}
@@ -229,7 +230,7 @@ func TestAddStoragePlanIsUpdated(t *testing.T) {
 	newNode := Node{
 		Host: "test",
 	}
-	updatedPlan, err := e.AddNode(originalPlan, newNode, []string{"storage"}, true)
+	updatedPlan, err := e.AddNode(context.Background(), originalPlan, newNode, []string{"storage"}, true)
 	if err != nil {
 		t.Errorf("unexpected error while adding worker: %v", err)
 	}
@@ -302,7 +303,7 @@ func TestAddAllRolesPlanIsUpdated(t *testing.T) {
 	newNode := Node{
 		Host: "test",
 	}
-	updatedPlan, err := e.AddNode(originalPlan, newNode, []string{"worker", "ingress", "storage"}, true)
+	updatedPlan, err := e.AddNode(context.Background(), originalPlan, newNode, []string{"worker", "ingress", "storage"}, true)
 	if err != nil {
 		t.Errorf("unexpected error while adding worker: %v", err)
 	}
@@ -377,7 +378,7 @@ func TestAddNodePlanNotUpdatedAfterFailure(t *testing.T) {
 	newNode := Node{
 		Host: "test",
 	}
-	updatedPlan, err := e.AddNode(originalPlan, newNode, []string{"worker"}, true)
+	updatedPlan, err := e.AddNode(context.Background(), originalPlan, newNode, []string{"worker"}, true)
 	if err == nil {
 		t.Errorf("expected an error, but didn't get one")
 	}
@@ -422,7 +423,7 @@ func TestAddNodeRestartServicesEnabled(t *testing.T) {
 	newNode := Node{
 		Host: "test",
 	}
-	_, err := e.AddNode(originalPlan, newNode, []string{"worker"}, true)
+	_, err := e.AddNode(context.Background(), originalPlan, newNode, []string{"worker"}, true)
 	if err != nil {
 		t.Errorf("unexpected error")
 	}
@@ -481,7 +482,7 @@ func TestAddNodeHostsFilesDNSEnabled(t *testing.T) {
 	newNode := Node{
 		Host: "test",
 	}
-	_, err := e.AddNode(originalPlan, newNode, []string{"worker"}, false)
+	_, err := e.AddNode(context.Background(), originalPlan, newNode, []string{"worker"}, false)
 	if err != nil {
 		t.Errorf("unexpected error")
 	}
@@ -537,14 +538,25 @@ type fakeRunner struct {
 	allNodesPlaybooks []string
 }
 
-func (f *fakeRunner) StartPlaybook(playbookFile string, inventory ansible.Inventory, cc ansible.ClusterCatalog) (<-chan ansible.Event, error) {
+func (f *fakeRunner) StartPlaybook(ctx context.Context, playbookFile string, inventory ansible.Inventory, cc ansible.ClusterCatalog) (<-chan ansible.Event, error) {
 	f.allNodesPlaybooks = append(f.allNodesPlaybooks, playbookFile)
-	return f.eventChan, f.err
+	return f.events(), f.err
 }
 func (f *fakeRunner) WaitPlaybook() error { return f.err }
-func (f *fakeRunner) StartPlaybookOnNode(playbookFile string, inventory ansible.Inventory, cc ansible.ClusterCatalog, node ...string) (<-chan ansible.Event, error) {
+func (f *fakeRunner) StartPlaybookOnNode(ctx context.Context, playbookFile string, inventory ansible.Inventory, cc ansible.ClusterCatalog, node ...string) (<-chan ansible.Event, error) {
 	f.incomingCatalog = cc
-	return f.eventChan, f.err
+	return f.events(), f.err
+}
+
+// events returns the stream of events of the runner, which is closed
+// like the stream of a playbook that ended when none were given
+func (f *fakeRunner) events() <-chan ansible.Event {
+	if f.eventChan != nil {
+		return f.eventChan
+	}
+	events := make(chan ansible.Event)
+	close(events)
+	return events
 }
 
 func fakeRunnerExplainer(execError error) func(explain.AnsibleEventExplainer, io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
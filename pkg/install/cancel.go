@@ -0,0 +1,37 @@
+package install
+
+import "fmt"
+
+// CancelledError is returned when an operation is cancelled with its context
+type CancelledError struct {
+	// Phase of the operation that was cancelled, such as "apply"
+	Phase string
+	// RunDirectory where the cancelled run is recorded. Empty when the phase
+	// was cancelled before it started.
+	RunDirectory string
+	// Err is the error of the context, such as context.Canceled
+	Err error
+}
+
+func (e CancelledError) Error() string {
+	if e.RunDirectory == "" {
+		return fmt.Sprintf("the %s phase was cancelled before it started: %v", e.Phase, e.Err)
+	}
+	return fmt.Sprintf("the %s phase was cancelled, the run was recorded in %q: %v", e.Phase, e.RunDirectory, e.Err)
+}
+
+// IsCancelled returns true if the error was returned because the operation
+// was cancelled with its context
+func IsCancelled(err error) bool {
+	_, ok := err.(CancelledError)
+	return ok
+}
+
+// taskError describes the error of a task with the message, unless the task was
+// cancelled, in which case the error is returned as is so that it is distinguishable
+func taskError(err error, msg string, a ...interface{}) error {
+	if IsCancelled(err) {
+		return err
+	}
+	return fmt.Errorf("%s: %v", fmt.Sprintf(msg, a...), err)
+}
@@ -0,0 +1,329 @@
+package install
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/util"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// The phases of an installation that completed are recorded in the run directory,
+// so that a failed installation can be resumed from the phase that failed.
+// A phase is one of the playbooks included by the installation playbook.
+const (
+	installCheckpointFile = "checkpoint.yaml"
+	installPlaybook       = "kubernetes.yaml"
+	// the playbook that resumes an installation, which is generated in the
+	// run directory
+	resumePlaybook = "kubernetes-resume.yaml"
+	// the phase that prepares the nodes, which is run again when resuming
+	prerequisitesPhase = "_all.yaml"
+)
+
+// installCheckpoint records the progress of an installation
+type installCheckpoint struct {
+	// Hash of the plan that was installed
+	PlanHash string `yaml:"plan_hash"`
+	// The nodes the installation was limited to
+	Limit []string `yaml:"limit,omitempty"`
+	// The phases that completed, in the order they were run
+	CompletedPhases []string `yaml:"completed_phases"`
+}
+
+// playbookPhase is a playbook included by the installation playbook
+type playbookPhase struct {
+	Include string `yaml:"include"`
+	When    string `yaml:"when,omitempty"`
+	// the number of plays in the included playbook
+	plays int
+}
+
+// InstallResumePoint is the point from which a failed installation is resumed
+type InstallResumePoint struct {
+	// The run directory of the failed installation
+	RunDirectory string
+	// The phases that completed, which are not run again
+	CompletedPhases []string
+	// The nodes the installation was limited to
+	Limit []string
+
+	planHash string
+}
+
+// planHash returns a hash of the plan, including its secrets
+func planHash(p *Plan) (string, error) {
+	b, err := yaml.Marshal(p)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling plan: %v", err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b)), nil
+}
+
+// readPlaybookPhases returns the playbooks included by the playbook, in order
+func readPlaybookPhases(playbooksDir, playbook string) ([]playbookPhase, error) {
+	b, err := ioutil.ReadFile(filepath.Join(playbooksDir, playbook))
+	if err != nil {
+		return nil, fmt.Errorf("error reading playbook %q: %v", playbook, err)
+	}
+	phases := []playbookPhase{}
+	if err = yaml.Unmarshal(b, &phases); err != nil {
+		return nil, fmt.Errorf("error unmarshalling playbook %q: %v", playbook, err)
+	}
+	for i, phase := range phases {
+		if phase.Include == "" {
+			return nil, fmt.Errorf("playbook %q can only include other playbooks", playbook)
+		}
+		b, err := ioutil.ReadFile(filepath.Join(playbooksDir, phase.Include))
+		if err != nil {
+			return nil, fmt.Errorf("error reading playbook %q: %v", phase.Include, err)
+		}
+		plays := []interface{}{}
+		if err = yaml.Unmarshal(b, &plays); err != nil {
+			return nil, fmt.Errorf("error unmarshalling playbook %q: %v", phase.Include, err)
+		}
+		phases[i].plays = len(plays)
+	}
+	return phases, nil
+}
+
+// resumePhases returns the phases that did not complete, after the phase
+// that prepares the nodes again
+func resumePhases(phases []playbookPhase, completed []string) []playbookPhase {
+	remaining := []playbookPhase{}
+	for _, phase := range phases {
+		if phase.Include == prerequisitesPhase || !util.Contains(phase.Include, completed) {
+			remaining = append(remaining, phase)
+		}
+	}
+	return remaining
+}
+
+// writeResumePlaybook writes a playbook that runs the given phases into the
+// directory, and returns its absolute path. The phases are included from the
+// playbooks directory, whose group variables are linked into the directory, as
+// ansible reads them next to the playbook that is run.
+func writeResumePlaybook(dir string, playbooksDir string, phases []playbookPhase) (string, error) {
+	playbooksDir, err := filepath.Abs(playbooksDir)
+	if err != nil {
+		return "", fmt.Errorf("error determining the absolute path of %q: %v", playbooksDir, err)
+	}
+	included := make([]playbookPhase, len(phases))
+	for i, phase := range phases {
+		included[i] = playbookPhase{Include: filepath.Join(playbooksDir, phase.Include), When: phase.When}
+	}
+	b, err := yaml.Marshal(included)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling playbook: %v", err)
+	}
+	b = append([]byte("---\n  # Generated by kismatic to resume a failed installation\n"), b...)
+	playbook, err := filepath.Abs(filepath.Join(dir, resumePlaybook))
+	if err != nil {
+		return "", fmt.Errorf("error determining the absolute path of %q: %v", resumePlaybook, err)
+	}
+	if err = ioutil.WriteFile(playbook, b, 0644); err != nil {
+		return "", fmt.Errorf("error writing playbook %q: %v", playbook, err)
+	}
+	groupVars := filepath.Join(playbooksDir, "group_vars")
+	if _, err = os.Stat(groupVars); err == nil {
+		if err = os.Symlink(groupVars, filepath.Join(dir, "group_vars")); err != nil {
+			return "", fmt.Errorf("error linking the group variables of the playbooks: %v", err)
+		}
+	}
+	return playbook, nil
+}
+
+func readInstallCheckpoint(runDirectory string) (*installCheckpoint, error) {
+	b, err := ioutil.ReadFile(filepath.Join(runDirectory, installCheckpointFile))
+	if err != nil {
+		return nil, err
+	}
+	c := &installCheckpoint{}
+	if err = yaml.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("error unmarshalling checkpoint: %v", err)
+	}
+	return c, nil
+}
+
+func writeInstallCheckpoint(runDirectory string, c installCheckpoint) error {
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("error marshalling checkpoint: %v", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(runDirectory, installCheckpointFile), b, 0644); err != nil {
+		return fmt.Errorf("error writing checkpoint: %v", err)
+	}
+	return nil
+}
+
+// FindInstallResumePoint returns the point from which the last installation
+// can be resumed with the plan. An error is returned if the last installation
+// succeeded, did not record its progress, or if the plan changed in a way that
+// affects the phases that completed.
+func FindInstallResumePoint(runsDirectory string, p *Plan) (*InstallResumePoint, error) {
+	runs, err := filepath.Glob(filepath.Join(runsDirectory, "apply", "*"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing runs: %v", err)
+	}
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("no installation was found in %q to resume", runsDirectory)
+	}
+	// run directories are named after the time the run started
+	sort.Slice(runs, func(i, j int) bool {
+		return runNameLess(filepath.Base(runs[i]), filepath.Base(runs[j]))
+	})
+	run := runs[len(runs)-1]
+	status, err := RunStatus(run)
+	if err != nil {
+		return nil, err
+	}
+	if status == RunStatusSucceeded {
+		return nil, fmt.Errorf("the last installation in %q succeeded, there is nothing to resume", run)
+	}
+	checkpoint, err := readInstallCheckpoint(run)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("the last installation in %q did not record its progress, and cannot be resumed", run)
+		}
+		return nil, fmt.Errorf("error reading the progress of the installation in %q: %v", run, err)
+	}
+	hash, err := planHash(p)
+	if err != nil {
+		return nil, err
+	}
+	if hash != checkpoint.PlanHash {
+		if err := validateResumedPlan(run, p, checkpoint.CompletedPhases); err != nil {
+			return nil, err
+		}
+	}
+	return &InstallResumePoint{
+		RunDirectory:    run,
+		CompletedPhases: checkpoint.CompletedPhases,
+		Limit:           checkpoint.Limit,
+		planHash:        hash,
+	}, nil
+}
+
+// validateResumedPlan returns an error if the plan changed since the run in a
+// way that affects the phases that completed
+func validateResumedPlan(run string, p *Plan, completed []string) error {
+	recorded, err := (&FilePlanner{File: filepath.Join(run, "kismatic-cluster.yaml")}).Read()
+	if err != nil {
+		return fmt.Errorf("error reading the plan file of the installation in %q: %v", run, err)
+	}
+	diff, err := DiffPlans(*recorded, *p)
+	if err != nil {
+		return err
+	}
+	if diff.Empty() {
+		return fmt.Errorf("cannot resume the installation: the secrets of the plan file changed since the installation in %q", run)
+	}
+	paths := []string{}
+	for _, a := range diff.Actions {
+		// changes that are applied by a phase that did not complete are
+		// applied when the installation is resumed
+		if a.Play == "" || util.Contains(a.Play, completed) {
+			paths = append(paths, a.Paths...)
+		}
+	}
+	if len(paths) > 0 {
+		return fmt.Errorf("cannot resume the installation: the plan file changed since the installation in %q in a way that affects the phases that completed: %s", run, strings.Join(paths, ", "))
+	}
+	return nil
+}
+
+// phaseTracker records the phases that complete as the events of the playbook
+// are received. A phase completes when the first play of a later phase starts,
+// or when the playbook ends, as long as no host failed.
+type phaseTracker struct {
+	runDirectory string
+	phases       []playbookPhase
+	checkpoint   installCheckpoint
+	playsStarted int
+	failed       bool
+	// closed once the end of the playbook was tracked
+	done     chan struct{}
+	doneOnce sync.Once
+	// the error of the last checkpoint write
+	err error
+}
+
+func newPhaseTracker(runDirectory string, phases []playbookPhase, checkpoint installCheckpoint) *phaseTracker {
+	return &phaseTracker{
+		runDirectory: runDirectory,
+		phases:       phases,
+		checkpoint:   checkpoint,
+		done:         make(chan struct{}),
+	}
+}
+
+// track returns a stream with the events of the given stream, recording the
+// phases that complete
+func (t *phaseTracker) track(in <-chan ansible.Event) <-chan ansible.Event {
+	out := make(chan ansible.Event)
+	go func() {
+		defer close(out)
+		defer t.doneOnce.Do(func() { close(t.done) })
+		for e := range in {
+			t.handle(e)
+			out <- e
+			if _, ok := e.(*ansible.PlaybookEndEvent); ok {
+				t.doneOnce.Do(func() { close(t.done) })
+			}
+		}
+	}()
+	return out
+}
+
+func (t *phaseTracker) handle(e ansible.Event) {
+	switch event := e.(type) {
+	case *ansible.RunnerFailedEvent:
+		if !event.IgnoreErrors {
+			t.failed = true
+		}
+	case *ansible.RunnerUnreachableEvent:
+		t.failed = true
+	case *ansible.PlayStartEvent:
+		t.completePhases(t.playsStarted)
+		t.playsStarted++
+	case *ansible.PlaybookEndEvent:
+		t.completePhases(-1)
+	}
+}
+
+// completePhases records the phases whose plays all started before the given
+// play. All phases are complete when the play is negative.
+func (t *phaseTracker) completePhases(play int) {
+	if t.failed {
+		return
+	}
+	changed := false
+	end := 0
+	for _, phase := range t.phases {
+		end += phase.plays
+		if play >= 0 && end > play {
+			break
+		}
+		if !util.Contains(phase.Include, t.checkpoint.CompletedPhases) {
+			t.checkpoint.CompletedPhases = append(t.checkpoint.CompletedPhases, phase.Include)
+			changed = true
+		}
+	}
+	if changed {
+		t.err = writeInstallCheckpoint(t.runDirectory, t.checkpoint)
+	}
+}
+
+// wait blocks until the end of the playbook, or of the stream of events, was
+// tracked, and returns the error of the last checkpoint write
+func (t *phaseTracker) wait() error {
+	<-t.done
+	return t.err
+}
@@ -0,0 +1,248 @@
+package install
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/install/explain"
+)
+
+func TestReadPlaybookPhases(t *testing.T) {
+	phases, err := readPlaybookPhases("../../ansible", installPlaybook)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(phases) == 0 || phases[0].Include != prerequisitesPhase {
+		t.Fatalf("expected the first phase to be %q, but got %v", prerequisitesPhase, phases)
+	}
+	// the prerequisites playbook has two plays
+	if phases[0].plays != 2 {
+		t.Errorf("expected the %q phase to have 2 plays, but got %d", prerequisitesPhase, phases[0].plays)
+	}
+	for _, p := range phases {
+		if p.plays == 0 {
+			t.Errorf("phase %q does not have any plays", p.Include)
+		}
+	}
+}
+
+func TestPhaseTracker(t *testing.T) {
+	tests := []struct {
+		events    []ansible.Event
+		completed []string
+	}{
+		{
+			// the first play of the second phase started
+			events:    []ansible.Event{&ansible.PlayStartEvent{}, &ansible.PlayStartEvent{}, &ansible.PlayStartEvent{}},
+			completed: []string{"_all.yaml"},
+		},
+		{
+			// a host failed in the second phase
+			events:    []ansible.Event{&ansible.PlayStartEvent{}, &ansible.PlayStartEvent{}, &ansible.PlayStartEvent{}, &ansible.RunnerFailedEvent{}, &ansible.PlayStartEvent{}, &ansible.PlaybookEndEvent{}},
+			completed: []string{"_all.yaml"},
+		},
+		{
+			// a host was unreachable in the first phase
+			events:    []ansible.Event{&ansible.PlayStartEvent{}, &ansible.RunnerUnreachableEvent{}, &ansible.PlayStartEvent{}, &ansible.PlayStartEvent{}, &ansible.PlaybookEndEvent{}},
+			completed: nil,
+		},
+		{
+			events:    []ansible.Event{&ansible.PlayStartEvent{}, &ansible.PlayStartEvent{}, &ansible.PlayStartEvent{}, &ansible.PlayStartEvent{}, &ansible.PlaybookEndEvent{}},
+			completed: []string{"_all.yaml", "_docker.yaml", "_etcd-k8s.yaml"},
+		},
+	}
+	phases := []playbookPhase{{Include: "_all.yaml", plays: 2}, {Include: "_docker.yaml", plays: 1}, {Include: "_etcd-k8s.yaml", plays: 1}}
+	for i, test := range tests {
+		dir, err := ioutil.TempDir("", "phase-tracker-test")
+		if err != nil {
+			t.Fatalf("error creating temp dir: %v", err)
+		}
+		defer os.RemoveAll(dir)
+		tracker := newPhaseTracker(dir, phases, installCheckpoint{PlanHash: "hash"})
+		in := make(chan ansible.Event)
+		out := tracker.track(in)
+		go func() {
+			for range out {
+			}
+		}()
+		for _, e := range test.events {
+			in <- e
+		}
+		close(in)
+		if err := tracker.wait(); err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i, err)
+		}
+		checkpoint, err := readInstallCheckpoint(dir)
+		if os.IsNotExist(err) && test.completed == nil {
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test %d: error reading checkpoint: %v", i, err)
+		}
+		if !reflect.DeepEqual(checkpoint.CompletedPhases, test.completed) {
+			t.Errorf("test %d: expected the completed phases to be %v, but got %v", i, test.completed, checkpoint.CompletedPhases)
+		}
+		if checkpoint.PlanHash != "hash" {
+			t.Errorf("test %d: expected the plan hash to be recorded, but got %q", i, checkpoint.PlanHash)
+		}
+	}
+}
+
+func TestWriteResumePlaybook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "resume-playbook-test")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	phases := []playbookPhase{
+		{Include: "_all.yaml", plays: 2},
+		{Include: "_docker.yaml", When: "docker.enabled|bool == true", plays: 1},
+		{Include: "_etcd-k8s.yaml", plays: 1},
+	}
+	remaining := resumePhases(phases, []string{"_all.yaml", "_docker.yaml"})
+	if expected := []playbookPhase{phases[0], phases[2]}; !reflect.DeepEqual(remaining, expected) {
+		t.Errorf("expected the remaining phases to be %v, but got %v", expected, remaining)
+	}
+	playbooksDir := filepath.Join(dir, "playbooks")
+	os.MkdirAll(filepath.Join(playbooksDir, "group_vars"), 0755)
+	runDir := filepath.Join(dir, "run")
+	os.MkdirAll(runDir, 0755)
+	playbook, err := writeResumePlaybook(runDir, playbooksDir, remaining)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if playbook != filepath.Join(runDir, resumePlaybook) {
+		t.Errorf("expected the playbook to be written to %q, but got %q", filepath.Join(runDir, resumePlaybook), playbook)
+	}
+	b, err := ioutil.ReadFile(playbook)
+	if err != nil {
+		t.Fatalf("error reading playbook: %v", err)
+	}
+	if strings.Contains(string(b), "_docker.yaml") || !strings.Contains(string(b), "include: "+filepath.Join(playbooksDir, "_etcd-k8s.yaml")) {
+		t.Errorf("unexpected resume playbook:\n%s", string(b))
+	}
+	if target, err := os.Readlink(filepath.Join(runDir, "group_vars")); err != nil || target != filepath.Join(playbooksDir, "group_vars") {
+		t.Errorf("expected the group variables of the playbooks to be linked, but got %q (%v)", target, err)
+	}
+}
+
+func TestResumeInstallWritesResumePlaybookToRunDirectory(t *testing.T) {
+	dir := mustGetTempDir(t)
+	defer os.RemoveAll(dir)
+	playbooksDir := filepath.Join(dir, "ansible", "playbooks")
+	os.MkdirAll(playbooksDir, 0755)
+	ioutil.WriteFile(filepath.Join(playbooksDir, installPlaybook), []byte("- include: _all.yaml\n- include: _etcd-k8s.yaml\n"), 0644)
+	ioutil.WriteFile(filepath.Join(playbooksDir, "_all.yaml"), []byte("- hosts: all\n"), 0644)
+	ioutil.WriteFile(filepath.Join(playbooksDir, "_etcd-k8s.yaml"), []byte("- hosts: etcd\n"), 0644)
+
+	runner := &fakeRunner{}
+	e := ansibleExecutor{
+		options:             ExecutorOptions{RunsDirectory: filepath.Join(dir, "runs")},
+		stdout:              ioutil.Discard,
+		consoleOutputFormat: ansible.RawFormat,
+		ansibleDir:          filepath.Join(dir, "ansible"),
+		runnerExplainerFactory: func(explain.AnsibleEventExplainer, io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+			return runner, &explain.AnsibleEventStreamExplainer{}, nil
+		},
+	}
+	p := validPlan()
+	if err := e.ResumeInstall(context.Background(), &p, InstallResumePoint{CompletedPhases: []string{"_all.yaml"}}, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	runs, err := filepath.Glob(filepath.Join(dir, "runs", "apply", "*"))
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("expected a single run, but got %v (%v)", runs, err)
+	}
+	if len(runner.allNodesPlaybooks) != 1 || runner.allNodesPlaybooks[0] != filepath.Join(runs[0], resumePlaybook) {
+		t.Errorf("expected the installation to be resumed with a playbook in its run directory, but ran %v", runner.allNodesPlaybooks)
+	}
+	files, err := ioutil.ReadDir(playbooksDir)
+	if err != nil {
+		t.Fatalf("error listing playbooks: %v", err)
+	}
+	if len(files) != 3 {
+		t.Errorf("expected the playbooks directory to be left as is, but found %d playbooks", len(files))
+	}
+}
+
+func TestFindInstallResumePoint(t *testing.T) {
+	runsDir, err := ioutil.TempDir("", "resume-point-test")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(runsDir)
+	if _, err := FindInstallResumePoint(runsDir, &Plan{}); err == nil {
+		t.Errorf("expected an error when there are no runs")
+	}
+
+	run := filepath.Join(runsDir, "apply", "2018-03-01-10-00-00")
+	if err := os.MkdirAll(run, 0777); err != nil {
+		t.Fatalf("error creating run dir: %v", err)
+	}
+	fp := &FilePlanner{File: filepath.Join(run, "kismatic-cluster.yaml")}
+	recorded := Plan{}
+	recorded.Cluster.Name = "test"
+	recorded.Cluster.Version = "v1.10.0"
+	if err := fp.Write(&recorded); err != nil {
+		t.Fatalf("error writing plan: %v", err)
+	}
+	if err := recordRunStatus(run, RunStatusFailed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	plan, err := fp.Read()
+	if err != nil {
+		t.Fatalf("error reading plan: %v", err)
+	}
+	if _, err := FindInstallResumePoint(runsDir, plan); err == nil {
+		t.Errorf("expected an error when the run did not record its progress")
+	}
+
+	hash, err := planHash(plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	completed := []string{"_all.yaml", "_docker.yaml"}
+	if err := writeInstallCheckpoint(run, installCheckpoint{PlanHash: hash, Limit: []string{"worker1"}, CompletedPhases: completed}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	from, err := FindInstallResumePoint(runsDir, plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if from.RunDirectory != run || !reflect.DeepEqual(from.CompletedPhases, completed) || !reflect.DeepEqual(from.Limit, []string{"worker1"}) {
+		t.Errorf("unexpected resume point %+v", from)
+	}
+
+	// changes applied by a phase that did not complete
+	changed := *plan
+	changed.AddOns.Dashboard = &Dashboard{Disable: true}
+	if _, err := FindInstallResumePoint(runsDir, &changed); err != nil {
+		t.Errorf("unexpected error resuming with a change to a phase that did not complete: %v", err)
+	}
+	// changes applied by a phase that completed
+	changed = *plan
+	changed.Docker.Logs.Driver = "journald"
+	if _, err := FindInstallResumePoint(runsDir, &changed); err == nil {
+		t.Errorf("expected an error resuming with a change to a phase that completed")
+	}
+	// changes to the secrets cannot be compared
+	changed = *plan
+	changed.Cluster.AdminPassword = "changed"
+	if _, err := FindInstallResumePoint(runsDir, &changed); err == nil {
+		t.Errorf("expected an error resuming with a change to the secrets")
+	}
+
+	if err := recordRunStatus(run, RunStatusSucceeded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := FindInstallResumePoint(runsDir, plan); err == nil {
+		t.Errorf("expected an error when the last installation succeeded")
+	}
+}
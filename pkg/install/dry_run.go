@@ -0,0 +1,122 @@
+package install
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/util"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const dryRunTaskFile = "task.yaml"
+
+// dryRunTask records what would run for a task during a dry run
+type dryRunTask struct {
+	// Name of the task, such as "apply" or "reset"
+	Name string `yaml:"name"`
+	// The playbook that would run
+	Playbook string `yaml:"playbook"`
+	// The hosts the playbook would be limited to. Empty when it would run on all nodes.
+	Limit []string `yaml:"limit,omitempty"`
+	// The services that would be forced to restart
+	RestartServices []string `yaml:"restart_services"`
+	// The playbooks included by the playbook that would run, when the
+	// installation is checkpointed
+	Phases []string `yaml:"phases,omitempty"`
+	// The ansible-playbook command that would run from the task directory.
+	// The cluster catalog it refers to is redacted, so its secrets must be
+	// set before it is run.
+	Command string `yaml:"command"`
+	// The environment the command would run with
+	Env []string `yaml:"env"`
+}
+
+// dryRun renders what would run for the task into a directory of the dry run
+// directory, along with the inventory and the cluster catalog, and prints
+// a summary.
+func (ae *ansibleExecutor) dryRun(t task) error {
+	if ae.dryRunDirectory == "" {
+		dir := filepath.Join(ae.options.DryRunDirectory, time.Now().Format(runDirectoryTimeFormat))
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			return fmt.Errorf("error creating dry run directory: %v", err)
+		}
+		ae.dryRunDirectory = dir
+	}
+	ae.dryRunTasks++
+	dir := filepath.Join(ae.dryRunDirectory, fmt.Sprintf("%02d-%s", ae.dryRunTasks, t.name))
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return fmt.Errorf("error creating dry run directory for %q: %v", t.name, err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "inventory.ini"), t.inventory.ToINI(), 0644); err != nil {
+		return fmt.Errorf("error writing inventory file: %v", err)
+	}
+	// the cluster catalog is recorded without its secrets
+	redacted := t.clusterCatalog.Redacted()
+	cc, err := redacted.ToYAML()
+	if err != nil {
+		return fmt.Errorf("error writing cluster catalog data to yaml: %v", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "clustercatalog.yaml"), cc, 0644); err != nil {
+		return fmt.Errorf("error writing cluster catalog file: %v", err)
+	}
+
+	rendered := dryRunTask{
+		Name:            t.name,
+		Playbook:        t.playbook,
+		Limit:           t.limit,
+		RestartServices: t.clusterCatalog.RestartedServices(),
+	}
+	for _, phase := range t.phases {
+		rendered.Phases = append(rendered.Phases, phase.Include)
+	}
+	// the command runs from the task directory, which has the inventory and
+	// the cluster catalog
+	ansibleDir, err := filepath.Abs(ae.ansibleDir)
+	if err != nil {
+		return fmt.Errorf("error determining the absolute path of %q: %v", ae.ansibleDir, err)
+	}
+	playbook := filepath.Join(ansibleDir, "playbooks", t.playbook)
+	if t.generatePlaybook {
+		if playbook, err = writeResumePlaybook(dir, ae.playbooksDir(), t.phases); err != nil {
+			return err
+		}
+	}
+	args := []string{filepath.Join(ansibleDir, "bin", "ansible-playbook"), "-i", "inventory.ini", "-s", playbook, "--extra-vars", "@clustercatalog.yaml"}
+	if len(t.limit) > 0 {
+		args = append(args, "--limit", strings.Join(t.limit, ","))
+	}
+	rendered.Command = strings.Join(args, " ")
+	if rendered.Env, err = ansible.Environment(ansibleDir); err != nil {
+		return err
+	}
+	b, err := yaml.Marshal(rendered)
+	if err != nil {
+		return fmt.Errorf("error marshalling dry run task: %v", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, dryRunTaskFile), b, 0644); err != nil {
+		return fmt.Errorf("error writing dry run task: %v", err)
+	}
+
+	hosts := "all nodes"
+	if len(t.limit) > 0 {
+		hosts = strings.Join(t.limit, ", ")
+	}
+	restart := "none"
+	if len(rendered.RestartServices) > 0 {
+		restart = strings.Join(rendered.RestartServices, ", ")
+	}
+	util.PrettyPrintSkipped(ae.stdout, "Dry run: playbook %q was not run, it was rendered in %q", t.playbook, dir)
+	fmt.Fprintf(ae.stdout, "  - Hosts: %s\n", hosts)
+	fmt.Fprintf(ae.stdout, "  - Restarted services: %s\n", restart)
+	if len(rendered.Phases) > 0 {
+		fmt.Fprintf(ae.stdout, "  - Phases: %s\n", strings.Join(rendered.Phases, ", "))
+	}
+	fmt.Fprintf(ae.stdout, "  - The cluster catalog was rendered without its secrets\n")
+	return nil
+}
@@ -0,0 +1,100 @@
+package install
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestExecuteDryRun(t *testing.T) {
+	dir := mustGetTempDir(t)
+	defer os.RemoveAll(dir)
+	out := &bytes.Buffer{}
+	e := ansibleExecutor{
+		options:    ExecutorOptions{DryRun: true, DryRunDirectory: dir, RunsDirectory: filepath.Join(dir, "runs")},
+		stdout:     out,
+		ansibleDir: "ansible",
+	}
+	cc := ansible.ClusterCatalog{AdminPassword: "secret"}
+	cc.EnableRestart()
+	inventory := ansible.Inventory{Roles: []ansible.Role{{Name: "worker", Nodes: []ansible.Node{{Host: "worker1", PublicIP: "10.0.0.1"}}}}}
+	tasks := []task{
+		{name: "preflight", playbook: "preflight.yaml", inventory: inventory},
+		{name: "apply", playbook: "kubernetes.yaml", inventory: inventory, clusterCatalog: cc, limit: []string{"worker1"}, phases: []playbookPhase{{Include: "_all.yaml"}}},
+		{name: "apply", playbook: resumePlaybook, inventory: inventory, phases: []playbookPhase{{Include: "_all.yaml"}}, generatePlaybook: true},
+	}
+	for _, task := range tasks {
+		if err := e.execute(context.Background(), task); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "runs")); !os.IsNotExist(err) {
+		t.Errorf("expected no run to be recorded during a dry run")
+	}
+	if filepath.Dir(e.DryRunDirectory()) != dir {
+		t.Errorf("expected the dry run to be rendered in a directory of %q, but got %q", dir, e.DryRunDirectory())
+	}
+	taskDir := filepath.Join(e.dryRunDirectory, "02-apply")
+	b, err := ioutil.ReadFile(filepath.Join(taskDir, dryRunTaskFile))
+	if err != nil {
+		t.Fatalf("error reading rendered task: %v", err)
+	}
+	rendered := dryRunTask{}
+	if err = yaml.Unmarshal(b, &rendered); err != nil {
+		t.Fatalf("error unmarshalling rendered task: %v", err)
+	}
+	expected := dryRunTask{
+		Name:            "apply",
+		Playbook:        "kubernetes.yaml",
+		Limit:           []string{"worker1"},
+		RestartServices: []string{"etcd", "apiserver", "controller-manager", "scheduler", "proxy", "kubelet", "calico-node", "docker"},
+		Phases:          []string{"_all.yaml"},
+	}
+	ansibleDir, _ := filepath.Abs("ansible")
+	expected.Command = fmt.Sprintf("%[1]s/bin/ansible-playbook -i inventory.ini -s %[1]s/playbooks/kubernetes.yaml --extra-vars @clustercatalog.yaml --limit worker1", ansibleDir)
+	expected.Env, _ = ansible.Environment(ansibleDir)
+	if !reflect.DeepEqual(rendered, expected) {
+		t.Errorf("expected the rendered task\n%+v\nbut got\n%+v", expected, rendered)
+	}
+	b, err = ioutil.ReadFile(filepath.Join(taskDir, "inventory.ini"))
+	if err != nil || !strings.Contains(string(b), "worker1") {
+		t.Errorf("expected the inventory to be rendered, but got %q (%v)", string(b), err)
+	}
+	b, err = ioutil.ReadFile(filepath.Join(taskDir, "clustercatalog.yaml"))
+	if err != nil || strings.Contains(string(b), "secret") {
+		t.Errorf("expected the cluster catalog to be rendered without its secrets, but got %q (%v)", string(b), err)
+	}
+	if _, err = os.Stat(filepath.Join(e.dryRunDirectory, "01-preflight", dryRunTaskFile)); err != nil {
+		t.Errorf("expected the first task to be rendered: %v", err)
+	}
+	// the resume playbook is generated in the task directory
+	resumeDir := filepath.Join(e.dryRunDirectory, "03-apply")
+	b, err = ioutil.ReadFile(filepath.Join(resumeDir, dryRunTaskFile))
+	if err != nil {
+		t.Fatalf("error reading rendered task: %v", err)
+	}
+	rendered = dryRunTask{}
+	if err = yaml.Unmarshal(b, &rendered); err != nil {
+		t.Fatalf("error unmarshalling rendered task: %v", err)
+	}
+	if !strings.Contains(rendered.Command, " -s "+filepath.Join(resumeDir, resumePlaybook)+" ") {
+		t.Errorf("expected the command to run the resume playbook of the task directory, but got %q", rendered.Command)
+	}
+	b, err = ioutil.ReadFile(filepath.Join(resumeDir, resumePlaybook))
+	if err != nil || !strings.Contains(string(b), "include: "+filepath.Join(ansibleDir, "playbooks", "_all.yaml")) {
+		t.Errorf("expected the resume playbook to include the playbooks by their absolute path, but got %q (%v)", string(b), err)
+	}
+	if !strings.Contains(out.String(), "Restarted services: none") || !strings.Contains(out.String(), "Hosts: worker1") || !strings.Contains(out.String(), "without its secrets") {
+		t.Errorf("unexpected summary of the dry run:\n%s", out.String())
+	}
+}
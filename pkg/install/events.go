@@ -0,0 +1,177 @@
+package install
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/install/explain"
+)
+
+// EventsVersion is the version of the schema of the events written with the
+// json output format. Fields may be added to the events, but fields are not
+// removed or changed without increasing the version.
+const EventsVersion = 1
+
+// The types of the events written with the json output format
+const (
+	// Events of the Ansible playbooks run by kismatic
+	EventPlaybookStart     = "playbook_start"
+	EventPlaybookEnd       = "playbook_end"
+	EventPlayStart         = "play_start"
+	EventTaskStart         = "task_start"
+	EventHandlerTaskStart  = "handler_task_start"
+	EventRunnerOK          = "runner_ok"
+	EventRunnerFailed      = "runner_failed"
+	EventRunnerUnreachable = "runner_unreachable"
+	EventRunnerSkipped     = "runner_skipped"
+	EventRunnerItemOK      = "runner_item_ok"
+	EventRunnerItemFailed  = "runner_item_failed"
+	EventRunnerItemRetry   = "runner_item_retry"
+
+	// Events of kismatic
+	EventPhaseStarted     = "phase_started"
+	EventPhaseFinished    = "phase_finished"
+	EventValidationResult = "validation_result"
+)
+
+// Event is written as a line of JSON for every step of an operation, when
+// using the json output format. Fields that do not apply to the type of the
+// event are omitted.
+type Event struct {
+	Version int       `json:"version"`
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	// Phase of the operation, such as "preflight" or "apply"
+	Phase string `json:"phase,omitempty"`
+	// ID of the run that records the phase in the runs directory
+	RunID string `json:"run_id,omitempty"`
+	// Status of the phase when it finished, "succeeded" or "failed"
+	Status string `json:"status,omitempty"`
+	// Error that caused the phase to fail
+	Error string `json:"error,omitempty"`
+	// Name of the playbook, play and task the event belongs to
+	Playbook string `json:"playbook,omitempty"`
+	Play     string `json:"play,omitempty"`
+	Task     string `json:"task,omitempty"`
+	// Host of the node the task ran on
+	Host string `json:"host,omitempty"`
+	// Whether the task ignores its failures
+	IgnoreErrors bool `json:"ignore_errors,omitempty"`
+	// Result of the task on the node
+	Result *EventResult `json:"result,omitempty"`
+	// How to remediate the failure of the task, when it is a known failure
+	Remediation string `json:"remediation,omitempty"`
+	// Result of a validation
+	Valid    *bool             `json:"valid,omitempty"`
+	Errors   []ValidationError `json:"errors,omitempty"`
+	Warnings []ValidationError `json:"warnings,omitempty"`
+}
+
+// EventResult is the result of a task on a node
+type EventResult struct {
+	Command  []string `json:"cmd,omitempty"`
+	Stdout   string   `json:"stdout,omitempty"`
+	Stderr   string   `json:"stderr,omitempty"`
+	Message  string   `json:"msg,omitempty"`
+	Item     string   `json:"item,omitempty"`
+	Attempts int      `json:"attempts,omitempty"`
+	Retries  int      `json:"retries,omitempty"`
+}
+
+// EventWriter writes events as lines of JSON
+type EventWriter struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewEventWriter returns a writer of events to out
+func NewEventWriter(out io.Writer) *EventWriter {
+	return &EventWriter{out: out}
+}
+
+// Write the event, setting its version and time
+func (w *EventWriter) Write(e Event) error {
+	e.Version = EventsVersion
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("error marshalling event: %v", err)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err = w.out.Write(append(b, '\n'))
+	return err
+}
+
+// jsonExplainer writes the events of the playbook with the json output format
+type jsonExplainer struct {
+	events   *EventWriter
+	playbook string
+	play     string
+	task     string
+}
+
+func (exp *jsonExplainer) ExplainEvent(e ansible.Event) {
+	out := Event{Playbook: exp.playbook, Play: exp.play, Task: exp.task}
+	var result *ansible.RunnerResultEvent
+	switch event := e.(type) {
+	case *ansible.PlaybookStartEvent:
+		exp.playbook = event.Name
+		out.Type = EventPlaybookStart
+		out.Playbook = event.Name
+	case *ansible.PlaybookEndEvent:
+		out.Type = EventPlaybookEnd
+		out.Play, out.Task = "", ""
+	case *ansible.PlayStartEvent:
+		exp.play, exp.task = event.Name, ""
+		out.Type = EventPlayStart
+		out.Play, out.Task = event.Name, ""
+	case *ansible.TaskStartEvent:
+		exp.task = event.Name
+		out.Type = EventTaskStart
+		out.Task = event.Name
+	case *ansible.HandlerTaskStartEvent:
+		exp.task = event.Name
+		out.Type = EventHandlerTaskStart
+		out.Task = event.Name
+	case *ansible.RunnerOKEvent:
+		out.Type, result = EventRunnerOK, &event.RunnerResultEvent
+	case *ansible.RunnerFailedEvent:
+		out.Type, result = EventRunnerFailed, &event.RunnerResultEvent
+	case *ansible.RunnerUnreachableEvent:
+		out.Type, result = EventRunnerUnreachable, &event.RunnerResultEvent
+	case *ansible.RunnerSkippedEvent:
+		out.Type, result = EventRunnerSkipped, &event.RunnerResultEvent
+	case *ansible.RunnerItemOKEvent:
+		out.Type, result = EventRunnerItemOK, &event.RunnerResultEvent
+	case *ansible.RunnerItemFailedEvent:
+		out.Type, result = EventRunnerItemFailed, &event.RunnerResultEvent
+	case *ansible.RunnerItemRetryEvent:
+		out.Type, result = EventRunnerItemRetry, &event.RunnerResultEvent
+	default:
+		return
+	}
+	if result != nil {
+		out.Host = result.Host
+		out.IgnoreErrors = result.IgnoreErrors
+		out.Result = &EventResult{
+			Command:  result.Result.Command,
+			Stdout:   result.Result.Stdout,
+			Stderr:   result.Result.Stderr,
+			Message:  result.Result.Message,
+			Item:     result.Result.Item,
+			Attempts: result.Result.Attempts,
+			Retries:  result.Result.MaxRetries,
+		}
+		if (out.Type == EventRunnerFailed || out.Type == EventRunnerItemFailed) && !result.IgnoreErrors {
+			out.Remediation = explain.Remediation(exp.task, result.Result)
+		}
+	}
+	exp.events.Write(out)
+}
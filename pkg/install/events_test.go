@@ -0,0 +1,203 @@
+package install
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/install/explain"
+)
+
+func readEvents(t *testing.T, out *bytes.Buffer) []Event {
+	events := []Event{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		e := Event{}
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("line %q is not an event: %v", line, err)
+		}
+		if e.Version != EventsVersion || e.Time.IsZero() {
+			t.Errorf("event %q does not have a version and time", line)
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestJSONExplainer(t *testing.T) {
+	out := &bytes.Buffer{}
+	exp := &jsonExplainer{events: NewEventWriter(out)}
+	failed := &ansible.RunnerFailedEvent{}
+	failed.Host = "worker1"
+	failed.IgnoreErrors = true
+	failed.Result.Message = "failed"
+	failed.Result.Stderr = "error"
+	unreachable := &ansible.RunnerUnreachableEvent{}
+	unreachable.Host = "worker2"
+	for _, e := range []ansible.Event{
+		&ansible.PlaybookStartEvent{},
+		&ansible.PlayStartEvent{},
+		&ansible.TaskStartEvent{},
+		failed,
+		unreachable,
+		&ansible.PlaybookEndEvent{},
+	} {
+		exp.ExplainEvent(e)
+	}
+	// names of the events are not exported
+	exp.play, exp.task = "play", "task"
+	exp.ExplainEvent(failed)
+
+	events := readEvents(t, out)
+	types := []string{}
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	expected := []string{EventPlaybookStart, EventPlayStart, EventTaskStart, EventRunnerFailed, EventRunnerUnreachable, EventPlaybookEnd, EventRunnerFailed}
+	if strings.Join(types, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected events %v, but got %v", expected, types)
+	}
+	if e := events[3]; e.Host != "worker1" || !e.IgnoreErrors || e.Result == nil || e.Result.Message != "failed" || e.Result.Stderr != "error" {
+		t.Errorf("unexpected failed event %+v", e)
+	}
+	if e := events[4]; e.Host != "worker2" || e.IgnoreErrors {
+		t.Errorf("unexpected unreachable event %+v", e)
+	}
+	if e := events[6]; e.Play != "play" || e.Task != "task" {
+		t.Errorf("expected the event to have the play and task it belongs to, but got %+v", e)
+	}
+}
+
+// waitErrRunner is a runner whose playbook fails once it started
+type waitErrRunner struct {
+	fakeRunner
+	err error
+}
+
+func (r *waitErrRunner) WaitPlaybook() error { return r.err }
+
+func TestExecuteWritesPhaseEvents(t *testing.T) {
+	tests := []struct {
+		err    error
+		status string
+	}{
+		{status: RunStatusSucceeded},
+		{err: errors.New("exit status 2"), status: RunStatusFailed},
+	}
+	for i, test := range tests {
+		runsDir := mustGetTempDir(t)
+		defer os.RemoveAll(runsDir)
+		out := &bytes.Buffer{}
+		e := ansibleExecutor{
+			options:             ExecutorOptions{RunsDirectory: runsDir},
+			stdout:              ioutil.Discard,
+			consoleOutputFormat: ansible.JSONLinesFormat,
+			events:              NewEventWriter(out),
+			runnerExplainerFactory: func(exp explain.AnsibleEventExplainer, _ io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+				events := make(chan ansible.Event, 1)
+				events <- &ansible.PlayStartEvent{}
+				close(events)
+				return &waitErrRunner{fakeRunner: fakeRunner{eventChan: events}, err: test.err}, &explain.AnsibleEventStreamExplainer{EventExplainer: exp}, nil
+			},
+		}
+		err := e.execute(context.Background(), task{name: "apply", explainer: e.defaultExplainer()})
+		if (err != nil) != (test.err != nil) {
+			t.Errorf("test %d: unexpected error %v", i, err)
+		}
+		events := readEvents(t, out)
+		if len(events) != 3 {
+			t.Fatalf("test %d: expected 3 events, but got %+v", i, events)
+		}
+		started, finished := events[0], events[2]
+		if started.Type != EventPhaseStarted || started.Phase != "apply" || !strings.HasPrefix(started.RunID, "apply/") {
+			t.Errorf("test %d: unexpected first event %+v", i, started)
+		}
+		if events[1].Type != EventPlayStart {
+			t.Errorf("test %d: expected the events of the playbook to be written before the phase finished, but got %+v", i, events[1])
+		}
+		if finished.Type != EventPhaseFinished || finished.RunID != started.RunID || finished.Status != test.status || (finished.Error != "") != (test.err != nil) {
+			t.Errorf("test %d: unexpected last event %+v", i, finished)
+		}
+	}
+}
+
+// cancellingRunner is a runner whose playbook is interrupted by cancelling the
+// context it was started with
+type cancellingRunner struct {
+	fakeRunner
+	cancel func()
+}
+
+func (r *cancellingRunner) WaitPlaybook() error {
+	r.cancel()
+	return errors.New("signal: interrupt")
+}
+
+func TestExecuteCancelled(t *testing.T) {
+	runsDir := mustGetTempDir(t)
+	defer os.RemoveAll(runsDir)
+	out := &bytes.Buffer{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	e := ansibleExecutor{
+		options:             ExecutorOptions{RunsDirectory: runsDir},
+		stdout:              ioutil.Discard,
+		consoleOutputFormat: ansible.JSONLinesFormat,
+		events:              NewEventWriter(out),
+		runnerExplainerFactory: func(exp explain.AnsibleEventExplainer, _ io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+			events := make(chan ansible.Event)
+			close(events)
+			return &cancellingRunner{fakeRunner: fakeRunner{eventChan: events}, cancel: cancel}, &explain.AnsibleEventStreamExplainer{EventExplainer: exp}, nil
+		},
+	}
+	err := e.execute(ctx, task{name: "apply", explainer: e.defaultExplainer()})
+	if !IsCancelled(err) {
+		t.Fatalf("expected a cancelled error, but got %v", err)
+	}
+	runDir := err.(CancelledError).RunDirectory
+	if status, err := RunStatus(runDir); err != nil || status != RunStatusCancelled {
+		t.Errorf("expected the run in %q to be %q, but got %q (%v)", runDir, RunStatusCancelled, status, err)
+	}
+	events := readEvents(t, out)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, but got %+v", events)
+	}
+	if finished := events[1]; finished.Type != EventPhaseFinished || finished.Status != RunStatusCancelled {
+		t.Errorf("unexpected last event %+v", finished)
+	}
+
+	// once cancelled, the next phases are not started
+	err = e.execute(ctx, task{name: "smoke-test", explainer: e.defaultExplainer()})
+	if !IsCancelled(err) || err.(CancelledError).RunDirectory != "" {
+		t.Errorf("expected the phase to be cancelled before it started, but got %v", err)
+	}
+}
+
+func TestJSONExplainerRemediation(t *testing.T) {
+	out := &bytes.Buffer{}
+	exp := &jsonExplainer{events: NewEventWriter(out), task: "run docker login"}
+	failed := &ansible.RunnerFailedEvent{}
+	failed.Host = "worker1"
+	failed.Result.Stderr = "x509: certificate signed by unknown authority"
+	exp.ExplainEvent(failed)
+	exp.task = "copy docker.service"
+	failed.Result.Stderr = "permission denied"
+	exp.ExplainEvent(failed)
+
+	events := readEvents(t, out)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, but got %+v", events)
+	}
+	if !strings.Contains(events[0].Remediation, "docker_registry.CA") {
+		t.Errorf("expected the known failure to be remediated, but got %+v", events[0])
+	}
+	if events[1].Remediation != "" {
+		t.Errorf("expected no remediation for an unknown failure, but got %+v", events[1])
+	}
+}
@@ -1,6 +1,7 @@
 package install
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/apprenda/kismatic/pkg/ansible"
 	"github.com/apprenda/kismatic/pkg/install/explain"
+	"github.com/apprenda/kismatic/pkg/ssh"
 	"github.com/apprenda/kismatic/pkg/tls"
 	"github.com/apprenda/kismatic/pkg/util"
 )
@@ -20,30 +22,38 @@ import (
 // The PreFlightExecutor will run pre-flight checks against the
 // environment defined in the plan file
 type PreFlightExecutor interface {
-	RunPreFlightCheck(plan *Plan, nodes ...string) error
-	RunNewNodePreFlightCheck(Plan, Node) error
-	RunUpgradePreFlightCheck(*Plan, ListableNode) error
+	RunPreFlightCheck(ctx context.Context, plan *Plan, nodes ...string) error
+	RunNewNodePreFlightCheck(context.Context, Plan, Node) error
+	RunUpgradePreFlightCheck(context.Context, *Plan, ListableNode) error
 }
 
-// The Executor will carry out the installation plan
+// The Executor will carry out the installation plan.
+// The playbook that is running when the context is cancelled is interrupted,
+// and a CancelledError is returned once it stopped.
 type Executor interface {
 	PreFlightExecutor
-	Install(plan *Plan, restartServices bool, nodes ...string) error
-	Reset(plan *Plan, nodes ...string) error
+	Install(ctx context.Context, plan *Plan, restartServices bool, nodes ...string) error
+	ResumeInstall(ctx context.Context, plan *Plan, from InstallResumePoint, restartServices bool) error
+	Reset(ctx context.Context, plan *Plan, nodes ...string) error
 	GenerateCertificates(p *Plan, useExistingCA bool) error
-	RunSmokeTest(*Plan) error
-	AddNode(plan *Plan, node Node, roles []string, restartServices bool) (*Plan, error)
-	RunPlay(name string, plan *Plan, restartServices bool, nodes ...string) error
-	AddVolume(*Plan, StorageVolume) error
-	DeleteVolume(*Plan, string) error
-	UpgradeNodes(plan Plan, nodesToUpgrade []ListableNode, onlineUpgrade bool, maxParallelWorkers int, restartServices bool) error
-	ValidateControlPlane(plan Plan) error
-	UpgradeClusterServices(plan Plan) error
+	RunSmokeTest(context.Context, *Plan) error
+	AddNode(ctx context.Context, plan *Plan, node Node, roles []string, restartServices bool) (*Plan, error)
+	RunPlay(ctx context.Context, name string, plan *Plan, restartServices bool, nodes ...string) error
+	AddVolume(context.Context, *Plan, StorageVolume) error
+	DeleteVolume(context.Context, *Plan, string) error
+	UpgradeNodes(ctx context.Context, plan Plan, nodesToUpgrade []ListableNode, onlineUpgrade bool, maxParallelWorkers int, restartServices bool) error
+	ValidateControlPlane(ctx context.Context, plan Plan) error
+	UpgradeClusterServices(ctx context.Context, plan Plan) error
+	// TaskTimings returns the time spent on the tasks that were run
+	TaskTimings() []TaskTiming
+	// DryRunDirectory returns the directory the playbooks were rendered in
+	// during a dry run, which is empty when none were rendered
+	DryRunDirectory() string
 }
 
 // DiagnosticsExecutor will run diagnostics on the nodes after an install
 type DiagnosticsExecutor interface {
-	DiagnoseNodes(plan Plan) error
+	DiagnoseNodes(ctx context.Context, plan Plan) error
 }
 
 // ExecutorOptions are used to configure the executor
@@ -59,8 +69,11 @@ type ExecutorOptions struct {
 	RunsDirectory string
 	// DiagnosticsDirecty is where the doDiagnostics information about the cluster will be dumped
 	DiagnosticsDirecty string
-	// DryRun determines if the executor should actually run the task
+	// DryRun determines if the executor should actually run the task.
+	// During a dry run, what would run is rendered in the DryRunDirectory.
 	DryRun bool
+	// DryRunDirectory is where what would run during a dry run is rendered
+	DryRunDirectory string
 }
 
 // NewExecutor returns an executor for performing installations according to the installation plan.
@@ -72,18 +85,20 @@ func NewExecutor(stdout io.Writer, errOut io.Writer, options ExecutorOptions) (E
 	if options.RunsDirectory == "" {
 		options.RunsDirectory = "./runs"
 	}
+	if options.DryRunDirectory == "" {
+		options.DryRunDirectory = "./dry-run"
+	}
 
 	// Setup the console output format
-	var outFormat ansible.OutputFormat
-	switch options.OutputFormat {
-	case "raw":
-		outFormat = ansible.RawFormat
-	case "simple":
-		outFormat = ansible.JSONLinesFormat
-	default:
-		return nil, fmt.Errorf("Output format %q is not supported", options.OutputFormat)
+	outFormat, stdout, events, err := consoleOutput(stdout, errOut, options.OutputFormat)
+	if err != nil {
+		return nil, err
 	}
 	certsDir := filepath.Join(options.GeneratedAssetsDirectory, "keys")
+	knownHostsFile, err := KnownHostsFile(options.GeneratedAssetsDirectory)
+	if err != nil {
+		return nil, err
+	}
 	pki := &LocalPKI{
 		CACsr: filepath.Join(ansibleDir, "playbooks", "tls", "ca-csr.json"),
 		GeneratedCertsDirectory: certsDir,
@@ -93,8 +108,10 @@ func NewExecutor(stdout io.Writer, errOut io.Writer, options ExecutorOptions) (E
 		options:             options,
 		stdout:              stdout,
 		consoleOutputFormat: outFormat,
+		events:              events,
 		ansibleDir:          ansibleDir,
 		certsDir:            certsDir,
+		knownHostsFile:      knownHostsFile,
 		pki:                 pki,
 	}, nil
 }
@@ -105,22 +122,28 @@ func NewPreFlightExecutor(stdout io.Writer, errOut io.Writer, options ExecutorOp
 	if options.RunsDirectory == "" {
 		options.RunsDirectory = "./runs"
 	}
+	if options.DryRunDirectory == "" {
+		options.DryRunDirectory = "./dry-run"
+	}
 	// Setup the console output format
-	var outFormat ansible.OutputFormat
-	switch options.OutputFormat {
-	case "raw":
-		outFormat = ansible.RawFormat
-	case "simple":
-		outFormat = ansible.JSONLinesFormat
-	default:
-		return nil, fmt.Errorf("Output format %q is not supported", options.OutputFormat)
+	outFormat, stdout, events, err := consoleOutput(stdout, errOut, options.OutputFormat)
+	if err != nil {
+		return nil, err
+	}
+	var knownHostsFile string
+	if options.GeneratedAssetsDirectory != "" {
+		if knownHostsFile, err = KnownHostsFile(options.GeneratedAssetsDirectory); err != nil {
+			return nil, err
+		}
 	}
 
 	return &ansibleExecutor{
 		options:             options,
 		stdout:              stdout,
 		consoleOutputFormat: outFormat,
+		events:              events,
 		ansibleDir:          ansibleDir,
+		knownHostsFile:      knownHostsFile,
 	}, nil
 }
 
@@ -130,6 +153,9 @@ func NewDiagnosticsExecutor(stdout io.Writer, errOut io.Writer, options Executor
 	if options.RunsDirectory == "" {
 		options.RunsDirectory = "./runs"
 	}
+	if options.DryRunDirectory == "" {
+		options.DryRunDirectory = "./dry-run"
+	}
 	if options.DiagnosticsDirecty == "" {
 		wd, err := os.Getwd()
 		if err != nil {
@@ -139,24 +165,44 @@ func NewDiagnosticsExecutor(stdout io.Writer, errOut io.Writer, options Executor
 	}
 
 	// Setup the console output format
-	var outFormat ansible.OutputFormat
-	switch options.OutputFormat {
-	case "raw":
-		outFormat = ansible.RawFormat
-	case "simple":
-		outFormat = ansible.JSONLinesFormat
-	default:
-		return nil, fmt.Errorf("Output format %q is not supported", options.OutputFormat)
+	outFormat, stdout, events, err := consoleOutput(stdout, errOut, options.OutputFormat)
+	if err != nil {
+		return nil, err
+	}
+	var knownHostsFile string
+	if options.GeneratedAssetsDirectory != "" {
+		if knownHostsFile, err = KnownHostsFile(options.GeneratedAssetsDirectory); err != nil {
+			return nil, err
+		}
 	}
 
 	return &ansibleExecutor{
 		options:             options,
 		stdout:              stdout,
 		consoleOutputFormat: outFormat,
+		events:              events,
 		ansibleDir:          ansibleDir,
+		knownHostsFile:      knownHostsFile,
 	}, nil
 }
 
+// consoleOutput returns the console output format of ansible, the writer of the
+// messages of the executor and the writer of events for the output format.
+// With the json output format, the events are the only output written to
+// stdout, and the messages are written to errOut.
+func consoleOutput(stdout, errOut io.Writer, format string) (ansible.OutputFormat, io.Writer, *EventWriter, error) {
+	switch format {
+	case "raw":
+		return ansible.RawFormat, stdout, nil, nil
+	case "simple":
+		return ansible.JSONLinesFormat, stdout, nil, nil
+	case "json":
+		return ansible.JSONLinesFormat, errOut, NewEventWriter(stdout), nil
+	default:
+		return "", nil, nil, fmt.Errorf("Output format %q is not supported", format)
+	}
+}
+
 type ansibleExecutor struct {
 	options             ExecutorOptions
 	stdout              io.Writer
@@ -164,6 +210,18 @@ type ansibleExecutor struct {
 	ansibleDir          string
 	certsDir            string
 	pki                 PKI
+	// the known_hosts file that the host keys are verified against.
+	// Host keys are not verified when empty.
+	knownHostsFile string
+	// the writer of events when using the json output format
+	events *EventWriter
+	// the directory of the dry run, and the number of tasks rendered in it
+	dryRunDirectory string
+	dryRunTasks     int
+	// the time spent on the tasks of the playbooks that were run
+	timings []TaskTiming
+	// the secrets that were resolved from references, by reference
+	secrets map[string]string
 
 	// Hook for testing purposes.. default implementation is used at runtime
 	runnerExplainerFactory func(explain.AnsibleEventExplainer, io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error)
@@ -184,57 +242,185 @@ type task struct {
 	plan Plan
 	// run the task on specific nodes
 	limit []string
+	// the phases of the playbook, which are checkpointed in the run
+	// directory when set
+	phases []playbookPhase
+	// the checkpoint that the completed phases are added to
+	checkpoint installCheckpoint
+	// the playbook is generated from the phases in the run directory, instead
+	// of being read from the playbooks directory
+	generatePlaybook bool
+}
+
+// targetNodes returns the hosts of the nodes the task runs on
+func (t task) targetNodes() []string {
+	if len(t.limit) > 0 {
+		return t.limit
+	}
+	nodes := []string{}
+	for _, n := range t.plan.GetUniqueNodes() {
+		nodes = append(nodes, n.Host)
+	}
+	return nodes
 }
 
 // execute will run the given task, and setup all what's needed for us to run ansible.
-func (ae *ansibleExecutor) execute(t task) error {
+func (ae *ansibleExecutor) execute(ctx context.Context, t task) (err error) {
 	if ae.options.DryRun {
-		return nil
+		return ae.dryRun(t)
+	}
+	if ctx.Err() != nil {
+		return CancelledError{Phase: t.name, Err: ctx.Err()}
+	}
+	if ae.knownHostsFile != "" {
+		if err := recordHostKeys(ctx, &t.plan, ae.knownHostsFile, t.limit...); err != nil {
+			return err
+		}
+	}
+	agentEnv, stopAgent, err := startSSHAgent(&t.plan, t.limit...)
+	if err != nil {
+		return fmt.Errorf("error starting ssh-agent: %v", err)
 	}
-	runDirectory, err := ae.createRunDirectory(t.name)
+	defer stopAgent()
+	runDirectory, err := createRunDirectory(ae.options.RunsDirectory, t.name, time.Now())
 	if err != nil {
 		return fmt.Errorf("error creating working directory for %q: %v", t.name, err)
 	}
-	// Save the plan file that was used for this execution
+	if ae.events != nil {
+		runID, _ := filepath.Rel(ae.options.RunsDirectory, runDirectory)
+		ae.events.Write(Event{Type: EventPhaseStarted, Phase: t.name, RunID: filepath.ToSlash(runID)})
+		defer func() { ae.writePhaseFinished(t.name, runID, err) }()
+	}
+	if err = recordRunStart(runDirectory, t.name, time.Now(), t.targetNodes()); err != nil {
+		return err
+	}
+	// Save the plan file that was used for this execution, without its secrets
 	fp := FilePlanner{
 		File: filepath.Join(runDirectory, "kismatic-cluster.yaml"),
 	}
-	if err = fp.Write(&t.plan); err != nil {
+	recordedPlan := redactedPlan(t.plan)
+	if err = fp.Write(&recordedPlan); err != nil {
 		return fmt.Errorf("error recording plan file to %s: %v", fp.File, err)
 	}
+	if err = recordKubernetesVersion(runDirectory, &t.plan); err != nil {
+		return err
+	}
 	ansibleLogFilename := filepath.Join(runDirectory, "ansible.log")
 	ansibleLogFile, err := os.Create(ansibleLogFilename)
 	if err != nil {
 		return fmt.Errorf("error creating ansible log file %q: %v", ansibleLogFilename, err)
 	}
-	runner, explainer, err := ae.ansibleRunnerWithExplainer(t.explainer, ansibleLogFile, runDirectory)
+	runner, explainer, err := ae.ansibleRunnerWithExplainer(t.explainer, ansibleLogFile, runDirectory, agentEnv)
 	if err != nil {
 		return err
 	}
 
+	if t.phases != nil {
+		if err = writeInstallCheckpoint(runDirectory, t.checkpoint); err != nil {
+			return err
+		}
+	}
+	if t.generatePlaybook {
+		if t.playbook, err = writeResumePlaybook(runDirectory, ae.playbooksDir(), t.phases); err != nil {
+			return err
+		}
+	}
+
 	// Start running ansible with the given playbook
 	var eventStream <-chan ansible.Event
 	if t.limit != nil && len(t.limit) != 0 {
-		eventStream, err = runner.StartPlaybookOnNode(t.playbook, t.inventory, t.clusterCatalog, t.limit...)
+		eventStream, err = runner.StartPlaybookOnNode(ctx, t.playbook, t.inventory, t.clusterCatalog, t.limit...)
 	} else {
-		eventStream, err = runner.StartPlaybook(t.playbook, t.inventory, t.clusterCatalog)
+		eventStream, err = runner.StartPlaybook(ctx, t.playbook, t.inventory, t.clusterCatalog)
 	}
 	if err != nil {
+		if ctx.Err() != nil {
+			return ae.cancelled(ctx, t.name, runDirectory)
+		}
+		if statusErr := recordRunStatus(runDirectory, RunStatusFailed); statusErr != nil {
+			fmt.Fprintf(ae.stdout, "%v\n", statusErr)
+		}
 		return fmt.Errorf("error running ansible playbook: %v", err)
 	}
+	var tracker *phaseTracker
+	if t.phases != nil {
+		tracker = newPhaseTracker(runDirectory, t.phases, t.checkpoint)
+		eventStream = tracker.track(eventStream)
+	}
+	timings := newTimingTracker(t.name)
+	eventStream = timings.track(eventStream)
 	// Ansible blocks until explainer starts reading from stream. Start
 	// explainer in a separate go routine
-	go explainer.Explain(eventStream)
+	explained := make(chan struct{})
+	go func() {
+		explainer.Explain(eventStream)
+		close(explained)
+	}()
 
 	// Wait until ansible exits
-	if err = runner.WaitPlaybook(); err != nil {
+	err = runner.WaitPlaybook()
+	// and until the event stream ends, so that the last events are explained
+	// before the end of the phase
+	<-explained
+	if tracker != nil {
+		if checkpointErr := tracker.wait(); checkpointErr != nil {
+			fmt.Fprintf(ae.stdout, "%v\n", checkpointErr)
+		}
+	}
+	ae.recordTimings(runDirectory, timings.wait())
+	if err != nil {
+		if ctx.Err() != nil {
+			return ae.cancelled(ctx, t.name, runDirectory)
+		}
+		if statusErr := recordRunStatus(runDirectory, RunStatusFailed); statusErr != nil {
+			fmt.Fprintf(ae.stdout, "%v\n", statusErr)
+		}
 		return fmt.Errorf("error running playbook: %v", err)
 	}
-	return nil
+	return recordRunStatus(runDirectory, RunStatusSucceeded)
+}
+
+// cancelled records the run as cancelled, and returns the error of the cancelled phase
+func (ae *ansibleExecutor) cancelled(ctx context.Context, phase string, runDirectory string) error {
+	if statusErr := recordRunStatus(runDirectory, RunStatusCancelled); statusErr != nil {
+		fmt.Fprintf(ae.stdout, "%v\n", statusErr)
+	}
+	util.PrettyPrintErr(ae.stdout, "The %s phase was cancelled", phase)
+	return CancelledError{Phase: phase, RunDirectory: runDirectory, Err: ctx.Err()}
+}
+
+// recordTimings writes the time spent on the tasks of the run in its directory
+func (ae *ansibleExecutor) recordTimings(runDirectory string, timings []TaskTiming) {
+	ae.timings = append(ae.timings, timings...)
+	if err := writeTaskTimings(runDirectory, timings); err != nil {
+		fmt.Fprintf(ae.stdout, "%v\n", err)
+	}
+}
+
+// TaskTimings returns the time spent on the tasks of the playbooks that were
+// run by the executor, in the order they ran
+func (ae *ansibleExecutor) TaskTimings() []TaskTiming {
+	return ae.timings
+}
+
+// DryRunDirectory returns the directory of the dry run, which is created under
+// the DryRunDirectory of the options when the first playbook is rendered
+func (ae *ansibleExecutor) DryRunDirectory() string {
+	return ae.dryRunDirectory
 }
 
 // GenerateCertificatesprivate generates keys and certificates for the cluster, if needed
 func (ae *ansibleExecutor) GenerateCertificates(p *Plan, useExistingCA bool) error {
+	if ae.events == nil {
+		return ae.generateCertificates(p, useExistingCA)
+	}
+	ae.events.Write(Event{Type: EventPhaseStarted, Phase: "certificates"})
+	err := ae.generateCertificates(p, useExistingCA)
+	ae.writePhaseFinished("certificates", "", err)
+	return err
+}
+
+func (ae *ansibleExecutor) generateCertificates(p *Plan, useExistingCA bool) error {
 	if err := os.MkdirAll(ae.certsDir, 0777); err != nil {
 		return fmt.Errorf("error creating directory %s for storing TLS assets: %v", ae.certsDir, err)
 	}
@@ -280,7 +466,7 @@ func (ae *ansibleExecutor) GenerateCertificates(p *Plan, useExistingCA bool) err
 }
 
 // Install the cluster according to the installation plan
-func (ae *ansibleExecutor) Install(p *Plan, restartServices bool, nodes ...string) error {
+func (ae *ansibleExecutor) Install(ctx context.Context, p *Plan, restartServices bool, nodes ...string) error {
 	// Build the ansible inventory
 	cc, err := ae.buildClusterCatalog(p)
 	if err != nil {
@@ -289,20 +475,62 @@ func (ae *ansibleExecutor) Install(p *Plan, restartServices bool, nodes ...strin
 	if restartServices {
 		cc.EnableRestart()
 	}
+	phases, err := readPlaybookPhases(ae.playbooksDir(), installPlaybook)
+	if err != nil {
+		return err
+	}
+	hash, err := planHash(p)
+	if err != nil {
+		return err
+	}
 	t := task{
 		name:           "apply",
-		playbook:       "kubernetes.yaml",
+		playbook:       installPlaybook,
 		plan:           *p,
-		inventory:      buildInventoryFromPlan(p),
+		inventory:      buildInventoryFromPlan(p, ae.knownHostsFile),
 		clusterCatalog: *cc,
 		explainer:      ae.defaultExplainer(),
 		limit:          nodes,
+		phases:         phases,
+		checkpoint:     installCheckpoint{PlanHash: hash, Limit: nodes},
 	}
 	util.PrintHeader(ae.stdout, "Installing Cluster", '=')
-	return ae.execute(t)
+	return ae.execute(ctx, t)
+}
+
+// ResumeInstall runs the phases of the installation that did not complete
+func (ae *ansibleExecutor) ResumeInstall(ctx context.Context, p *Plan, from InstallResumePoint, restartServices bool) error {
+	cc, err := ae.buildClusterCatalog(p)
+	if err != nil {
+		return err
+	}
+	if restartServices {
+		cc.EnableRestart()
+	}
+	phases, err := readPlaybookPhases(ae.playbooksDir(), installPlaybook)
+	if err != nil {
+		return err
+	}
+	remaining := resumePhases(phases, from.CompletedPhases)
+	completed := append([]string{}, from.CompletedPhases...)
+	t := task{
+		name:             "apply",
+		playbook:         resumePlaybook,
+		plan:             *p,
+		inventory:        buildInventoryFromPlan(p, ae.knownHostsFile),
+		clusterCatalog:   *cc,
+		explainer:        ae.defaultExplainer(),
+		limit:            from.Limit,
+		phases:           remaining,
+		checkpoint:       installCheckpoint{PlanHash: from.planHash, Limit: from.Limit, CompletedPhases: completed},
+		generatePlaybook: true,
+	}
+	util.PrintHeader(ae.stdout, "Resuming Cluster Installation", '=')
+	util.PrettyPrintOk(ae.stdout, "Skipping the %d phases completed by the installation in %q", len(from.CompletedPhases), from.RunDirectory)
+	return ae.execute(ctx, t)
 }
 
-func (ae *ansibleExecutor) Reset(p *Plan, nodes ...string) error {
+func (ae *ansibleExecutor) Reset(ctx context.Context, p *Plan, nodes ...string) error {
 	cc, err := ae.buildClusterCatalog(p)
 	if err != nil {
 		return err
@@ -312,15 +540,15 @@ func (ae *ansibleExecutor) Reset(p *Plan, nodes ...string) error {
 		playbook:       "reset.yaml",
 		explainer:      ae.defaultExplainer(),
 		plan:           *p,
-		inventory:      buildInventoryFromPlan(p),
+		inventory:      buildInventoryFromPlan(p, ae.knownHostsFile),
 		clusterCatalog: *cc,
 		limit:          nodes,
 	}
 	util.PrintHeader(ae.stdout, "Resetting Nodes in the Cluster", '=')
-	return ae.execute(t)
+	return ae.execute(ctx, t)
 }
 
-func (ae *ansibleExecutor) RunSmokeTest(p *Plan) error {
+func (ae *ansibleExecutor) RunSmokeTest(ctx context.Context, p *Plan) error {
 	cc, err := ae.buildClusterCatalog(p)
 	if err != nil {
 		return err
@@ -330,15 +558,15 @@ func (ae *ansibleExecutor) RunSmokeTest(p *Plan) error {
 		playbook:       "smoketest.yaml",
 		explainer:      ae.defaultExplainer(),
 		plan:           *p,
-		inventory:      buildInventoryFromPlan(p),
+		inventory:      buildInventoryFromPlan(p, ae.knownHostsFile),
 		clusterCatalog: *cc,
 	}
 	util.PrintHeader(ae.stdout, "Running Smoke Test", '=')
-	return ae.execute(t)
+	return ae.execute(ctx, t)
 }
 
 // RunPreflightCheck against the nodes defined in the plan
-func (ae *ansibleExecutor) RunPreFlightCheck(p *Plan, nodes ...string) error {
+func (ae *ansibleExecutor) RunPreFlightCheck(ctx context.Context, p *Plan, nodes ...string) error {
 	cc, err := ae.buildClusterCatalog(p)
 	if err != nil {
 		return err
@@ -346,17 +574,17 @@ func (ae *ansibleExecutor) RunPreFlightCheck(p *Plan, nodes ...string) error {
 	t := task{
 		name:           "preflight",
 		playbook:       "preflight.yaml",
-		inventory:      buildInventoryFromPlan(p),
+		inventory:      buildInventoryFromPlan(p, ae.knownHostsFile),
 		clusterCatalog: *cc,
 		explainer:      ae.preflightExplainer(),
 		plan:           *p,
 		limit:          nodes,
 	}
-	return ae.execute(t)
+	return ae.execute(ctx, t)
 }
 
 // RunNewNodePreFlightCheck runs the preflight checks against a new node
-func (ae *ansibleExecutor) RunNewNodePreFlightCheck(p Plan, node Node) error {
+func (ae *ansibleExecutor) RunNewNodePreFlightCheck(ctx context.Context, p Plan, node Node) error {
 	cc, err := ae.buildClusterCatalog(&p)
 	if err != nil {
 		return err
@@ -364,12 +592,12 @@ func (ae *ansibleExecutor) RunNewNodePreFlightCheck(p Plan, node Node) error {
 	t := task{
 		name:           "copy-inspector",
 		playbook:       "copy-inspector.yaml",
-		inventory:      buildInventoryFromPlan(&p),
+		inventory:      buildInventoryFromPlan(&p, ae.knownHostsFile),
 		clusterCatalog: *cc,
 		explainer:      ae.preflightExplainer(),
 		plan:           p,
 	}
-	if err := ae.execute(t); err != nil {
+	if err := ae.execute(ctx, t); err != nil {
 		return err
 	}
 
@@ -378,17 +606,17 @@ func (ae *ansibleExecutor) RunNewNodePreFlightCheck(p Plan, node Node) error {
 	t = task{
 		name:           "add-node-preflight",
 		playbook:       "preflight.yaml",
-		inventory:      buildInventoryFromPlan(&p),
+		inventory:      buildInventoryFromPlan(&p, ae.knownHostsFile),
 		clusterCatalog: *cc,
 		explainer:      ae.preflightExplainer(),
 		plan:           p,
 		limit:          []string{node.Host},
 	}
-	return ae.execute(t)
+	return ae.execute(ctx, t)
 }
 
-func (ae *ansibleExecutor) RunUpgradePreFlightCheck(p *Plan, node ListableNode) error {
-	inventory := buildInventoryFromPlan(p)
+func (ae *ansibleExecutor) RunUpgradePreFlightCheck(ctx context.Context, p *Plan, node ListableNode) error {
+	inventory := buildInventoryFromPlan(p, ae.knownHostsFile)
 	cc, err := ae.buildClusterCatalog(p)
 	if err != nil {
 		return err
@@ -396,12 +624,12 @@ func (ae *ansibleExecutor) RunUpgradePreFlightCheck(p *Plan, node ListableNode)
 	t := task{
 		name:           "copy-inspector",
 		playbook:       "copy-inspector.yaml",
-		inventory:      buildInventoryFromPlan(p),
+		inventory:      buildInventoryFromPlan(p, ae.knownHostsFile),
 		clusterCatalog: *cc,
 		explainer:      ae.preflightExplainer(),
 		plan:           *p,
 	}
-	if err := ae.execute(t); err != nil {
+	if err := ae.execute(ctx, t); err != nil {
 		return err
 	}
 	t = task{
@@ -413,10 +641,10 @@ func (ae *ansibleExecutor) RunUpgradePreFlightCheck(p *Plan, node ListableNode)
 		clusterCatalog: *cc,
 		limit:          []string{node.Node.Host},
 	}
-	return ae.execute(t)
+	return ae.execute(ctx, t)
 }
 
-func (ae *ansibleExecutor) RunPlay(playName string, p *Plan, restartServices bool, nodes ...string) error {
+func (ae *ansibleExecutor) RunPlay(ctx context.Context, playName string, p *Plan, restartServices bool, nodes ...string) error {
 	cc, err := ae.buildClusterCatalog(p)
 	if err != nil {
 		return err
@@ -427,16 +655,16 @@ func (ae *ansibleExecutor) RunPlay(playName string, p *Plan, restartServices boo
 	t := task{
 		name:           "step",
 		playbook:       playName,
-		inventory:      buildInventoryFromPlan(p),
+		inventory:      buildInventoryFromPlan(p, ae.knownHostsFile),
 		clusterCatalog: *cc,
 		explainer:      ae.defaultExplainer(),
 		plan:           *p,
 		limit:          nodes,
 	}
-	return ae.execute(t)
+	return ae.execute(ctx, t)
 }
 
-func (ae *ansibleExecutor) AddVolume(plan *Plan, volume StorageVolume) error {
+func (ae *ansibleExecutor) AddVolume(ctx context.Context, plan *Plan, volume StorageVolume) error {
 	// Validate that there are enough storage nodes to satisfy the request
 	nodesRequired := volume.ReplicateCount * volume.DistributionCount
 	if nodesRequired > len(plan.Storage.Nodes) {
@@ -479,15 +707,15 @@ func (ae *ansibleExecutor) AddVolume(plan *Plan, volume StorageVolume) error {
 		name:           "add-volume",
 		playbook:       "volume-add.yaml",
 		plan:           *plan,
-		inventory:      buildInventoryFromPlan(plan),
+		inventory:      buildInventoryFromPlan(plan, ae.knownHostsFile),
 		clusterCatalog: *cc,
 		explainer:      ae.defaultExplainer(),
 	}
 	util.PrintHeader(ae.stdout, "Add Persistent Storage Volume", '=')
-	return ae.execute(t)
+	return ae.execute(ctx, t)
 }
 
-func (ae *ansibleExecutor) DeleteVolume(plan *Plan, name string) error {
+func (ae *ansibleExecutor) DeleteVolume(ctx context.Context, plan *Plan, name string) error {
 	cc, err := ae.buildClusterCatalog(plan)
 	if err != nil {
 		return err
@@ -500,12 +728,12 @@ func (ae *ansibleExecutor) DeleteVolume(plan *Plan, name string) error {
 		name:           "delete-volume",
 		playbook:       "volume-delete.yaml",
 		plan:           *plan,
-		inventory:      buildInventoryFromPlan(plan),
+		inventory:      buildInventoryFromPlan(plan, ae.knownHostsFile),
 		clusterCatalog: *cc,
 		explainer:      ae.defaultExplainer(),
 	}
 	util.PrintHeader(ae.stdout, "Delete Persistent Storage Volume", '=')
-	return ae.execute(t)
+	return ae.execute(ctx, t)
 }
 
 // UpgradeNodes upgrades the nodes of the cluster in the following phases:
@@ -517,7 +745,7 @@ func (ae *ansibleExecutor) DeleteVolume(plan *Plan, name string) error {
 // which phase of the upgrade we are in. For example, when upgrading a node that is both an etcd and master,
 // the etcd components and the master components will be upgraded when we are in the upgrade etcd nodes
 // phase.
-func (ae *ansibleExecutor) UpgradeNodes(plan Plan, nodesToUpgrade []ListableNode, onlineUpgrade bool, maxParallelWorkers int, restartServices bool) error {
+func (ae *ansibleExecutor) UpgradeNodes(ctx context.Context, plan Plan, nodesToUpgrade []ListableNode, onlineUpgrade bool, maxParallelWorkers int, restartServices bool) error {
 	// Nodes can have multiple roles. For this reason, we need to keep track of which nodes
 	// have been upgraded to avoid re-upgrading them.
 	upgradedNodes := map[string]bool{}
@@ -526,8 +754,8 @@ func (ae *ansibleExecutor) UpgradeNodes(plan Plan, nodesToUpgrade []ListableNode
 		for _, role := range nodeToUpgrade.Roles {
 			if role == "etcd" {
 				node := nodeToUpgrade
-				if err := ae.upgradeNodes(plan, onlineUpgrade, restartServices, node); err != nil {
-					return fmt.Errorf("error upgrading node %q: %v", node.Node.Host, err)
+				if err := ae.upgradeNodes(ctx, plan, onlineUpgrade, restartServices, node); err != nil {
+					return taskError(err, "error upgrading node %q", node.Node.Host)
 				}
 				upgradedNodes[node.Node.IP] = true
 				break
@@ -543,8 +771,8 @@ func (ae *ansibleExecutor) UpgradeNodes(plan Plan, nodesToUpgrade []ListableNode
 		for _, role := range nodeToUpgrade.Roles {
 			if role == "master" {
 				node := nodeToUpgrade
-				if err := ae.upgradeNodes(plan, onlineUpgrade, restartServices, node); err != nil {
-					return fmt.Errorf("error upgrading node %q: %v", node.Node.Host, err)
+				if err := ae.upgradeNodes(ctx, plan, onlineUpgrade, restartServices, node); err != nil {
+					return taskError(err, "error upgrading node %q", node.Node.Host)
 				}
 				upgradedNodes[node.Node.IP] = true
 				break
@@ -564,8 +792,8 @@ func (ae *ansibleExecutor) UpgradeNodes(plan Plan, nodesToUpgrade []ListableNode
 				limitNodes = append(limitNodes, node)
 				// don't forget to run the remaining nodes if its < maxParallelWorkers
 				if len(limitNodes) == maxParallelWorkers || n == len(nodesToUpgrade)-1 {
-					if err := ae.upgradeNodes(plan, onlineUpgrade, restartServices, limitNodes...); err != nil {
-						return fmt.Errorf("error upgrading node %q: %v", node.Node.Host, err)
+					if err := ae.upgradeNodes(ctx, plan, onlineUpgrade, restartServices, limitNodes...); err != nil {
+						return taskError(err, "error upgrading node %q", node.Node.Host)
 					}
 					// empty the slice
 					limitNodes = limitNodes[:0]
@@ -578,8 +806,8 @@ func (ae *ansibleExecutor) UpgradeNodes(plan Plan, nodesToUpgrade []ListableNode
 	return nil
 }
 
-func (ae *ansibleExecutor) upgradeNodes(plan Plan, onlineUpgrade bool, restartServices bool, nodes ...ListableNode) error {
-	inventory := buildInventoryFromPlan(&plan)
+func (ae *ansibleExecutor) upgradeNodes(ctx context.Context, plan Plan, onlineUpgrade bool, restartServices bool, nodes ...ListableNode) error {
+	inventory := buildInventoryFromPlan(&plan, ae.knownHostsFile)
 	cc, err := ae.buildClusterCatalog(&plan)
 	if err != nil {
 		return err
@@ -609,11 +837,11 @@ func (ae *ansibleExecutor) upgradeNodes(plan Plan, onlineUpgrade bool, restartSe
 		util.PrintHeader(ae.stdout, "Upgrade Nodes:", '=')
 		util.PrintTable(ae.stdout, nodeRoles)
 	}
-	return ae.execute(t)
+	return ae.execute(ctx, t)
 }
 
-func (ae *ansibleExecutor) ValidateControlPlane(plan Plan) error {
-	inventory := buildInventoryFromPlan(&plan)
+func (ae *ansibleExecutor) ValidateControlPlane(ctx context.Context, plan Plan) error {
+	inventory := buildInventoryFromPlan(&plan, ae.knownHostsFile)
 	cc, err := ae.buildClusterCatalog(&plan)
 	if err != nil {
 		return err
@@ -626,11 +854,11 @@ func (ae *ansibleExecutor) ValidateControlPlane(plan Plan) error {
 		plan:           plan,
 		explainer:      ae.defaultExplainer(),
 	}
-	return ae.execute(t)
+	return ae.execute(ctx, t)
 }
 
-func (ae *ansibleExecutor) UpgradeClusterServices(plan Plan) error {
-	inventory := buildInventoryFromPlan(&plan)
+func (ae *ansibleExecutor) UpgradeClusterServices(ctx context.Context, plan Plan) error {
+	inventory := buildInventoryFromPlan(&plan, ae.knownHostsFile)
 	cc, err := ae.buildClusterCatalog(&plan)
 	if err != nil {
 		return err
@@ -643,11 +871,11 @@ func (ae *ansibleExecutor) UpgradeClusterServices(plan Plan) error {
 		plan:           plan,
 		explainer:      ae.defaultExplainer(),
 	}
-	return ae.execute(t)
+	return ae.execute(ctx, t)
 }
 
-func (ae *ansibleExecutor) DiagnoseNodes(plan Plan) error {
-	inventory := buildInventoryFromPlan(&plan)
+func (ae *ansibleExecutor) DiagnoseNodes(ctx context.Context, plan Plan) error {
+	inventory := buildInventoryFromPlan(&plan, ae.knownHostsFile)
 	cc, err := ae.buildClusterCatalog(&plan)
 	if err != nil {
 		return err
@@ -664,7 +892,27 @@ func (ae *ansibleExecutor) DiagnoseNodes(plan Plan) error {
 		plan:           plan,
 		explainer:      ae.defaultExplainer(),
 	}
-	return ae.execute(t)
+	return ae.execute(ctx, t)
+}
+
+// resolveSecret returns the secret that the value refers to. References are
+// resolved once per executor, so that their commands are not run for every playbook.
+func (ae *ansibleExecutor) resolveSecret(v string) (string, error) {
+	if !isSecretReference(v) {
+		return v, nil
+	}
+	if s, ok := ae.secrets[v]; ok {
+		return s, nil
+	}
+	s, err := resolveSecret(v)
+	if err != nil {
+		return "", err
+	}
+	if ae.secrets == nil {
+		ae.secrets = map[string]string{}
+	}
+	ae.secrets[v] = s
+	return s, nil
 }
 
 // creates the extra vars that are required for the installation playbook.
@@ -679,9 +927,15 @@ func (ae *ansibleExecutor) buildClusterCatalog(p *Plan) (*ansible.ClusterCatalog
 		return nil, fmt.Errorf("error getting DNS service IP: %v", err)
 	}
 
+	// secrets are only resolved in memory, right before they are needed
+	adminPassword, err := ae.resolveSecret(p.Cluster.AdminPassword)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving the cluster admin password: %v", err)
+	}
+
 	cc := ansible.ClusterCatalog{
 		ClusterName:                   p.Cluster.Name,
-		AdminPassword:                 p.Cluster.AdminPassword,
+		AdminPassword:                 adminPassword,
 		TLSDirectory:                  tlsDir,
 		ServicesCIDR:                  p.Cluster.Networking.ServiceCIDRBlock,
 		PodCIDR:                       p.Cluster.Networking.PodCIDRBlock,
@@ -702,9 +956,14 @@ func (ae *ansibleExecutor) buildClusterCatalog(p *Plan) (*ansible.ClusterCatalog
 	}
 
 	// set versions
+	release, ok := versionCatalog.Release(p.Cluster.Version)
+	if !ok {
+		return nil, fmt.Errorf("Kubernetes version %q is not supported, the supported versions are %v", p.Cluster.Version, versionCatalog.Minors())
+	}
 	cc.Versions.Kubernetes = p.Cluster.Version
-	cc.Versions.KubernetesYum = p.Cluster.Version[1:] + "-0"
-	cc.Versions.KubernetesDeb = p.Cluster.Version[1:] + "-00"
+	cc.Versions.KubernetesYum = release.YumVersion(p.Cluster.Version)
+	cc.Versions.KubernetesDeb = release.DebVersion(p.Cluster.Version)
+	cc.Versions.Images = release.Images
 
 	cc.NoProxy = strings.Join(p.AllAddresses(), ",")
 	if p.Cluster.Networking.NoProxy != "" {
@@ -731,7 +990,10 @@ func (ae *ansibleExecutor) buildClusterCatalog(p *Plan) (*ansible.ClusterCatalog
 		cc.DockerRegistryServer = p.DockerRegistry.Server
 		cc.DockerRegistryCAPath = p.DockerRegistry.CAPath
 		cc.DockerRegistryUsername = p.DockerRegistry.Username
-		cc.DockerRegistryPassword = p.DockerRegistry.Password
+		cc.DockerRegistryPassword, err = ae.resolveSecret(p.DockerRegistry.Password)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving the docker registry password: %v", err)
+		}
 	}
 
 	// Setup docker options
@@ -772,6 +1034,14 @@ func (ae *ansibleExecutor) buildClusterCatalog(p *Plan) (*ansible.ClusterCatalog
 
 	cc.CloudProvider = p.Cluster.CloudProvider.Provider
 	cc.CloudConfig = p.Cluster.CloudProvider.Config
+	if isSecretReference(p.Cluster.CloudProvider.Config) {
+		// the runner writes the configuration to a file of the run directory
+		cc.CloudConfig = ""
+		cc.CloudConfigContents, err = ae.resolveSecret(p.Cluster.CloudProvider.Config)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving the cloud provider configuration: %v", err)
+		}
+	}
 
 	// additional files
 	for _, n := range p.AdditionalFiles {
@@ -795,7 +1065,10 @@ func (ae *ansibleExecutor) buildClusterCatalog(p *Plan) (*ansible.ClusterCatalog
 		cc.CNI.Options.Calico.FelixInputMTU = p.AddOns.CNI.Options.Calico.FelixInputMTU
 		cc.CNI.Options.Calico.IPAutodetectionMethod = p.AddOns.CNI.Options.Calico.IPAutodetectionMethod
 		// Weave
-		cc.CNI.Options.Weave.Password = p.AddOns.CNI.Options.Weave.Password
+		cc.CNI.Options.Weave.Password, err = ae.resolveSecret(p.AddOns.CNI.Options.Weave.Password)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving the weave password: %v", err)
+		}
 		if cc.CNI.Provider == cniProviderContiv {
 			cc.InsecureNetworkingEtcd = true
 		}
@@ -871,16 +1144,24 @@ func (ae *ansibleExecutor) buildClusterCatalog(p *Plan) (*ansible.ClusterCatalog
 	return &cc, nil
 }
 
-func (ae *ansibleExecutor) createRunDirectory(runName string) (string, error) {
-	start := time.Now()
-	runDirectory := filepath.Join(ae.options.RunsDirectory, runName, start.Format("2006-01-02-15-04-05"))
-	if err := os.MkdirAll(runDirectory, 0777); err != nil {
-		return "", fmt.Errorf("error creating directory: %v", err)
+// writePhaseFinished writes the event of a phase that finished with the given error
+func (ae *ansibleExecutor) writePhaseFinished(phase, runID string, err error) {
+	e := Event{Type: EventPhaseFinished, Phase: phase, RunID: filepath.ToSlash(runID), Status: RunStatusSucceeded}
+	if err != nil {
+		e.Status = RunStatusFailed
+		if IsCancelled(err) {
+			e.Status = RunStatusCancelled
+		}
+		e.Error = err.Error()
 	}
-	return runDirectory, nil
+	ae.events.Write(e)
+}
+
+func (ae *ansibleExecutor) playbooksDir() string {
+	return filepath.Join(ae.ansibleDir, "playbooks")
 }
 
-func (ae *ansibleExecutor) ansibleRunnerWithExplainer(explainer explain.AnsibleEventExplainer, ansibleLog io.Writer, runDirectory string) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+func (ae *ansibleExecutor) ansibleRunnerWithExplainer(explainer explain.AnsibleEventExplainer, ansibleLog io.Writer, runDirectory string, env []string) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
 	if ae.runnerExplainerFactory != nil {
 		return ae.runnerExplainerFactory(explainer, ansibleLog)
 	}
@@ -895,7 +1176,7 @@ func (ae *ansibleExecutor) ansibleRunnerWithExplainer(explainer explain.AnsibleE
 	}
 
 	// Send stdout and stderr to ansibleOut
-	runner, err := ansible.NewRunner(ansibleOut, ansibleOut, ae.ansibleDir, runDirectory)
+	runner, err := ansible.NewRunner(ansibleOut, ansibleOut, ae.ansibleDir, runDirectory, env...)
 	if err != nil {
 		return nil, nil, fmt.Errorf("error creating ansible runner: %v", err)
 	}
@@ -908,6 +1189,9 @@ func (ae *ansibleExecutor) ansibleRunnerWithExplainer(explainer explain.AnsibleE
 }
 
 func (ae *ansibleExecutor) defaultExplainer() explain.AnsibleEventExplainer {
+	if ae.events != nil {
+		return &jsonExplainer{events: ae.events}
+	}
 	var out io.Writer
 	switch ae.consoleOutputFormat {
 	case ansible.JSONLinesFormat:
@@ -919,6 +1203,9 @@ func (ae *ansibleExecutor) defaultExplainer() explain.AnsibleEventExplainer {
 }
 
 func (ae *ansibleExecutor) preflightExplainer() explain.AnsibleEventExplainer {
+	if ae.events != nil {
+		return &jsonExplainer{events: ae.events}
+	}
 	var out io.Writer
 	switch ae.consoleOutputFormat {
 	case ansible.JSONLinesFormat:
@@ -929,29 +1216,29 @@ func (ae *ansibleExecutor) preflightExplainer() explain.AnsibleEventExplainer {
 	return explain.PreflightExplainer(ae.options.Verbose, out)
 }
 
-func buildInventoryFromPlan(p *Plan) ansible.Inventory {
+func buildInventoryFromPlan(p *Plan, knownHostsFile string) ansible.Inventory {
 	etcdNodes := []ansible.Node{}
 	for _, n := range p.Etcd.Nodes {
-		etcdNodes = append(etcdNodes, installNodeToAnsibleNode(&n, &p.Cluster.SSH))
+		etcdNodes = append(etcdNodes, installNodeToAnsibleNode(&n, p.NodeSSHConfig(n), knownHostsFile))
 	}
 	masterNodes := []ansible.Node{}
 	for _, n := range p.Master.Nodes {
-		masterNodes = append(masterNodes, installNodeToAnsibleNode(&n, &p.Cluster.SSH))
+		masterNodes = append(masterNodes, installNodeToAnsibleNode(&n, p.NodeSSHConfig(n), knownHostsFile))
 	}
 	workerNodes := []ansible.Node{}
 	for _, n := range p.Worker.Nodes {
-		workerNodes = append(workerNodes, installNodeToAnsibleNode(&n, &p.Cluster.SSH))
+		workerNodes = append(workerNodes, installNodeToAnsibleNode(&n, p.NodeSSHConfig(n), knownHostsFile))
 	}
 	ingressNodes := []ansible.Node{}
 	if p.Ingress.Nodes != nil {
 		for _, n := range p.Ingress.Nodes {
-			ingressNodes = append(ingressNodes, installNodeToAnsibleNode(&n, &p.Cluster.SSH))
+			ingressNodes = append(ingressNodes, installNodeToAnsibleNode(&n, p.NodeSSHConfig(n), knownHostsFile))
 		}
 	}
 	storageNodes := []ansible.Node{}
 	if p.Storage.Nodes != nil {
 		for _, n := range p.Storage.Nodes {
-			storageNodes = append(storageNodes, installNodeToAnsibleNode(&n, &p.Cluster.SSH))
+			storageNodes = append(storageNodes, installNodeToAnsibleNode(&n, p.NodeSSHConfig(n), knownHostsFile))
 		}
 	}
 
@@ -984,15 +1271,25 @@ func buildInventoryFromPlan(p *Plan) ansible.Inventory {
 }
 
 // Converts plan node to ansible node
-func installNodeToAnsibleNode(n *Node, s *SSHConfig) ansible.Node {
-	return ansible.Node{
-		Host:          n.Host,
-		PublicIP:      n.IP,
-		InternalIP:    n.InternalIP,
-		SSHPrivateKey: s.Key,
-		SSHUser:       s.User,
-		SSHPort:       s.Port,
-	}
+func installNodeToAnsibleNode(n *Node, s SSHConfig, knownHostsFile string) ansible.Node {
+	node := ansible.Node{
+		Host:       n.Host,
+		PublicIP:   n.IP,
+		InternalIP: n.InternalIP,
+		SSHUser:    s.User,
+		SSHPort:    s.Port,
+	}
+	// encrypted keys are served by the ssh-agent started for the run, so that
+	// ssh does not prompt for their passphrase
+	if s.Key != "" && !ssh.EncryptedPrivateKey(s.Key) {
+		node.SSHPrivateKey = s.Key
+	}
+	args := ssh.HostKeyCheckingArgs(knownHostsFile)
+	if b := s.sshBastion(); b != nil {
+		args = append(args, "-o", fmt.Sprintf("ProxyCommand=\"%s\"", b.ProxyCommand("ssh", knownHostsFile)))
+	}
+	node.SSHCommonArgs = strings.Join(args, " ")
+	return node
 }
 
 // Prepend each line of the incoming stream with a timestamp
@@ -0,0 +1,74 @@
+package explain
+
+import (
+	"io"
+	"regexp"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/util"
+)
+
+// knownFailure is the signature of a failure that is known to happen when
+// running the playbooks, with the steps that remediate it
+type knownFailure struct {
+	// matched against the name of the failing task. Any task matches when nil.
+	task *regexp.Regexp
+	// matched against the stdout, stderr and message of the result
+	output      *regexp.Regexp
+	remediation string
+}
+
+var knownFailures = []knownFailure{
+	{
+		task:        regexp.MustCompile(`(?i)package`),
+		output:      regexp.MustCompile(`(?i)yum lock|/var/run/yum\.pid`),
+		remediation: "Another process, such as yum-cron, is holding the yum lock on the node. Wait for it to finish, or stop it, and run kismatic again.",
+	},
+	{
+		task:        regexp.MustCompile(`(?i)package`),
+		output:      regexp.MustCompile(`(?i)could not get lock /var/lib/(dpkg|apt)|unable to lock the administration directory`),
+		remediation: "Another process, such as unattended-upgrades, is holding the apt lock on the node. Wait for it to finish, or stop it, and run kismatic again.",
+	},
+	{
+		// the nodes talk to the docker registry when they log in to it or pull images
+		task:        regexp.MustCompile(`(?i)docker|image|registry`),
+		output:      regexp.MustCompile(`x509: certificate signed by unknown authority`),
+		remediation: "The docker registry presented a certificate that the node does not trust. Set docker_registry.CA in the plan file to the CA that signed the certificate of the registry, and run kismatic again.",
+	},
+	{
+		output:      regexp.MustCompile(`(?i)address already in use`),
+		remediation: "A port that the cluster needs is used by another process on the node. Find the process with \"ss -tlnp\", stop it, and run kismatic again.",
+	},
+	{
+		task:        regexp.MustCompile(`(?i)etcd`),
+		output:      regexp.MustCompile(`(?i)cluster ID mismatch`),
+		remediation: "The etcd member has the data of another etcd cluster, such as one left by a previous installation. If that data is no longer needed, remove /var/lib/etcd_k8s and /var/lib/etcd_networking on the node, or run \"kismatic reset\", and install again.",
+	},
+}
+
+// Remediation returns how to remediate the failure of the task with the given
+// result, when it is a known failure. An empty string is returned otherwise.
+func Remediation(task string, result ansible.RunnerResult) string {
+	for _, f := range knownFailures {
+		if f.task != nil && !f.task.MatchString(task) {
+			continue
+		}
+		for _, s := range []string{result.Stdout, result.Stderr, result.Message} {
+			if f.output.MatchString(s) {
+				return f.remediation
+			}
+		}
+	}
+	return ""
+}
+
+// printRemediation prints how to remediate the failure of the task, when it is
+// a known failure that was not ignored
+func printRemediation(out io.Writer, task string, event ansible.RunnerResultEvent) {
+	if event.IgnoreErrors {
+		return
+	}
+	if r := Remediation(task, event.Result); r != "" {
+		util.PrintColor(out, util.Orange, "---- REMEDIATION ----\n%s\n---------------------\n", r)
+	}
+}
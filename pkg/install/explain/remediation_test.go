@@ -0,0 +1,102 @@
+package explain
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+)
+
+func TestRemediation(t *testing.T) {
+	tests := []struct {
+		task   string
+		result ansible.RunnerResult
+		// a part of the expected remediation, none is expected when empty
+		expected string
+	}{
+		{
+			task:     "install kubelet yum package",
+			result:   ansible.RunnerResult{Stderr: "Existing lock /var/run/yum.pid: another copy is running as pid 1234."},
+			expected: "yum lock",
+		},
+		{
+			task:     "install docker-ce deb package",
+			result:   ansible.RunnerResult{Message: "E: Could not get lock /var/lib/dpkg/lock - open (11: Resource temporarily unavailable)"},
+			expected: "apt lock",
+		},
+		{
+			task:     "run docker login",
+			result:   ansible.RunnerResult{Stderr: "Get https://registry:8443/v2/: x509: certificate signed by unknown authority"},
+			expected: "docker_registry.CA",
+		},
+		{
+			task:     "download contiv container images",
+			result:   ansible.RunnerResult{Stderr: "Error response from daemon: Get https://registry:8443/v2/: x509: certificate signed by unknown authority"},
+			expected: "docker_registry.CA",
+		},
+		{
+			task:     "start kubelet service",
+			result:   ansible.RunnerResult{Stdout: "listen tcp 0.0.0.0:10250: bind: address already in use"},
+			expected: "ss -tlnp",
+		},
+		{
+			task:     "verify etcd_k8s cluster health",
+			result:   ansible.RunnerResult{Stdout: "request cluster ID mismatch (got 5e5d2ad5 want cdf818194e3a8c32)"},
+			expected: "/var/lib/etcd_k8s",
+		},
+		// the signature includes the task
+		{
+			task:   "copy kubelet.service",
+			result: ansible.RunnerResult{Stderr: "another app is currently holding the yum lock"},
+		},
+		{
+			task:   "start kubelet service",
+			result: ansible.RunnerResult{Stdout: "cluster ID mismatch"},
+		},
+		{
+			task:   "install kubelet yum package",
+			result: ansible.RunnerResult{Message: "No package matching 'kubelet' found available"},
+		},
+		{
+			task:   "verify etcd_k8s is running",
+			result: ansible.RunnerResult{Stderr: "Get https://10.0.0.1:2379/health: x509: certificate signed by unknown authority"},
+		},
+		{
+			task:   "get the nodes with kubectl",
+			result: ansible.RunnerResult{Stderr: "Unable to connect to the server: x509: certificate signed by unknown authority"},
+		},
+	}
+	for i, test := range tests {
+		r := Remediation(test.task, test.result)
+		if test.expected == "" && r != "" {
+			t.Errorf("test %d: expected no remediation, but got %q", i, r)
+		}
+		if test.expected != "" && !strings.Contains(r, test.expected) {
+			t.Errorf("test %d: expected a remediation containing %q, but got %q", i, test.expected, r)
+		}
+	}
+}
+
+func TestVerboseExplainerPrintsRemediation(t *testing.T) {
+	out := &bytes.Buffer{}
+	exp := &verboseExplainer{out: out}
+	task := &ansible.TaskStartEvent{}
+	task.Name = "run docker login"
+	failed := &ansible.RunnerFailedEvent{}
+	failed.Host = "worker1"
+	failed.Result.Stderr = "x509: certificate signed by unknown authority"
+	exp.ExplainEvent(task)
+	exp.ExplainEvent(failed)
+	if !strings.Contains(out.String(), "REMEDIATION") {
+		t.Errorf("expected the remediation to be printed under the failing task, but got %q", out.String())
+	}
+
+	// failures that are ignored are not remediated
+	out.Reset()
+	failed.IgnoreErrors = true
+	exp.ExplainEvent(failed)
+	if strings.Contains(out.String(), "REMEDIATION") {
+		t.Errorf("expected no remediation for an ignored failure, but got %q", out.String())
+	}
+}
@@ -111,6 +111,7 @@ func (e *updatingExplainer) ExplainEvent(ansibleEvent ansible.Event) {
 		if event.Result.Stderr != "" || event.Result.Stdout != "" {
 			util.PrintColor(buf, util.Red, "---------------\n")
 		}
+		printRemediation(buf, e.currentTask, event.RunnerResultEvent)
 		fmt.Fprintf(e.out.Bypass(), buf.String())
 		e.failureOccurred = true
 	case *ansible.RunnerUnreachableEvent:
@@ -148,6 +149,7 @@ func (e *updatingExplainer) ExplainEvent(ansibleEvent ansible.Event) {
 		if event.Result.Stderr != "" || event.Result.Stdout != "" {
 			util.PrintColor(buf, util.Red, "---------------\n")
 		}
+		printRemediation(buf, e.currentTask, event.RunnerResultEvent)
 		fmt.Fprintf(e.out.Bypass(), buf.String())
 		e.failureOccurred = true
 
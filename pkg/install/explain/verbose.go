@@ -65,6 +65,7 @@ func (explainer *verboseExplainer) ExplainEvent(e ansible.Event) {
 		if event.Result.Stderr != "" || event.Result.Stdout != "" {
 			util.PrintColor(out, util.Red, "---------------\n")
 		}
+		printRemediation(out, explainer.currentTask, event.RunnerResultEvent)
 	case *ansible.RunnerUnreachableEvent:
 		// Host is unreachable
 		// Print newline before first task
@@ -133,6 +134,7 @@ func (explainer *verboseExplainer) ExplainEvent(e ansible.Event) {
 		if event.Result.Stderr != "" || event.Result.Stdout != "" {
 			util.PrintColor(out, util.Red, "---------------\n")
 		}
+		printRemediation(out, explainer.currentTask, event.RunnerResultEvent)
 
 	// Ignored events
 	case *ansible.RunnerItemRetryEvent:
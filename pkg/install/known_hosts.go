@@ -0,0 +1,115 @@
+package install
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/apprenda/kismatic/pkg/ssh"
+)
+
+// the maximum number of hosts that are scanned concurrently, which matches the ansible forks
+const maxConcurrentHostKeyScans = 50
+
+// KnownHostsFile returns the absolute path to the known_hosts file of the
+// generated assets directory, in which the host keys of the nodes are recorded
+func KnownHostsFile(generatedAssetsDir string) (string, error) {
+	file, err := filepath.Abs(filepath.Join(generatedAssetsDir, "known_hosts"))
+	if err != nil {
+		return "", fmt.Errorf("failed to determine absolute path to the known_hosts file: %v", err)
+	}
+	return file, nil
+}
+
+type hostKeyTarget struct {
+	host    string
+	port    int
+	bastion *ssh.Bastion
+}
+
+// recordHostKeys records the keys of the nodes, and of the bastions used to reach them,
+// that are not in the known_hosts file yet, so that Ansible can verify them.
+// Only the given hosts are scanned when any are provided. Nodes that cannot be reached
+// are skipped, as Ansible reports them as unreachable.
+func recordHostKeys(ctx context.Context, p *Plan, knownHostsFile string, hosts ...string) error {
+	targets := map[string]hostKeyTarget{}
+	for _, n := range p.GetUniqueNodes() {
+		if len(hosts) > 0 && !contains(n.Host, hosts) {
+			continue
+		}
+		s := p.NodeSSHConfig(n)
+		b := s.sshBastion()
+		targets[fmt.Sprintf("%s:%d", n.IP, s.Port)] = hostKeyTarget{host: n.IP, port: s.Port, bastion: b}
+		if b != nil {
+			targets[fmt.Sprintf("%s:%d", b.Host, b.Port)] = hostKeyTarget{host: b.Host, port: b.Port}
+		}
+	}
+
+	kh := ssh.NewKnownHosts(knownHostsFile)
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []string
+	)
+	sem := make(chan struct{}, maxConcurrentHostKeyScans)
+	for _, t := range targets {
+		wg.Add(1)
+		go func(t hostKeyTarget) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if err := recordHostKey(ctx, kh, t); err != nil {
+				mu.Lock()
+				errs = append(errs, err.Error())
+				mu.Unlock()
+			}
+		}(t)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("error recording host keys:\n%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+func recordHostKey(ctx context.Context, kh *ssh.KnownHosts, t hostKeyTarget) error {
+	known, err := kh.Lookup(t.host, t.port)
+	if err != nil {
+		return err
+	}
+	if len(known) > 0 {
+		return nil
+	}
+	key, err := ssh.ScanHostKeyContext(ctx, t.host, t.port, t.bastion)
+	if err != nil {
+		return nil
+	}
+	return kh.Check(t.host, t.port, key)
+}
+
+// RescanHostKey records the key that the node presents in the known_hosts file,
+// replacing the keys recorded for the node. It returns the fingerprints of the
+// replaced keys, and the fingerprint of the recorded key.
+func (con *SSHConnection) RescanHostKey(knownHostsFile string) ([]string, string, error) {
+	kh := ssh.NewKnownHosts(knownHostsFile)
+	known, err := kh.Lookup(con.Node.IP, con.SSHConfig.Port)
+	if err != nil {
+		return nil, "", err
+	}
+	key, err := ssh.ScanHostKey(con.Node.IP, con.SSHConfig.Port, con.SSHConfig.sshBastion())
+	if err != nil {
+		return nil, "", fmt.Errorf("error getting the host key of %q: %v", con.Node.Host, err)
+	}
+	if err = kh.Replace(con.Node.IP, con.SSHConfig.Port, key); err != nil {
+		return nil, "", err
+	}
+	replaced := []string{}
+	for _, k := range known {
+		replaced = append(replaced, ssh.Fingerprint(k))
+	}
+	return replaced, ssh.Fingerprint(key), nil
+}
@@ -0,0 +1,210 @@
+package install
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// the network of the docker0 bridge, unless configured otherwise
+	defaultDockerBridgeCIDR = "172.17.0.0/16"
+	// the size of the blocks handed out to each node by the calico IPAM
+	calicoIPAMBlockSize = 26
+	// the size of the CIDRs allocated to each node by the controller manager,
+	// unless overridden with the node-cidr-mask-size option
+	defaultNodeCIDRMaskSize = 24
+)
+
+// networkTopology validates that the networks used by the cluster do not
+// overlap with each other, nor with the networks that the nodes are on.
+// It assumes that the pod and service CIDR blocks have been validated.
+type networkTopology struct {
+	plan *Plan
+}
+
+func (nt *networkTopology) validate() (bool, []error) {
+	v := newValidator()
+	p := nt.plan
+	_, podNet, err := net.ParseCIDR(p.Cluster.Networking.PodCIDRBlock)
+	if err != nil {
+		return v.valid()
+	}
+	_, serviceNet, err := net.ParseCIDR(p.Cluster.Networking.ServiceCIDRBlock)
+	if err != nil {
+		return v.valid()
+	}
+
+	if cidrsOverlap(podNet, serviceNet) {
+		v.addError(fieldError("cluster.networking.service_cidr_block", ValidationCodeInvalid, "Service CIDR block %q overlaps with the Pod CIDR block %q", serviceNet, podNet))
+	}
+
+	// The docker bridge is created on every node
+	_, bridgeNet, _ := net.ParseCIDR(defaultDockerBridgeCIDR)
+	for _, n := range []struct {
+		field string
+		name  string
+		ipnet *net.IPNet
+	}{{"cluster.networking.pod_cidr_block", "Pod", podNet}, {"cluster.networking.service_cidr_block", "Service", serviceNet}} {
+		if !cidrsOverlap(n.ipnet, bridgeNet) {
+			continue
+		}
+		// When docker is not installed by kismatic, the bridge network might have been configured otherwise
+		if p.Docker.Disable {
+			v.addError(fieldWarning(n.field, ValidationCodeInvalid, "%s CIDR block %q overlaps with the default docker bridge network %q. Ensure the docker bridge is configured with a different network on all nodes", n.name, n.ipnet, bridgeNet))
+			continue
+		}
+		v.addError(fieldError(n.field, ValidationCodeInvalid, "%s CIDR block %q overlaps with the docker bridge network %q", n.name, n.ipnet, bridgeNet))
+	}
+
+	// The nodes must be reachable without going through the pod or service networks.
+	// Nodes in multiple groups are only reported once.
+	nl := p.allNodesList()
+	seen := map[string]bool{}
+	for i, node := range nl.Nodes {
+		for _, addr := range []struct {
+			field string
+			ip    string
+		}{{"ip", node.IP}, {"internalip", node.InternalIP}} {
+			ip := net.ParseIP(addr.ip)
+			if ip == nil || seen[addr.ip] {
+				continue
+			}
+			seen[addr.ip] = true
+			if podNet.Contains(ip) {
+				v.addError(fieldError(nl.field(i, addr.field), ValidationCodeInvalid, "Node %q: IP %q is within the Pod CIDR block %q", node.Host, addr.ip, podNet))
+			}
+			if serviceNet.Contains(ip) {
+				v.addError(fieldError(nl.field(i, addr.field), ValidationCodeInvalid, "Node %q: IP %q is within the Service CIDR block %q", node.Host, addr.ip, serviceNet))
+			}
+		}
+	}
+
+	// Proxies that are referenced by host name cannot be verified without resolving them
+	for _, proxy := range []struct {
+		field string
+		url   string
+	}{{"cluster.networking.http_proxy", p.Cluster.Networking.HTTPProxy}, {"cluster.networking.https_proxy", p.Cluster.Networking.HTTPSProxy}} {
+		ip := proxyIP(proxy.url)
+		if ip == nil {
+			continue
+		}
+		if podNet.Contains(ip) {
+			v.addError(fieldError(proxy.field, ValidationCodeInvalid, "Proxy %q is within the Pod CIDR block %q", proxy.url, podNet))
+		}
+		if serviceNet.Contains(ip) {
+			v.addError(fieldError(proxy.field, ValidationCodeInvalid, "Proxy %q is within the Service CIDR block %q", proxy.url, serviceNet))
+		}
+	}
+
+	// The kubernetes and DNS services get the first IPs of the service network
+	for _, service := range []struct {
+		name  string
+		getIP func(*Plan) (string, error)
+	}{{"kubernetes", getKubernetesServiceIP}, {"DNS", getDNSServiceIP}} {
+		ip, err := service.getIP(p)
+		if err != nil || !usableIP(serviceNet, net.ParseIP(ip)) {
+			v.addError(fieldError("cluster.networking.service_cidr_block", ValidationCodeInvalid, "Service CIDR block %q is too small to allocate the %s service IP", serviceNet, service.name))
+			break
+		}
+	}
+
+	nt.validatePodCIDRCapacity(v, podNet)
+	return v.valid()
+}
+
+// validatePodCIDRCapacity validates that the pod CIDR can hand out a block to each node
+// that runs pods, using the block size of the CNI provider
+func (nt *networkTopology) validatePodCIDRCapacity(v *validator, podNet *net.IPNet) {
+	p := nt.plan
+	podPrefix, bits := podNet.Mask.Size()
+
+	nodeMaskSize := defaultNodeCIDRMaskSize
+	if size, ok := p.Cluster.KubeControllerManagerOptions.Overrides["node-cidr-mask-size"]; ok {
+		n, err := strconv.Atoi(size)
+		if err != nil {
+			v.addError(fieldError("cluster.kube_controller_manager.option_overrides.node-cidr-mask-size", ValidationCodeInvalid, "Invalid node CIDR mask size %q", size))
+			return
+		}
+		nodeMaskSize = n
+	}
+	// The controller manager does not start when the node CIDRs are bigger than the pod CIDR
+	if podPrefix > nodeMaskSize {
+		v.addError(fieldError("cluster.networking.pod_cidr_block", ValidationCodeInvalid, "Pod CIDR block %q is smaller than the /%d CIDR allocated to each node", podNet, nodeMaskSize))
+		return
+	}
+
+	blockSize := nodeMaskSize
+	provider := "the controller manager"
+	if p.AddOns.CNI != nil && !p.AddOns.CNI.Disable && p.AddOns.CNI.Provider == cniProviderCalico {
+		blockSize = calicoIPAMBlockSize
+		provider = "the calico CNI provider"
+	}
+	nodes := len(p.podNetworkNodes())
+	if blockSize < podPrefix || blockSize > bits {
+		v.addError(fieldError("cluster.networking.pod_cidr_block", ValidationCodeInvalid, "Pod CIDR block %q is smaller than the /%d block allocated to each node by %s", podNet, blockSize, provider))
+		return
+	}
+	// avoid overflowing when the pod CIDR is very large
+	if blockSize-podPrefix < 31 {
+		if blocks := 1 << uint(blockSize-podPrefix); blocks < nodes {
+			v.addError(fieldError("cluster.networking.pod_cidr_block", ValidationCodeInvalid, "Pod CIDR block %q can only be split into %d /%d blocks, but %s allocates a block to each of the %d nodes", podNet, blocks, blockSize, provider, nodes))
+		}
+	}
+}
+
+// podNetworkNodes returns the nodes that run pods, and thus require
+// addresses from the pod network
+func (p *Plan) podNetworkNodes() []Node {
+	seen := map[string]bool{}
+	nodes := []Node{}
+	for _, group := range [][]Node{p.Master.Nodes, p.Worker.Nodes, p.Ingress.Nodes, p.Storage.Nodes} {
+		for _, n := range group {
+			if seen[n.Host] {
+				continue
+			}
+			seen[n.Host] = true
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+func cidrsOverlap(a *net.IPNet, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// usableIP returns true if the IP is within the network, and is not
+// the network or broadcast address
+func usableIP(ipnet *net.IPNet, ip net.IP) bool {
+	if ip == nil || !ipnet.Contains(ip) {
+		return false
+	}
+	network := ipnet.IP
+	if len(ipnet.Mask) == net.IPv4len {
+		network = network.To4()
+		ip = ip.To4()
+	}
+	broadcast := make(net.IP, len(network))
+	for i := range network {
+		broadcast[i] = network[i] | ^ipnet.Mask[i]
+	}
+	return !ip.Equal(network) && !ip.Equal(broadcast)
+}
+
+// proxyIP returns the IP of the proxy, or nil if the proxy is not
+// set or is referenced by host name
+func proxyIP(proxy string) net.IP {
+	if proxy == "" {
+		return nil
+	}
+	host := proxy
+	if u, err := url.Parse(proxy); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return net.ParseIP(strings.Trim(host, "[]"))
+}
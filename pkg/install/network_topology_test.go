@@ -0,0 +1,124 @@
+package install
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNetworkTopologyValidation(t *testing.T) {
+	tests := []struct {
+		name          string
+		modify        func(p *Plan)
+		expectedField string
+		warning       bool
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name:          "pod and service CIDRs overlap",
+			modify:        func(p *Plan) { p.Cluster.Networking.ServiceCIDRBlock = "172.16.128.0/24" },
+			expectedField: "cluster.networking.service_cidr_block",
+		},
+		{
+			name:          "pod CIDR overlaps docker bridge",
+			modify:        func(p *Plan) { p.Cluster.Networking.PodCIDRBlock = "172.17.0.0/16" },
+			expectedField: "cluster.networking.pod_cidr_block",
+		},
+		{
+			name: "pod CIDR overlaps docker bridge, docker not installed by kismatic",
+			modify: func(p *Plan) {
+				p.Cluster.Networking.PodCIDRBlock = "172.17.0.0/16"
+				p.Docker.Disable = true
+			},
+			expectedField: "cluster.networking.pod_cidr_block",
+			warning:       true,
+		},
+		{
+			name:          "node IP within pod CIDR",
+			modify:        func(p *Plan) { p.Worker.Nodes[0].IP = "172.16.10.10" },
+			expectedField: "worker.nodes[0].ip",
+		},
+		{
+			name:          "node internal IP within service CIDR",
+			modify:        func(p *Plan) { p.Master.Nodes[0].InternalIP = "172.20.0.10" },
+			expectedField: "master.nodes[0].internalip",
+		},
+		{
+			name:          "proxy within service CIDR",
+			modify:        func(p *Plan) { p.Cluster.Networking.HTTPSProxy = "https://172.20.0.20:3128" },
+			expectedField: "cluster.networking.https_proxy",
+		},
+		{
+			name:          "service CIDR too small for DNS service",
+			modify:        func(p *Plan) { p.Cluster.Networking.ServiceCIDRBlock = "10.0.0.0/31" },
+			expectedField: "cluster.networking.service_cidr_block",
+		},
+		{
+			name:          "pod CIDR smaller than node CIDR",
+			modify:        func(p *Plan) { p.Cluster.Networking.PodCIDRBlock = "172.16.0.0/25" },
+			expectedField: "cluster.networking.pod_cidr_block",
+		},
+		{
+			name: "pod CIDR cannot hand out a calico block to each node",
+			modify: func(p *Plan) {
+				p.Cluster.Networking.PodCIDRBlock = "172.16.0.0/24"
+				for i := 0; i < 4; i++ {
+					p.Worker.Nodes = append(p.Worker.Nodes, Node{Host: fmt.Sprintf("worker%d", i+2), IP: fmt.Sprintf("192.168.205.%d", 20+i)})
+				}
+			},
+			expectedField: "cluster.networking.pod_cidr_block",
+		},
+		{
+			name: "pod CIDR can hand out a calico block to each node",
+			modify: func(p *Plan) {
+				p.Cluster.Networking.PodCIDRBlock = "172.16.0.0/24"
+				p.Worker.Nodes = append(p.Worker.Nodes, Node{Host: "worker02", IP: "192.168.205.20"})
+			},
+		},
+		{
+			name: "pod CIDR cannot hand out a node CIDR to each node",
+			modify: func(p *Plan) {
+				p.AddOns.CNI.Provider = cniProviderWeave
+				p.Cluster.Networking.PodCIDRBlock = "172.16.0.0/23"
+				p.Worker.Nodes = append(p.Worker.Nodes, Node{Host: "worker02", IP: "192.168.205.20"})
+			},
+			expectedField: "cluster.networking.pod_cidr_block",
+		},
+		{
+			name: "node CIDR mask size override",
+			modify: func(p *Plan) {
+				p.AddOns.CNI.Provider = cniProviderWeave
+				p.Cluster.Networking.PodCIDRBlock = "172.16.0.0/23"
+				p.Cluster.KubeControllerManagerOptions.Overrides = map[string]string{"node-cidr-mask-size": "26"}
+				p.Worker.Nodes = append(p.Worker.Nodes, Node{Host: "worker02", IP: "192.168.205.20"})
+			},
+		},
+	}
+	for _, test := range tests {
+		p := validPlan()
+		if test.modify != nil {
+			test.modify(&p)
+		}
+		nt := &networkTopology{plan: &p}
+		valid, errs := nt.validate()
+		if test.expectedField == "" {
+			if len(errs) != 0 {
+				t.Errorf("%s: expected no errors, but got %v", test.name, errs)
+			}
+			continue
+		}
+		if valid == !test.warning {
+			t.Errorf("%s: expected valid to be %v, but got %v", test.name, test.warning, valid)
+		}
+		found := false
+		for _, verr := range ValidationErrors(errs) {
+			if verr.Field == test.expectedField && (verr.Severity == ValidationSeverityWarning) == test.warning {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s: expected an error for field %q, but got %v", test.name, test.expectedField, errs)
+		}
+	}
+}
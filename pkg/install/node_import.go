@@ -0,0 +1,422 @@
+package install
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Supported formats of a node inventory
+const (
+	NodeInventoryFormatCSV       = "csv"
+	NodeInventoryFormatTerraform = "terraform"
+	NodeInventoryFormatAnsible   = "ansible"
+)
+
+// NodeInventoryFormats returns the supported node inventory formats
+func NodeInventoryFormats() []string {
+	return []string{NodeInventoryFormatCSV, NodeInventoryFormatTerraform, NodeInventoryFormatAnsible}
+}
+
+// NodeInventoryFormatFromFile returns the format of the node inventory
+// based on the extension of the file.
+func NodeInventoryFormatFromFile(file string) (string, error) {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".csv":
+		return NodeInventoryFormatCSV, nil
+	case ".json", ".tfstate":
+		return NodeInventoryFormatTerraform, nil
+	case ".ini", ".cfg", "":
+		return NodeInventoryFormatAnsible, nil
+	}
+	return "", fmt.Errorf("could not determine the format of %q. Options are %v", file, NodeInventoryFormats())
+}
+
+// NodeInventory is a list of nodes, grouped by role
+type NodeInventory map[string][]Node
+
+// Roles returns the roles found in the inventory, in the order they
+// appear in the plan file.
+func (inv NodeInventory) Roles() []string {
+	r := []string{}
+	for _, role := range roles() {
+		if _, ok := inv[role]; ok {
+			r = append(r, role)
+		}
+	}
+	return r
+}
+
+func (inv NodeInventory) add(role string, node Node) error {
+	if node.Host == "" {
+		return fmt.Errorf("the host of the %s node is empty", role)
+	}
+	if net.ParseIP(node.IP) == nil {
+		return fmt.Errorf("%q is not a valid IP address for host %q", node.IP, node.Host)
+	}
+	if node.InternalIP != "" && net.ParseIP(node.InternalIP) == nil {
+		return fmt.Errorf("%q is not a valid internal IP address for host %q", node.InternalIP, node.Host)
+	}
+	inv[role] = append(inv[role], node)
+	return nil
+}
+
+// ReadNodeInventory reads a node inventory in the given format.
+//
+// A CSV inventory must have a header row with the "roles", "host" and "ip"
+// columns, and optionally the "internal_ip", "labels" and "taints" columns.
+//
+// A Terraform inventory is the output of "terraform output -json", where each
+// role is an output whose value is a list of objects with the "host", "ip",
+// "internal_ip", "labels" and "taints" keys.
+//
+// An Ansible inventory is an INI file where each role is a group, and each
+// host sets the "ansible_host", "internal_ip", "labels" and "taints" variables.
+//
+// Labels are written as "key=value,key2=value2", and taints as
+// "key=value:Effect,key2:Effect".
+func ReadNodeInventory(r io.Reader, format string) (NodeInventory, error) {
+	switch format {
+	case NodeInventoryFormatCSV:
+		return readCSVNodeInventory(r)
+	case NodeInventoryFormatTerraform:
+		return readTerraformNodeInventory(r)
+	case NodeInventoryFormatAnsible:
+		return readAnsibleNodeInventory(r)
+	}
+	return nil, fmt.Errorf("%q is not a valid inventory format. Options are %v", format, NodeInventoryFormats())
+}
+
+// inventoryRole returns the plan role for a role or group name found in an inventory,
+// such as "masters" or "worker_nodes".
+func inventoryRole(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.TrimSuffix(name, "_nodes")
+	for _, r := range roles() {
+		if name == r || name == r+"s" {
+			return r, true
+		}
+	}
+	return "", false
+}
+
+func parseInventoryLabels(s string) (map[string]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	labels := map[string]string{}
+	for _, l := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(l), "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("label %q must be in the format key=value", l)
+		}
+		labels[kv[0]] = kv[1]
+	}
+	return labels, nil
+}
+
+func parseInventoryTaints(s string) ([]Taint, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	taints := []Taint{}
+	for _, t := range strings.Split(s, ",") {
+		t = strings.TrimSpace(t)
+		i := strings.LastIndex(t, ":")
+		if i <= 0 || i == len(t)-1 {
+			return nil, fmt.Errorf("taint %q must be in the format key=value:Effect", t)
+		}
+		taint := Taint{Effect: t[i+1:]}
+		kv := strings.SplitN(t[:i], "=", 2)
+		taint.Key = kv[0]
+		if len(kv) == 2 {
+			taint.Value = kv[1]
+		}
+		taints = append(taints, taint)
+	}
+	return taints, nil
+}
+
+func readCSVNodeInventory(r io.Reader) (NodeInventory, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error reading CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("the CSV file is empty")
+	}
+	columns := map[string]int{}
+	for i, c := range records[0] {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if c == "role" {
+			c = "roles"
+		}
+		columns[c] = i
+	}
+	for _, c := range []string{"roles", "host", "ip"} {
+		if _, ok := columns[c]; !ok {
+			return nil, fmt.Errorf("the CSV header is missing the %q column", c)
+		}
+	}
+	get := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	inv := NodeInventory{}
+	for i, record := range records[1:] {
+		line := i + 2
+		node := Node{
+			Host:       get(record, "host"),
+			IP:         get(record, "ip"),
+			InternalIP: get(record, "internal_ip"),
+		}
+		if node.Labels, err = parseInventoryLabels(get(record, "labels")); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if node.Taints, err = parseInventoryTaints(get(record, "taints")); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		// roles can be separated by spaces or semicolons
+		nodeRoles := strings.FieldsFunc(get(record, "roles"), func(r rune) bool { return r == ' ' || r == ';' || r == '|' })
+		if len(nodeRoles) == 0 {
+			return nil, fmt.Errorf("line %d: no roles defined for host %q", line, node.Host)
+		}
+		for _, name := range nodeRoles {
+			role, ok := inventoryRole(name)
+			if !ok {
+				return nil, fmt.Errorf("line %d: %q is not a valid role. Options are %v", line, name, roles())
+			}
+			if err = inv.add(role, node); err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+		}
+	}
+	return inv, nil
+}
+
+type terraformNode struct {
+	Host       string            `json:"host"`
+	IP         string            `json:"ip"`
+	InternalIP string            `json:"internal_ip"`
+	Labels     map[string]string `json:"labels"`
+	Taints     []Taint           `json:"taints"`
+}
+
+func readTerraformNodeInventory(r io.Reader) (NodeInventory, error) {
+	var outputs map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&outputs); err != nil {
+		return nil, fmt.Errorf("error reading terraform output: %v", err)
+	}
+	// sort the outputs to get a stable order of errors
+	names := []string{}
+	for n := range outputs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	inv := NodeInventory{}
+	for _, name := range names {
+		role, ok := inventoryRole(name)
+		if !ok {
+			continue
+		}
+		raw := outputs[name]
+		// "terraform output -json" wraps the value of each output
+		var wrapped struct {
+			Value json.RawMessage `json:"value"`
+		}
+		if err := json.Unmarshal(raw, &wrapped); err == nil && wrapped.Value != nil {
+			raw = wrapped.Value
+		}
+		var nodes []terraformNode
+		if err := json.Unmarshal(raw, &nodes); err != nil {
+			return nil, fmt.Errorf("output %q is not a list of nodes: %v", name, err)
+		}
+		inv[role] = []Node{}
+		for _, n := range nodes {
+			node := Node{
+				Host:       n.Host,
+				IP:         n.IP,
+				InternalIP: n.InternalIP,
+				Labels:     n.Labels,
+				Taints:     n.Taints,
+			}
+			if err := inv.add(role, node); err != nil {
+				return nil, fmt.Errorf("output %q: %v", name, err)
+			}
+		}
+	}
+	if len(inv) == 0 {
+		return nil, fmt.Errorf("no outputs found for any of the roles %v", roles())
+	}
+	return inv, nil
+}
+
+func readAnsibleNodeInventory(r io.Reader) (NodeInventory, error) {
+	inv := NodeInventory{}
+	var role string
+	var inRole bool
+	s := bufio.NewScanner(r)
+	line := 0
+	for s.Scan() {
+		line++
+		l := strings.TrimSpace(s.Text())
+		if l == "" || strings.HasPrefix(l, "#") || strings.HasPrefix(l, ";") {
+			continue
+		}
+		if strings.HasPrefix(l, "[") && strings.HasSuffix(l, "]") {
+			group := strings.TrimSuffix(strings.TrimPrefix(l, "["), "]")
+			// sections such as [group:vars] and [group:children] do not contain hosts
+			if strings.Contains(group, ":") {
+				inRole = false
+				continue
+			}
+			role, inRole = inventoryRole(group)
+			if inRole {
+				if _, ok := inv[role]; !ok {
+					inv[role] = []Node{}
+				}
+			}
+			continue
+		}
+		if !inRole {
+			continue
+		}
+		fields, err := splitAnsibleHostLine(l)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		node := Node{Host: fields[0]}
+		for _, f := range fields[1:] {
+			kv := strings.SplitN(f, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("line %d: host variable %q must be in the format key=value", line, f)
+			}
+			switch kv[0] {
+			case "ansible_host", "ansible_ssh_host":
+				node.IP = kv[1]
+			case "internal_ip", "private_ip":
+				node.InternalIP = kv[1]
+			case "labels":
+				if node.Labels, err = parseInventoryLabels(kv[1]); err != nil {
+					return nil, fmt.Errorf("line %d: %v", line, err)
+				}
+			case "taints":
+				if node.Taints, err = parseInventoryTaints(kv[1]); err != nil {
+					return nil, fmt.Errorf("line %d: %v", line, err)
+				}
+			}
+		}
+		// the host itself is used to connect when ansible_host is not set
+		if node.IP == "" && net.ParseIP(node.Host) != nil {
+			node.IP = node.Host
+		}
+		if err = inv.add(role, node); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("error reading ansible inventory: %v", err)
+	}
+	if len(inv) == 0 {
+		return nil, fmt.Errorf("no groups found for any of the roles %v", roles())
+	}
+	return inv, nil
+}
+
+// splitAnsibleHostLine splits a host line on whitespace, keeping quoted values together
+func splitAnsibleHostLine(l string) ([]string, error) {
+	fields := []string{}
+	var current []rune
+	var quote rune
+	for _, c := range l {
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			current = append(current, c)
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ' ' || c == '\t':
+			if len(current) > 0 {
+				fields = append(fields, string(current))
+				current = nil
+			}
+		default:
+			current = append(current, c)
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", l)
+	}
+	if len(current) > 0 {
+		fields = append(fields, string(current))
+	}
+	return fields, nil
+}
+
+// ImportNodes sets the nodes of the plan to the nodes found in the inventory,
+// and updates the expected count of each node group. Only the node groups
+// found in the inventory are updated. When merge is true, the nodes of the
+// inventory are added to the existing nodes, replacing the nodes that have
+// the same host.
+func (p *Plan) ImportNodes(inv NodeInventory, merge bool) {
+	for _, role := range inv.Roles() {
+		var nodes *[]Node
+		var count *int
+		switch role {
+		case "etcd":
+			nodes, count = &p.Etcd.Nodes, &p.Etcd.ExpectedCount
+		case "master":
+			nodes, count = &p.Master.Nodes, &p.Master.ExpectedCount
+		case "worker":
+			nodes, count = &p.Worker.Nodes, &p.Worker.ExpectedCount
+		case "ingress":
+			nodes, count = &p.Ingress.Nodes, &p.Ingress.ExpectedCount
+		case "storage":
+			nodes, count = &p.Storage.Nodes, &p.Storage.ExpectedCount
+		}
+		if merge {
+			*nodes = mergeNodes(*nodes, inv[role])
+		} else {
+			*nodes = inv[role]
+		}
+		*count = len(*nodes)
+	}
+}
+
+// mergeNodes adds the imported nodes to the existing nodes. Existing nodes with
+// the same host are replaced, keeping their position, kubelet options and SSH
+// overrides, which are not imported.
+func mergeNodes(existing []Node, imported []Node) []Node {
+	merged := append([]Node{}, existing...)
+	for _, n := range imported {
+		found := false
+		for i, e := range merged {
+			if e.Host == n.Host {
+				n.KubeletOptions = e.KubeletOptions
+				n.SSH = e.SSH
+				merged[i] = n
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, n)
+		}
+	}
+	return merged
+}
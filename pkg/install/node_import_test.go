@@ -0,0 +1,152 @@
+package install
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadNodeInventory(t *testing.T) {
+	expected := NodeInventory{
+		"etcd":   []Node{{Host: "master1", IP: "10.0.0.1", InternalIP: "192.168.0.1"}},
+		"master": []Node{{Host: "master1", IP: "10.0.0.1", InternalIP: "192.168.0.1"}},
+		"worker": []Node{
+			{
+				Host:       "worker1",
+				IP:         "10.0.0.2",
+				InternalIP: "192.168.0.2",
+				Labels:     map[string]string{"env": "prod", "tier": "web"},
+				Taints:     []Taint{{Key: "dedicated", Value: "web", Effect: "NoSchedule"}, {Key: "gpu", Effect: "NoExecute"}},
+			},
+			{Host: "worker2", IP: "10.0.0.3"},
+		},
+	}
+	tests := []struct {
+		format string
+		data   string
+	}{
+		{
+			format: NodeInventoryFormatCSV,
+			data: `# exported from the CMDB
+roles,host,ip,internal_ip,labels,taints
+etcd;master,master1,10.0.0.1,192.168.0.1,,
+workers,worker1,10.0.0.2,192.168.0.2,"env=prod,tier=web","dedicated=web:NoSchedule,gpu:NoExecute"
+worker,worker2,10.0.0.3,,,
+`,
+		},
+		{
+			format: NodeInventoryFormatTerraform,
+			data: `{
+  "etcd": {"sensitive": false, "type": "list", "value": [{"host": "master1", "ip": "10.0.0.1", "internal_ip": "192.168.0.1"}]},
+  "master_nodes": {"sensitive": false, "type": "list", "value": [{"host": "master1", "ip": "10.0.0.1", "internal_ip": "192.168.0.1"}]},
+  "worker": {"sensitive": false, "type": "list", "value": [
+    {"host": "worker1", "ip": "10.0.0.2", "internal_ip": "192.168.0.2", "labels": {"env": "prod", "tier": "web"},
+     "taints": [{"key": "dedicated", "value": "web", "effect": "NoSchedule"}, {"key": "gpu", "effect": "NoExecute"}]},
+    {"host": "worker2", "ip": "10.0.0.3"}
+  ]},
+  "vpc_id": {"sensitive": false, "type": "string", "value": "vpc-1234"}
+}`,
+		},
+		{
+			format: NodeInventoryFormatAnsible,
+			data: `[etcd]
+master1 ansible_host=10.0.0.1 internal_ip=192.168.0.1
+
+[masters]
+master1 ansible_host=10.0.0.1 internal_ip=192.168.0.1
+
+[worker]
+worker1 ansible_host=10.0.0.2 internal_ip=192.168.0.2 labels="env=prod,tier=web" taints='dedicated=web:NoSchedule,gpu:NoExecute'
+10.0.0.3 ansible_host=10.0.0.3
+
+[worker:vars]
+ansible_user=centos
+
+[bastion]
+bastion1 ansible_host=10.0.0.100
+`,
+		},
+	}
+	for _, test := range tests {
+		inv, err := ReadNodeInventory(strings.NewReader(test.data), test.format)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.format, err)
+			continue
+		}
+		// ansible inventories use the host name as is
+		if test.format == NodeInventoryFormatAnsible {
+			inv["worker"][1].Host = "worker2"
+		}
+		if !reflect.DeepEqual(inv, expected) {
+			t.Errorf("%s: expected %v, but got %v", test.format, expected, inv)
+		}
+	}
+}
+
+func TestReadNodeInventoryInvalid(t *testing.T) {
+	tests := []struct {
+		format string
+		data   string
+	}{
+		{format: NodeInventoryFormatCSV, data: "host,ip\nworker1,10.0.0.1\n"},
+		{format: NodeInventoryFormatCSV, data: "roles,host,ip\nfoo,worker1,10.0.0.1\n"},
+		{format: NodeInventoryFormatCSV, data: "roles,host,ip\nworker,worker1,notanip\n"},
+		{format: NodeInventoryFormatCSV, data: "roles,host,ip,labels\nworker,worker1,10.0.0.1,foo\n"},
+		{format: NodeInventoryFormatCSV, data: "roles,host,ip,taints\nworker,worker1,10.0.0.1,foo\n"},
+		{format: NodeInventoryFormatTerraform, data: `{"worker": {"value": "worker1"}}`},
+		{format: NodeInventoryFormatTerraform, data: `{"vpc_id": {"value": "vpc-1234"}}`},
+		{format: NodeInventoryFormatAnsible, data: "[worker]\nworker1\n"},
+		{format: NodeInventoryFormatAnsible, data: "[worker]\nworker1 ansible_host=10.0.0.1 labels=\"env=prod\n"},
+		{format: "foo", data: ""},
+	}
+	for _, test := range tests {
+		if _, err := ReadNodeInventory(strings.NewReader(test.data), test.format); err == nil {
+			t.Errorf("%s: expected an error reading %q, but didn't get one", test.format, test.data)
+		}
+	}
+}
+
+func TestImportNodes(t *testing.T) {
+	p := Plan{}
+	p.Cluster.Name = "test"
+	p.Etcd = NodeGroup{ExpectedCount: 1, Nodes: []Node{{Host: "etcd1", IP: "10.0.0.1"}}}
+	p.Worker = NodeGroup{
+		ExpectedCount: 2,
+		Nodes: []Node{
+			{Host: "worker1", IP: "10.0.0.2", KubeletOptions: KubeletOptions{Overrides: map[string]string{"max-pods": "50"}}},
+			{Host: "worker2", IP: "10.0.0.3", SSH: &SSHOverrides{User: "admin", Key: "/keys/worker2.pem"}},
+		},
+	}
+	inv := NodeInventory{
+		"worker": []Node{
+			{Host: "worker1", IP: "10.0.1.2", Labels: map[string]string{"env": "prod"}},
+			{Host: "worker2", IP: "10.0.1.3"},
+			{Host: "worker3", IP: "10.0.1.4"},
+		},
+	}
+
+	replaced := p
+	replaced.ImportNodes(inv, false)
+	if replaced.Worker.ExpectedCount != 3 || !reflect.DeepEqual(replaced.Worker.Nodes, inv["worker"]) {
+		t.Errorf("expected worker nodes to be replaced, but got %v", replaced.Worker)
+	}
+	if replaced.Etcd.ExpectedCount != 1 || len(replaced.Etcd.Nodes) != 1 {
+		t.Errorf("expected etcd nodes to be left untouched, but got %v", replaced.Etcd)
+	}
+	if replaced.Cluster.Name != "test" {
+		t.Errorf("expected the rest of the plan to be left untouched")
+	}
+
+	merged := p
+	merged.ImportNodes(inv, true)
+	if merged.Worker.ExpectedCount != 3 {
+		t.Errorf("expected 3 worker nodes after merging, but got %d", merged.Worker.ExpectedCount)
+	}
+	w := merged.Worker.Nodes
+	if len(w) != 3 || w[0].IP != "10.0.1.2" || w[0].KubeletOptions.Overrides["max-pods"] != "50" || w[1].Host != "worker2" || w[2].Host != "worker3" {
+		t.Errorf("unexpected worker nodes after merging: %v", w)
+	}
+	if len(w) == 3 && (w[1].IP != "10.0.1.3" || w[1].SSH == nil || w[1].SSH.User != "admin" || w[1].SSH.Key != "/keys/worker2.pem") {
+		t.Errorf("expected the SSH overrides of worker2 to be kept after merging, but got %+v", w[1])
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
@@ -31,6 +33,13 @@ type PlanTemplateOptions struct {
 	StorageNodes    int
 	AdditionalFiles int
 	AdminPassword   string
+	// CNIProvider defaults to calico when empty
+	CNIProvider string
+	// DNSProvider defaults to kubedns when empty
+	DNSProvider           string
+	DisableHeapster       bool
+	DisableDashboard      bool
+	DisablePackageManager bool
 }
 
 // PlanReadWriter is capable of reading/writing a Plan
@@ -48,13 +57,41 @@ type Planner interface {
 // FilePlanner is a file-based installation planner
 type FilePlanner struct {
 	File string
+	// Overlays are plan files that are deep-merged, in order, into the plan file
+	// when it is read.
+	Overlays []string
+	// Log is where warnings about the plan file are written to. Warnings are
+	// discarded when nil.
+	Log io.Writer
+	// keep track of the warnings that have been printed, so that reading
+	// the plan multiple times does not print them again
+	warned bool
 }
 
-// Read the plan from the file system
+// Read the plan from the file system. Plans written for an older schema
+// version are migrated to the current version in memory.
 func (fp *FilePlanner) Read() (*Plan, error) {
-	d, err := ioutil.ReadFile(fp.File)
+	p, err := fp.ReadWithoutDefaults()
 	if err != nil {
-		return nil, fmt.Errorf("could not read file: %v", err)
+		return nil, err
+	}
+	// set nil values to defaults
+	setDefaults(p)
+	return p, nil
+}
+
+// ReadWithoutDefaults reads the plan like Read, without setting the defaults of
+// the fields that are not set. It is used to update the plan file, so that the
+// defaults are not written to it.
+func (fp *FilePlanner) ReadWithoutDefaults() (*Plan, error) {
+	d, err := fp.readPlanFile()
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := planSchemaVersion(d)
+	if err != nil {
+		return nil, err
 	}
 
 	p := &Plan{}
@@ -62,12 +99,19 @@ func (fp *FilePlanner) Read() (*Plan, error) {
 		return nil, fmt.Errorf("failed to unmarshal plan: %v", err)
 	}
 
-	// read deprecated fields and set it the new version of the cluster file
-	readDeprecatedFields(p)
-
-	// set nil values to defaults
-	setDefaults(p)
-
+	// bring the plan up to the current schema version
+	if err = migratePlan(p, version); err != nil {
+		return nil, err
+	}
+	// plans written for the current schema version may still set the deprecated
+	// fields. They are read into their replacements, and reported when validated.
+	if version == PlanAPIVersion {
+		readDeprecatedFields(p)
+	}
+	if version != PlanAPIVersion && fp.Log != nil && !fp.warned {
+		util.PrettyPrintWarn(fp.Log, "Plan file %q was written for schema version %q. Use \"kismatic plan migrate\" to upgrade it to version %q", fp.File, version, PlanAPIVersion)
+		fp.warned = true
+	}
 	return p, nil
 }
 
@@ -109,12 +153,29 @@ func readDeprecatedFields(p *Plan) {
 		p.Docker.Storage.DirectLVMBlockDevice.ThinpoolAutoextendPercent = "20"
 		p.Docker.Storage.DirectLVM = nil
 	}
+
+	// read KET <v1.5.0 plan option
+	if p.AddOns.CNI == nil && p.Cluster.Networking.Type != "" {
+		p.AddOns.CNI = &CNI{}
+		p.AddOns.CNI.Provider = cniProviderCalico
+		p.AddOns.CNI.Options.Calico.Mode = p.Cluster.Networking.Type
+	}
+
+	// read fields from KET < v1.5.0
+	if p.AddOns.HeapsterMonitoring != nil {
+		if p.AddOns.HeapsterMonitoring.Options.HeapsterReplicas != 0 {
+			p.AddOns.HeapsterMonitoring.Options.Heapster.Replicas = p.AddOns.HeapsterMonitoring.Options.HeapsterReplicas
+		}
+		if p.AddOns.HeapsterMonitoring.Options.InfluxDBPVCName != "" {
+			p.AddOns.HeapsterMonitoring.Options.InfluxDB.PVCName = p.AddOns.HeapsterMonitoring.Options.InfluxDBPVCName
+		}
+	}
 }
 
 func setDefaults(p *Plan) {
-	// Set to either the latest version or the tested one if an error occurs
+	// Set to either the latest known version or the tested one
 	if p.Cluster.Version == "" {
-		p.Cluster.Version = kubernetesVersionString
+		p.Cluster.Version = kubernetesReleaseResolver.defaultVersion(p.Cluster.DisconnectedInstallation)
 	}
 
 	if p.Docker.Logs.Driver == "" {
@@ -156,10 +217,6 @@ func setDefaults(p *Plan) {
 		p.AddOns.CNI.Provider = cniProviderCalico
 		p.AddOns.CNI.Options.Calico.Mode = "overlay"
 		p.AddOns.CNI.Options.Calico.LogLevel = "info"
-		// read KET <v1.5.0 plan option
-		if p.Cluster.Networking.Type != "" {
-			p.AddOns.CNI.Options.Calico.Mode = p.Cluster.Networking.Type
-		}
 	}
 	if p.AddOns.CNI.Options.Calico.LogLevel == "" {
 		p.AddOns.CNI.Options.Calico.LogLevel = "info"
@@ -189,19 +246,12 @@ func setDefaults(p *Plan) {
 	if p.AddOns.HeapsterMonitoring.Options.Heapster.Replicas == 0 {
 		p.AddOns.HeapsterMonitoring.Options.Heapster.Replicas = 2
 	}
-	// read field from KET < v1.5.0
-	if p.AddOns.HeapsterMonitoring.Options.HeapsterReplicas != 0 {
-		p.AddOns.HeapsterMonitoring.Options.Heapster.Replicas = p.AddOns.HeapsterMonitoring.Options.HeapsterReplicas
-	}
 	if p.AddOns.HeapsterMonitoring.Options.Heapster.Sink == "" {
 		p.AddOns.HeapsterMonitoring.Options.Heapster.Sink = "influxdb:http://heapster-influxdb.kube-system.svc:8086"
 	}
 	if p.AddOns.HeapsterMonitoring.Options.Heapster.ServiceType == "" {
 		p.AddOns.HeapsterMonitoring.Options.Heapster.ServiceType = "ClusterIP"
 	}
-	if p.AddOns.HeapsterMonitoring.Options.InfluxDBPVCName != "" {
-		p.AddOns.HeapsterMonitoring.Options.InfluxDB.PVCName = p.AddOns.HeapsterMonitoring.Options.InfluxDBPVCName
-	}
 
 	if p.Cluster.Certificates.CAExpiry == "" {
 		p.Cluster.Certificates.CAExpiry = defaultCAExpiry
@@ -224,6 +274,43 @@ var yamlKeyRE = regexp.MustCompile(`[^a-zA-Z]*([a-z_\-\/A-Z.\d]+)[ ]*:`)
 
 // Write the plan to the file system
 func (fp *FilePlanner) Write(p *Plan) error {
+	// writing the merged plan would flatten the overlays into the plan file
+	if len(fp.Overlays) > 0 {
+		return errors.New("cannot write the plan file when overlays are in use")
+	}
+	// the plan is rendered before the plan file is replaced, so that the plan
+	// file is left untouched when the plan cannot be written
+	buf := &bytes.Buffer{}
+	if err := WritePlan(buf, p); err != nil {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if fi, err := os.Stat(fp.File); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	f, err := ioutil.TempFile(filepath.Dir(fp.File), "."+filepath.Base(fp.File))
+	if err != nil {
+		return fmt.Errorf("error making plan file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(buf.Bytes())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing plan file: %v", err)
+	}
+	if err = os.Chmod(f.Name(), mode); err != nil {
+		return fmt.Errorf("error writing plan file: %v", err)
+	}
+	if err = os.Rename(f.Name(), fp.File); err != nil {
+		return fmt.Errorf("error writing plan file: %v", err)
+	}
+	return nil
+}
+
+// WritePlan writes the plan as commented YAML to the given writer
+func WritePlan(w io.Writer, p *Plan) error {
 	// make a copy of the global comment map
 	oneTimeComments := map[string][]string{}
 	for k, v := range commentMap {
@@ -234,19 +321,14 @@ func (fp *FilePlanner) Write(p *Plan) error {
 		return fmt.Errorf("error marshalling plan to yaml: %v", marshalErr)
 	}
 
-	f, err := os.Create(fp.File)
-	if err != nil {
-		return fmt.Errorf("error making plan file: %v", err)
-	}
-	defer f.Close()
-
 	// the stack keeps track of the object we are in
 	// for example, when we are inside cluster.networking, looking at the key 'foo'
 	// the stack will have [cluster, networking, foo]
 	s := newStack()
 	scanner := bufio.NewScanner(bytes.NewReader(bytez))
 	prevIndent := -1
-	addNewLineBeforeComment := true
+	// don't start the file with an empty line
+	addNewLineBeforeComment := false
 	var etcdBlock bool
 	for scanner.Scan() {
 		text := scanner.Text()
@@ -269,7 +351,7 @@ func (fp *FilePlanner) Write(p *Plan) error {
 			// Add a new line if we are leaving a major indentation block
 			// (leaving a struct)..
 			if indent < prevIndent {
-				f.WriteString("\n")
+				io.WriteString(w, "\n")
 				// suppress the new line that would be added if this
 				// field has a comment
 				addNewLineBeforeComment = false
@@ -288,7 +370,7 @@ func (fp *FilePlanner) Write(p *Plan) error {
 
 			// Full key match (e.g. "cluster.networking.pod_cidr")
 			if thiscomment, ok := oneTimeComments[strings.Join(s.s, ".")]; ok {
-				if _, err := f.WriteString(getCommentedLine(text, thiscomment, addNewLineBeforeComment)); err != nil {
+				if _, err := io.WriteString(w, getCommentedLine(text, thiscomment, addNewLineBeforeComment)); err != nil {
 					return err
 				}
 				delete(oneTimeComments, matched[1])
@@ -297,7 +379,7 @@ func (fp *FilePlanner) Write(p *Plan) error {
 			}
 		}
 		// we don't want to comment this line... just print it out
-		if _, err := f.WriteString(text + "\n"); err != nil {
+		if _, err := io.WriteString(w, text+"\n"); err != nil {
 			return err
 		}
 		addNewLineBeforeComment = true
@@ -354,6 +436,7 @@ func WritePlanTemplate(planTemplateOpts PlanTemplateOptions, w PlanReadWriter) e
 // template options
 func buildPlanFromTemplateOptions(templateOpts PlanTemplateOptions) Plan {
 	p := Plan{}
+	p.APIVersion = PlanAPIVersion
 	p.Cluster.Name = "kubernetes"
 	p.Cluster.Version = kubernetesVersionString
 	p.Cluster.AdminPassword = templateOpts.AdminPassword
@@ -391,26 +474,34 @@ func buildPlanFromTemplateOptions(templateOpts PlanTemplateOptions) Plan {
 	// CNI
 	p.AddOns.CNI = &CNI{}
 	p.AddOns.CNI.Provider = cniProviderCalico
+	if templateOpts.CNIProvider != "" {
+		p.AddOns.CNI.Provider = templateOpts.CNIProvider
+	}
 	p.AddOns.CNI.Options.Calico.Mode = "overlay"
 	p.AddOns.CNI.Options.Calico.LogLevel = "info"
 	p.AddOns.CNI.Options.Calico.WorkloadMTU = 1500
 	p.AddOns.CNI.Options.Calico.FelixInputMTU = 1440
 	p.AddOns.CNI.Options.Calico.IPAutodetectionMethod = "first-found"
 	// DNS
-	p.AddOns.DNS.Provider = "kubedns"
+	p.AddOns.DNS.Provider = dnsProviderKubedns
+	if templateOpts.DNSProvider != "" {
+		p.AddOns.DNS.Provider = templateOpts.DNSProvider
+	}
 	p.AddOns.DNS.Options.Replicas = 2
 	// Heapster
 	p.AddOns.HeapsterMonitoring = &HeapsterMonitoring{}
+	p.AddOns.HeapsterMonitoring.Disable = templateOpts.DisableHeapster
 	p.AddOns.HeapsterMonitoring.Options.Heapster.Replicas = 2
 	p.AddOns.HeapsterMonitoring.Options.Heapster.ServiceType = "ClusterIP"
 	p.AddOns.HeapsterMonitoring.Options.Heapster.Sink = "influxdb:http://heapster-influxdb.kube-system.svc:8086"
 
 	// Package Manager
+	p.AddOns.PackageManager.Disable = templateOpts.DisablePackageManager
 	p.AddOns.PackageManager.Provider = "helm"
 	p.AddOns.PackageManager.Options.Helm.Namespace = "kube-system"
 
 	p.AddOns.Dashboard = &Dashboard{}
-	p.AddOns.Dashboard.Disable = false
+	p.AddOns.Dashboard.Disable = templateOpts.DisableDashboard
 	p.AddOns.Dashboard.Options.ServiceType = "ClusterIP"
 
 	// Generate entries for all node types
@@ -473,8 +564,9 @@ func getDNSServiceIP(p *Plan) (string, error) {
 // in the plan file. The value of the map contains the comment, split into
 // separate lines.
 var commentMap = map[string][]string{
+	"apiVersion":                                         []string{"Schema version of this plan file. Use \"kismatic plan migrate\" to upgrade older plan files."},
 	"cluster.admin_password":                             []string{"This password is used to login to the Kubernetes Dashboard and can also be", "used for administration without a security certificate."},
-	"cluster.version":                                    []string{fmt.Sprintf("Kubernetes cluster version (supported minor version %q).", kubernetesMinorVersionString)},
+	"cluster.version":                                    []string{fmt.Sprintf("Kubernetes cluster version (supported minor versions %s).", strings.Join(versionCatalog.Minors(), ", ")), "Run \"kismatic versions\" to list the supported versions."},
 	"cluster.disable_package_installation":               []string{"Set to true if the nodes have the required packages installed."},
 	"cluster.disconnected_installation":                  []string{"Set to true if you are performing a disconnected installation."},
 	"cluster.networking":                                 []string{"Networking configuration of your cluster."},
@@ -503,7 +595,7 @@ var commentMap = map[string][]string{
 	"docker_registry.server":                             []string{"IP or hostname and port for your registry."},
 	"docker_registry.CA":                                 []string{"Absolute path to the certificate authority that should be trusted when", "connecting to your registry."},
 	"docker_registry.username":                           []string{"Leave blank for unauthenticated access."},
-	"docker_registry.password":                           []string{"Leave blank for unauthenticated access."},
+	"docker_registry.password":                           []string{"Leave blank for unauthenticated access.", "Use env:VARIABLE, file:/path/to/file or exec:command to avoid storing the password in this file."},
 	"add_ons":                                            []string{"Add-ons are additional components that KET installs on the cluster."},
 	"add_ons.cni.provider":                               []string{"Selecting 'custom' will result in a CNI ready cluster, however it is up to", "you to configure a plugin after the install.", "Options: 'calico','weave','contiv','custom'."},
 	"add_ons.cni.options.calico.mode":                    []string{"Options: 'overlay','routed'."},
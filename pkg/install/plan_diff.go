@@ -0,0 +1,371 @@
+package install
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/apprenda/kismatic/pkg/util"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Types of changes between two plans
+const (
+	PlanChangeAdded   = "added"
+	PlanChangeRemoved = "removed"
+	PlanChangeChanged = "changed"
+)
+
+// PlanChange is a change made to a field of the plan
+type PlanChange struct {
+	// Type of the change
+	Type string
+	// Path of the field that changed, such as "cluster.kube_apiserver.option_overrides.v".
+	// Nodes are identified by their host, such as "worker.nodes[worker1]".
+	Path string
+	// Old value of the field. Empty if the field was added.
+	Old string
+	// New value of the field. Empty if the field was removed.
+	New string
+}
+
+// PlanAction is an action required to apply a set of changes to the cluster
+type PlanAction struct {
+	// Description of the action
+	Description string
+	// The command that applies the changes. Empty if the changes
+	// cannot be applied by kismatic.
+	Command string
+	// The playbook that applies the changes. Empty if the changes are not
+	// applied by a single playbook.
+	Play string
+	// The cluster components that are restarted when the changes are applied
+	Restarts []string
+	// The paths of the changes that require this action
+	Paths []string
+}
+
+// PlanDiff is the set of changes between two plans, and the actions
+// required to apply them to the cluster
+type PlanDiff struct {
+	Changes []PlanChange
+	Actions []PlanAction
+}
+
+// Empty returns true if there are no changes between the plans
+func (d PlanDiff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// planDiffRule maps the changes it matches to an action
+type planDiffRule struct {
+	match  func(c PlanChange) bool
+	action PlanAction
+}
+
+func pathPrefix(prefixes ...string) func(c PlanChange) bool {
+	return func(c PlanChange) bool {
+		for _, p := range prefixes {
+			if c.Path == p || strings.HasPrefix(c.Path, p+".") || strings.HasPrefix(c.Path, p+"[") {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// nodeField matches changes to the given fields of nodes that exist in both plans
+func nodeField(fields ...string) func(c PlanChange) bool {
+	return func(c PlanChange) bool {
+		_, _, field := splitNodePath(c.Path)
+		for _, f := range fields {
+			if field == f || strings.HasPrefix(field, f+".") || strings.HasPrefix(field, f+"[") {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func nodeAdded(roles ...string) func(c PlanChange) bool {
+	return func(c PlanChange) bool {
+		role, _, field := splitNodePath(c.Path)
+		return c.Type == PlanChangeAdded && role != "" && field == "" && util.Contains(role, roles)
+	}
+}
+
+func nodeRemoved(c PlanChange) bool {
+	role, _, field := splitNodePath(c.Path)
+	return c.Type == PlanChangeRemoved && role != "" && field == ""
+}
+
+func stepAction(description string, play string, restarts ...string) PlanAction {
+	command := "kismatic install step " + play
+	if len(restarts) > 0 {
+		command += " --restart-services"
+	}
+	return PlanAction{Description: description, Command: command, Play: play, Restarts: restarts}
+}
+
+// planDiffRules are evaluated in order, and each change is mapped to
+// the first rule that matches it. Changes that do not match any rule
+// require a full installation.
+var planDiffRules = []planDiffRule{
+	// fields that are only used by kismatic itself
+	{match: pathPrefix("apiVersion", "cluster.ssh", "etcd.expected_count", "master.expected_count", "worker.expected_count", "ingress.expected_count", "storage.expected_count")},
+	{match: pathPrefix("etcd.ssh", "master.ssh", "worker.ssh", "ingress.ssh", "storage.ssh")},
+	{match: nodeField("ssh")},
+	{
+		match:  nodeAdded("worker", "ingress", "storage"),
+		action: PlanAction{Description: "Add the new nodes to the cluster", Command: "kismatic install add-node"},
+	},
+	{
+		match:  nodeAdded("etcd", "master"),
+		action: PlanAction{Description: "Install the new etcd and master nodes", Command: "kismatic install apply"},
+	},
+	{
+		match:  nodeRemoved,
+		action: PlanAction{Description: "Removed nodes are not removed from the cluster by kismatic. Drain and delete them with kubectl, then reset them"},
+	},
+	{
+		match:  nodeField("labels", "taints"),
+		action: stepAction("Label and taint the nodes", "_label-nodes.yaml"),
+	},
+	{
+		match:  nodeField("kubelet"),
+		action: stepAction("Reconfigure the kubelet", "_kubelet.yaml", "kubelet"),
+	},
+	{
+		match:  pathPrefix("cluster.version"),
+		action: PlanAction{Description: "Upgrade the cluster", Command: "kismatic upgrade offline"},
+	},
+	{
+		match:  pathPrefix("cluster.networking.pod_cidr_block", "cluster.networking.service_cidr_block", "add_ons.cni.provider"),
+		action: PlanAction{Description: "The pod network, service network and CNI provider cannot be changed on an existing cluster. The cluster must be reinstalled"},
+	},
+	{
+		match:  pathPrefix("cluster.kube_apiserver"),
+		action: stepAction("Reconfigure the Kubernetes API server", "_kube-apiserver.yaml", "kube-apiserver"),
+	},
+	{
+		match:  pathPrefix("cluster.kube_controller_manager"),
+		action: stepAction("Reconfigure the Kubernetes controller manager", "_kube-controller-manager.yaml", "kube-controller-manager"),
+	},
+	{
+		match:  pathPrefix("cluster.kube_scheduler"),
+		action: stepAction("Reconfigure the Kubernetes scheduler", "_kube-scheduler.yaml", "kube-scheduler"),
+	},
+	{
+		match:  pathPrefix("cluster.kube_proxy"),
+		action: stepAction("Reconfigure the Kubernetes proxy", "_kube-proxy.yaml", "kube-proxy"),
+	},
+	{
+		match:  pathPrefix("cluster.kubelet"),
+		action: stepAction("Reconfigure the kubelet", "_kubelet.yaml", "kubelet"),
+	},
+	{
+		match:  pathPrefix("docker"),
+		action: stepAction("Reconfigure docker", "_docker.yaml", "docker"),
+	},
+	{
+		match:  pathPrefix("add_ons.dns"),
+		action: stepAction("Update the DNS add-on", "_cluster-dns.yaml"),
+	},
+	{
+		match:  pathPrefix("add_ons.heapster"),
+		action: stepAction("Update the heapster add-on", "_heapster.yaml"),
+	},
+	{
+		match:  pathPrefix("add_ons.metrics_server"),
+		action: stepAction("Update the metrics server add-on", "_metrics-server.yaml"),
+	},
+	{
+		match:  pathPrefix("add_ons.dashboard"),
+		action: stepAction("Update the dashboard add-on", "_kube-dashboard.yaml"),
+	},
+	{
+		match:  pathPrefix("add_ons.package_manager"),
+		action: stepAction("Update the package manager add-on", "_helm.yaml"),
+	},
+	{
+		match:  pathPrefix("add_ons.rescheduler"),
+		action: stepAction("Update the rescheduler add-on", "_rescheduler.yaml"),
+	},
+	{
+		match:  pathPrefix("additional_files"),
+		action: stepAction("Copy the additional files", "_additional-files.yaml"),
+	},
+	{
+		match:  pathPrefix("nfs"),
+		action: stepAction("Update the NFS volumes", "_nfs-volumes.yaml"),
+	},
+}
+
+// the action for changes that do not match any rule
+var planDiffInstallAction = PlanAction{
+	Description: "Reinstall the cluster with the changes, restarting the cluster components",
+	Command:     "kismatic install apply --restart-services",
+	Restarts:    []string{"etcd", "kube-apiserver", "kube-controller-manager", "kube-scheduler", "kube-proxy", "kubelet", "docker"},
+}
+
+// DiffPlans returns the changes made to the old plan to get the new plan, and
+// the actions required to apply them to the cluster. Secrets are not compared,
+// as they are redacted from the plans recorded on disk.
+func DiffPlans(oldPlan, newPlan Plan) (*PlanDiff, error) {
+	oldFields, err := flattenPlan(oldPlan)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := flattenPlan(newPlan)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+	for p := range oldFields {
+		paths = append(paths, p)
+	}
+	for p := range newFields {
+		if _, ok := oldFields[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	// nodes that were added or removed are reported once, instead of once per field
+	nodeChanges := map[string]PlanChange{}
+	for _, p := range paths {
+		role, host, field := splitNodePath(p)
+		if role == "" || field != "host" {
+			continue
+		}
+		node := strings.TrimSuffix(p, ".host")
+		if _, ok := oldFields[p]; !ok {
+			nodeChanges[node] = PlanChange{Type: PlanChangeAdded, Path: node, New: fmt.Sprintf("%s (%v)", host, newFields[node+".ip"])}
+		} else if _, ok := newFields[p]; !ok {
+			nodeChanges[node] = PlanChange{Type: PlanChangeRemoved, Path: node, Old: fmt.Sprintf("%s (%v)", host, oldFields[node+".ip"])}
+		}
+	}
+
+	diff := &PlanDiff{}
+	reported := map[string]bool{}
+	for _, p := range paths {
+		if role, host, _ := splitNodePath(p); role != "" {
+			node := fmt.Sprintf("%s.nodes[%s]", role, host)
+			if c, ok := nodeChanges[node]; ok {
+				if !reported[node] {
+					diff.Changes = append(diff.Changes, c)
+					reported[node] = true
+				}
+				continue
+			}
+		}
+		oldValue, inOld := oldFields[p]
+		newValue, inNew := newFields[p]
+		switch {
+		case inOld && inNew:
+			if !reflect.DeepEqual(oldValue, newValue) {
+				diff.Changes = append(diff.Changes, PlanChange{Type: PlanChangeChanged, Path: p, Old: fmt.Sprint(oldValue), New: fmt.Sprint(newValue)})
+			}
+		case inNew:
+			diff.Changes = append(diff.Changes, PlanChange{Type: PlanChangeAdded, Path: p, New: fmt.Sprint(newValue)})
+		default:
+			diff.Changes = append(diff.Changes, PlanChange{Type: PlanChangeRemoved, Path: p, Old: fmt.Sprint(oldValue)})
+		}
+	}
+	diff.Actions = planDiffActions(diff.Changes)
+	return diff, nil
+}
+
+func planDiffActions(changes []PlanChange) []PlanAction {
+	actions := []PlanAction{}
+	// index of the action in the list, by rule
+	indexes := map[int]int{}
+	for _, c := range changes {
+		rule := -1
+		action := planDiffInstallAction
+		for i, r := range planDiffRules {
+			if r.match(c) {
+				rule = i
+				action = r.action
+				break
+			}
+		}
+		// the change does not require any action
+		if rule >= 0 && action.Description == "" {
+			continue
+		}
+		i, ok := indexes[rule]
+		if !ok {
+			i = len(actions)
+			indexes[rule] = i
+			actions = append(actions, action)
+			actions[i].Paths = nil
+		}
+		actions[i].Paths = append(actions[i].Paths, c.Path)
+	}
+	return actions
+}
+
+// flattenPlan returns the fields of the plan, keyed by their path. Nodes are
+// keyed by their host, so that the order of the nodes does not matter.
+func flattenPlan(p Plan) (map[string]interface{}, error) {
+	p = redactedPlan(p)
+	b, err := yaml.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling plan: %v", err)
+	}
+	var m interface{}
+	if err = yaml.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("error unmarshalling plan: %v", err)
+	}
+	fields := map[string]interface{}{}
+	flattenValue("", m, fields)
+	return fields, nil
+}
+
+func flattenValue(path string, v interface{}, fields map[string]interface{}) {
+	switch t := v.(type) {
+	case nil:
+		// unset values are the same as missing values
+	case map[interface{}]interface{}:
+		for k, v := range t {
+			key := fmt.Sprint(k)
+			if path != "" {
+				key = path + "." + key
+			}
+			flattenValue(key, v, fields)
+		}
+	case []interface{}:
+		isNodeList := strings.HasSuffix(path, ".nodes") && util.Contains(strings.TrimSuffix(path, ".nodes"), roles())
+		for i, item := range t {
+			key := fmt.Sprintf("%s[%d]", path, i)
+			if m, ok := item.(map[interface{}]interface{}); ok && isNodeList {
+				key = fmt.Sprintf("%s[%v]", path, m["host"])
+			}
+			flattenValue(key, item, fields)
+		}
+	default:
+		fields[path] = t
+	}
+}
+
+// splitNodePath returns the role, host and field of a path that points to a
+// node, such as "worker.nodes[worker1].labels.env". The role is empty if the path
+// does not point to a node.
+func splitNodePath(path string) (role string, host string, field string) {
+	for _, r := range roles() {
+		prefix := r + ".nodes["
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(path, prefix)
+		end := strings.Index(rest, "]")
+		if end < 0 {
+			return "", "", ""
+		}
+		return r, rest[:end], strings.TrimPrefix(rest[end+1:], ".")
+	}
+	return "", "", ""
+}
@@ -0,0 +1,130 @@
+package install
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiffPlans(t *testing.T) {
+	oldPlan := Plan{}
+	oldPlan.Cluster.Name = "test"
+	oldPlan.Cluster.AdminPassword = redactedSecret
+	oldPlan.Cluster.APIServerOptions.Overrides = map[string]string{"v": "2"}
+	oldPlan.Worker = NodeGroup{
+		ExpectedCount: 2,
+		Nodes: []Node{
+			{Host: "worker1", IP: "10.0.0.1", Labels: map[string]string{"env": "dev"}},
+			{Host: "worker2", IP: "10.0.0.2"},
+		},
+	}
+
+	newPlan := oldPlan
+	newPlan.Cluster.AdminPassword = "plaintext"
+	newPlan.Cluster.APIServerOptions.Overrides = map[string]string{"v": "4"}
+	newPlan.Worker = NodeGroup{
+		ExpectedCount: 2,
+		Nodes: []Node{
+			{Host: "worker3", IP: "10.0.0.3"},
+			{Host: "worker1", IP: "10.0.0.1", Labels: map[string]string{"env": "prod"}},
+		},
+	}
+
+	diff, err := DiffPlans(oldPlan, newPlan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedChanges := []PlanChange{
+		{Type: PlanChangeChanged, Path: "cluster.kube_apiserver.option_overrides.v", Old: "2", New: "4"},
+		{Type: PlanChangeChanged, Path: "worker.nodes[worker1].labels.env", Old: "dev", New: "prod"},
+		{Type: PlanChangeRemoved, Path: "worker.nodes[worker2]", Old: "worker2 (10.0.0.2)"},
+		{Type: PlanChangeAdded, Path: "worker.nodes[worker3]", New: "worker3 (10.0.0.3)"},
+	}
+	if !reflect.DeepEqual(diff.Changes, expectedChanges) {
+		t.Errorf("expected changes %v, but got %v", expectedChanges, diff.Changes)
+	}
+
+	expectedCommands := []string{
+		"kismatic install step _kube-apiserver.yaml --restart-services",
+		"kismatic install step _label-nodes.yaml",
+		"",
+		"kismatic install add-node",
+	}
+	commands := []string{}
+	for _, a := range diff.Actions {
+		commands = append(commands, a.Command)
+	}
+	if !reflect.DeepEqual(commands, expectedCommands) {
+		t.Errorf("expected actions %v, but got %v", expectedCommands, commands)
+	}
+
+	diff, err = DiffPlans(oldPlan, oldPlan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !diff.Empty() {
+		t.Errorf("expected no changes, but got %v", diff.Changes)
+	}
+}
+
+func TestDiffPlansUnknownChange(t *testing.T) {
+	oldPlan := Plan{}
+	newPlan := Plan{}
+	newPlan.Master.LoadBalancedFQDN = "lb.example.com"
+	diff, err := DiffPlans(oldPlan, newPlan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(diff.Actions) != 1 || diff.Actions[0].Command != planDiffInstallAction.Command {
+		t.Errorf("expected changes that do not match any rule to require an installation, but got %v", diff.Actions)
+	}
+}
+
+func TestLastAppliedPlanFile(t *testing.T) {
+	runsDir, err := ioutil.TempDir("", "test-last-applied-plan")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(runsDir)
+
+	runs := []struct {
+		dir    string
+		status string
+	}{
+		{dir: "apply/2018-01-01-10-00-00", status: RunStatusSucceeded},
+		{dir: "step/2018-01-02-10-00-00", status: RunStatusSucceeded},
+		{dir: "apply/2018-01-03-10-00-00", status: RunStatusFailed},
+		{dir: "preflight/2018-01-04-10-00-00", status: RunStatusSucceeded},
+		// runs recorded by earlier versions have no status
+		{dir: "apply/2018-01-05-10-00-00"},
+	}
+	for _, r := range runs {
+		dir := filepath.Join(runsDir, r.dir)
+		if err = os.MkdirAll(dir, 0777); err != nil {
+			t.Fatalf("error creating run dir: %v", err)
+		}
+		if err = ioutil.WriteFile(filepath.Join(dir, "kismatic-cluster.yaml"), []byte{}, 0644); err != nil {
+			t.Fatalf("error writing plan file: %v", err)
+		}
+		if r.status != "" {
+			if err = recordRunStatus(dir, r.status); err != nil {
+				t.Fatalf("error recording status: %v", err)
+			}
+		}
+	}
+
+	file, err := LastAppliedPlanFile(runsDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := filepath.Join(runsDir, "step/2018-01-02-10-00-00", "kismatic-cluster.yaml")
+	if file != expected {
+		t.Errorf("expected %q, but got %q", expected, file)
+	}
+
+	if _, err = LastAppliedPlanFile(filepath.Join(runsDir, "notfound")); err == nil {
+		t.Errorf("expected an error when there are no runs")
+	}
+}
@@ -0,0 +1,133 @@
+package install
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// planAPIVersionV1 is the schema version of plan files that were written
+	// before the plan file was versioned. These plans do not have an apiVersion.
+	planAPIVersionV1 = "v1"
+	planAPIVersionV2 = "v2"
+	// PlanAPIVersion is the schema version of the plan files written by this
+	// version of KET.
+	PlanAPIVersion = planAPIVersionV2
+)
+
+// a planMigration upgrades a plan from one schema version to the next one
+type planMigration struct {
+	from        string
+	to          string
+	description string
+	migrate     func(p *Plan)
+}
+
+// planMigrations is the ordered list of steps that are required to bring a
+// plan file up to the current schema version. When the schema changes in a way
+// that requires a migration, bump PlanAPIVersion and append a step here.
+var planMigrations = []planMigration{
+	{
+		from:        planAPIVersionV1,
+		to:          planAPIVersionV2,
+		description: "Replace deprecated fields with their newer equivalents",
+		migrate: func(p *Plan) {
+			readDeprecatedFields(p)
+			clearDeprecatedFields(p)
+		},
+	},
+}
+
+// PlanMigrationStep describes a step that is taken to migrate a plan file
+// from one schema version to the next
+type PlanMigrationStep struct {
+	From        string
+	To          string
+	Description string
+}
+
+// PlanMigrationSteps returns the steps required to migrate a plan that was
+// written for the given schema version to the current schema version.
+func PlanMigrationSteps(from string) ([]PlanMigrationStep, error) {
+	migrations, err := migrationsFrom(from)
+	if err != nil {
+		return nil, err
+	}
+	steps := []PlanMigrationStep{}
+	for _, m := range migrations {
+		steps = append(steps, PlanMigrationStep{From: m.from, To: m.to, Description: m.description})
+	}
+	return steps, nil
+}
+
+func migrationsFrom(from string) ([]planMigration, error) {
+	if from == PlanAPIVersion {
+		return nil, nil
+	}
+	for i, m := range planMigrations {
+		if m.from == from {
+			return planMigrations[i:], nil
+		}
+	}
+	return nil, fmt.Errorf("plan schema version %q is not supported by this version of KET. The latest supported version is %q", from, PlanAPIVersion)
+}
+
+// migratePlan upgrades the plan, which was written for the given schema
+// version, to the current schema version.
+func migratePlan(p *Plan, from string) error {
+	migrations, err := migrationsFrom(from)
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		m.migrate(p)
+	}
+	p.APIVersion = PlanAPIVersion
+	return nil
+}
+
+// planSchemaVersion returns the schema version of the given plan file contents
+func planSchemaVersion(d []byte) (string, error) {
+	v := struct {
+		APIVersion string `yaml:"apiVersion"`
+	}{}
+	if err := yaml.Unmarshal(d, &v); err != nil {
+		return "", fmt.Errorf("failed to unmarshal plan: %v", err)
+	}
+	if v.APIVersion == "" {
+		return planAPIVersionV1, nil
+	}
+	return v.APIVersion, nil
+}
+
+// SchemaVersion returns the schema version that the plan file was written for
+func (fp *FilePlanner) SchemaVersion() (string, error) {
+	d, err := ioutil.ReadFile(fp.File)
+	if err != nil {
+		return "", fmt.Errorf("could not read file: %v", err)
+	}
+	return planSchemaVersion(d)
+}
+
+// clearDeprecatedFields removes the deprecated fields from the plan. It must be
+// called after the deprecated fields have been read into their replacements.
+// Fields that could not be read into a replacement are left untouched.
+func clearDeprecatedFields(p *Plan) {
+	p.Features = nil
+	p.Cluster.AllowPackageInstallation = nil
+	p.AddOns.DashboardDeprecated = nil
+	if p.DockerRegistry.Server != "" {
+		p.DockerRegistry.Address = ""
+		p.DockerRegistry.Port = 0
+	}
+	if p.Docker.Storage.DirectLVM != nil && !p.Docker.Storage.DirectLVM.Enabled {
+		p.Docker.Storage.DirectLVM = nil
+	}
+	p.Cluster.Networking.Type = ""
+	if p.AddOns.HeapsterMonitoring != nil {
+		p.AddOns.HeapsterMonitoring.Options.HeapsterReplicas = 0
+		p.AddOns.HeapsterMonitoring.Options.InfluxDBPVCName = ""
+	}
+}
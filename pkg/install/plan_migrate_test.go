@@ -0,0 +1,174 @@
+package install
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadMigratesPlan(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-read-migrates-plan")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	file := filepath.Join(tmpDir, "kismatic-cluster.yaml")
+
+	tests := []struct {
+		name        string
+		planStr     string
+		shouldWarn  bool
+		shouldError bool
+	}{
+		{
+			name:       "plan without a version",
+			planStr:    `{'cluster': {'allow_package_installation': false, 'networking': {'type': 'routed'}}, 'add_ons': {'heapster': {'options': {'heapster_replicas': 3}}}}`,
+			shouldWarn: true,
+		},
+		{
+			name:    "plan at the current version",
+			planStr: `{'apiVersion': 'v2', 'cluster': {'disable_package_installation': true}, 'add_ons': {'cni': {'provider': 'calico', 'options': {'calico': {'mode': 'routed'}}}, 'heapster': {'options': {'heapster': {'replicas': 3}}}}}`,
+		},
+		{
+			name:        "plan at an unknown version",
+			planStr:     `{'apiVersion': 'v100'}`,
+			shouldError: true,
+		},
+	}
+
+	for _, test := range tests {
+		if err = ioutil.WriteFile(file, []byte(test.planStr), 0666); err != nil {
+			t.Fatalf("error writing plan file")
+		}
+
+		out := &bytes.Buffer{}
+		planner := FilePlanner{File: file, Log: out}
+		p, err := planner.Read()
+		if test.shouldError {
+			if err == nil {
+				t.Errorf("%s: expected an error, but didn't get one", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: error reading plan file: %v", test.name, err)
+		}
+		if p.APIVersion != PlanAPIVersion {
+			t.Errorf("%s: expected apiVersion to be %q, but got %q", test.name, PlanAPIVersion, p.APIVersion)
+		}
+		if !p.Cluster.DisablePackageInstallation || p.Cluster.AllowPackageInstallation != nil {
+			t.Errorf("%s: expected allow_package_installation to be migrated", test.name)
+		}
+		if p.AddOns.CNI.Options.Calico.Mode != "routed" || p.Cluster.Networking.Type != "" {
+			t.Errorf("%s: expected networking.type to be migrated", test.name)
+		}
+		if p.AddOns.HeapsterMonitoring.Options.Heapster.Replicas != 3 || p.AddOns.HeapsterMonitoring.Options.HeapsterReplicas != 0 {
+			t.Errorf("%s: expected heapster_replicas to be migrated", test.name)
+		}
+		warned := strings.Contains(out.String(), `schema version "v1"`)
+		if warned != test.shouldWarn {
+			t.Errorf("%s: expected warning to be %v, but got output %q", test.name, test.shouldWarn, out.String())
+		}
+
+		// reading the plan again should not print the warning again
+		out.Reset()
+		if _, err = planner.Read(); err != nil {
+			t.Fatalf("%s: error reading plan file: %v", test.name, err)
+		}
+		if out.Len() != 0 {
+			t.Errorf("%s: expected no output when reading the plan a second time, but got %q", test.name, out.String())
+		}
+	}
+}
+
+func TestReadDeprecatedFieldsOfCurrentVersion(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-read-deprecated-fields")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	file := filepath.Join(tmpDir, "kismatic-cluster.yaml")
+	planStr := `{'apiVersion': 'v2', 'cluster': {'allow_package_installation': false}, 'docker_registry': {'address': '10.0.0.1', 'port': 8443}, 'add_ons': {'heapster': {'options': {'heapster_replicas': 3}}}}`
+	if err = ioutil.WriteFile(file, []byte(planStr), 0666); err != nil {
+		t.Fatalf("error writing plan file")
+	}
+
+	p, err := (&FilePlanner{File: file}).Read()
+	if err != nil {
+		t.Fatalf("error reading plan file: %v", err)
+	}
+	if !p.Cluster.DisablePackageInstallation {
+		t.Errorf("expected allow_package_installation to be read")
+	}
+	if p.DockerRegistry.Server != "10.0.0.1:8443" {
+		t.Errorf("expected the docker registry address and port to be read, but got server %q", p.DockerRegistry.Server)
+	}
+	if p.AddOns.HeapsterMonitoring.Options.Heapster.Replicas != 3 {
+		t.Errorf("expected heapster_replicas to be read")
+	}
+	// the deprecated fields are kept, so that they are reported
+	if warnings := p.deprecatedFieldWarnings(); len(warnings) != 4 {
+		t.Errorf("expected the 4 deprecated fields to be reported, but got %v", warnings)
+	}
+}
+
+func TestReadWithoutDefaults(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-read-without-defaults")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	file := filepath.Join(tmpDir, "kismatic-cluster.yaml")
+	if err = ioutil.WriteFile(file, []byte(`{'cluster': {'allow_package_installation': false}}`), 0666); err != nil {
+		t.Fatalf("error writing plan file")
+	}
+
+	planner := FilePlanner{File: file}
+	p, err := planner.ReadWithoutDefaults()
+	if err != nil {
+		t.Fatalf("error reading plan file: %v", err)
+	}
+	if p.APIVersion != PlanAPIVersion || !p.Cluster.DisablePackageInstallation {
+		t.Errorf("expected the plan to be migrated, but got %+v", p)
+	}
+	if p.Cluster.Version != "" || p.Docker.Logs.Driver != "" {
+		t.Errorf("expected the defaults not to be set, but got version %q and log driver %q", p.Cluster.Version, p.Docker.Logs.Driver)
+	}
+
+	if p, err = planner.Read(); err != nil {
+		t.Fatalf("error reading plan file: %v", err)
+	}
+	if p.Cluster.Version == "" || p.Docker.Logs.Driver == "" {
+		t.Errorf("expected the defaults to be set when reading the plan")
+	}
+}
+
+func TestPlanMigrationSteps(t *testing.T) {
+	steps, err := PlanMigrationSteps(planAPIVersionV1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(steps) == 0 || steps[len(steps)-1].To != PlanAPIVersion {
+		t.Errorf("expected the last migration step to migrate to %q, but got %v", PlanAPIVersion, steps)
+	}
+	for i := 1; i < len(steps); i++ {
+		if steps[i].From != steps[i-1].To {
+			t.Errorf("migration step %d starts at %q, but the previous step ends at %q", i, steps[i].From, steps[i-1].To)
+		}
+	}
+
+	steps, err = PlanMigrationSteps(PlanAPIVersion)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(steps) != 0 {
+		t.Errorf("expected no migration steps for a plan at the current version, but got %v", steps)
+	}
+
+	if _, err = PlanMigrationSteps("v100"); err == nil {
+		t.Errorf("expected an error for an unknown schema version")
+	}
+}
@@ -0,0 +1,70 @@
+package install
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// readPlanFile returns the contents of the plan file, with all overlays merged
+// into it. The overlays are merged in order, so that the last overlay wins.
+func (fp *FilePlanner) readPlanFile() ([]byte, error) {
+	d, err := ioutil.ReadFile(fp.File)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file: %v", err)
+	}
+	if len(fp.Overlays) == 0 {
+		return d, nil
+	}
+
+	var merged interface{}
+	if err = yaml.Unmarshal(d, &merged); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal plan: %v", err)
+	}
+	for _, o := range fp.Overlays {
+		od, err := ioutil.ReadFile(o)
+		if err != nil {
+			return nil, fmt.Errorf("could not read overlay file: %v", err)
+		}
+		var overlay interface{}
+		if err = yaml.Unmarshal(od, &overlay); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal overlay %q: %v", o, err)
+		}
+		// an empty overlay does not change the plan
+		if overlay == nil {
+			continue
+		}
+		if _, ok := overlay.(map[interface{}]interface{}); !ok {
+			return nil, fmt.Errorf("overlay %q is not a valid plan file overlay", o)
+		}
+		merged = mergeOverlay(merged, overlay)
+	}
+	return yaml.Marshal(merged)
+}
+
+// mergeOverlay deep-merges the overlay into the base. Maps are merged key by
+// key, while any other value in the overlay (including lists, such as the nodes
+// of a node group) replaces the value found in the base.
+func mergeOverlay(base, overlay interface{}) interface{} {
+	baseMap, ok := base.(map[interface{}]interface{})
+	if !ok {
+		return overlay
+	}
+	overlayMap, ok := overlay.(map[interface{}]interface{})
+	if !ok {
+		return overlay
+	}
+	merged := make(map[interface{}]interface{}, len(baseMap))
+	for k, v := range baseMap {
+		merged[k] = v
+	}
+	for k, v := range overlayMap {
+		if bv, ok := merged[k]; ok {
+			merged[k] = mergeOverlay(bv, v)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,136 @@
+package install
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadWithOverlays(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-read-with-overlays")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	base := `
+cluster:
+  name: dev
+  networking:
+    pod_cidr_block: 172.16.0.0/16
+    service_cidr_block: 172.20.0.0/16
+  kube_apiserver:
+    option_overrides:
+      v: "2"
+      runtime-config: batch/v2alpha1=true
+worker:
+  expected_count: 2
+  nodes:
+  - host: worker1
+    ip: 10.0.0.1
+    labels:
+      env: dev
+      tier: backend
+  - host: worker2
+    ip: 10.0.0.2
+`
+	prod := `
+cluster:
+  name: prod
+  kube_apiserver:
+    option_overrides:
+      v: "4"
+docker_registry:
+  server: registry.prod:443
+worker:
+  nodes:
+  - host: prod-worker1
+    ip: 10.1.0.1
+    labels:
+      env: prod
+`
+	labels := `
+cluster:
+  networking:
+    pod_cidr_block: 172.18.0.0/16
+`
+	files := map[string]string{"base.yaml": base, "prod.yaml": prod, "labels.yaml": labels, "empty.yaml": ""}
+	for name, content := range files {
+		if err = ioutil.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0666); err != nil {
+			t.Fatalf("error writing file: %v", err)
+		}
+	}
+
+	fp := &FilePlanner{
+		File:     filepath.Join(tmpDir, "base.yaml"),
+		Overlays: []string{filepath.Join(tmpDir, "prod.yaml"), filepath.Join(tmpDir, "empty.yaml"), filepath.Join(tmpDir, "labels.yaml")},
+	}
+	p, err := fp.Read()
+	if err != nil {
+		t.Fatalf("unexpected error reading plan: %v", err)
+	}
+
+	if p.Cluster.Name != "prod" {
+		t.Errorf("expected cluster name to be overridden, but got %q", p.Cluster.Name)
+	}
+	if p.Cluster.Networking.PodCIDRBlock != "172.18.0.0/16" {
+		t.Errorf("expected the last overlay to win, but got pod CIDR %q", p.Cluster.Networking.PodCIDRBlock)
+	}
+	if p.Cluster.Networking.ServiceCIDRBlock != "172.20.0.0/16" {
+		t.Errorf("expected service CIDR to be read from the base plan, but got %q", p.Cluster.Networking.ServiceCIDRBlock)
+	}
+	if p.DockerRegistry.Server != "registry.prod:443" {
+		t.Errorf("expected docker registry to be read from the overlay, but got %q", p.DockerRegistry.Server)
+	}
+	expectedOverrides := map[string]string{"v": "4", "runtime-config": "batch/v2alpha1=true"}
+	if !reflect.DeepEqual(p.Cluster.APIServerOptions.Overrides, expectedOverrides) {
+		t.Errorf("expected option overrides to be merged to %v, but got %v", expectedOverrides, p.Cluster.APIServerOptions.Overrides)
+	}
+	if p.Worker.ExpectedCount != 2 {
+		t.Errorf("expected worker expected_count to be read from the base plan, but got %d", p.Worker.ExpectedCount)
+	}
+	if len(p.Worker.Nodes) != 1 || p.Worker.Nodes[0].Host != "prod-worker1" {
+		t.Errorf("expected worker nodes to be replaced by the overlay, but got %v", p.Worker.Nodes)
+	}
+
+	if err = fp.Write(p); err == nil {
+		t.Errorf("expected an error writing a plan that uses overlays")
+	}
+}
+
+func TestMergeOverlay(t *testing.T) {
+	tests := []struct {
+		base     interface{}
+		overlay  interface{}
+		expected interface{}
+	}{
+		{
+			base:     map[interface{}]interface{}{"labels": map[interface{}]interface{}{"a": "1", "b": "2"}},
+			overlay:  map[interface{}]interface{}{"labels": map[interface{}]interface{}{"b": "3", "c": "4"}},
+			expected: map[interface{}]interface{}{"labels": map[interface{}]interface{}{"a": "1", "b": "3", "c": "4"}},
+		},
+		{
+			base:     map[interface{}]interface{}{"nodes": []interface{}{"a", "b"}},
+			overlay:  map[interface{}]interface{}{"nodes": []interface{}{"c"}},
+			expected: map[interface{}]interface{}{"nodes": []interface{}{"c"}},
+		},
+		{
+			base:     map[interface{}]interface{}{"labels": map[interface{}]interface{}{"a": "1"}},
+			overlay:  map[interface{}]interface{}{"labels": nil},
+			expected: map[interface{}]interface{}{"labels": nil},
+		},
+		{
+			base:     nil,
+			overlay:  map[interface{}]interface{}{"a": "1"},
+			expected: map[interface{}]interface{}{"a": "1"},
+		},
+	}
+	for i, test := range tests {
+		merged := mergeOverlay(test.base, test.overlay)
+		if !reflect.DeepEqual(merged, test.expected) {
+			t.Errorf("test %d: expected %v, but got %v", i, test.expected, merged)
+		}
+	}
+}
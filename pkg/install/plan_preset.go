@@ -0,0 +1,64 @@
+package install
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultPlanTemplatePreset is the preset used when generating a plan file
+// template without specifying a preset.
+const DefaultPlanTemplatePreset = "ha-small"
+
+// planTemplatePresets are named cluster topologies that can be used
+// as a starting point when generating a plan file template
+var planTemplatePresets = map[string]PlanTemplateOptions{
+	// a single node of each role, for trying out KET
+	"minikube-like": {
+		EtcdNodes:        1,
+		MasterNodes:      1,
+		WorkerNodes:      1,
+		IngressNodes:     1,
+		CNIProvider:      cniProviderCalico,
+		DNSProvider:      dnsProviderKubedns,
+		DisableHeapster:  true,
+		DisableDashboard: false,
+	},
+	// a highly available cluster for small workloads
+	"ha-small": {
+		EtcdNodes:    3,
+		MasterNodes:  2,
+		WorkerNodes:  3,
+		IngressNodes: 2,
+		CNIProvider:  cniProviderCalico,
+		DNSProvider:  dnsProviderKubedns,
+	},
+	// a highly available cluster for large workloads, with storage nodes
+	"ha-large": {
+		EtcdNodes:    5,
+		MasterNodes:  3,
+		WorkerNodes:  10,
+		IngressNodes: 3,
+		StorageNodes: 2,
+		CNIProvider:  cniProviderCalico,
+		DNSProvider:  dnsProviderCoredns,
+	},
+}
+
+// PlanTemplatePresets returns the names of the available presets
+func PlanTemplatePresets() []string {
+	names := []string{}
+	for n := range planTemplatePresets {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PlanTemplatePreset returns the plan template options of the given preset
+func PlanTemplatePreset(name string) (PlanTemplateOptions, error) {
+	opts, ok := planTemplatePresets[name]
+	if !ok {
+		return PlanTemplateOptions{}, fmt.Errorf("%q is not a valid preset. Options are %v", name, PlanTemplatePresets())
+	}
+	return opts, nil
+}
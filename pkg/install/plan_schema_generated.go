@@ -0,0 +1,1622 @@
+// Code generated by gen-kismatic-ref-docs. DO NOT EDIT.
+
+package install
+
+// PlanJSONSchema is the JSON Schema of the plan file
+const PlanJSONSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "title": "Kismatic Plan File",
+  "type": "object",
+  "properties": {
+    "add_ons": {
+      "description": "Add on configuration",
+      "type": "object",
+      "properties": {
+        "cni": {
+          "description": "The Container Networking Interface (CNI) add-on configuration.",
+          "type": [
+            "object",
+            "null"
+          ],
+          "properties": {
+            "disable": {
+              "description": "Whether the CNI add-on is disabled. When set to true, CNI will not be installed on the cluster. Furthermore, the smoke test and any validation that depends on a functional pod network will be skipped.",
+              "type": "boolean",
+              "default": false
+            },
+            "options": {
+              "description": "The CNI options that can be configured for each CNI provider.",
+              "type": "object",
+              "properties": {
+                "calico": {
+                  "description": "The options that can be configured for the Calico CNI provider.",
+                  "type": "object",
+                  "properties": {
+                    "felix_input_mtu": {
+                      "description": "MTU for the tunnel device used if IPIP is enabled.",
+                      "type": "integer",
+                      "default": 1440
+                    },
+                    "ip_autodetection_method": {
+                      "description": "IPAutodetectionMethod is used to detect the IPv4 address of the host. The value gets set in IP_AUTODETECTION_METHOD variable in the pod.",
+                      "type": "string",
+                      "default": "first-found"
+                    },
+                    "log_level": {
+                      "description": "The logging level for the CNI plugin",
+                      "type": "string",
+                      "enum": [
+                        "warning",
+                        "info",
+                        "debug",
+                        ""
+                      ],
+                      "default": "info"
+                    },
+                    "mode": {
+                      "description": "The datapath technique that should be configured in Calico.",
+                      "type": "string",
+                      "enum": [
+                        "overlay",
+                        "routed",
+                        ""
+                      ],
+                      "default": "overlay"
+                    },
+                    "workload_mtu": {
+                      "description": "MTU for the workload interface, configures the CNI config.",
+                      "type": "integer",
+                      "default": 1500
+                    }
+                  },
+                  "additionalProperties": false
+                },
+                "weave": {
+                  "description": "The options that can be configured for the Weave CNI provider.",
+                  "type": "object",
+                  "properties": {
+                    "password": {
+                      "description": "The password to use for network traffic encryption. A reference to the password can be provided instead, using ` + "`" + `env:VARIABLE` + "`" + `, ` + "`" + `file:/path/to/file` + "`" + ` or ` + "`" + `exec:command` + "`" + `.",
+                      "type": "string"
+                    }
+                  },
+                  "additionalProperties": false
+                }
+              },
+              "additionalProperties": false
+            },
+            "provider": {
+              "description": "The CNI provider that should be installed on the cluster.",
+              "type": "string",
+              "enum": [
+                "calico",
+                "weave",
+                "contiv",
+                "custom",
+                ""
+              ],
+              "default": "calico"
+            }
+          },
+          "additionalProperties": false
+        },
+        "dashbard": {
+          "description": "The Dashboard add-on configuration.",
+          "type": [
+            "object",
+            "null"
+          ],
+          "properties": {
+            "disable": {
+              "description": "Whether the dashboard add-on should be disabled. When set to true, the Kubernetes Dashboard will not be installed on the cluster.",
+              "type": "boolean",
+              "default": false
+            },
+            "options": {
+              "description": "The options that can be configured for the Dashboard add-on",
+              "type": "object",
+              "properties": {
+                "service_type": {
+                  "description": "Kubernetes service type of the Dashboard service.",
+                  "type": "string",
+                  "enum": [
+                    "ClusterIP",
+                    "NodePort",
+                    "LoadBalancer",
+                    "ExternalName",
+                    ""
+                  ],
+                  "default": "ClusterIP"
+                }
+              },
+              "additionalProperties": false
+            }
+          },
+          "additionalProperties": false,
+          "deprecated": true
+        },
+        "dashboard": {
+          "description": "The Dashboard add-on configuration.",
+          "type": [
+            "object",
+            "null"
+          ],
+          "properties": {
+            "disable": {
+              "description": "Whether the dashboard add-on should be disabled. When set to true, the Kubernetes Dashboard will not be installed on the cluster.",
+              "type": "boolean",
+              "default": false
+            },
+            "options": {
+              "description": "The options that can be configured for the Dashboard add-on",
+              "type": "object",
+              "properties": {
+                "service_type": {
+                  "description": "Kubernetes service type of the Dashboard service.",
+                  "type": "string",
+                  "enum": [
+                    "ClusterIP",
+                    "NodePort",
+                    "LoadBalancer",
+                    "ExternalName",
+                    ""
+                  ],
+                  "default": "ClusterIP"
+                }
+              },
+              "additionalProperties": false
+            }
+          },
+          "additionalProperties": false
+        },
+        "dns": {
+          "description": "The DNS add-on configuration.",
+          "type": "object",
+          "properties": {
+            "disable": {
+              "description": "Whether the DNS add-on should be disabled. When set to true, no DNS solution will be deployed on the cluster.",
+              "type": "boolean"
+            },
+            "options": {
+              "description": "The options that can be configured for the cluster DNS add-on",
+              "type": "object",
+              "properties": {
+                "replicas": {
+                  "description": "Number of cluster DNS replicas that should be scheduled on the cluster.",
+                  "type": "integer",
+                  "default": 2
+                }
+              },
+              "additionalProperties": false
+            },
+            "provider": {
+              "description": "This property indicates the in-cluster DNS provider.",
+              "type": "string",
+              "enum": [
+                "kubedns",
+                "coredns"
+              ],
+              "default": "kubedns"
+            }
+          },
+          "required": [
+            "provider"
+          ],
+          "additionalProperties": false
+        },
+        "heapster": {
+          "description": "The Heapster Monitoring add-on configuration.",
+          "type": [
+            "object",
+            "null"
+          ],
+          "properties": {
+            "disable": {
+              "description": "Whether the Heapster add-on should be disabled. When set to true, Heapster and InfluxDB will not be deployed on the cluster.",
+              "type": "boolean",
+              "default": false
+            },
+            "options": {
+              "description": "The options that can be configured for the Heapster add-on",
+              "type": "object",
+              "properties": {
+                "heapster": {
+                  "description": "The Heapster configuration options.",
+                  "type": "object",
+                  "properties": {
+                    "replicas": {
+                      "description": "Number of Heapster replicas that should be scheduled on the cluster.",
+                      "type": "integer",
+                      "default": 2
+                    },
+                    "service_type": {
+                      "description": "Kubernetes service type of the Heapster service.",
+                      "type": "string",
+                      "enum": [
+                        "ClusterIP",
+                        "NodePort",
+                        "LoadBalancer",
+                        "ExternalName",
+                        ""
+                      ],
+                      "default": "ClusterIP"
+                    },
+                    "sink": {
+                      "description": "URL of the backend store that will be used as the Heapster sink.",
+                      "type": "string",
+                      "default": "influxdb:http://heapster-influxdb.kube-system.svc:8086"
+                    }
+                  },
+                  "additionalProperties": false
+                },
+                "heapster_replicas": {
+                  "description": "Number of Heapster replicas that should be scheduled on the cluster.",
+                  "type": "integer",
+                  "deprecated": true
+                },
+                "influxdb": {
+                  "description": "The InfluxDB configuration options.",
+                  "type": "object",
+                  "properties": {
+                    "pvc_name": {
+                      "description": "Name of the Persistent Volume Claim that will be used by InfluxDB. This PVC must be created after the installation. If not set, InfluxDB will be configured with ephemeral storage.",
+                      "type": "string"
+                    }
+                  },
+                  "additionalProperties": false
+                },
+                "influxdb_pvc_name": {
+                  "description": "Name of the Persistent Volume Claim that will be used by InfluxDB. When set, this PVC must be created after the installation. If not set, InfluxDB will be configured with ephemeral storage.",
+                  "type": "string",
+                  "deprecated": true
+                }
+              },
+              "additionalProperties": false
+            }
+          },
+          "additionalProperties": false
+        },
+        "metrics_server": {
+          "description": "Metrics Server add-on configuration. A cluster-wide aggregator of resource usage data. Required for Horizontal Pod Autoscaler to function properly.",
+          "type": "object",
+          "properties": {
+            "disable": {
+              "description": "Whether the metrics-server add-on should be disabled. When set to true, metrics-server will not be deployed on the cluster.",
+              "type": "boolean",
+              "default": false
+            }
+          },
+          "additionalProperties": false
+        },
+        "package_manager": {
+          "description": "The PackageManager add-on configuration.",
+          "type": "object",
+          "properties": {
+            "disable": {
+              "description": "Whether the package manager add-on should be disabled. When set to true, the package manager will not be installed on the cluster.",
+              "type": "boolean",
+              "default": false
+            },
+            "options": {
+              "description": "The PackageManager options.",
+              "type": "object",
+              "properties": {
+                "helm": {
+                  "description": "Helm PackageManager options",
+                  "type": "object",
+                  "properties": {
+                    "namespace": {
+                      "description": "Namespace to deploy tiller",
+                      "type": "string",
+                      "default": "kube-system"
+                    }
+                  },
+                  "additionalProperties": false
+                }
+              },
+              "additionalProperties": false
+            },
+            "provider": {
+              "description": "This property indicates the package manager provider.",
+              "type": "string",
+              "enum": [
+                "helm"
+              ]
+            }
+          },
+          "required": [
+            "provider"
+          ],
+          "additionalProperties": false
+        },
+        "rescheduler": {
+          "description": "The Rescheduler add-on configuration. Because the Rescheduler does not have leader election and therefore can only run as a single instance in a cluster, it will be deployed as a static pod on the first master. More information about the Rescheduler can be found here: https://kubernetes.io/docs/tasks/administer-cluster/guaranteed-scheduling-critical-addon-pods/",
+          "type": "object",
+          "properties": {
+            "disable": {
+              "description": "Whether the pod rescheduler add-on should be disabled. When set to true, the rescheduler will not be installed on the cluster.",
+              "type": "boolean",
+              "default": false
+            }
+          },
+          "additionalProperties": false
+        }
+      },
+      "additionalProperties": false
+    },
+    "additional_files": {
+      "description": "A set of files or directories to copy from the local machine to any of the nodes in the cluster.",
+      "type": [
+        "array",
+        "null"
+      ],
+      "items": {
+        "type": "object",
+        "properties": {
+          "destination": {
+            "description": "Path to the file or directory on remote machine, where file will be copied. Must be an absolute path.",
+            "type": "string"
+          },
+          "hosts": {
+            "description": "Hostname or role where additional files or directories will be copied.",
+            "type": [
+              "array",
+              "null"
+            ],
+            "items": {
+              "type": "string"
+            }
+          },
+          "skip_validation": {
+            "description": "Set to true if validation will be run before the file exists on the local machine. Useful for files generated at install time, ie. assets in generated/ directory.",
+            "type": "boolean"
+          },
+          "source": {
+            "description": "Path to the file or directory on local machine. Must be an absolute path.",
+            "type": "string"
+          }
+        },
+        "required": [
+          "hosts",
+          "source",
+          "destination"
+        ],
+        "additionalProperties": false
+      }
+    },
+    "apiVersion": {
+      "description": "The schema version of the plan file. Plan files without a version were written for schema version v1.",
+      "type": "string",
+      "default": "v2"
+    },
+    "cluster": {
+      "description": "Kubernetes cluster configuration",
+      "type": "object",
+      "properties": {
+        "admin_password": {
+          "description": "The password for the admin user. If provided, ABAC will be enabled in the cluster. A reference to the password can be provided instead, using ` + "`" + `env:VARIABLE` + "`" + `, ` + "`" + `file:/path/to/file` + "`" + ` or ` + "`" + `exec:command` + "`" + `. This field will be removed completely in a future release.",
+          "type": "string",
+          "deprecated": true
+        },
+        "allow_package_installation": {
+          "description": "Whether KET should install the packages on the cluster nodes. Use DisablePackageInstallation instead.",
+          "type": "boolean",
+          "deprecated": true
+        },
+        "certificates": {
+          "description": "The Certificates configuration for the cluster.",
+          "type": "object",
+          "properties": {
+            "ca_expiry": {
+              "description": "The length of time that the generated Certificate Authority should be valid for. For example: \"17520h\" for 2 years.",
+              "type": "string"
+            },
+            "expiry": {
+              "description": "The length of time that the generated certificates should be valid for. For example: \"17520h\" for 2 years.",
+              "type": "string"
+            }
+          },
+          "required": [
+            "expiry",
+            "ca_expiry"
+          ],
+          "additionalProperties": false
+        },
+        "cloud_provider": {
+          "description": "The CloudProvider configuration for the cluster.",
+          "type": "object",
+          "properties": {
+            "config": {
+              "description": "Path to the cloud provider config file. This will be copied to all the machines in the cluster A reference to the contents of the config file can be provided instead, using ` + "`" + `env:VARIABLE` + "`" + `, ` + "`" + `file:/path/to/file` + "`" + ` or ` + "`" + `exec:command` + "`" + `.",
+              "type": "string"
+            },
+            "provider": {
+              "description": "The cloud provider that should be set in the Kubernetes components",
+              "type": "string",
+              "enum": [
+                "aws",
+                "azure",
+                "cloudstack",
+                "fake",
+                "gce",
+                "mesos",
+                "openstack",
+                "ovirt",
+                "photon",
+                "rackspace",
+                "vsphere",
+                ""
+              ]
+            }
+          },
+          "additionalProperties": false
+        },
+        "disable_package_installation": {
+          "description": "Whether KET should install the packages on the cluster nodes. When true, KET will not install the required packages. Instead, it will verify that the packages have been installed by the operator.",
+          "type": "boolean"
+        },
+        "disconnected_installation": {
+          "description": "Whether the cluster nodes are disconnected from the internet. When set to ` + "`" + `true` + "`" + `, internal package repositories and a container image registry are required for installation.",
+          "type": "boolean",
+          "default": false
+        },
+        "kube_apiserver": {
+          "description": "Kubernetes API Server configuration.",
+          "type": "object",
+          "properties": {
+            "option_overrides": {
+              "description": "Listing of option overrides that are to be applied to the Kubernetes API server configuration. This is an advanced feature that can prevent the API server from starting up if invalid configuration is provided.",
+              "type": [
+                "object",
+                "null"
+              ],
+              "additionalProperties": {
+                "type": "string"
+              }
+            }
+          },
+          "additionalProperties": false
+        },
+        "kube_controller_manager": {
+          "description": "Kubernetes Controller Manager configuration.",
+          "type": "object",
+          "properties": {
+            "option_overrides": {
+              "description": "Listing of option overrides that are to be applied to the Kubernetes Controller Manager configuration. This is an advanced feature that can prevent the Controller Manager from starting up if invalid configuration is provided.",
+              "type": [
+                "object",
+                "null"
+              ],
+              "additionalProperties": {
+                "type": "string"
+              }
+            }
+          },
+          "additionalProperties": false
+        },
+        "kube_proxy": {
+          "description": "Kubernetes Proxy configuration.",
+          "type": "object",
+          "properties": {
+            "option_overrides": {
+              "description": "Listing of option overrides that are to be applied to the Kubernetes Proxy configuration. This is an advanced feature that can prevent the Proxy from starting up if invalid configuration is provided.",
+              "type": [
+                "object",
+                "null"
+              ],
+              "additionalProperties": {
+                "type": "string"
+              }
+            }
+          },
+          "additionalProperties": false
+        },
+        "kube_scheduler": {
+          "description": "Kubernetes Scheduler configuration.",
+          "type": "object",
+          "properties": {
+            "option_overrides": {
+              "description": "Listing of option overrides that are to be applied to the Kubernetes Scheduler configuration. This is an advanced feature that can prevent the Scheduler from starting up if invalid configuration is provided.",
+              "type": [
+                "object",
+                "null"
+              ],
+              "additionalProperties": {
+                "type": "string"
+              }
+            }
+          },
+          "additionalProperties": false
+        },
+        "kubelet": {
+          "description": "Kubelet configuration applied to all nodes.",
+          "type": "object",
+          "properties": {
+            "option_overrides": {
+              "description": "Listing of option overrides that are to be applied to the Kubelet configurations. This is an advanced feature that can prevent the Kubelet from starting up if invalid configuration is provided.",
+              "type": [
+                "object",
+                "null"
+              ],
+              "additionalProperties": {
+                "type": "string"
+              }
+            }
+          },
+          "additionalProperties": false
+        },
+        "name": {
+          "description": "Name of the cluster to be used when generating assets that require a cluster name, such as kubeconfig files and certificates.",
+          "type": "string"
+        },
+        "networking": {
+          "description": "The Networking configuration for the cluster.",
+          "type": "object",
+          "properties": {
+            "http_proxy": {
+              "description": "The URL of the proxy that should be used for HTTP connections.",
+              "type": "string"
+            },
+            "https_proxy": {
+              "description": "The URL of the proxy that should be used for HTTPS connections.",
+              "type": "string"
+            },
+            "no_proxy": {
+              "description": "Comma-separated list of host names and/or IPs for which connections should not go through a proxy. All nodes' 'host' and 'IPs' are always set.",
+              "type": "string"
+            },
+            "pod_cidr_block": {
+              "description": "The pod network's CIDR block. For example: ` + "`" + `172.16.0.0/16` + "`" + `",
+              "type": "string"
+            },
+            "service_cidr_block": {
+              "description": "The Kubernetes service network's CIDR block. For example: ` + "`" + `172.20.0.0/16` + "`" + `",
+              "type": "string"
+            },
+            "type": {
+              "description": "The datapath technique that should be configured in Calico.",
+              "type": "string",
+              "enum": [
+                "overlay",
+                "routed",
+                ""
+              ],
+              "default": "overlay",
+              "deprecated": true
+            },
+            "update_hosts_files": {
+              "description": "Whether the /etc/hosts file should be updated on the cluster nodes. When set to true, KET will update the hosts file on all nodes to include entries for all other nodes in the cluster.",
+              "type": "boolean",
+              "default": false
+            }
+          },
+          "required": [
+            "pod_cidr_block",
+            "service_cidr_block"
+          ],
+          "additionalProperties": false
+        },
+        "ssh": {
+          "description": "The SSH configuration for the cluster nodes.",
+          "type": "object",
+          "properties": {
+            "bastion": {
+              "description": "The bastion host through which the cluster nodes are accessed via SSH, when they are not directly reachable.",
+              "type": [
+                "object",
+                "null"
+              ],
+              "properties": {
+                "host": {
+                  "description": "The hostname or IP address of the bastion host.",
+                  "type": "string"
+                },
+                "ssh_key": {
+                  "description": "The absolute path of the SSH key that should be used for accessing the bastion host. Defaults to the SSH key for accessing the node.",
+                  "type": "string"
+                },
+                "ssh_port": {
+                  "description": "The port number on which the bastion host is listening for SSH connections.",
+                  "type": "integer",
+                  "default": 22
+                },
+                "user": {
+                  "description": "The user for accessing the bastion host via SSH. Defaults to the user for accessing the node.",
+                  "type": "string"
+                }
+              },
+              "required": [
+                "host"
+              ],
+              "additionalProperties": false
+            },
+            "ssh_key": {
+              "description": "The absolute path of the SSH key that should be used for accessing the cluster nodes via SSH. The key can be protected with a passphrase, which is read from the KISMATIC_SSH_KEY_PASSPHRASE environment variable, or prompted for. Required unless use_agent is set.",
+              "type": "string"
+            },
+            "ssh_port": {
+              "description": "The port number on which cluster nodes are listening for SSH connections.",
+              "type": "integer"
+            },
+            "use_agent": {
+              "description": "Set to true to authenticate with the keys of the running ssh-agent, found with the SSH_AUTH_SOCK environment variable.",
+              "type": "boolean"
+            },
+            "user": {
+              "description": "The user for accessing the cluster nodes via SSH. This user requires sudo elevation privileges on the cluster nodes.",
+              "type": "string"
+            }
+          },
+          "required": [
+            "user",
+            "ssh_port"
+          ],
+          "additionalProperties": false
+        },
+        "version": {
+          "description": "The Kubernetes version to install. If left blank will be set to the latest tested version. Only a single Minor version is supported with.",
+          "type": "string",
+          "default": "v1.10.0"
+        }
+      },
+      "required": [
+        "name"
+      ],
+      "additionalProperties": false
+    },
+    "docker": {
+      "description": "Configuration for the docker engine installed by KET",
+      "type": "object",
+      "properties": {
+        "disable": {
+          "description": "Set to true to disable the installation of docker container runtime on the nodes. The installer will validate that docker is installed and running prior to proceeding. Use this option if a different version of docker from the included one is required.",
+          "type": "boolean"
+        },
+        "logs": {
+          "description": "Log configuration for the docker engine.",
+          "type": "object",
+          "properties": {
+            "driver": {
+              "description": "Docker logging driver, more details https://docs.docker.com/engine/admin/logging/overview/.",
+              "type": "string",
+              "default": "json-file"
+            },
+            "opts": {
+              "description": "Driver specific options.",
+              "type": [
+                "object",
+                "null"
+              ],
+              "additionalProperties": {
+                "type": "string"
+              }
+            }
+          },
+          "additionalProperties": false
+        },
+        "storage": {
+          "description": "Storage configuration for the docker engine.",
+          "type": "object",
+          "properties": {
+            "direct_lvm": {
+              "description": "DirectLVM is the configuration required for setting up device mapper in direct-lvm mode.",
+              "type": [
+                "object",
+                "null"
+              ],
+              "properties": {
+                "block_device": {
+                  "description": "The path to the block storage device that will be used by the devicemapper storage driver.",
+                  "type": "string"
+                },
+                "enable_deferred_deletion": {
+                  "description": "Whether deferred deletion should be enabled when using devicemapper in direct_lvm mode.",
+                  "type": "boolean",
+                  "default": false
+                },
+                "enabled": {
+                  "description": "Whether the direct_lvm mode of the devicemapper storage driver should be enabled. When set to true, a dedicated block storage device must be available on each cluster node.",
+                  "type": "boolean",
+                  "default": false
+                }
+              },
+              "additionalProperties": false,
+              "deprecated": true
+            },
+            "direct_lvm_block_device": {
+              "description": "DirectLVMBlockDevice is the configuration required for setting up Device Mapper storage driver in direct-lvm mode. Refer to https://docs.docker.com/v17.03/engine/userguide/storagedriver/device-mapper-driver/#manage-devicemapper docs.",
+              "type": "object",
+              "properties": {
+                "path": {
+                  "description": "The path to the block device.",
+                  "type": "string"
+                },
+                "thinpool_autoextend_percent": {
+                  "description": "The percentage to increase the thin pool by when an autoextend is triggered.",
+                  "type": "string",
+                  "default": "20"
+                },
+                "thinpool_autoextend_threshold": {
+                  "description": "The threshold for when lvm should automatically extend the thin pool as a percentage of the total storage space.",
+                  "type": "string",
+                  "default": "80"
+                },
+                "thinpool_metapercent": {
+                  "description": "The percentage of space to for metadata storage from the passed in block device.",
+                  "type": "string",
+                  "default": "1"
+                },
+                "thinpool_percent": {
+                  "description": "The percentage of space to use for storage from the passed in block device.",
+                  "type": "string",
+                  "default": "95"
+                }
+              },
+              "additionalProperties": false
+            },
+            "driver": {
+              "description": "Docker storage driver, more details https://docs.docker.com/engine/userguide/storagedriver/. Leave empty to have docker automatically select the driver.",
+              "type": "string",
+              "default": ""
+            },
+            "opts": {
+              "description": "Driver specific options",
+              "type": [
+                "object",
+                "null"
+              ],
+              "additionalProperties": {
+                "type": "string"
+              }
+            }
+          },
+          "additionalProperties": false
+        }
+      },
+      "additionalProperties": false
+    },
+    "docker_registry": {
+      "description": "Docker registry configuration",
+      "type": "object",
+      "properties": {
+        "CA": {
+          "description": "The absolute path of the Certificate Authority that should be installed on all cluster nodes that have a docker daemon. This is required to establish trust between the daemons and the private registry when the registry is using a self-signed certificate.",
+          "type": "string"
+        },
+        "address": {
+          "description": "The hostname or IP address of a private container image registry. When performing a disconnected installation, this registry will be used to fetch all the required container images.",
+          "type": "string",
+          "deprecated": true
+        },
+        "password": {
+          "description": "The password that should be used when connecting to a registry that has authentication enabled. Otherwise leave blank for unauthenticated access. A reference to the password can be provided instead, using ` + "`" + `env:VARIABLE` + "`" + `, ` + "`" + `file:/path/to/file` + "`" + ` or ` + "`" + `exec:command` + "`" + `.",
+          "type": "string"
+        },
+        "port": {
+          "description": "The port on which the private container image registry is listening on.",
+          "type": "integer",
+          "deprecated": true
+        },
+        "server": {
+          "description": "The hostname or IP address and port of a private container image registry. Do not include http or https. When performing a disconnected installation, this registry will be used to fetch all the required container images.",
+          "type": "string"
+        },
+        "username": {
+          "description": "The username that should be used when connecting to a registry that has authentication enabled. Otherwise leave blank for unauthenticated access.",
+          "type": "string"
+        }
+      },
+      "additionalProperties": false
+    },
+    "etcd": {
+      "description": "Etcd nodes of the cluster",
+      "type": "object",
+      "properties": {
+        "expected_count": {
+          "description": "Number of nodes.",
+          "type": "integer"
+        },
+        "nodes": {
+          "description": "List of nodes.",
+          "type": [
+            "array",
+            "null"
+          ],
+          "items": {
+            "type": "object",
+            "properties": {
+              "host": {
+                "description": "The hostname of the node. The hostname is verified in the validation phase of the installation.",
+                "type": "string"
+              },
+              "internalip": {
+                "description": "The internal (or private) IP address of the node. If set, this IP will be used when configuring cluster components.",
+                "type": "string"
+              },
+              "ip": {
+                "description": "The IP address of the node. This is the IP address that will be used to connect to the node over SSH.",
+                "type": "string"
+              },
+              "kubelet": {
+                "description": "Kubelet configuration applied to this node. If a node is repeated for multiple roles, the overrides cannot be different.",
+                "type": "object",
+                "properties": {
+                  "option_overrides": {
+                    "description": "Listing of option overrides that are to be applied to the Kubelet configurations. This is an advanced feature that can prevent the Kubelet from starting up if invalid configuration is provided.",
+                    "type": [
+                      "object",
+                      "null"
+                    ],
+                    "additionalProperties": {
+                      "type": "string"
+                    }
+                  }
+                },
+                "additionalProperties": false
+              },
+              "labels": {
+                "description": "Labels to add when installing the node in the cluster. If a node is defined under multiple roles, the labels for that node will be merged. If a label is repeated for the same node, only one will be used in this order: etcd,master,worker,ingress,storage roles where 'storage' has the highest precedence. It is recommended to use reverse-DNS notation to avoid collision with other labels.",
+                "type": [
+                  "object",
+                  "null"
+                ],
+                "additionalProperties": {
+                  "type": "string"
+                }
+              },
+              "ssh": {
+                "description": "SSH configuration for accessing this node, overriding the SSH configuration of the cluster and of the node's groups.",
+                "type": [
+                  "object",
+                  "null"
+                ],
+                "properties": {
+                  "ssh_key": {
+                    "description": "The absolute path of the SSH key that should be used for accessing the nodes via SSH.",
+                    "type": "string"
+                  },
+                  "ssh_port": {
+                    "description": "The port number on which the nodes are listening for SSH connections.",
+                    "type": "integer"
+                  },
+                  "user": {
+                    "description": "The user for accessing the nodes via SSH. This user requires sudo elevation privileges on the nodes.",
+                    "type": "string"
+                  }
+                },
+                "additionalProperties": false
+              },
+              "taints": {
+                "description": "Taints to add when installing the node in the cluster. If a node is defined under multiple roles, the taints for that node will be merged. If a taint is repeated for the same node, only one will be used in this order: etcd,master,worker,ingress,storage roles where 'storage' has the highest precedence.",
+                "type": [
+                  "array",
+                  "null"
+                ],
+                "items": {
+                  "type": "object",
+                  "properties": {
+                    "effect": {
+                      "description": "Effect for the taint",
+                      "type": "string",
+                      "enum": [
+                        "NoSchedule",
+                        "PreferNoSchedule",
+                        "NoExecute",
+                        ""
+                      ]
+                    },
+                    "key": {
+                      "description": "Key for the taint",
+                      "type": "string"
+                    },
+                    "value": {
+                      "description": "Value for the taint",
+                      "type": "string"
+                    }
+                  },
+                  "additionalProperties": false
+                }
+              }
+            },
+            "required": [
+              "host",
+              "ip"
+            ],
+            "additionalProperties": false
+          }
+        },
+        "ssh": {
+          "description": "SSH configuration for accessing the nodes, overriding the cluster's SSH configuration.",
+          "type": [
+            "object",
+            "null"
+          ],
+          "properties": {
+            "ssh_key": {
+              "description": "The absolute path of the SSH key that should be used for accessing the nodes via SSH.",
+              "type": "string"
+            },
+            "ssh_port": {
+              "description": "The port number on which the nodes are listening for SSH connections.",
+              "type": "integer"
+            },
+            "user": {
+              "description": "The user for accessing the nodes via SSH. This user requires sudo elevation privileges on the nodes.",
+              "type": "string"
+            }
+          },
+          "additionalProperties": false
+        }
+      },
+      "required": [
+        "expected_count",
+        "nodes"
+      ],
+      "additionalProperties": false
+    },
+    "features": {
+      "description": "Feature configuration",
+      "type": [
+        "object",
+        "null"
+      ],
+      "properties": {
+        "package_manager": {
+          "description": "The PackageManager feature configuration.",
+          "type": [
+            "object",
+            "null"
+          ],
+          "properties": {
+            "enabled": {
+              "description": "Whether the package manager add-on should be enabled.",
+              "type": "boolean",
+              "deprecated": true
+            }
+          },
+          "additionalProperties": false,
+          "deprecated": true
+        }
+      },
+      "additionalProperties": false,
+      "deprecated": true
+    },
+    "ingress": {
+      "description": "Ingress nodes of the cluster",
+      "type": "object",
+      "properties": {
+        "expected_count": {
+          "description": "Number of nodes.",
+          "type": "integer"
+        },
+        "nodes": {
+          "description": "List of nodes.",
+          "type": [
+            "array",
+            "null"
+          ],
+          "items": {
+            "type": "object",
+            "properties": {
+              "host": {
+                "description": "The hostname of the node. The hostname is verified in the validation phase of the installation.",
+                "type": "string"
+              },
+              "internalip": {
+                "description": "The internal (or private) IP address of the node. If set, this IP will be used when configuring cluster components.",
+                "type": "string"
+              },
+              "ip": {
+                "description": "The IP address of the node. This is the IP address that will be used to connect to the node over SSH.",
+                "type": "string"
+              },
+              "kubelet": {
+                "description": "Kubelet configuration applied to this node. If a node is repeated for multiple roles, the overrides cannot be different.",
+                "type": "object",
+                "properties": {
+                  "option_overrides": {
+                    "description": "Listing of option overrides that are to be applied to the Kubelet configurations. This is an advanced feature that can prevent the Kubelet from starting up if invalid configuration is provided.",
+                    "type": [
+                      "object",
+                      "null"
+                    ],
+                    "additionalProperties": {
+                      "type": "string"
+                    }
+                  }
+                },
+                "additionalProperties": false
+              },
+              "labels": {
+                "description": "Labels to add when installing the node in the cluster. If a node is defined under multiple roles, the labels for that node will be merged. If a label is repeated for the same node, only one will be used in this order: etcd,master,worker,ingress,storage roles where 'storage' has the highest precedence. It is recommended to use reverse-DNS notation to avoid collision with other labels.",
+                "type": [
+                  "object",
+                  "null"
+                ],
+                "additionalProperties": {
+                  "type": "string"
+                }
+              },
+              "ssh": {
+                "description": "SSH configuration for accessing this node, overriding the SSH configuration of the cluster and of the node's groups.",
+                "type": [
+                  "object",
+                  "null"
+                ],
+                "properties": {
+                  "ssh_key": {
+                    "description": "The absolute path of the SSH key that should be used for accessing the nodes via SSH.",
+                    "type": "string"
+                  },
+                  "ssh_port": {
+                    "description": "The port number on which the nodes are listening for SSH connections.",
+                    "type": "integer"
+                  },
+                  "user": {
+                    "description": "The user for accessing the nodes via SSH. This user requires sudo elevation privileges on the nodes.",
+                    "type": "string"
+                  }
+                },
+                "additionalProperties": false
+              },
+              "taints": {
+                "description": "Taints to add when installing the node in the cluster. If a node is defined under multiple roles, the taints for that node will be merged. If a taint is repeated for the same node, only one will be used in this order: etcd,master,worker,ingress,storage roles where 'storage' has the highest precedence.",
+                "type": [
+                  "array",
+                  "null"
+                ],
+                "items": {
+                  "type": "object",
+                  "properties": {
+                    "effect": {
+                      "description": "Effect for the taint",
+                      "type": "string",
+                      "enum": [
+                        "NoSchedule",
+                        "PreferNoSchedule",
+                        "NoExecute",
+                        ""
+                      ]
+                    },
+                    "key": {
+                      "description": "Key for the taint",
+                      "type": "string"
+                    },
+                    "value": {
+                      "description": "Value for the taint",
+                      "type": "string"
+                    }
+                  },
+                  "additionalProperties": false
+                }
+              }
+            },
+            "required": [
+              "host",
+              "ip"
+            ],
+            "additionalProperties": false
+          }
+        },
+        "ssh": {
+          "description": "SSH configuration for accessing the nodes, overriding the cluster's SSH configuration.",
+          "type": [
+            "object",
+            "null"
+          ],
+          "properties": {
+            "ssh_key": {
+              "description": "The absolute path of the SSH key that should be used for accessing the nodes via SSH.",
+              "type": "string"
+            },
+            "ssh_port": {
+              "description": "The port number on which the nodes are listening for SSH connections.",
+              "type": "integer"
+            },
+            "user": {
+              "description": "The user for accessing the nodes via SSH. This user requires sudo elevation privileges on the nodes.",
+              "type": "string"
+            }
+          },
+          "additionalProperties": false
+        }
+      },
+      "required": [
+        "expected_count",
+        "nodes"
+      ],
+      "additionalProperties": false
+    },
+    "master": {
+      "description": "Master nodes of the cluster",
+      "type": "object",
+      "properties": {
+        "expected_count": {
+          "description": "Number of master nodes that are part of the cluster.",
+          "type": "integer"
+        },
+        "load_balanced_fqdn": {
+          "description": "The FQDN of the load balancer that is fronting multiple master nodes. In the case where there is only one master node, this can be set to the IP address of the master node.",
+          "type": "string"
+        },
+        "load_balanced_short_name": {
+          "description": "The short name of the load balancer that is fronting multiple master nodes. In the case where there is only one master node, this can be set to the IP address of the master nodes.",
+          "type": "string"
+        },
+        "nodes": {
+          "description": "List of master nodes that are part of the cluster.",
+          "type": [
+            "array",
+            "null"
+          ],
+          "items": {
+            "type": "object",
+            "properties": {
+              "host": {
+                "description": "The hostname of the node. The hostname is verified in the validation phase of the installation.",
+                "type": "string"
+              },
+              "internalip": {
+                "description": "The internal (or private) IP address of the node. If set, this IP will be used when configuring cluster components.",
+                "type": "string"
+              },
+              "ip": {
+                "description": "The IP address of the node. This is the IP address that will be used to connect to the node over SSH.",
+                "type": "string"
+              },
+              "kubelet": {
+                "description": "Kubelet configuration applied to this node. If a node is repeated for multiple roles, the overrides cannot be different.",
+                "type": "object",
+                "properties": {
+                  "option_overrides": {
+                    "description": "Listing of option overrides that are to be applied to the Kubelet configurations. This is an advanced feature that can prevent the Kubelet from starting up if invalid configuration is provided.",
+                    "type": [
+                      "object",
+                      "null"
+                    ],
+                    "additionalProperties": {
+                      "type": "string"
+                    }
+                  }
+                },
+                "additionalProperties": false
+              },
+              "labels": {
+                "description": "Labels to add when installing the node in the cluster. If a node is defined under multiple roles, the labels for that node will be merged. If a label is repeated for the same node, only one will be used in this order: etcd,master,worker,ingress,storage roles where 'storage' has the highest precedence. It is recommended to use reverse-DNS notation to avoid collision with other labels.",
+                "type": [
+                  "object",
+                  "null"
+                ],
+                "additionalProperties": {
+                  "type": "string"
+                }
+              },
+              "ssh": {
+                "description": "SSH configuration for accessing this node, overriding the SSH configuration of the cluster and of the node's groups.",
+                "type": [
+                  "object",
+                  "null"
+                ],
+                "properties": {
+                  "ssh_key": {
+                    "description": "The absolute path of the SSH key that should be used for accessing the nodes via SSH.",
+                    "type": "string"
+                  },
+                  "ssh_port": {
+                    "description": "The port number on which the nodes are listening for SSH connections.",
+                    "type": "integer"
+                  },
+                  "user": {
+                    "description": "The user for accessing the nodes via SSH. This user requires sudo elevation privileges on the nodes.",
+                    "type": "string"
+                  }
+                },
+                "additionalProperties": false
+              },
+              "taints": {
+                "description": "Taints to add when installing the node in the cluster. If a node is defined under multiple roles, the taints for that node will be merged. If a taint is repeated for the same node, only one will be used in this order: etcd,master,worker,ingress,storage roles where 'storage' has the highest precedence.",
+                "type": [
+                  "array",
+                  "null"
+                ],
+                "items": {
+                  "type": "object",
+                  "properties": {
+                    "effect": {
+                      "description": "Effect for the taint",
+                      "type": "string",
+                      "enum": [
+                        "NoSchedule",
+                        "PreferNoSchedule",
+                        "NoExecute",
+                        ""
+                      ]
+                    },
+                    "key": {
+                      "description": "Key for the taint",
+                      "type": "string"
+                    },
+                    "value": {
+                      "description": "Value for the taint",
+                      "type": "string"
+                    }
+                  },
+                  "additionalProperties": false
+                }
+              }
+            },
+            "required": [
+              "host",
+              "ip"
+            ],
+            "additionalProperties": false
+          }
+        },
+        "ssh": {
+          "description": "SSH configuration for accessing the master nodes, overriding the cluster's SSH configuration.",
+          "type": [
+            "object",
+            "null"
+          ],
+          "properties": {
+            "ssh_key": {
+              "description": "The absolute path of the SSH key that should be used for accessing the nodes via SSH.",
+              "type": "string"
+            },
+            "ssh_port": {
+              "description": "The port number on which the nodes are listening for SSH connections.",
+              "type": "integer"
+            },
+            "user": {
+              "description": "The user for accessing the nodes via SSH. This user requires sudo elevation privileges on the nodes.",
+              "type": "string"
+            }
+          },
+          "additionalProperties": false
+        }
+      },
+      "required": [
+        "expected_count",
+        "load_balanced_fqdn",
+        "load_balanced_short_name",
+        "nodes"
+      ],
+      "additionalProperties": false
+    },
+    "nfs": {
+      "description": "NFS volumes of the cluster.",
+      "type": [
+        "object",
+        "null"
+      ],
+      "properties": {
+        "nfs_volume": {
+          "description": "List of NFS volumes that should be attached to the cluster during the installation.",
+          "type": [
+            "array",
+            "null"
+          ],
+          "items": {
+            "type": "object",
+            "properties": {
+              "mount_path": {
+                "description": "The path where the NFS volume should be mounted.",
+                "type": "string"
+              },
+              "nfs_host": {
+                "description": "The hostname or IP of the NFS volume.",
+                "type": "string"
+              }
+            },
+            "required": [
+              "nfs_host",
+              "mount_path"
+            ],
+            "additionalProperties": false
+          }
+        }
+      },
+      "additionalProperties": false
+    },
+    "storage": {
+      "description": "Storage nodes of the cluster.",
+      "type": "object",
+      "properties": {
+        "expected_count": {
+          "description": "Number of nodes.",
+          "type": "integer"
+        },
+        "nodes": {
+          "description": "List of nodes.",
+          "type": [
+            "array",
+            "null"
+          ],
+          "items": {
+            "type": "object",
+            "properties": {
+              "host": {
+                "description": "The hostname of the node. The hostname is verified in the validation phase of the installation.",
+                "type": "string"
+              },
+              "internalip": {
+                "description": "The internal (or private) IP address of the node. If set, this IP will be used when configuring cluster components.",
+                "type": "string"
+              },
+              "ip": {
+                "description": "The IP address of the node. This is the IP address that will be used to connect to the node over SSH.",
+                "type": "string"
+              },
+              "kubelet": {
+                "description": "Kubelet configuration applied to this node. If a node is repeated for multiple roles, the overrides cannot be different.",
+                "type": "object",
+                "properties": {
+                  "option_overrides": {
+                    "description": "Listing of option overrides that are to be applied to the Kubelet configurations. This is an advanced feature that can prevent the Kubelet from starting up if invalid configuration is provided.",
+                    "type": [
+                      "object",
+                      "null"
+                    ],
+                    "additionalProperties": {
+                      "type": "string"
+                    }
+                  }
+                },
+                "additionalProperties": false
+              },
+              "labels": {
+                "description": "Labels to add when installing the node in the cluster. If a node is defined under multiple roles, the labels for that node will be merged. If a label is repeated for the same node, only one will be used in this order: etcd,master,worker,ingress,storage roles where 'storage' has the highest precedence. It is recommended to use reverse-DNS notation to avoid collision with other labels.",
+                "type": [
+                  "object",
+                  "null"
+                ],
+                "additionalProperties": {
+                  "type": "string"
+                }
+              },
+              "ssh": {
+                "description": "SSH configuration for accessing this node, overriding the SSH configuration of the cluster and of the node's groups.",
+                "type": [
+                  "object",
+                  "null"
+                ],
+                "properties": {
+                  "ssh_key": {
+                    "description": "The absolute path of the SSH key that should be used for accessing the nodes via SSH.",
+                    "type": "string"
+                  },
+                  "ssh_port": {
+                    "description": "The port number on which the nodes are listening for SSH connections.",
+                    "type": "integer"
+                  },
+                  "user": {
+                    "description": "The user for accessing the nodes via SSH. This user requires sudo elevation privileges on the nodes.",
+                    "type": "string"
+                  }
+                },
+                "additionalProperties": false
+              },
+              "taints": {
+                "description": "Taints to add when installing the node in the cluster. If a node is defined under multiple roles, the taints for that node will be merged. If a taint is repeated for the same node, only one will be used in this order: etcd,master,worker,ingress,storage roles where 'storage' has the highest precedence.",
+                "type": [
+                  "array",
+                  "null"
+                ],
+                "items": {
+                  "type": "object",
+                  "properties": {
+                    "effect": {
+                      "description": "Effect for the taint",
+                      "type": "string",
+                      "enum": [
+                        "NoSchedule",
+                        "PreferNoSchedule",
+                        "NoExecute",
+                        ""
+                      ]
+                    },
+                    "key": {
+                      "description": "Key for the taint",
+                      "type": "string"
+                    },
+                    "value": {
+                      "description": "Value for the taint",
+                      "type": "string"
+                    }
+                  },
+                  "additionalProperties": false
+                }
+              }
+            },
+            "required": [
+              "host",
+              "ip"
+            ],
+            "additionalProperties": false
+          }
+        },
+        "ssh": {
+          "description": "SSH configuration for accessing the nodes, overriding the cluster's SSH configuration.",
+          "type": [
+            "object",
+            "null"
+          ],
+          "properties": {
+            "ssh_key": {
+              "description": "The absolute path of the SSH key that should be used for accessing the nodes via SSH.",
+              "type": "string"
+            },
+            "ssh_port": {
+              "description": "The port number on which the nodes are listening for SSH connections.",
+              "type": "integer"
+            },
+            "user": {
+              "description": "The user for accessing the nodes via SSH. This user requires sudo elevation privileges on the nodes.",
+              "type": "string"
+            }
+          },
+          "additionalProperties": false
+        }
+      },
+      "required": [
+        "expected_count",
+        "nodes"
+      ],
+      "additionalProperties": false
+    },
+    "worker": {
+      "description": "Worker nodes of the cluster",
+      "type": "object",
+      "properties": {
+        "expected_count": {
+          "description": "Number of nodes.",
+          "type": "integer"
+        },
+        "nodes": {
+          "description": "List of nodes.",
+          "type": [
+            "array",
+            "null"
+          ],
+          "items": {
+            "type": "object",
+            "properties": {
+              "host": {
+                "description": "The hostname of the node. The hostname is verified in the validation phase of the installation.",
+                "type": "string"
+              },
+              "internalip": {
+                "description": "The internal (or private) IP address of the node. If set, this IP will be used when configuring cluster components.",
+                "type": "string"
+              },
+              "ip": {
+                "description": "The IP address of the node. This is the IP address that will be used to connect to the node over SSH.",
+                "type": "string"
+              },
+              "kubelet": {
+                "description": "Kubelet configuration applied to this node. If a node is repeated for multiple roles, the overrides cannot be different.",
+                "type": "object",
+                "properties": {
+                  "option_overrides": {
+                    "description": "Listing of option overrides that are to be applied to the Kubelet configurations. This is an advanced feature that can prevent the Kubelet from starting up if invalid configuration is provided.",
+                    "type": [
+                      "object",
+                      "null"
+                    ],
+                    "additionalProperties": {
+                      "type": "string"
+                    }
+                  }
+                },
+                "additionalProperties": false
+              },
+              "labels": {
+                "description": "Labels to add when installing the node in the cluster. If a node is defined under multiple roles, the labels for that node will be merged. If a label is repeated for the same node, only one will be used in this order: etcd,master,worker,ingress,storage roles where 'storage' has the highest precedence. It is recommended to use reverse-DNS notation to avoid collision with other labels.",
+                "type": [
+                  "object",
+                  "null"
+                ],
+                "additionalProperties": {
+                  "type": "string"
+                }
+              },
+              "ssh": {
+                "description": "SSH configuration for accessing this node, overriding the SSH configuration of the cluster and of the node's groups.",
+                "type": [
+                  "object",
+                  "null"
+                ],
+                "properties": {
+                  "ssh_key": {
+                    "description": "The absolute path of the SSH key that should be used for accessing the nodes via SSH.",
+                    "type": "string"
+                  },
+                  "ssh_port": {
+                    "description": "The port number on which the nodes are listening for SSH connections.",
+                    "type": "integer"
+                  },
+                  "user": {
+                    "description": "The user for accessing the nodes via SSH. This user requires sudo elevation privileges on the nodes.",
+                    "type": "string"
+                  }
+                },
+                "additionalProperties": false
+              },
+              "taints": {
+                "description": "Taints to add when installing the node in the cluster. If a node is defined under multiple roles, the taints for that node will be merged. If a taint is repeated for the same node, only one will be used in this order: etcd,master,worker,ingress,storage roles where 'storage' has the highest precedence.",
+                "type": [
+                  "array",
+                  "null"
+                ],
+                "items": {
+                  "type": "object",
+                  "properties": {
+                    "effect": {
+                      "description": "Effect for the taint",
+                      "type": "string",
+                      "enum": [
+                        "NoSchedule",
+                        "PreferNoSchedule",
+                        "NoExecute",
+                        ""
+                      ]
+                    },
+                    "key": {
+                      "description": "Key for the taint",
+                      "type": "string"
+                    },
+                    "value": {
+                      "description": "Value for the taint",
+                      "type": "string"
+                    }
+                  },
+                  "additionalProperties": false
+                }
+              }
+            },
+            "required": [
+              "host",
+              "ip"
+            ],
+            "additionalProperties": false
+          }
+        },
+        "ssh": {
+          "description": "SSH configuration for accessing the nodes, overriding the cluster's SSH configuration.",
+          "type": [
+            "object",
+            "null"
+          ],
+          "properties": {
+            "ssh_key": {
+              "description": "The absolute path of the SSH key that should be used for accessing the nodes via SSH.",
+              "type": "string"
+            },
+            "ssh_port": {
+              "description": "The port number on which the nodes are listening for SSH connections.",
+              "type": "integer"
+            },
+            "user": {
+              "description": "The user for accessing the nodes via SSH. This user requires sudo elevation privileges on the nodes.",
+              "type": "string"
+            }
+          },
+          "additionalProperties": false
+        }
+      },
+      "required": [
+        "expected_count",
+        "nodes"
+      ],
+      "additionalProperties": false
+    }
+  },
+  "required": [
+    "cluster",
+    "etcd",
+    "master",
+    "worker"
+  ],
+  "additionalProperties": false
+}
+`
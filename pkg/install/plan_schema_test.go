@@ -0,0 +1,57 @@
+package install
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type jsonSchemaNode struct {
+	Properties map[string]*jsonSchemaNode `json:"properties"`
+	Items      *jsonSchemaNode            `json:"items"`
+}
+
+// Verify that the generated schema is up to date with the plan types.
+// Run "make docs/update-plan-file-schema.json" to regenerate it.
+func TestPlanJSONSchemaCoversPlan(t *testing.T) {
+	root := &jsonSchemaNode{}
+	if err := json.Unmarshal([]byte(PlanJSONSchema), root); err != nil {
+		t.Fatalf("the plan JSON schema is not valid JSON: %v", err)
+	}
+	checkSchemaCoversType(t, "", reflect.TypeOf(Plan{}), root)
+}
+
+func checkSchemaCoversType(t *testing.T, path string, typ reflect.Type, s *jsonSchemaNode) {
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		prop, ok := s.Properties[name]
+		if !ok {
+			t.Errorf("the plan JSON schema is missing property %q", fieldPath)
+			continue
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Slice {
+			ft = ft.Elem()
+			prop = prop.Items
+			if prop == nil {
+				t.Errorf("the plan JSON schema is missing the items of property %q", fieldPath)
+				continue
+			}
+		}
+		if ft.Kind() == reflect.Struct {
+			checkSchemaCoversType(t, fieldPath, ft, prop)
+		}
+	}
+}
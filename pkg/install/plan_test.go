@@ -48,7 +48,7 @@ func TestWritePlanTemplate(t *testing.T) {
 			t.Fatalf("error creating temp dir: %v", err)
 		}
 		file := filepath.Join(tmp, "kismatic-cluster.yaml")
-		fp := &FilePlanner{file}
+		fp := &FilePlanner{File: file}
 		if err = WritePlanTemplate(test.template, fp); err != nil {
 			t.Fatalf("error writing plan template: %v", err)
 		}
@@ -68,6 +68,34 @@ func TestWritePlanTemplate(t *testing.T) {
 	}
 }
 
+func TestFilePlannerWriteReplacesPlanFile(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-write-plan")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	file := filepath.Join(tmp, "kismatic-cluster.yaml")
+	if err = ioutil.WriteFile(file, []byte("old plan"), 0600); err != nil {
+		t.Fatalf("error writing plan file: %v", err)
+	}
+	p := &Plan{}
+	p.Cluster.Name = "replaced"
+	if err = (&FilePlanner{File: file}).Write(p); err != nil {
+		t.Fatalf("error writing plan file: %v", err)
+	}
+	read, err := (&FilePlanner{File: file}).Read()
+	if err != nil || read.Cluster.Name != "replaced" {
+		t.Errorf("expected the plan file to be replaced, but got %+v (%v)", read, err)
+	}
+	fi, err := os.Stat(file)
+	if err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("expected the permissions of the plan file to be kept, but got %v (%v)", fi.Mode(), err)
+	}
+	if files, _ := ioutil.ReadDir(tmp); len(files) != 1 {
+		t.Errorf("expected only the plan file to be left in the directory, but found %d files", len(files))
+	}
+}
+
 func TestReadWithDeprecated(t *testing.T) {
 	pm := &DeprecatedPackageManager{
 		Enabled: true,
@@ -188,7 +216,7 @@ func TestReadDeprecatedDashboard(t *testing.T) {
 			t.Fatalf("error writing plan file")
 		}
 
-		planner := FilePlanner{file}
+		planner := FilePlanner{File: file}
 		plan, err := planner.Read()
 		if err != nil {
 			t.Fatalf("error reading plan file")
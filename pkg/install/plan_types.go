@@ -59,6 +59,10 @@ func taintEffects() []string {
 
 // Plan is the installation plan that the user intends to execute
 type Plan struct {
+	// The schema version of the plan file. Plan files without a version were
+	// written for schema version v1.
+	// +default=v2
+	APIVersion string `yaml:"apiVersion"`
 	// Kubernetes cluster configuration
 	// +required
 	Cluster Cluster
@@ -103,6 +107,8 @@ type Cluster struct {
 	Version string
 	// The password for the admin user.
 	// If provided, ABAC will be enabled in the cluster.
+	// A reference to the password can be provided instead, using `env:VARIABLE`,
+	// `file:/path/to/file` or `exec:command`.
 	// This field will be removed completely in a future release.
 	// +deprecated
 	AdminPassword string `yaml:"admin_password,omitempty"`
@@ -220,12 +226,94 @@ type SSHConfig struct {
 	// +required
 	User string
 	// The absolute path of the SSH key that should be used for accessing the
-	// cluster nodes via SSH.
-	// +required
+	// cluster nodes via SSH. The key can be protected with a passphrase, which is
+	// read from the KISMATIC_SSH_KEY_PASSPHRASE environment variable, or prompted for.
+	// Required unless use_agent is set.
 	Key string `yaml:"ssh_key"`
 	// The port number on which cluster nodes are listening for SSH connections.
 	// +required
 	Port int `yaml:"ssh_port"`
+	// Set to true to authenticate with the keys of the running ssh-agent,
+	// found with the SSH_AUTH_SOCK environment variable.
+	UseAgent bool `yaml:"use_agent,omitempty"`
+	// The bastion host through which the cluster nodes are accessed via SSH,
+	// when they are not directly reachable.
+	Bastion *SSHBastion `yaml:"bastion,omitempty"`
+}
+
+// SSHBastion describes the bastion host used for accessing the nodes via SSH
+type SSHBastion struct {
+	// The hostname or IP address of the bastion host.
+	// +required
+	Host string
+	// The user for accessing the bastion host via SSH.
+	// Defaults to the user for accessing the node.
+	User string `yaml:"user,omitempty"`
+	// The absolute path of the SSH key that should be used for accessing the bastion host.
+	// Defaults to the SSH key for accessing the node.
+	Key string `yaml:"ssh_key,omitempty"`
+	// The port number on which the bastion host is listening for SSH connections.
+	// +default=22
+	Port int `yaml:"ssh_port,omitempty"`
+}
+
+// sshBastion returns the bastion used for accessing nodes with this
+// configuration, or nil if the nodes are accessed directly
+func (s SSHConfig) sshBastion() *ssh.Bastion {
+	if s.Bastion == nil {
+		return nil
+	}
+	b := &ssh.Bastion{
+		Host: s.Bastion.Host,
+		Port: s.Bastion.Port,
+		User: s.Bastion.User,
+		Auth: ssh.Auth{Key: s.Bastion.Key, UseAgent: s.UseAgent},
+	}
+	if b.Port == 0 {
+		b.Port = 22
+	}
+	if b.User == "" {
+		b.User = s.User
+	}
+	if b.Key == "" {
+		b.Key = s.Key
+	}
+	return b
+}
+
+// sshAuth returns the credentials used for accessing nodes with this configuration
+func (s SSHConfig) sshAuth() ssh.Auth {
+	return ssh.Auth{Key: s.Key, UseAgent: s.UseAgent}
+}
+
+// SSHOverrides override the cluster's SSH configuration for a group of nodes,
+// or for a single node. Fields that are not set are inherited.
+type SSHOverrides struct {
+	// The user for accessing the nodes via SSH.
+	// This user requires sudo elevation privileges on the nodes.
+	User string `yaml:"user,omitempty"`
+	// The absolute path of the SSH key that should be used for accessing the
+	// nodes via SSH.
+	Key string `yaml:"ssh_key,omitempty"`
+	// The port number on which the nodes are listening for SSH connections.
+	Port int `yaml:"ssh_port,omitempty"`
+}
+
+// apply returns the SSH configuration with the overrides applied
+func (o *SSHOverrides) apply(s SSHConfig) SSHConfig {
+	if o == nil {
+		return s
+	}
+	if o.User != "" {
+		s.User = o.User
+	}
+	if o.Key != "" {
+		s.Key = o.Key
+	}
+	if o.Port != 0 {
+		s.Port = o.Port
+	}
+	return s
 }
 
 // CloudProvider controls the Kubernetes cloud providers feature
@@ -234,6 +322,8 @@ type CloudProvider struct {
 	// +options=aws,azure,cloudstack,fake,gce,mesos,openstack,ovirt,photon,rackspace,vsphere
 	Provider string
 	// Path to the cloud provider config file. This will be copied to all the machines in the cluster
+	// A reference to the contents of the config file can be provided instead, using `env:VARIABLE`,
+	// `file:/path/to/file` or `exec:command`.
 	Config string
 }
 
@@ -348,6 +438,8 @@ type DockerRegistry struct {
 	Username string
 	// The password that should be used when connecting to a registry that has authentication enabled.
 	// Otherwise leave blank for unauthenticated access.
+	// A reference to the password can be provided instead, using `env:VARIABLE`,
+	// `file:/path/to/file` or `exec:command`.
 	Password string
 }
 
@@ -433,6 +525,8 @@ type CalicoOptions struct {
 // The WeaveOptions that can be configured for the Weave CNI provider.
 type WeaveOptions struct {
 	// The password to use for network traffic encryption.
+	// A reference to the password can be provided instead, using `env:VARIABLE`,
+	// `file:/path/to/file` or `exec:command`.
 	Password string
 }
 
@@ -587,6 +681,8 @@ type MasterNodeGroup struct {
 	// List of master nodes that are part of the cluster.
 	// +required
 	Nodes []Node
+	// SSH configuration for accessing the master nodes, overriding the cluster's SSH configuration.
+	SSH *SSHOverrides `yaml:"ssh,omitempty"`
 }
 
 // A NodeGroup is a collection of nodes
@@ -597,6 +693,8 @@ type NodeGroup struct {
 	// List of nodes.
 	// +required
 	Nodes []Node
+	// SSH configuration for accessing the nodes, overriding the cluster's SSH configuration.
+	SSH *SSHOverrides `yaml:"ssh,omitempty"`
 }
 
 // An OptionalNodeGroup is a collection of nodes that can be empty
@@ -629,6 +727,9 @@ type Node struct {
 	// Kubelet configuration applied to this node.
 	// If a node is repeated for multiple roles, the overrides cannot be different.
 	KubeletOptions KubeletOptions `yaml:"kubelet,omitempty"`
+	// SSH configuration for accessing this node, overriding the SSH configuration
+	// of the cluster and of the node's groups.
+	SSH *SSHOverrides `yaml:"ssh,omitempty"`
 }
 
 // Taint for nodes
@@ -824,7 +925,49 @@ func (p *Plan) GetSSHConnection(host string) (*SSHConnection, error) {
 		return nil, notFoundErr
 	}
 
-	return &SSHConnection{&p.Cluster.SSH, foundNode}, nil
+	sshConfig := p.NodeSSHConfig(*foundNode)
+	return &SSHConnection{&sshConfig, foundNode}, nil
+}
+
+// NodeSSHConfig returns the SSH configuration for accessing the node.
+// The overrides of the node take precedence over the overrides of the first
+// group that the node belongs to, which take precedence over the cluster's SSH configuration.
+func (p *Plan) NodeSSHConfig(node Node) SSHConfig {
+	s := p.Cluster.SSH
+	for _, g := range p.nodeGroupSSHOverrides() {
+		if hasHost(g.nodes, node.Host) {
+			s = g.overrides.apply(s)
+			break
+		}
+	}
+	return node.SSH.apply(s)
+}
+
+type nodeGroupSSHOverrides struct {
+	name      string
+	nodes     []Node
+	overrides *SSHOverrides
+}
+
+// nodeGroupSSHOverrides returns the SSH overrides of each node group,
+// in the same order as getAllNodes
+func (p *Plan) nodeGroupSSHOverrides() []nodeGroupSSHOverrides {
+	return []nodeGroupSSHOverrides{
+		{"etcd", p.Etcd.Nodes, p.Etcd.SSH},
+		{"master", p.Master.Nodes, p.Master.SSH},
+		{"worker", p.Worker.Nodes, p.Worker.SSH},
+		{"ingress", p.Ingress.Nodes, p.Ingress.SSH},
+		{"storage", p.Storage.Nodes, p.Storage.SSH},
+	}
+}
+
+func hasHost(nodes []Node, host string) bool {
+	for _, n := range nodes {
+		if n.Host == host {
+			return true
+		}
+	}
+	return false
 }
 
 // GetSSHClient is a convience method that calls GetSSHConnection and returns an SSH client with the result
@@ -833,7 +976,7 @@ func (p *Plan) GetSSHClient(host string) (ssh.Client, error) {
 	if err != nil {
 		return nil, err
 	}
-	client, err := ssh.NewClient(con.Node.IP, con.SSHConfig.Port, con.SSHConfig.User, con.SSHConfig.Key)
+	client, err := con.NewClient()
 	if err != nil {
 		return nil, fmt.Errorf("error creating SSH client for host %s: %v", host, err)
 	}
@@ -841,6 +984,12 @@ func (p *Plan) GetSSHClient(host string) (ssh.Client, error) {
 	return client, nil
 }
 
+// NewClient returns an SSH client for the node, that connects
+// through the bastion if one is configured
+func (con *SSHConnection) NewClient() (ssh.Client, error) {
+	return ssh.NewClientWithBastion(con.Node.IP, con.SSHConfig.Port, con.SSHConfig.User, con.SSHConfig.sshAuth(), con.SSHConfig.sshBastion())
+}
+
 func firstIfItExists(nodes []Node) *Node {
 	if len(nodes) > 0 {
 		return &nodes[0]
@@ -900,13 +1049,7 @@ func (p Plan) Versions() map[string]string {
 	if p.Cluster.Version != "" {
 		kubernetesVersion = p.Cluster.Version
 	}
-	versions := make(map[string]string)
-	versions["kube_proxy"] = kubernetesVersion
-	versions["kube_controller_manager"] = kubernetesVersion
-	versions["kube_scheduler"] = kubernetesVersion
-	versions["kube_apiserver"] = kubernetesVersion
-
-	return versions
+	return imageVersions(kubernetesVersion)
 }
 
 // returns a list of specs for all the certs that are required for the node
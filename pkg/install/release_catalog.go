@@ -0,0 +1,210 @@
+package install
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ReleaseCatalogFile is the name of the release catalog that is read from
+// the directory of the kismatic binary, such as the offline bundle
+const ReleaseCatalogFile = "kubernetes-releases.yaml"
+
+// The sources of the Kubernetes version of a cluster
+const (
+	// KubernetesVersionSourcePlan is used when the version is set in the plan file
+	KubernetesVersionSourcePlan = "plan"
+	// KubernetesVersionSourceReleaseCatalog is used when the version is the
+	// latest stable release listed in the release catalog
+	KubernetesVersionSourceReleaseCatalog = "release-catalog"
+	// KubernetesVersionSourceOnline is used when the version is the latest
+	// stable release published online
+	KubernetesVersionSourceOnline = "online"
+	// KubernetesVersionSourceDefault is used when the version is the tested
+	// release of the default minor version
+	KubernetesVersionSourceDefault = "default"
+)
+
+const kubernetesVersionRecordFile = "kubernetes-version.yaml"
+
+// ReleaseCatalog lists the latest stable release of Kubernetes minor versions,
+// for example:
+//
+//	kubernetes:
+//	  v1.9: v1.9.8
+//	  v1.10: v1.10.5
+type ReleaseCatalog struct {
+	Kubernetes map[string]string
+}
+
+// ReleaseResolutionOptions control how the Kubernetes version of the cluster
+// is chosen when the plan file does not set one, and how the version set in
+// the plan file is validated.
+type ReleaseResolutionOptions struct {
+	// The release catalog file. No catalog is used when empty.
+	CatalogFile string
+	// When set and the file does not exist, no catalog is used instead of
+	// returning an error
+	CatalogFileOptional bool
+	// Look up the latest stable releases that are not listed in the catalog
+	// online. The lookup is never done for disconnected installations.
+	OnlineLookup bool
+}
+
+// KubernetesVersionResolution records how the Kubernetes version of the cluster was chosen
+type KubernetesVersionResolution struct {
+	Version string `yaml:"version"`
+	Source  string `yaml:"source"`
+	Reason  string `yaml:"reason"`
+}
+
+type releaseResolver struct {
+	opts    ReleaseResolutionOptions
+	catalog ReleaseCatalog
+
+	mu sync.Mutex
+	// the latest stable releases that were resolved, by minor version
+	latest map[string]KubernetesVersionResolution
+	// the version chosen for a plan file that does not set one
+	defaulted *KubernetesVersionResolution
+}
+
+var kubernetesReleaseResolver = &releaseResolver{}
+
+// SetReleaseResolutionOptions sets how Kubernetes releases are resolved
+func SetReleaseResolutionOptions(opts ReleaseResolutionOptions) error {
+	r := &releaseResolver{opts: opts}
+	if opts.CatalogFile != "" {
+		if abs, err := filepath.Abs(opts.CatalogFile); err == nil {
+			r.opts.CatalogFile = abs
+		}
+		c, err := ReadReleaseCatalog(r.opts.CatalogFile)
+		if err != nil && !(opts.CatalogFileOptional && os.IsNotExist(err)) {
+			return err
+		}
+		if err != nil {
+			r.opts.CatalogFile = ""
+		}
+		r.catalog = c
+	}
+	kubernetesReleaseResolver = r
+	return nil
+}
+
+// ReadReleaseCatalog reads the release catalog file. Releases listed for minor
+// versions that are not supported are not used.
+func ReadReleaseCatalog(file string) (ReleaseCatalog, error) {
+	c := ReleaseCatalog{}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, err
+		}
+		return c, fmt.Errorf("error reading release catalog %q: %v", file, err)
+	}
+	if err = yaml.Unmarshal(b, &c); err != nil {
+		return c, fmt.Errorf("error unmarshalling release catalog %q: %v", file, err)
+	}
+	for minor, version := range c.Kubernetes {
+		if m, err := kubernetesMinorVersion(version); err != nil || m != minor {
+			return c, fmt.Errorf("invalid release catalog %q: %q is not a release of Kubernetes %s", file, version, minor)
+		}
+	}
+	return c, nil
+}
+
+// latestStable returns the latest stable release of the minor version, and
+// whether it is known. The release is looked up online at most once.
+func (r *releaseResolver) latestStable(release KubernetesRelease, disconnected bool) (KubernetesVersionResolution, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if res, ok := r.latest[release.Minor]; ok {
+		return res, res.Source != KubernetesVersionSourceDefault
+	}
+	res := r.resolveLatestStable(release, disconnected)
+	if r.latest == nil {
+		r.latest = map[string]KubernetesVersionResolution{}
+	}
+	// the release is not looked up for disconnected installations, so that
+	// a connected one can still look it up online
+	if res.Source != KubernetesVersionSourceDefault || !disconnected {
+		r.latest[release.Minor] = res
+	}
+	return res, res.Source != KubernetesVersionSourceDefault
+}
+
+func (r *releaseResolver) resolveLatestStable(release KubernetesRelease, disconnected bool) KubernetesVersionResolution {
+	if v, ok := r.catalog.Kubernetes[release.Minor]; ok {
+		return KubernetesVersionResolution{
+			Version: v,
+			Source:  KubernetesVersionSourceReleaseCatalog,
+			Reason:  fmt.Sprintf("latest stable release of Kubernetes %s listed in the release catalog %q", release.Minor, r.opts.CatalogFile),
+		}
+	}
+	var reason string
+	switch {
+	case disconnected:
+		reason = "the installation is disconnected"
+	case !r.opts.OnlineLookup:
+		reason = "online release lookup is disabled"
+	default:
+		_, latest, err := kubernetesLatestStableVersion(release)
+		if err == nil {
+			return KubernetesVersionResolution{
+				Version: latest,
+				Source:  KubernetesVersionSourceOnline,
+				Reason:  fmt.Sprintf("latest stable release of Kubernetes %s published at %s", release.Minor, kubernetesReleaseURL(release.Minor)),
+			}
+		}
+		reason = fmt.Sprintf("online release lookup failed: %v", err)
+	}
+	if r.opts.CatalogFile == "" {
+		reason = "no release catalog was found and " + reason
+	} else {
+		reason = fmt.Sprintf("Kubernetes %s is not listed in the release catalog %q and %s", release.Minor, r.opts.CatalogFile, reason)
+	}
+	return KubernetesVersionResolution{
+		Version: release.Version,
+		Source:  KubernetesVersionSourceDefault,
+		Reason:  fmt.Sprintf("tested release of Kubernetes %s, as %s", release.Minor, reason),
+	}
+}
+
+// defaultVersion returns the version of a cluster whose plan file does not set one
+func (r *releaseResolver) defaultVersion(disconnected bool) string {
+	res, _ := r.latestStable(versionCatalog.DefaultRelease(), disconnected)
+	res.Reason = "the plan file does not set a version, using the " + res.Reason
+	r.mu.Lock()
+	r.defaulted = &res
+	r.mu.Unlock()
+	return res.Version
+}
+
+// resolution returns how the Kubernetes version of the plan was chosen
+func (r *releaseResolver) resolution(p *Plan) KubernetesVersionResolution {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.defaulted != nil && r.defaulted.Version == p.Cluster.Version {
+		return *r.defaulted
+	}
+	return KubernetesVersionResolution{
+		Version: p.Cluster.Version,
+		Source:  KubernetesVersionSourcePlan,
+		Reason:  "set in the plan file",
+	}
+}
+
+func recordKubernetesVersion(runDirectory string, p *Plan) error {
+	b, err := yaml.Marshal(kubernetesReleaseResolver.resolution(p))
+	if err != nil {
+		return fmt.Errorf("error marshalling Kubernetes version: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(runDirectory, kubernetesVersionRecordFile), b, 0644); err != nil {
+		return fmt.Errorf("error recording Kubernetes version: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package install
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeReleaseCatalog(t *testing.T, contents string) (string, func()) {
+	dir, err := ioutil.TempDir("", "release-catalog-test")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	file := filepath.Join(dir, ReleaseCatalogFile)
+	if err := ioutil.WriteFile(file, []byte(contents), 0644); err != nil {
+		t.Fatalf("error writing release catalog: %v", err)
+	}
+	return file, func() { os.RemoveAll(dir) }
+}
+
+func TestReadReleaseCatalog(t *testing.T) {
+	tests := []struct {
+		contents string
+		valid    bool
+	}{
+		{contents: "kubernetes:\n  v1.10: v1.10.5\n  v1.9: v1.9.9\n", valid: true},
+		{contents: "kubernetes:\n  v1.10: v1.9.9\n", valid: false},
+		{contents: "kubernetes:\n  v1.10: 1.10.5\n", valid: false},
+		{contents: "kubernetes: [", valid: false},
+	}
+	for _, test := range tests {
+		file, cleanup := writeReleaseCatalog(t, test.contents)
+		_, err := ReadReleaseCatalog(file)
+		cleanup()
+		if (err == nil) != test.valid {
+			t.Errorf("catalog %q: expected valid to be %v, but got error %v", test.contents, test.valid, err)
+		}
+	}
+}
+
+func TestSetReleaseResolutionOptionsMissingCatalog(t *testing.T) {
+	defer func(r *releaseResolver) { kubernetesReleaseResolver = r }(kubernetesReleaseResolver)
+	if err := SetReleaseResolutionOptions(ReleaseResolutionOptions{CatalogFile: "/does/not/exist.yaml"}); err == nil {
+		t.Errorf("expected an error for a missing release catalog")
+	}
+	if err := SetReleaseResolutionOptions(ReleaseResolutionOptions{CatalogFile: "/does/not/exist.yaml", CatalogFileOptional: true}); err != nil {
+		t.Errorf("unexpected error for a missing optional release catalog: %v", err)
+	}
+}
+
+func TestKubernetesVersionResolution(t *testing.T) {
+	defer func(r *releaseResolver) { kubernetesReleaseResolver = r }(kubernetesReleaseResolver)
+	defaultRelease := versionCatalog.DefaultRelease()
+	file, cleanup := writeReleaseCatalog(t, "kubernetes:\n  "+defaultRelease.Minor+": "+defaultRelease.Minor+".99\n")
+	defer cleanup()
+
+	tests := []struct {
+		opts            ReleaseResolutionOptions
+		planVersion     string
+		expectedVersion string
+		expectedSource  string
+	}{
+		{
+			opts:            ReleaseResolutionOptions{},
+			expectedVersion: defaultRelease.Version,
+			expectedSource:  KubernetesVersionSourceDefault,
+		},
+		{
+			opts:            ReleaseResolutionOptions{CatalogFile: file},
+			expectedVersion: defaultRelease.Minor + ".99",
+			expectedSource:  KubernetesVersionSourceReleaseCatalog,
+		},
+		{
+			opts:            ReleaseResolutionOptions{CatalogFile: file},
+			planVersion:     defaultRelease.Version,
+			expectedVersion: defaultRelease.Version,
+			expectedSource:  KubernetesVersionSourcePlan,
+		},
+	}
+	for _, test := range tests {
+		if err := SetReleaseResolutionOptions(test.opts); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		p := &Plan{}
+		p.Cluster.Version = test.planVersion
+		setDefaults(p)
+		if p.Cluster.Version != test.expectedVersion {
+			t.Errorf("expected version %q, but got %q", test.expectedVersion, p.Cluster.Version)
+		}
+		res := kubernetesReleaseResolver.resolution(p)
+		if res.Source != test.expectedSource || res.Version != test.expectedVersion {
+			t.Errorf("expected the version to be resolved from %q, but got %+v", test.expectedSource, res)
+		}
+		if res.Reason == "" {
+			t.Errorf("expected the resolution to have a reason")
+		}
+	}
+}
+
+func TestValidateClusterVersionAgainstReleaseCatalog(t *testing.T) {
+	defer func(r *releaseResolver) { kubernetesReleaseResolver = r }(kubernetesReleaseResolver)
+	file, cleanup := writeReleaseCatalog(t, "kubernetes:\n  v1.10: v1.10.2\n")
+	defer cleanup()
+	if err := SetReleaseResolutionOptions(ReleaseResolutionOptions{CatalogFile: file}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := []struct {
+		version string
+		valid   bool
+	}{
+		{version: "v1.10.1", valid: true},
+		{version: "v1.10.2", valid: true},
+		{version: "v1.10.3", valid: false},
+		// the latest v1.9 release is not known, so any release is valid
+		{version: "v1.9.99", valid: true},
+	}
+	for _, test := range tests {
+		c := Cluster{Version: test.version}
+		_, errs := c.validate()
+		valid := true
+		for _, err := range errs {
+			if strings.Contains(err.Error(), "latest stable version") {
+				valid = false
+			}
+		}
+		if valid != test.valid {
+			t.Errorf("version %q: expected valid to be %v, but got errors %v", test.version, test.valid, errs)
+		}
+	}
+}
+
+func TestRecordKubernetesVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "record-kubernetes-version-test")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	p := &Plan{}
+	p.Cluster.Version = "v1.10.1"
+	if err := recordKubernetesVersion(dir, p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, kubernetesVersionRecordFile))
+	if err != nil {
+		t.Fatalf("error reading the recorded version: %v", err)
+	}
+	expected := "version: v1.10.1\nsource: plan\nreason: set in the plan file\n"
+	if string(b) != expected {
+		t.Errorf("expected the recorded version to be\n%s\nbut got\n%s", expected, string(b))
+	}
+}
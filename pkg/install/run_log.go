@@ -0,0 +1,131 @@
+package install
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/apprenda/kismatic/pkg/util"
+)
+
+// TaskFailure is a task that failed on a node during a run
+type TaskFailure struct {
+	// Name of the task
+	Task string
+	// Host of the node
+	Host string
+	// Item the task failed on, if the task loops over items
+	Item string `json:",omitempty"`
+	// Whether the node was unreachable
+	Unreachable bool `json:",omitempty"`
+	// Message explaining the failure
+	Message string
+}
+
+var (
+	// the timestamp prepended to the lines of the ansible log
+	ansibleLogTimestampRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\.\d{3}[+-]\d{4} - `)
+	ansibleTaskRegex         = regexp.MustCompile(`^(?:TASK|RUNNING HANDLER) \[(.*)\] \**$`)
+	ansibleFailureRegex      = regexp.MustCompile(`^(fatal|failed): \[([^\]]+)\](?:: (FAILED|UNREACHABLE)!)?(?: \(item=(.*)\))? => (.*)$`)
+)
+
+// RunFailures returns the tasks that failed during the run, as found in its ansible log.
+// Failures of tasks that ignore errors are not returned.
+func RunFailures(r Run) ([]TaskFailure, error) {
+	f, err := os.Open(filepath.Join(r.Directory, "ansible.log"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []TaskFailure{}, nil
+		}
+		return nil, fmt.Errorf("error opening ansible log: %v", err)
+	}
+	defer f.Close()
+
+	failures := []TaskFailure{}
+	var (
+		task    string
+		pending *TaskFailure
+		result  []string
+	)
+	lr := util.NewLineReader(f, 1024*1024)
+	for {
+		b, err := lr.Read()
+		if err != nil {
+			break
+		}
+		line := ansibleLogTimestampRegex.ReplaceAllString(string(b), "")
+		// the result of the failure spans lines when ansible is verbose
+		if result != nil {
+			result = append(result, line)
+			if m, ok := parseTaskResult(result); ok {
+				if !completeFailure(pending, m) {
+					pending = nil
+				}
+				result = nil
+			} else if len(result) > 10000 {
+				result = nil
+			}
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if pending != nil {
+			if trimmed == "" {
+				continue
+			}
+			if trimmed != "...ignoring" {
+				failures = append(failures, *pending)
+			}
+			pending = nil
+		}
+		if m := ansibleTaskRegex.FindStringSubmatch(trimmed); m != nil {
+			task = m[1]
+			continue
+		}
+		m := ansibleFailureRegex.FindStringSubmatch(trimmed)
+		if m == nil {
+			continue
+		}
+		pending = &TaskFailure{Task: task, Host: m[2], Unreachable: m[3] == "UNREACHABLE", Item: m[4]}
+		result = []string{m[5]}
+		if r, ok := parseTaskResult(result); ok {
+			if !completeFailure(pending, r) {
+				pending = nil
+			}
+			result = nil
+		}
+	}
+	if pending != nil {
+		failures = append(failures, *pending)
+	}
+	return failures, nil
+}
+
+func parseTaskResult(lines []string) (map[string]interface{}, bool) {
+	m := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(strings.Join(lines, "\n")), &m); err != nil {
+		return nil, false
+	}
+	return m, true
+}
+
+// completeFailure sets the message of the failure from the result of the task.
+// It returns false if the failure is the summary of the items that failed,
+// which are reported individually.
+func completeFailure(f *TaskFailure, result map[string]interface{}) bool {
+	if f == nil {
+		return false
+	}
+	if _, ok := result["results"]; ok && f.Item == "" {
+		return false
+	}
+	for _, key := range []string{"msg", "stderr", "stdout"} {
+		if s, ok := result[key].(string); ok && strings.TrimSpace(s) != "" {
+			f.Message = strings.TrimSpace(s)
+			return true
+		}
+	}
+	return true
+}
@@ -0,0 +1,329 @@
+package install
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// The status of a run is recorded in the run directory once the run is complete.
+// Runs that were started by earlier versions of kismatic do not have a status.
+const (
+	runStatusFile = "status"
+	runInfoFile   = "run.yaml"
+	// the format of the name of run directories, which is the time the run started.
+	// Runs of an operation that start in the same second have a numbered suffix.
+	runDirectoryTimeFormat = "2006-01-02-15-04-05"
+
+	// RunStatusSucceeded is the status of a run that completed successfully
+	RunStatusSucceeded = "succeeded"
+	// RunStatusFailed is the status of a run that failed
+	RunStatusFailed = "failed"
+	// RunStatusCancelled is the status of a run that was cancelled
+	RunStatusCancelled = "cancelled"
+)
+
+// the runs that apply the plan to the cluster
+var planApplyingRuns = []string{"apply", "add-node", "step", "upgrade-nodes", "upgrade-cluster-services"}
+
+// runInfo is recorded in the run directory when the run starts, and updated when it ends
+type runInfo struct {
+	Operation string     `yaml:"operation"`
+	Start     time.Time  `yaml:"start"`
+	End       *time.Time `yaml:"end,omitempty"`
+	Nodes     []string   `yaml:"nodes"`
+	Operator  string     `yaml:"operator"`
+	Version   string     `yaml:"kismatic_version"`
+}
+
+// Run is an operation performed by kismatic, recorded in the runs directory
+type Run struct {
+	// ID of the run, which is the path of its directory relative to the runs
+	// directory, such as "apply/2018-03-01-10-00-00"
+	ID string
+	// Directory of the run
+	Directory string
+	// Operation performed by the run, such as "apply"
+	Operation string
+	// Start time of the run
+	Start time.Time
+	// End time of the run. Zero if the run has not ended, or if it was not recorded.
+	End time.Time
+	// Status of the run. Empty if it was not recorded.
+	Status string
+	// The nodes targeted by the run. Empty if they were not recorded.
+	Nodes []string
+	// The user that performed the run. Empty if it was not recorded.
+	Operator string
+}
+
+func recordRunStart(runDirectory string, operation string, start time.Time, nodes []string) error {
+	info := runInfo{
+		Operation: operation,
+		Start:     start,
+		Nodes:     nodes,
+		Operator:  operator(),
+		Version:   KismaticVersion.String(),
+	}
+	return writeRunInfo(runDirectory, info)
+}
+
+func recordRunStatus(runDirectory string, status string) error {
+	if err := ioutil.WriteFile(filepath.Join(runDirectory, runStatusFile), []byte(status+"\n"), 0644); err != nil {
+		return fmt.Errorf("error recording run status: %v", err)
+	}
+	info, err := readRunInfo(runDirectory)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	end := time.Now()
+	info.End = &end
+	return writeRunInfo(runDirectory, *info)
+}
+
+func readRunInfo(runDirectory string) (*runInfo, error) {
+	b, err := ioutil.ReadFile(filepath.Join(runDirectory, runInfoFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error reading run information: %v", err)
+	}
+	info := &runInfo{}
+	if err = yaml.Unmarshal(b, info); err != nil {
+		return nil, fmt.Errorf("error unmarshalling run information: %v", err)
+	}
+	return info, nil
+}
+
+func writeRunInfo(runDirectory string, info runInfo) error {
+	b, err := yaml.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("error marshalling run information: %v", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(runDirectory, runInfoFile), b, 0644); err != nil {
+		return fmt.Errorf("error recording run information: %v", err)
+	}
+	return nil
+}
+
+// createRunDirectory creates the directory of a run of the operation that started
+// at the given time. The directory is named after the start time, with a suffix
+// when other runs of the operation started in the same second, so that runs
+// never share a directory.
+func createRunDirectory(runsDirectory string, operation string, start time.Time) (string, error) {
+	parent := filepath.Join(runsDirectory, operation)
+	if err := os.MkdirAll(parent, 0777); err != nil {
+		return "", fmt.Errorf("error creating directory: %v", err)
+	}
+	name := start.Format(runDirectoryTimeFormat)
+	for i := 1; ; i++ {
+		dir := filepath.Join(parent, name)
+		err := os.Mkdir(dir, 0777)
+		if err == nil {
+			return dir, nil
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("error creating directory: %v", err)
+		}
+		name = fmt.Sprintf("%s-%d", start.Format(runDirectoryTimeFormat), i)
+	}
+}
+
+// runNameLess returns true if the run directory named a sorts before the one
+// named b, which is when the run started first
+func runNameLess(a, b string) bool {
+	startA, nA := splitRunName(a)
+	startB, nB := splitRunName(b)
+	if startA != startB {
+		return startA < startB
+	}
+	return nA < nB
+}
+
+// splitRunName returns the start time and the suffix of the name of a run directory
+func splitRunName(name string) (string, int) {
+	n := len(runDirectoryTimeFormat)
+	if len(name) > n+1 && name[n] == '-' {
+		if i, err := strconv.Atoi(name[n+1:]); err == nil {
+			return name[:n], i
+		}
+	}
+	return name, 0
+}
+
+// operator returns the name of the user running kismatic
+func operator() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// ListRuns returns the runs recorded in the runs directory, from oldest to newest
+func ListRuns(runsDirectory string) ([]Run, error) {
+	dirs, err := filepath.Glob(filepath.Join(runsDirectory, "*", "*"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing runs: %v", err)
+	}
+	runs := []Run{}
+	for _, dir := range dirs {
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			continue
+		}
+		id, err := filepath.Rel(runsDirectory, dir)
+		if err != nil {
+			return nil, fmt.Errorf("error listing runs: %v", err)
+		}
+		r, err := readRun(runsDirectory, filepath.ToSlash(id))
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *r)
+	}
+	sort.SliceStable(runs, func(i, j int) bool {
+		if !runs[i].Start.Equal(runs[j].Start) {
+			return runs[i].Start.Before(runs[j].Start)
+		}
+		return runNameLess(filepath.Base(runs[i].Directory), filepath.Base(runs[j].Directory))
+	})
+	return runs, nil
+}
+
+// GetRun returns the run with the given ID
+func GetRun(runsDirectory string, id string) (*Run, error) {
+	parts := strings.Split(strings.Trim(filepath.ToSlash(id), "/"), "/")
+	if len(parts) != 2 || parts[0] == "." || parts[0] == ".." || parts[1] == "." || parts[1] == ".." {
+		return nil, fmt.Errorf("invalid run ID %q, run IDs are of the form <operation>/<start time>", id)
+	}
+	if fi, err := os.Stat(filepath.Join(runsDirectory, parts[0], parts[1])); err != nil || !fi.IsDir() {
+		return nil, fmt.Errorf("run %q was not found in %q", id, runsDirectory)
+	}
+	return readRun(runsDirectory, parts[0]+"/"+parts[1])
+}
+
+func readRun(runsDirectory string, id string) (*Run, error) {
+	dir := filepath.Join(runsDirectory, filepath.FromSlash(id))
+	r := &Run{ID: id, Directory: dir, Operation: filepath.Dir(filepath.FromSlash(id))}
+	status, err := RunStatus(dir)
+	if err != nil {
+		return nil, err
+	}
+	r.Status = status
+	info, err := readRunInfo(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if info != nil {
+		r.Start = info.Start
+		if info.End != nil {
+			r.End = *info.End
+		}
+		r.Nodes = info.Nodes
+		r.Operator = info.Operator
+		return r, nil
+	}
+	// runs recorded by earlier versions only have the start time in their name,
+	// and end when the last line is written to the ansible log
+	if start, err := time.ParseInLocation(runDirectoryTimeFormat, filepath.Base(dir), time.Local); err == nil {
+		r.Start = start
+	}
+	if fi, err := os.Stat(filepath.Join(dir, "ansible.log")); err == nil {
+		r.End = fi.ModTime()
+	}
+	return r, nil
+}
+
+// PruneRuns removes all but the most recent runs from the runs directory.
+// The most recent successful run that applied the plan to the cluster is kept,
+// as it records the plan that was last applied. The removed runs are returned.
+func PruneRuns(runsDirectory string, keep int) ([]Run, error) {
+	if keep < 0 {
+		return nil, fmt.Errorf("the number of runs to keep cannot be negative")
+	}
+	runs, err := ListRuns(runsDirectory)
+	if err != nil {
+		return nil, err
+	}
+	var lastApplied string
+	if planFile, err := LastAppliedPlanFile(runsDirectory); err == nil {
+		lastApplied = filepath.Dir(planFile)
+	}
+	removed := []Run{}
+	for i := 0; i < len(runs)-keep; i++ {
+		r := runs[i]
+		if r.Directory == lastApplied {
+			continue
+		}
+		if err := os.RemoveAll(r.Directory); err != nil {
+			return removed, fmt.Errorf("error removing run %q: %v", r.ID, err)
+		}
+		removed = append(removed, r)
+	}
+	// remove the directories of operations that have no runs left
+	dirs, err := filepath.Glob(filepath.Join(runsDirectory, "*"))
+	if err != nil {
+		return removed, fmt.Errorf("error listing runs: %v", err)
+	}
+	for _, d := range dirs {
+		if runs, err := ioutil.ReadDir(d); err == nil && len(runs) == 0 {
+			os.Remove(d)
+		}
+	}
+	return removed, nil
+}
+
+// RunStatus returns the status of the run found in the given directory.
+// An empty status is returned if the status of the run was not recorded.
+func RunStatus(runDirectory string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(runDirectory, runStatusFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("error reading run status: %v", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// LastAppliedPlanFile returns the plan file recorded by the most recent successful
+// run that applied the plan to the cluster.
+func LastAppliedPlanFile(runsDirectory string) (string, error) {
+	// run directories are named after the time the run started
+	runs := []string{}
+	for _, name := range planApplyingRuns {
+		dirs, err := filepath.Glob(filepath.Join(runsDirectory, name, "*"))
+		if err != nil {
+			return "", fmt.Errorf("error listing runs: %v", err)
+		}
+		runs = append(runs, dirs...)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runNameLess(filepath.Base(runs[j]), filepath.Base(runs[i]))
+	})
+	for _, r := range runs {
+		status, err := RunStatus(r)
+		if err != nil {
+			return "", err
+		}
+		if status != RunStatusSucceeded {
+			continue
+		}
+		planFile := filepath.Join(r, "kismatic-cluster.yaml")
+		if _, err := os.Stat(planFile); err == nil {
+			return planFile, nil
+		}
+	}
+	return "", fmt.Errorf("no successful run was found in %q", runsDirectory)
+}
@@ -0,0 +1,230 @@
+package install
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func createRuns(t *testing.T, runsDir string, runs map[string]string) {
+	for dir, status := range runs {
+		dir = filepath.Join(runsDir, dir)
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			t.Fatalf("error creating run dir: %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "kismatic-cluster.yaml"), []byte{}, 0644); err != nil {
+			t.Fatalf("error writing plan file: %v", err)
+		}
+		if status != "" {
+			if err := recordRunStatus(dir, status); err != nil {
+				t.Fatalf("error recording status: %v", err)
+			}
+		}
+	}
+}
+
+func TestListRuns(t *testing.T) {
+	runsDir, err := ioutil.TempDir("", "test-list-runs")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(runsDir)
+	createRuns(t, runsDir, map[string]string{
+		"apply/2018-01-01-10-00-00":     RunStatusSucceeded,
+		"preflight/2018-01-01-09-00-00": RunStatusSucceeded,
+	})
+	dir := filepath.Join(runsDir, "step", "2018-01-02-10-00-00")
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		t.Fatalf("error creating run dir: %v", err)
+	}
+	start := time.Date(2018, 1, 2, 10, 0, 0, 0, time.UTC)
+	if err := recordRunStart(dir, "step", start, []string{"worker1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := recordRunStatus(dir, RunStatusFailed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	runs, err := ListRuns(runsDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ids := []string{}
+	for _, r := range runs {
+		ids = append(ids, r.ID)
+	}
+	if expected := []string{"preflight/2018-01-01-09-00-00", "apply/2018-01-01-10-00-00", "step/2018-01-02-10-00-00"}; !reflect.DeepEqual(ids, expected) {
+		t.Fatalf("expected runs %v, but got %v", expected, ids)
+	}
+	step := runs[2]
+	if step.Operation != "step" || step.Status != RunStatusFailed || !step.Start.Equal(start) || step.End.IsZero() || !reflect.DeepEqual(step.Nodes, []string{"worker1"}) {
+		t.Errorf("unexpected run %+v", step)
+	}
+	if runs[1].Operation != "apply" || runs[1].Status != RunStatusSucceeded || runs[1].Start.IsZero() {
+		t.Errorf("unexpected run recorded by an earlier version %+v", runs[1])
+	}
+
+	if _, err := GetRun(runsDir, "step/2018-01-02-10-00-00"); err != nil {
+		t.Errorf("unexpected error getting run: %v", err)
+	}
+	for _, id := range []string{"step/2018-01-03-10-00-00", "step", "../step/2018-01-02-10-00-00"} {
+		if _, err := GetRun(runsDir, id); err == nil {
+			t.Errorf("expected an error getting run %q", id)
+		}
+	}
+}
+
+func TestRunsStartedInTheSameSecond(t *testing.T) {
+	runsDir, err := ioutil.TempDir("", "test-same-second-runs")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(runsDir)
+	start := time.Date(2018, 1, 2, 10, 0, 0, 0, time.Local)
+	dirs := []string{}
+	for i := 0; i < 11; i++ {
+		dir, err := createRunDirectory(runsDir, "apply", start)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := recordRunStart(dir, "apply", start, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "kismatic-cluster.yaml"), []byte(filepath.Base(dir)), 0644); err != nil {
+			t.Fatalf("error writing plan file: %v", err)
+		}
+		if err := recordRunStatus(dir, RunStatusSucceeded); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		dirs = append(dirs, dir)
+	}
+	if filepath.Base(dirs[0]) != "2018-01-02-10-00-00" || filepath.Base(dirs[1]) != "2018-01-02-10-00-00-1" || filepath.Base(dirs[10]) != "2018-01-02-10-00-00-10" {
+		t.Errorf("unexpected run directories %v", dirs)
+	}
+
+	runs, err := ListRuns(runsDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(runs) != len(dirs) {
+		t.Fatalf("expected %d runs, but got %d", len(dirs), len(runs))
+	}
+	for i, r := range runs {
+		if r.Directory != dirs[i] {
+			t.Errorf("expected run %d to be %q, but got %q", i, dirs[i], r.Directory)
+		}
+	}
+	file, err := LastAppliedPlanFile(runsDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b, _ := ioutil.ReadFile(file); string(b) != "2018-01-02-10-00-00-10" {
+		t.Errorf("expected the plan of the last run, but got the plan of %q", b)
+	}
+}
+
+func TestPruneRuns(t *testing.T) {
+	runsDir, err := ioutil.TempDir("", "test-prune-runs")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(runsDir)
+	createRuns(t, runsDir, map[string]string{
+		"apply/2018-01-01-10-00-00":     RunStatusSucceeded,
+		"apply/2018-01-02-10-00-00":     RunStatusFailed,
+		"preflight/2018-01-03-10-00-00": RunStatusSucceeded,
+		"smoketest/2018-01-04-10-00-00": RunStatusSucceeded,
+	})
+	removed, err := PruneRuns(runsDir, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ids := []string{}
+	for _, r := range removed {
+		ids = append(ids, r.ID)
+	}
+	// the last successful apply is kept
+	if expected := []string{"apply/2018-01-02-10-00-00", "preflight/2018-01-03-10-00-00"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected the removed runs to be %v, but got %v", expected, ids)
+	}
+	runs, err := ListRuns(runsDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(runs) != 2 {
+		t.Errorf("expected 2 runs to be left, but got %v", runs)
+	}
+	if _, err := os.Stat(filepath.Join(runsDir, "preflight")); !os.IsNotExist(err) {
+		t.Errorf("expected the preflight directory to be removed")
+	}
+}
+
+func TestGetRunRecordedByEarlierVersion(t *testing.T) {
+	runsDir, err := ioutil.TempDir("", "test-get-run")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(runsDir)
+	// earlier versions recorded neither the status nor the information of a run
+	dir := filepath.Join(runsDir, "apply", "2018-01-01-10-00-00")
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		t.Fatalf("error creating run dir: %v", err)
+	}
+	log := filepath.Join(dir, "ansible.log")
+	if err := ioutil.WriteFile(log, []byte("PLAY RECAP\n"), 0644); err != nil {
+		t.Fatalf("error writing log: %v", err)
+	}
+	end := time.Date(2018, 1, 1, 10, 30, 0, 0, time.Local)
+	if err := os.Chtimes(log, end, end); err != nil {
+		t.Fatalf("error setting the time of the log: %v", err)
+	}
+
+	r, err := GetRun(runsDir, "apply/2018-01-01-10-00-00")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if start := time.Date(2018, 1, 1, 10, 0, 0, 0, time.Local); !r.Start.Equal(start) || !r.End.Equal(end) || r.Status != "" {
+		t.Errorf("expected the run to start at %v and end at %v, but got %+v", start, end, r)
+	}
+}
+
+func TestRunFailures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-run-failures")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	log := `2018-03-01 10:00:01.000+0000 - TASK [docker : install docker] *****************************************
+2018-03-01 10:00:01.000+0000 - fatal: [worker1]: FAILED! => {
+2018-03-01 10:00:01.000+0000 -     "changed": false, 
+2018-03-01 10:00:01.000+0000 -     "msg": "No package matching 'docker-ce' found available"
+2018-03-01 10:00:01.000+0000 - }
+2018-03-01 10:00:01.000+0000 - fatal: [worker2]: FAILED! => {"changed": false, "msg": "ignored"}
+2018-03-01 10:00:01.000+0000 - ...ignoring
+2018-03-01 10:00:02.000+0000 - TASK [packages : install packages] *************************************
+2018-03-01 10:00:02.000+0000 - failed: [master1] (item=kubelet) => {"item": "kubelet", "msg": "not found"}
+2018-03-01 10:00:02.000+0000 - fatal: [master1]: FAILED! => {"msg": "One or more items failed", "results": []}
+2018-03-01 10:00:02.000+0000 - fatal: [etcd1]: UNREACHABLE! => {"msg": "Failed to connect", "unreachable": true}
+2018-03-01 10:00:03.000+0000 - RUNNING HANDLER [etcd : restart etcd] *********************************
+2018-03-01 10:00:03.000+0000 - fatal: [etcd2]: FAILED! => {"stderr": "timed out"}
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "ansible.log"), []byte(log), 0644); err != nil {
+		t.Fatalf("error writing log: %v", err)
+	}
+	failures, err := RunFailures(Run{Directory: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []TaskFailure{
+		{Task: "docker : install docker", Host: "worker1", Message: "No package matching 'docker-ce' found available"},
+		{Task: "packages : install packages", Host: "master1", Item: "kubelet", Message: "not found"},
+		{Task: "packages : install packages", Host: "etcd1", Unreachable: true, Message: "Failed to connect"},
+		{Task: "etcd : restart etcd", Host: "etcd2", Message: "timed out"},
+	}
+	if !reflect.DeepEqual(failures, expected) {
+		t.Errorf("expected failures\n%+v\nbut got\n%+v", expected, failures)
+	}
+}
@@ -0,0 +1,109 @@
+package install
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Secret fields of the plan file can either contain the secret itself, or
+// a reference to where the secret can be found. References are resolved in
+// memory right before the secret is needed.
+const (
+	// env:VARIABLE_NAME reads the secret from an environment variable
+	secretEnvPrefix = "env:"
+	// file:/path/to/file reads the secret from a file
+	secretFilePrefix = "file:"
+	// exec:command runs the command with "sh -c", and reads the secret from its output
+	secretExecPrefix = "exec:"
+
+	// redactedSecret replaces the secrets of plans that are recorded on disk
+	redactedSecret = "REDACTED"
+)
+
+// isSecretReference returns true if the value is a reference to a secret
+func isSecretReference(v string) bool {
+	return strings.HasPrefix(v, secretEnvPrefix) || strings.HasPrefix(v, secretFilePrefix) || strings.HasPrefix(v, secretExecPrefix)
+}
+
+// resolveSecret returns the secret that the value refers to. Values that
+// are not secret references are returned as is.
+func resolveSecret(v string) (string, error) {
+	switch {
+	case strings.HasPrefix(v, secretEnvPrefix):
+		name := strings.TrimPrefix(v, secretEnvPrefix)
+		s, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %q is not set", name)
+		}
+		return s, nil
+	case strings.HasPrefix(v, secretFilePrefix):
+		file := strings.TrimPrefix(v, secretFilePrefix)
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("error reading secret from file: %v", err)
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	case strings.HasPrefix(v, secretExecPrefix):
+		command := strings.TrimPrefix(v, secretExecPrefix)
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Stderr = os.Stderr
+		b, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("error running command %q to get secret: %v", command, err)
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	default:
+		return v, nil
+	}
+}
+
+// validateSecretReference checks that the secret reference can be resolved,
+// without running any commands.
+func validateSecretReference(v string) error {
+	switch {
+	case strings.HasPrefix(v, secretEnvPrefix):
+		name := strings.TrimPrefix(v, secretEnvPrefix)
+		if name == "" {
+			return errors.New("the environment variable name is empty")
+		}
+		if _, ok := os.LookupEnv(name); !ok {
+			return fmt.Errorf("environment variable %q is not set", name)
+		}
+	case strings.HasPrefix(v, secretFilePrefix):
+		file := strings.TrimPrefix(v, secretFilePrefix)
+		if _, err := os.Stat(file); err != nil {
+			return fmt.Errorf("secret file %q was not found", file)
+		}
+	case strings.HasPrefix(v, secretExecPrefix):
+		if strings.TrimSpace(strings.TrimPrefix(v, secretExecPrefix)) == "" {
+			return errors.New("the command is empty")
+		}
+	}
+	return nil
+}
+
+// redactSecret replaces the secret with a placeholder. Secret references are
+// kept, as they do not contain the secret itself.
+func redactSecret(v string) string {
+	if v == "" || isSecretReference(v) {
+		return v
+	}
+	return redactedSecret
+}
+
+// redactedPlan returns a copy of the plan that does not contain any secrets,
+// which is safe to record on disk.
+func redactedPlan(p Plan) Plan {
+	p.Cluster.AdminPassword = redactSecret(p.Cluster.AdminPassword)
+	p.DockerRegistry.Password = redactSecret(p.DockerRegistry.Password)
+	if p.AddOns.CNI != nil {
+		cni := *p.AddOns.CNI
+		cni.Options.Weave.Password = redactSecret(cni.Options.Weave.Password)
+		p.AddOns.CNI = &cni
+	}
+	return p
+}
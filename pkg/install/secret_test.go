@@ -0,0 +1,125 @@
+package install
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveSecret(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-resolve-secret")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	secretFile := filepath.Join(tmpDir, "secret")
+	if err = ioutil.WriteFile(secretFile, []byte("fromfile\n"), 0600); err != nil {
+		t.Fatalf("error writing secret file: %v", err)
+	}
+	os.Setenv("KET_TEST_SECRET", "fromenv")
+	defer os.Unsetenv("KET_TEST_SECRET")
+
+	tests := []struct {
+		value       string
+		expected    string
+		shouldError bool
+	}{
+		{
+			value:    "",
+			expected: "",
+		},
+		{
+			value:    "plaintext",
+			expected: "plaintext",
+		},
+		{
+			value:    "env:KET_TEST_SECRET",
+			expected: "fromenv",
+		},
+		{
+			value:       "env:KET_TEST_SECRET_NOT_SET",
+			shouldError: true,
+		},
+		{
+			value:    "file:" + secretFile,
+			expected: "fromfile",
+		},
+		{
+			value:       "file:" + filepath.Join(tmpDir, "not-found"),
+			shouldError: true,
+		},
+		{
+			value:    "exec:echo fromexec",
+			expected: "fromexec",
+		},
+		{
+			value:       "exec:exit 1",
+			shouldError: true,
+		},
+	}
+	for _, test := range tests {
+		s, err := resolveSecret(test.value)
+		if test.shouldError {
+			if err == nil {
+				t.Errorf("%q: expected an error, but didn't get one", test.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.value, err)
+		}
+		if s != test.expected {
+			t.Errorf("%q: expected %q, but got %q", test.value, test.expected, s)
+		}
+	}
+}
+
+func TestExecutorResolvesSecretReferencesOnce(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test-resolve-secret")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	// the command counts how many times it ran
+	counter := filepath.Join(tmpDir, "counter")
+	ref := "exec:echo x >> " + counter + " && echo secret"
+	ae := &ansibleExecutor{}
+	for i := 0; i < 3; i++ {
+		s, err := ae.resolveSecret(ref)
+		if err != nil || s != "secret" {
+			t.Fatalf("unexpected secret %q (%v)", s, err)
+		}
+	}
+	b, err := ioutil.ReadFile(counter)
+	if err != nil {
+		t.Fatalf("error reading counter: %v", err)
+	}
+	if runs := strings.Count(string(b), "x"); runs != 1 {
+		t.Errorf("expected the command to run once, but it ran %d times", runs)
+	}
+}
+
+func TestRedactedPlan(t *testing.T) {
+	p := Plan{}
+	p.Cluster.AdminPassword = "env:ADMIN_PASSWORD"
+	p.DockerRegistry.Password = "plaintext"
+	p.AddOns.CNI = &CNI{}
+	p.AddOns.CNI.Options.Weave.Password = "plaintext"
+
+	redacted := redactedPlan(p)
+	if redacted.Cluster.AdminPassword != "env:ADMIN_PASSWORD" {
+		t.Errorf("expected secret reference to be kept, but got %q", redacted.Cluster.AdminPassword)
+	}
+	if redacted.DockerRegistry.Password != redactedSecret {
+		t.Errorf("expected docker registry password to be redacted, but got %q", redacted.DockerRegistry.Password)
+	}
+	if redacted.AddOns.CNI.Options.Weave.Password != redactedSecret {
+		t.Errorf("expected weave password to be redacted, but got %q", redacted.AddOns.CNI.Options.Weave.Password)
+	}
+	// the original plan must not be modified
+	if p.DockerRegistry.Password != "plaintext" || p.AddOns.CNI.Options.Weave.Password != "plaintext" {
+		t.Errorf("expected the original plan to be left untouched")
+	}
+}
@@ -0,0 +1,48 @@
+package install
+
+import (
+	"sort"
+
+	"github.com/apprenda/kismatic/pkg/ssh"
+)
+
+// encryptedSSHKeys returns the encrypted private keys used for accessing the nodes,
+// and the bastions used to reach them. Only the keys of the given hosts are
+// returned when any are provided.
+func encryptedSSHKeys(p *Plan, hosts ...string) []string {
+	keys := map[string]bool{}
+	for _, n := range p.GetUniqueNodes() {
+		if len(hosts) > 0 && !contains(n.Host, hosts) {
+			continue
+		}
+		s := p.NodeSSHConfig(n)
+		keys[s.Key] = true
+		if b := s.sshBastion(); b != nil {
+			keys[b.Key] = true
+		}
+	}
+	encrypted := []string{}
+	for k := range keys {
+		if k != "" && ssh.EncryptedPrivateKey(k) {
+			encrypted = append(encrypted, k)
+		}
+	}
+	sort.Strings(encrypted)
+	return encrypted
+}
+
+// startSSHAgent starts an ssh-agent serving the encrypted keys used for accessing
+// the hosts. It returns the environment that points Ansible at the agent, and a
+// func that stops the agent. No agent is started when none of the keys are encrypted.
+func startSSHAgent(p *Plan, hosts ...string) ([]string, func(), error) {
+	keys := encryptedSSHKeys(p, hosts...)
+	if len(keys) == 0 {
+		return nil, func() {}, nil
+	}
+	agent, err := ssh.StartAgent(keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	env := []string{ssh.AgentSocketEnvVar + "=" + agent.Socket()}
+	return env, func() { agent.Close() }, nil
+}
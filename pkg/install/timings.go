@@ -0,0 +1,207 @@
+package install
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+)
+
+// The time spent on the tasks of a playbook is recorded in the run directory,
+// so that the slowest parts of an operation can be found.
+const timingsFile = "timings.json"
+
+// TaskTiming is the time spent running a task of a playbook. The events of the
+// playbook are not timestamped, so the times are those of the events when they
+// were received by kismatic.
+type TaskTiming struct {
+	// Phase of the operation the task ran in, such as "apply"
+	Phase   string `json:"phase"`
+	Play    string `json:"play"`
+	Task    string `json:"task"`
+	Handler bool   `json:"handler,omitempty"`
+	// Start of the task, and its end, when the next task started or the playbook ended
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Seconds float64   `json:"seconds"`
+	// Time spent on each host, until the host returned its result
+	Hosts []HostTiming `json:"hosts,omitempty"`
+}
+
+// HostTiming is the time a host spent running a task
+type HostTiming struct {
+	Host string `json:"host"`
+	// Status of the task on the host: "ok", "failed", "skipped" or "unreachable"
+	Status  string    `json:"status"`
+	End     time.Time `json:"end"`
+	Seconds float64   `json:"seconds"`
+}
+
+// Duration of the task
+func (t TaskTiming) Duration() time.Duration {
+	return t.End.Sub(t.Start)
+}
+
+// SlowestHost returns the host that spent the most time on the task,
+// and false if no host returned a result.
+func (t TaskTiming) SlowestHost() (HostTiming, bool) {
+	var slowest HostTiming
+	for _, h := range t.Hosts {
+		if h.Seconds > slowest.Seconds || slowest.Host == "" {
+			slowest = h
+		}
+	}
+	return slowest, slowest.Host != ""
+}
+
+// SlowestTasks returns the n tasks that took the longest, slowest first
+func SlowestTasks(timings []TaskTiming, n int) []TaskTiming {
+	sorted := make([]TaskTiming, len(timings))
+	copy(sorted, timings)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Duration() > sorted[j].Duration()
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// taskTimings is the content of the timings file
+type taskTimings struct {
+	Seconds float64      `json:"seconds"`
+	Tasks   []TaskTiming `json:"tasks"`
+}
+
+func writeTaskTimings(runDirectory string, timings []TaskTiming) error {
+	t := taskTimings{Tasks: timings}
+	if len(timings) > 0 {
+		t.Seconds = seconds(timings[len(timings)-1].End.Sub(timings[0].Start))
+	}
+	b, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling task timings: %v", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(runDirectory, timingsFile), b, 0644); err != nil {
+		return fmt.Errorf("error writing task timings: %v", err)
+	}
+	return nil
+}
+
+// seconds returns the duration in seconds, rounded to the millisecond
+func seconds(d time.Duration) float64 {
+	return float64(d/time.Millisecond) / 1000
+}
+
+// timingTracker records the time spent on the tasks as the events of the
+// playbook are received. A task ends when the next task or play starts, or
+// when the playbook ends.
+type timingTracker struct {
+	phase string
+	now   func() time.Time
+
+	mu    sync.Mutex
+	play  string
+	tasks []TaskTiming
+	// whether the last task is still running
+	running bool
+	// closed once the stream of events was closed
+	done chan struct{}
+}
+
+func newTimingTracker(phase string) *timingTracker {
+	return &timingTracker{
+		phase: phase,
+		now:   time.Now,
+		done:  make(chan struct{}),
+	}
+}
+
+// track returns a stream with the events of the given stream, recording the
+// time spent on the tasks
+func (t *timingTracker) track(in <-chan ansible.Event) <-chan ansible.Event {
+	out := make(chan ansible.Event)
+	go func() {
+		defer close(t.done)
+		defer close(out)
+		for e := range in {
+			t.handle(e)
+			out <- e
+		}
+		// the playbook did not end when it failed or was interrupted
+		t.mu.Lock()
+		t.endTask(t.now())
+		t.mu.Unlock()
+	}()
+	return out
+}
+
+func (t *timingTracker) handle(e ansible.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	switch event := e.(type) {
+	case *ansible.PlayStartEvent:
+		t.endTask(now)
+		t.play = event.Name
+	case *ansible.TaskStartEvent:
+		t.startTask(now, event.Name, false)
+	case *ansible.HandlerTaskStartEvent:
+		t.startTask(now, event.Name, true)
+	case *ansible.RunnerOKEvent:
+		t.endHost(now, event.Host, "ok")
+	case *ansible.RunnerFailedEvent:
+		t.endHost(now, event.Host, "failed")
+	case *ansible.RunnerSkippedEvent:
+		t.endHost(now, event.Host, "skipped")
+	case *ansible.RunnerUnreachableEvent:
+		t.endHost(now, event.Host, "unreachable")
+	case *ansible.PlaybookEndEvent:
+		t.endTask(now)
+	}
+}
+
+func (t *timingTracker) startTask(now time.Time, name string, handler bool) {
+	t.endTask(now)
+	t.tasks = append(t.tasks, TaskTiming{Phase: t.phase, Play: t.play, Task: name, Handler: handler, Start: now})
+	t.running = true
+}
+
+func (t *timingTracker) endTask(now time.Time) {
+	if !t.running {
+		return
+	}
+	task := &t.tasks[len(t.tasks)-1]
+	task.End = now
+	task.Seconds = seconds(task.Duration())
+	t.running = false
+}
+
+func (t *timingTracker) endHost(now time.Time, host string, status string) {
+	if !t.running {
+		return
+	}
+	task := &t.tasks[len(t.tasks)-1]
+	task.Hosts = append(task.Hosts, HostTiming{Host: host, Status: status, End: now, Seconds: seconds(now.Sub(task.Start))})
+}
+
+// wait blocks until the stream of events was closed, and returns the timings
+// of the tasks
+func (t *timingTracker) wait() []TaskTiming {
+	<-t.done
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	timings := make([]TaskTiming, 0, len(t.tasks))
+	for i, task := range t.tasks {
+		if i == len(t.tasks)-1 && t.running {
+			break
+		}
+		timings = append(timings, task)
+	}
+	return timings
+}
@@ -0,0 +1,144 @@
+package install
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/install/explain"
+)
+
+// names of the events are not exported, but can be set once created
+func playStart(name string) ansible.Event {
+	e := &ansible.PlayStartEvent{}
+	e.Name = name
+	return e
+}
+
+func taskStart(name string) ansible.Event {
+	e := &ansible.TaskStartEvent{}
+	e.Name = name
+	return e
+}
+
+func handlerTaskStart(name string) ansible.Event {
+	e := &ansible.HandlerTaskStartEvent{}
+	e.Name = name
+	return e
+}
+
+func runnerResult(host string) ansible.RunnerResultEvent {
+	return ansible.RunnerResultEvent{Host: host}
+}
+
+func TestTimingTrackerRecordsTasks(t *testing.T) {
+	start := time.Date(2018, 3, 1, 10, 0, 0, 0, time.UTC)
+	// the clock moves forward a second for every event
+	clock := start
+	tracker := newTimingTracker("apply")
+	tracker.now = func() time.Time {
+		now := clock
+		clock = clock.Add(time.Second)
+		return now
+	}
+	in := make(chan ansible.Event, 10)
+	in <- playStart("etcd")                                                     // 0s
+	in <- taskStart("install etcd")                                             // 1s
+	in <- &ansible.RunnerOKEvent{RunnerResultEvent: runnerResult("etcd1")}      // 2s
+	in <- &ansible.RunnerFailedEvent{RunnerResultEvent: runnerResult("etcd2")}  // 3s
+	in <- handlerTaskStart("restart etcd")                                      // 4s
+	in <- &ansible.RunnerSkippedEvent{RunnerResultEvent: runnerResult("etcd1")} // 5s
+	in <- &ansible.PlaybookEndEvent{}                                           // 6s
+	in <- taskStart("interrupted")                                              // 7s
+	close(in)                                                                   // 8s
+	for range tracker.track(in) {
+	}
+	timings := tracker.wait()
+
+	if len(timings) != 3 {
+		t.Fatalf("expected 3 tasks, but got %+v", timings)
+	}
+	install := timings[0]
+	if install.Phase != "apply" || install.Play != "etcd" || install.Task != "install etcd" || install.Handler {
+		t.Errorf("unexpected task %+v", install)
+	}
+	if !install.Start.Equal(start.Add(time.Second)) || install.Duration() != 3*time.Second || install.Seconds != 3 {
+		t.Errorf("expected the task to take 3s from %v, but got %+v", start.Add(time.Second), install)
+	}
+	if len(install.Hosts) != 2 || install.Hosts[0].Status != "ok" || install.Hosts[0].Seconds != 1 || install.Hosts[1].Status != "failed" || install.Hosts[1].Seconds != 2 {
+		t.Errorf("unexpected host timings %+v", install.Hosts)
+	}
+	if h, ok := install.SlowestHost(); !ok || h.Host != "etcd2" {
+		t.Errorf("expected the slowest host to be etcd2, but got %+v", h)
+	}
+	if restart := timings[1]; !restart.Handler || restart.Seconds != 2 || len(restart.Hosts) != 1 || restart.Hosts[0].Status != "skipped" {
+		t.Errorf("unexpected handler task %+v", restart)
+	}
+	// a task that is running when the stream is closed ends with the stream
+	if interrupted := timings[2]; interrupted.Task != "interrupted" || interrupted.Seconds != 1 || len(interrupted.Hosts) != 0 {
+		t.Errorf("unexpected interrupted task %+v", interrupted)
+	}
+}
+
+func TestSlowestTasks(t *testing.T) {
+	start := time.Now()
+	timings := []TaskTiming{}
+	for _, d := range []time.Duration{3, 1, 5, 2, 4} {
+		timings = append(timings, TaskTiming{Task: d.String(), Start: start, End: start.Add(d * time.Minute)})
+	}
+	slowest := SlowestTasks(timings, 3)
+	if len(slowest) != 3 || slowest[0].Duration() != 5*time.Minute || slowest[1].Duration() != 4*time.Minute || slowest[2].Duration() != 3*time.Minute {
+		t.Errorf("unexpected slowest tasks %+v", slowest)
+	}
+	if timings[0].Duration() != 3*time.Minute {
+		t.Errorf("expected the timings not to be reordered")
+	}
+	if slowest := SlowestTasks(timings[:2], 3); len(slowest) != 2 {
+		t.Errorf("expected all the tasks when there are fewer than requested, but got %+v", slowest)
+	}
+}
+
+func TestExecuteRecordsTaskTimings(t *testing.T) {
+	runsDir := mustGetTempDir(t)
+	defer os.RemoveAll(runsDir)
+	e := ansibleExecutor{
+		options:             ExecutorOptions{RunsDirectory: runsDir},
+		stdout:              ioutil.Discard,
+		consoleOutputFormat: ansible.RawFormat,
+		runnerExplainerFactory: func(exp explain.AnsibleEventExplainer, _ io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+			events := make(chan ansible.Event, 3)
+			events <- taskStart("task")
+			events <- &ansible.RunnerOKEvent{RunnerResultEvent: runnerResult("worker1")}
+			events <- &ansible.PlaybookEndEvent{}
+			close(events)
+			return &fakeRunner{eventChan: events}, &explain.AnsibleEventStreamExplainer{EventExplainer: exp}, nil
+		},
+	}
+	if err := e.execute(context.Background(), task{name: "apply", explainer: e.defaultExplainer()}); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if timings := e.TaskTimings(); len(timings) != 1 || timings[0].Task != "task" || timings[0].Phase != "apply" {
+		t.Errorf("unexpected task timings %+v", timings)
+	}
+	files, err := filepath.Glob(filepath.Join(runsDir, "apply", "*", timingsFile))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected the timings to be recorded in the run directory, but got %v (%v)", files, err)
+	}
+	b, err := ioutil.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("error reading timings: %v", err)
+	}
+	recorded := taskTimings{}
+	if err = json.Unmarshal(b, &recorded); err != nil {
+		t.Fatalf("error unmarshalling timings: %v", err)
+	}
+	if len(recorded.Tasks) != 1 || len(recorded.Tasks[0].Hosts) != 1 || recorded.Tasks[0].Hosts[0].Host != "worker1" {
+		t.Errorf("unexpected recorded timings %+v", recorded)
+	}
+}
@@ -1,7 +1,6 @@
 package install
 
 import (
-	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -39,6 +38,14 @@ func ValidateNode(node *Node) (bool, []error) {
 	return v.valid()
 }
 
+// ValidatePlanTemplateOptions runs validation against the options used to
+// generate a plan file template.
+func ValidatePlanTemplateOptions(o *PlanTemplateOptions) (bool, []error) {
+	v := newValidator()
+	v.validate(o)
+	return v.valid()
+}
+
 // ValidateNodes runs validation against the given node.
 // Validates if the details of the nodes are unique.
 func ValidateNodes(nodes []Node) (bool, []error) {
@@ -51,7 +58,12 @@ func ValidateNodes(nodes []Node) (bool, []error) {
 func ValidatePlanSSHConnections(p *Plan) (bool, []error) {
 	v := newValidator()
 
-	s := sshConnectionSet{p.Cluster.SSH, p.GetUniqueNodes()}
+	s := sshConnectionSet{KeyFields: p.sshKeyFields()}
+	for _, n := range p.GetUniqueNodes() {
+		node := n
+		sshConfig := p.NodeSSHConfig(node)
+		s.Connections = append(s.Connections, SSHConnection{SSHConfig: &sshConfig, Node: &node})
+	}
 
 	v.validateWithErrPrefix("Node Connnection", s)
 
@@ -59,28 +71,51 @@ func ValidatePlanSSHConnections(p *Plan) (bool, []error) {
 }
 
 type sshConnectionSet struct {
-	SSHConfig SSHConfig
-	Nodes     []Node
+	Connections []SSHConnection
+	// KeyFields is the field path of each SSH key, used to report invalid keys
+	KeyFields map[string]string
 }
 
 // ValidateSSHConnection tries to establish SSH connection with the details provieded for a single node
 func ValidateSSHConnection(con *SSHConnection, prefix string) (bool, []error) {
 	v := newValidator()
-	s := sshConnectionSet{*con.SSHConfig, []Node{*con.Node}}
+	s := sshConnectionSet{Connections: []SSHConnection{*con}}
 	v.validateWithErrPrefix(prefix, s)
 	return v.valid()
 }
 
+// sshKeyFields returns the path of the field where each SSH key
+// is first set in the plan
+func (p *Plan) sshKeyFields() map[string]string {
+	fields := map[string]string{}
+	set := func(key string, field string) {
+		if _, ok := fields[key]; key != "" && !ok {
+			fields[key] = field
+		}
+	}
+	set(p.Cluster.SSH.Key, "cluster.ssh.ssh_key")
+	for _, g := range p.nodeGroupSSHOverrides() {
+		if g.overrides != nil {
+			set(g.overrides.Key, g.name+".ssh.ssh_key")
+		}
+	}
+	for _, g := range p.nodeGroupSSHOverrides() {
+		for i, n := range g.nodes {
+			if n.SSH != nil {
+				set(n.SSH.Key, fmt.Sprintf("%s.nodes[%d].ssh.ssh_key", g.name, i))
+			}
+		}
+	}
+	return fields
+}
+
 // ValidateCertificates checks if certificates exist and are valid
 func ValidateCertificates(p *Plan, pki *LocalPKI) (bool, []error) {
 	v := newValidator()
 
 	warn, err := pki.ValidateClusterCertificates(p)
-	if err != nil && len(err) > 0 {
-		v.addError(err...)
-	}
-	if warn != nil && len(warn) > 0 {
-		v.addError(warn...)
+	for _, e := range append(err, warn...) {
+		v.addError(fieldError("", ValidationCodeCertificateInvalid, "%v", e))
 	}
 
 	return v.valid()
@@ -109,107 +144,189 @@ func (v *validator) addError(err ...error) {
 	v.errs = append(v.errs, err...)
 }
 
+// validate the object, keeping both its errors and warnings
 func (v *validator) validate(obj validatable) {
-	if ok, err := obj.validate(); !ok {
-		v.addError(err...)
-	}
+	_, errs := obj.validate()
+	v.addError(errs...)
 }
 
 func (v *validator) validateWithErrPrefix(prefix string, objs ...validatable) {
 	for _, obj := range objs {
-		if ok, err := obj.validate(); !ok {
-			newErrs := make([]error, len(err), len(err))
-			for i, err := range err {
-				newErrs[i] = fmt.Errorf("%s: %v", prefix, err)
-			}
-			v.addError(newErrs...)
-		}
+		v.validateFieldWithErrPrefix("", prefix, obj)
 	}
 }
 
+// validateField validates an object found under the given field of the
+// object being validated. The field is prepended to the path of the errors.
+func (v *validator) validateField(field string, obj validatable) {
+	v.validateFieldWithErrPrefix(field, "", obj)
+}
+
+func (v *validator) validateFieldWithErrPrefix(field string, prefix string, obj validatable) {
+	_, errs := obj.validate()
+	for _, err := range errs {
+		v.addError(withField(field, prefix, err))
+	}
+}
+
+// valid returns false if any errors were found. The errors are returned
+// along with any warnings. Warnings alone do not make the object invalid.
 func (v *validator) valid() (bool, []error) {
-	if len(v.errs) > 0 {
-		return false, v.errs
+	if len(v.errs) == 0 {
+		return true, nil
 	}
-	return true, nil
+	for _, err := range v.errs {
+		if !IsValidationWarning(err) {
+			return false, v.errs
+		}
+	}
+	return true, v.errs
 }
 
 func (p *Plan) validate() (bool, []error) {
 	v := newValidator()
 
-	v.validate(&p.Cluster)
-	v.validate(&p.DockerRegistry)
+	v.validateField("cluster", &p.Cluster)
+	v.validateField("docker_registry", &p.DockerRegistry)
 	if p.Cluster.DisconnectedInstallation && !p.PrivateRegistryProvided() {
-		v.addError(fmt.Errorf("A container image registry is required when disconnected_installation is true"))
-	}
-
-	v.validateWithErrPrefix("Docker", p.Docker)
-	v.validate(&additionalFilesGroup{AdditionalFiles: p.AdditionalFiles, Plan: p})
-	v.validate(&p.AddOns)
-	v.validate(nodeList{Nodes: p.getAllNodes()})
-	v.validateWithErrPrefix("Etcd nodes", &p.Etcd)
-	v.validateWithErrPrefix("Master nodes", &p.Master)
-	v.validateWithErrPrefix("Worker nodes", &p.Worker)
-	v.validateWithErrPrefix("Ingress nodes", &p.Ingress)
-	v.validate(p.NFS)
-	v.validateWithErrPrefix("Storage nodes", &p.Storage)
+		v.addError(fieldError("cluster.disconnected_installation", ValidationCodeInvalid, "A container image registry is required when disconnected_installation is true"))
+	}
+
+	v.validateFieldWithErrPrefix("docker", "Docker", p.Docker)
+	v.validateField("additional_files", &additionalFilesGroup{AdditionalFiles: p.AdditionalFiles, Plan: p})
+	v.validateField("add_ons", &p.AddOns)
+	v.validate(p.allNodesList())
+	v.validateFieldWithErrPrefix("etcd", "Etcd nodes", &p.Etcd)
+	v.validateFieldWithErrPrefix("master", "Master nodes", &p.Master)
+	v.validateFieldWithErrPrefix("worker", "Worker nodes", &p.Worker)
+	v.validateFieldWithErrPrefix("ingress", "Ingress nodes", &p.Ingress)
+	v.validateField("nfs", p.NFS)
+	v.validateFieldWithErrPrefix("storage", "Storage nodes", &p.Storage)
+	v.addError(p.validateNodeSSHDefinedOnce()...)
+	v.validate(&networkTopology{plan: p})
+	v.addError(p.deprecatedFieldWarnings()...)
 
 	return v.valid()
 }
 
+// deprecatedFieldWarnings returns a warning for each deprecated field that is set.
+// The deprecated fields of plan files written for an older schema version are
+// migrated when the plan is read, so these are usually found in newer plan files.
+func (p *Plan) deprecatedFieldWarnings() []error {
+	warnings := []error{}
+	deprecated := func(field string, replacement string) {
+		warnings = append(warnings, fieldWarning(field, ValidationCodeDeprecated, "%s is deprecated. Use %s instead", field, replacement))
+	}
+	if p.Cluster.AdminPassword != "" {
+		warnings = append(warnings, fieldWarning("cluster.admin_password", ValidationCodeDeprecated, "cluster.admin_password is deprecated and will be removed in a future release"))
+	}
+	if p.Cluster.AllowPackageInstallation != nil {
+		deprecated("cluster.allow_package_installation", "cluster.disable_package_installation")
+	}
+	if p.Cluster.Networking.Type != "" {
+		deprecated("cluster.networking.type", "add_ons.cni.options.calico.mode")
+	}
+	if p.DockerRegistry.Address != "" {
+		deprecated("docker_registry.address", "docker_registry.server")
+	}
+	if p.DockerRegistry.Port != 0 {
+		deprecated("docker_registry.port", "docker_registry.server")
+	}
+	if p.Docker.Storage.DirectLVM != nil {
+		deprecated("docker.storage.direct_lvm", "docker.storage.direct_lvm_block_device")
+	}
+	if p.AddOns.DashboardDeprecated != nil {
+		deprecated("add_ons.dashbard", "add_ons.dashboard")
+	}
+	if p.AddOns.HeapsterMonitoring != nil && p.AddOns.HeapsterMonitoring.Options.HeapsterReplicas != 0 {
+		deprecated("add_ons.heapster.options.heapster_replicas", "add_ons.heapster.options.heapster.replicas")
+	}
+	if p.AddOns.HeapsterMonitoring != nil && p.AddOns.HeapsterMonitoring.Options.InfluxDBPVCName != "" {
+		deprecated("add_ons.heapster.options.influxdb_pvc_name", "add_ons.heapster.options.influxdb.pvc_name")
+	}
+	if p.Features != nil && p.Features.PackageManager != nil {
+		deprecated("features.package_manager", "add_ons.package_manager")
+	}
+	return warnings
+}
+
 func (c *Cluster) validate() (bool, []error) {
 	v := newValidator()
 	if c.Name == "" {
-		v.addError(errors.New("Cluster name cannot be empty"))
+		v.addError(fieldError("name", ValidationCodeRequired, "Cluster name cannot be empty"))
 	}
 	// must be a valid semver, start with "v" and be a "suppored" version
-	if !kubernetesVersionValid(c.Version) {
-		v.addError(fmt.Errorf("Cluster version %q invalid, must be a valid %q version, ie %q", c.Version, kubernetesMinorVersionString, kubernetesVersionString))
-	} else {
-		// only go out and get latest version if not disconnected install
-		if !c.DisconnectedInstallation {
-			// should not fail here as its a valid regex
-			version, err := parseVersion(c.Version)
-			// TODO print a warning
-			if err == nil {
-				latestSemver, latest, err := kubernetesLatestStableVersion() // will always return some version
-				if err == nil {
-					if version.GT(latestSemver) {
-						v.addError(fmt.Errorf("Cluster version %q invalid, the latest stable version is %q", c.Version, latest))
-					}
-				}
-				// continue with the installation if an error occurs getting the latest version
-			}
+	if release, ok := versionCatalog.Release(c.Version); !ok {
+		v.addError(fieldError("version", ValidationCodeInvalid, "Cluster version %q invalid, must be a release of a supported minor version %v, ie %q", c.Version, versionCatalog.Minors(), kubernetesVersionString))
+	} else if latest, ok := kubernetesReleaseResolver.latestStable(release, c.DisconnectedInstallation); ok {
+		// the version can only be checked when the latest stable version is known
+		version, err := parseVersion(c.Version)
+		latestSemver, latestErr := parseVersion(latest.Version)
+		if err == nil && latestErr == nil && version.GT(latestSemver) {
+			v.addError(fieldError("version", ValidationCodeInvalid, "Cluster version %q invalid, the latest stable version is %q (%s)", c.Version, latest.Version, latest.Reason))
 		}
 	}
 
-	v.validate(&c.Networking)
-	v.validate(&c.Certificates)
-	v.validate(&c.SSH)
-	v.validate(&c.APIServerOptions)
-	v.validate(&c.KubeControllerManagerOptions)
-	v.validate(&c.KubeProxyOptions)
-	v.validate(&c.KubeSchedulerOptions)
-	v.validate(&c.KubeletOptions)
-	v.validate(&c.CloudProvider)
+	v.validateField("networking", &c.Networking)
+	v.validateField("certificates", &c.Certificates)
+	v.validateField("ssh", &c.SSH)
+	v.validateField("kube_apiserver.option_overrides", &c.APIServerOptions)
+	v.validateField("kube_controller_manager.option_overrides", &c.KubeControllerManagerOptions)
+	v.validateField("kube_proxy.option_overrides", &c.KubeProxyOptions)
+	v.validateField("kube_scheduler.option_overrides", &c.KubeSchedulerOptions)
+	v.validateField("kubelet.option_overrides", &c.KubeletOptions)
+	v.validateField("cloud_provider", &c.CloudProvider)
+
+	if err := validateSecretReference(c.AdminPassword); err != nil {
+		v.addError(fieldError("admin_password", ValidationCodeInvalid, "Cluster admin password is invalid: %v", err))
+	}
 
 	return v.valid()
 }
 
+func (o *PlanTemplateOptions) validate() (bool, []error) {
+	v := newValidator()
+	if o.EtcdNodes <= 0 {
+		v.addError(fieldError("etcd_nodes", ValidationCodeInvalid, "The number of etcd nodes must be greater than zero"))
+	}
+	if o.MasterNodes <= 0 {
+		v.addError(fieldError("master_nodes", ValidationCodeInvalid, "The number of master nodes must be greater than zero"))
+	}
+	if o.WorkerNodes <= 0 {
+		v.addError(fieldError("worker_nodes", ValidationCodeInvalid, "The number of worker nodes must be greater than zero"))
+	}
+	if o.IngressNodes < 0 {
+		v.addError(fieldError("ingress_nodes", ValidationCodeInvalid, "The number of ingress nodes must be greater than or equal to zero"))
+	}
+	if o.StorageNodes < 0 {
+		v.addError(fieldError("storage_nodes", ValidationCodeInvalid, "The number of storage nodes must be greater than or equal to zero"))
+	}
+	if o.AdditionalFiles < 0 {
+		v.addError(fieldError("additional_files", ValidationCodeInvalid, "The number of files or directories must be greater than or equal to zero"))
+	}
+	if o.CNIProvider != "" && !util.Contains(o.CNIProvider, cniProviders()) {
+		v.addError(fieldError("cni_provider", ValidationCodeNotSupported, "%q is not a valid CNI provider. Options are %v", o.CNIProvider, cniProviders()))
+	}
+	if o.DNSProvider != "" && !util.Contains(o.DNSProvider, dnsProviders()) {
+		v.addError(fieldError("dns_provider", ValidationCodeNotSupported, "%q is not a valid DNS provider. Options are %v", o.DNSProvider, dnsProviders()))
+	}
+	return v.valid()
+}
+
 func (n *NetworkConfig) validate() (bool, []error) {
 	v := newValidator()
 	if n.PodCIDRBlock == "" {
-		v.addError(errors.New("Pod CIDR block cannot be empty"))
+		v.addError(fieldError("pod_cidr_block", ValidationCodeRequired, "Pod CIDR block cannot be empty"))
 	}
 	if _, _, err := net.ParseCIDR(n.PodCIDRBlock); n.PodCIDRBlock != "" && err != nil {
-		v.addError(fmt.Errorf("Invalid Pod CIDR block provided: %v", err))
+		v.addError(fieldError("pod_cidr_block", ValidationCodeInvalid, "Invalid Pod CIDR block provided: %v", err))
 	}
 
 	if n.ServiceCIDRBlock == "" {
-		v.addError(errors.New("Service CIDR block cannot be empty"))
+		v.addError(fieldError("service_cidr_block", ValidationCodeRequired, "Service CIDR block cannot be empty"))
 	}
 	if _, _, err := net.ParseCIDR(n.ServiceCIDRBlock); n.ServiceCIDRBlock != "" && err != nil {
-		v.addError(fmt.Errorf("Invalid Service CIDR block provided: %v", err))
+		v.addError(fieldError("service_cidr_block", ValidationCodeInvalid, "Invalid Service CIDR block provided: %v", err))
 	}
 	return v.valid()
 }
@@ -217,10 +334,29 @@ func (n *NetworkConfig) validate() (bool, []error) {
 func (c *CertsConfig) validate() (bool, []error) {
 	v := newValidator()
 	if _, err := time.ParseDuration(c.Expiry); err != nil {
-		v.addError(fmt.Errorf("Invalid certificate expiry %q provided: %v", c.Expiry, err))
+		v.addError(fieldError("expiry", ValidationCodeInvalid, "Invalid certificate expiry %q provided: %v", c.Expiry, err))
 	}
 	if _, err := time.ParseDuration(c.CAExpiry); c.CAExpiry != "" && err != nil { // don't error when empty for backwards compat
-		v.addError(fmt.Errorf("Invalid CA certificate expiry %q provider: %v", c.CAExpiry, err))
+		v.addError(fieldError("ca_expiry", ValidationCodeInvalid, "Invalid CA certificate expiry %q provider: %v", c.CAExpiry, err))
+	}
+	return v.valid()
+}
+
+func (o *SSHOverrides) validate() (bool, []error) {
+	v := newValidator()
+	if o == nil {
+		return v.valid()
+	}
+	if o.Key != "" {
+		if _, err := os.Stat(o.Key); os.IsNotExist(err) {
+			v.addError(fieldError("ssh_key", ValidationCodeFileNotFound, "SSH Key file was not found at %q", o.Key))
+		}
+		if !filepath.IsAbs(o.Key) {
+			v.addError(fieldError("ssh_key", ValidationCodeInvalid, "SSH Key field must be an absolute path"))
+		}
+	}
+	if o.Port < 0 || o.Port > 65535 {
+		v.addError(fieldError("ssh_port", ValidationCodeInvalid, "SSH port %d is invalid. Port must be in the range 1-65535", o.Port))
 	}
 	return v.valid()
 }
@@ -228,19 +364,47 @@ func (c *CertsConfig) validate() (bool, []error) {
 func (s *SSHConfig) validate() (bool, []error) {
 	v := newValidator()
 	if s.User == "" {
-		v.addError(errors.New("SSH user field is required"))
+		v.addError(fieldError("user", ValidationCodeRequired, "SSH user field is required"))
 	}
-	if s.Key == "" {
-		v.addError(errors.New("SSH key field is required"))
+	if s.Key == "" && !s.UseAgent {
+		v.addError(fieldError("ssh_key", ValidationCodeRequired, "SSH key field is required when use_agent is not set"))
 	}
-	if _, err := os.Stat(s.Key); os.IsNotExist(err) {
-		v.addError(fmt.Errorf("SSH Key file was not found at %q", s.Key))
+	if s.Key != "" || !s.UseAgent {
+		if _, err := os.Stat(s.Key); os.IsNotExist(err) {
+			v.addError(fieldError("ssh_key", ValidationCodeFileNotFound, "SSH Key file was not found at %q", s.Key))
+		}
+		if !filepath.IsAbs(s.Key) {
+			v.addError(fieldError("ssh_key", ValidationCodeInvalid, "SSH Key field must be an absolute path"))
+		}
 	}
-	if !filepath.IsAbs(s.Key) {
-		v.addError(errors.New("SSH Key field must be an absolute path"))
+	if s.UseAgent && os.Getenv(ssh.AgentSocketEnvVar) == "" {
+		v.addError(fieldError("use_agent", ValidationCodeInvalid, "The ssh-agent cannot be used as %s is not set", ssh.AgentSocketEnvVar))
 	}
 	if s.Port < 1 || s.Port > 65535 {
-		v.addError(fmt.Errorf("SSH port %d is invalid. Port must be in the range 1-65535", s.Port))
+		v.addError(fieldError("ssh_port", ValidationCodeInvalid, "SSH port %d is invalid. Port must be in the range 1-65535", s.Port))
+	}
+	v.validateFieldWithErrPrefix("bastion", "Bastion", s.Bastion)
+	return v.valid()
+}
+
+func (b *SSHBastion) validate() (bool, []error) {
+	v := newValidator()
+	if b == nil {
+		return v.valid()
+	}
+	if b.Host == "" {
+		v.addError(fieldError("host", ValidationCodeRequired, "SSH bastion host field is required"))
+	}
+	if b.Key != "" {
+		if _, err := os.Stat(b.Key); os.IsNotExist(err) {
+			v.addError(fieldError("ssh_key", ValidationCodeFileNotFound, "SSH Key file was not found at %q", b.Key))
+		}
+		if !filepath.IsAbs(b.Key) {
+			v.addError(fieldError("ssh_key", ValidationCodeInvalid, "SSH Key field must be an absolute path"))
+		}
+	}
+	if b.Port < 0 || b.Port > 65535 {
+		v.addError(fieldError("ssh_port", ValidationCodeInvalid, "SSH port %d is invalid. Port must be in the range 1-65535", b.Port))
 	}
 	return v.valid()
 }
@@ -249,11 +413,15 @@ func (c *CloudProvider) validate() (bool, []error) {
 	v := newValidator()
 	if c.Provider != "" {
 		if !util.Contains(c.Provider, cloudProviders()) {
-			v.addError(fmt.Errorf("%q is not a valid cloud provider. Options are %v", c.Provider, cloudProviders()))
+			v.addError(fieldError("provider", ValidationCodeNotSupported, "%q is not a valid cloud provider. Options are %v", c.Provider, cloudProviders()))
 		}
-		if c.Config != "" {
+		if isSecretReference(c.Config) {
+			if err := validateSecretReference(c.Config); err != nil {
+				v.addError(fieldError("config", ValidationCodeInvalid, "cloud config is invalid: %v", err))
+			}
+		} else if c.Config != "" {
 			if _, err := os.Stat(c.Config); os.IsNotExist(err) {
-				v.addError(fmt.Errorf("cloud config file was not found at %q", c.Config))
+				v.addError(fieldError("config", ValidationCodeFileNotFound, "cloud config file was not found at %q", c.Config))
 			}
 		}
 	}
@@ -267,25 +435,25 @@ type additionalFilesGroup struct {
 
 func (fg *additionalFilesGroup) validate() (bool, []error) {
 	v := newValidator()
-	for _, f := range fg.AdditionalFiles {
+	for i, f := range fg.AdditionalFiles {
 		if len(f.Hosts) < 1 {
-			v.addError(errors.New("File hosts cannot be empty"))
+			v.addError(fieldError(fmt.Sprintf("[%d].hosts", i), ValidationCodeRequired, "File hosts cannot be empty"))
 		}
 		for _, h := range f.Hosts {
 			if !(fg.Plan.HostExists(h) || h == "all" || fg.Plan.ValidRole(h)) {
-				v.addError(fmt.Errorf("File host %q does not match any hosts or roles in the plan file", h))
+				v.addError(fieldError(fmt.Sprintf("[%d].hosts", i), ValidationCodeInvalid, "File host %q does not match any hosts or roles in the plan file", h))
 			}
 		}
 		if !f.SkipValidation {
 			if _, err := os.Stat(f.Source); os.IsNotExist(err) {
-				v.addError(fmt.Errorf("File source %q doesn't exist", f.Source))
+				v.addError(fieldError(fmt.Sprintf("[%d].source", i), ValidationCodeFileNotFound, "File source %q doesn't exist", f.Source))
 			}
 		}
 		if f.Source == "" || !filepath.IsAbs(f.Source) {
-			v.addError(fmt.Errorf("File source %q must be a valid absolute path", f.Source))
+			v.addError(fieldError(fmt.Sprintf("[%d].source", i), ValidationCodeInvalid, "File source %q must be a valid absolute path", f.Source))
 		}
 		if f.Destination == "" || !filepath.IsAbs(f.Destination) {
-			v.addError(fmt.Errorf("File destination %q must be a valid absolute path", f.Destination))
+			v.addError(fieldError(fmt.Sprintf("[%d].destination", i), ValidationCodeInvalid, "File destination %q must be a valid absolute path", f.Destination))
 		}
 	}
 	return v.valid()
@@ -293,11 +461,11 @@ func (fg *additionalFilesGroup) validate() (bool, []error) {
 
 func (f *AddOns) validate() (bool, []error) {
 	v := newValidator()
-	v.validate(f.CNI)
-	v.validate(f.DNS)
-	v.validate(f.HeapsterMonitoring)
-	v.validate(f.Dashboard)
-	v.validate(&f.PackageManager)
+	v.validateField("cni", f.CNI)
+	v.validateField("dns", f.DNS)
+	v.validateField("heapster", f.HeapsterMonitoring)
+	v.validateField("dashboard", f.Dashboard)
+	v.validateField("package_manager", &f.PackageManager)
 	return v.valid()
 }
 
@@ -305,14 +473,19 @@ func (n *CNI) validate() (bool, []error) {
 	v := newValidator()
 	if n != nil && !n.Disable {
 		if !util.Contains(n.Provider, cniProviders()) {
-			v.addError(fmt.Errorf("%q is not a valid CNI provider. Options are %v", n.Provider, cniProviders()))
+			v.addError(fieldError("provider", ValidationCodeNotSupported, "%q is not a valid CNI provider. Options are %v", n.Provider, cniProviders()))
 		}
 		if n.Provider == "calico" {
 			if !util.Contains(n.Options.Calico.Mode, calicoMode()) {
-				v.addError(fmt.Errorf("%q is not a valid Calico mode. Options are %v", n.Options.Calico.Mode, calicoMode()))
+				v.addError(fieldError("options.calico.mode", ValidationCodeNotSupported, "%q is not a valid Calico mode. Options are %v", n.Options.Calico.Mode, calicoMode()))
 			}
 			if !util.Contains(n.Options.Calico.LogLevel, calicoLogLevel()) {
-				v.addError(fmt.Errorf("%q is not a valid Calico log level. Options are %v", n.Options.Calico.LogLevel, calicoLogLevel()))
+				v.addError(fieldError("options.calico.log_level", ValidationCodeNotSupported, "%q is not a valid Calico log level. Options are %v", n.Options.Calico.LogLevel, calicoLogLevel()))
+			}
+		}
+		if n.Provider == "weave" {
+			if err := validateSecretReference(n.Options.Weave.Password); err != nil {
+				v.addError(fieldError("options.weave.password", ValidationCodeInvalid, "Weave password is invalid: %v", err))
 			}
 		}
 	}
@@ -323,7 +496,7 @@ func (n DNS) validate() (bool, []error) {
 	v := newValidator()
 	if !n.Disable {
 		if !util.Contains(n.Provider, dnsProviders()) {
-			v.addError(fmt.Errorf("%q is not a valid DNS provider. Optins are %v", n.Provider, dnsProviders()))
+			v.addError(fieldError("provider", ValidationCodeNotSupported, "%q is not a valid DNS provider. Optins are %v", n.Provider, dnsProviders()))
 		}
 	}
 	return v.valid()
@@ -333,10 +506,10 @@ func (h *HeapsterMonitoring) validate() (bool, []error) {
 	v := newValidator()
 	if h != nil && !h.Disable {
 		if h.Options.Heapster.Replicas <= 0 {
-			v.addError(fmt.Errorf("Heapster replicas %d is not valid, must be greater than 0", h.Options.HeapsterReplicas))
+			v.addError(fieldError("options.heapster.replicas", ValidationCodeInvalid, "Heapster replicas %d is not valid, must be greater than 0", h.Options.HeapsterReplicas))
 		}
 		if !util.Contains(h.Options.Heapster.ServiceType, serviceTypes()) {
-			v.addError(fmt.Errorf("Heapster Service Type %q is not a valid option %v", h.Options.Heapster.ServiceType, serviceTypes()))
+			v.addError(fieldError("options.heapster.service_type", ValidationCodeNotSupported, "Heapster Service Type %q is not a valid option %v", h.Options.Heapster.ServiceType, serviceTypes()))
 		}
 	}
 	return v.valid()
@@ -346,7 +519,7 @@ func (d *Dashboard) validate() (bool, []error) {
 	v := newValidator()
 	if d != nil && !d.Disable {
 		if !util.Contains(d.Options.ServiceType, serviceTypes()) {
-			v.addError(fmt.Errorf("Dashboard Service Type %q is not a valid option %v", d.Options.ServiceType, serviceTypes()))
+			v.addError(fieldError("options.service_type", ValidationCodeNotSupported, "Dashboard Service Type %q is not a valid option %v", d.Options.ServiceType, serviceTypes()))
 		}
 	}
 	return v.valid()
@@ -356,7 +529,7 @@ func (p *PackageManager) validate() (bool, []error) {
 	v := newValidator()
 	if !p.Disable {
 		if !util.Contains(p.Provider, packageManagerProviders()) {
-			v.addError(fmt.Errorf("Package Manager %q is not a valid option %v", p.Provider, packageManagerProviders()))
+			v.addError(fieldError("provider", ValidationCodeNotSupported, "Package Manager %q is not a valid option %v", p.Provider, packageManagerProviders()))
 		}
 	}
 	return v.valid()
@@ -366,39 +539,46 @@ func (p *PackageManager) validate() (bool, []error) {
 func (s sshConnectionSet) validate() (bool, []error) {
 	v := newValidator()
 
-	err := ssh.ValidUnencryptedPrivateKey(s.SSHConfig.Key)
-	if err != nil {
-		v.addError(fmt.Errorf("SSH key validation error: %v", err))
-	} else {
-		var wg sync.WaitGroup
-		errQueue := make(chan error, len(s.Nodes))
-		// number of nodes
-		wg.Add(len(s.Nodes))
-		for _, node := range s.Nodes {
-			go func(ip string) {
-				defer wg.Done()
-				sshErr := ssh.TestConnection(ip, s.SSHConfig.Port, s.SSHConfig.User, s.SSHConfig.Key)
-				// Need to send something the buffered channel
-				if sshErr != nil {
-					errQueue <- fmt.Errorf("SSH connectivity validation failed for %q: %v", ip, sshErr)
-				} else {
-					errQueue <- nil
-				}
-			}(node.IP)
-		}
-
-		// Wait for all nodes to complete, then close channel
-		go func() {
-			wg.Wait()
-			close(errQueue)
-		}()
-
-		// Read any error
-		for err := range errQueue {
+	// validate each key once, and skip the nodes that use an invalid key
+	invalidKeys := map[string]bool{}
+	var wg sync.WaitGroup
+	errQueue := make(chan error, len(s.Connections))
+	for _, con := range s.Connections {
+		key := con.SSHConfig.Key
+		if _, validated := invalidKeys[key]; !validated && key != "" {
+			err := ssh.ValidPrivateKey(key)
+			invalidKeys[key] = err != nil
 			if err != nil {
-				v.addError(err)
+				v.addError(fieldError(s.KeyFields[key], ValidationCodeInvalid, "SSH key validation error: %v", err))
 			}
 		}
+		if invalidKeys[key] {
+			continue
+		}
+		wg.Add(1)
+		go func(ip string, sshConfig SSHConfig) {
+			defer wg.Done()
+			sshErr := ssh.TestConnectionWithBastion(ip, sshConfig.Port, sshConfig.User, sshConfig.sshAuth(), sshConfig.sshBastion())
+			// Need to send something the buffered channel
+			if sshErr != nil {
+				errQueue <- fieldError("", ValidationCodeConnectionFailed, "SSH connectivity validation failed for %q: %v", ip, sshErr)
+			} else {
+				errQueue <- nil
+			}
+		}(con.Node.IP, *con.SSHConfig)
+	}
+
+	// Wait for all nodes to complete, then close channel
+	go func() {
+		wg.Wait()
+		close(errQueue)
+	}()
+
+	// Read any error
+	for err := range errQueue {
+		if err != nil {
+			v.addError(err)
+		}
 	}
 
 	return v.valid()
@@ -409,33 +589,67 @@ type nodeList struct {
 }
 
 func (nl nodeList) validate() (bool, []error) {
+	return planNodeList{Nodes: nl.Nodes}.validate()
+}
+
+// planNodeList is the list of all nodes of the plan, along with the
+// path of each node, used to report the field of the errors.
+type planNodeList struct {
+	Nodes []Node
+	Paths []string
+}
+
+// allNodesList returns the nodes of the plan, along with their path
+func (p *Plan) allNodesList() planNodeList {
+	nl := planNodeList{Nodes: p.getAllNodes()}
+	groups := []struct {
+		name  string
+		nodes []Node
+	}{{"etcd", p.Etcd.Nodes}, {"master", p.Master.Nodes}, {"worker", p.Worker.Nodes}, {"ingress", p.Ingress.Nodes}, {"storage", p.Storage.Nodes}}
+	for _, g := range groups {
+		for i := range g.nodes {
+			nl.Paths = append(nl.Paths, fmt.Sprintf("%s.nodes[%d]", g.name, i))
+		}
+	}
+	return nl
+}
+
+// field returns the path of the given field of the i-th node
+func (nl planNodeList) field(i int, field string) string {
+	if i >= len(nl.Paths) {
+		return ""
+	}
+	return fieldPath(nl.Paths[i], field)
+}
+
+func (nl planNodeList) validate() (bool, []error) {
 	v := newValidator()
-	v.addError(validateNoDuplicateNodeInfo(nl.Nodes)...)
-	v.addError(validateKubeletOptionsDefinedOnce(nl.Nodes)...)
+	v.addError(validateNoDuplicateNodeInfo(nl)...)
+	v.addError(validateKubeletOptionsDefinedOnce(nl)...)
 	return v.valid()
 }
 
-func validateNoDuplicateNodeInfo(nodes []Node) []error {
+func validateNoDuplicateNodeInfo(nl planNodeList) []error {
 	errs := []error{}
 	hostnames := map[string]string{}
 	ips := map[string]string{}
 	internalIPs := map[string]string{}
-	for _, n := range nodes {
+	for i, n := range nl.Nodes {
 		// Validate all hostnames are unique
 		if val, ok := hostnames[n.Host]; n.Host != "" && ok && val != n.HashCode() {
-			errs = append(errs, fmt.Errorf("Two different nodes cannot have the same hostname %q", n.Host))
+			errs = append(errs, fieldError(nl.field(i, "host"), ValidationCodeDuplicate, "Two different nodes cannot have the same hostname %q", n.Host))
 		} else if n.Host != "" {
 			hostnames[n.Host] = n.HashCode()
 		}
 		// Validate all IPs are unique
 		if val, ok := ips[n.IP]; n.IP != "" && ok && val != n.HashCode() {
-			errs = append(errs, fmt.Errorf("Two different nodes cannot have the same IP %q", n.IP))
+			errs = append(errs, fieldError(nl.field(i, "ip"), ValidationCodeDuplicate, "Two different nodes cannot have the same IP %q", n.IP))
 		} else if n.IP != "" {
 			ips[n.IP] = n.HashCode()
 		}
 		// Validate all internal IPs are unique
 		if val, ok := internalIPs[n.InternalIP]; n.InternalIP != "" && ok && val != n.HashCode() {
-			errs = append(errs, fmt.Errorf("Two different nodes cannot have the same internal IP %q", n.InternalIP))
+			errs = append(errs, fieldError(nl.field(i, "internalip"), ValidationCodeDuplicate, "Two different nodes cannot have the same internal IP %q", n.InternalIP))
 		} else if n.InternalIP != "" {
 			internalIPs[n.InternalIP] = n.HashCode()
 		}
@@ -443,12 +657,12 @@ func validateNoDuplicateNodeInfo(nodes []Node) []error {
 	return errs
 }
 
-func validateKubeletOptionsDefinedOnce(nodes []Node) []error {
+func validateKubeletOptionsDefinedOnce(nl planNodeList) []error {
 	errs := []error{}
 	seenNodes := map[string]map[string]string{}
-	for _, n := range nodes {
+	for i, n := range nl.Nodes {
 		if val, ok := seenNodes[n.HashCode()]; ok && !reflect.DeepEqual(val, n.KubeletOptions.Overrides) {
-			errs = append(errs, fmt.Errorf("Cannot redefine kubelet options for node %q", n.Host))
+			errs = append(errs, fieldError(nl.field(i, "kubelet"), ValidationCodeInvalid, "Cannot redefine kubelet options for node %q", n.Host))
 		} else {
 			seenNodes[n.HashCode()] = n.KubeletOptions.Overrides
 		}
@@ -456,20 +670,39 @@ func validateKubeletOptionsDefinedOnce(nodes []Node) []error {
 	return errs
 }
 
+// validateNodeSSHDefinedOnce validates that nodes in multiple groups are
+// accessed with the same SSH configuration in each group
+func (p *Plan) validateNodeSSHDefinedOnce() []error {
+	errs := []error{}
+	seenNodes := map[string]SSHConfig{}
+	for _, g := range p.nodeGroupSSHOverrides() {
+		for i, n := range g.nodes {
+			sshConfig := n.SSH.apply(g.overrides.apply(p.Cluster.SSH))
+			if val, ok := seenNodes[n.Host]; ok && val != sshConfig {
+				errs = append(errs, fieldError(fmt.Sprintf("%s.nodes[%d].ssh", g.name, i), ValidationCodeInvalid, "Cannot use a different SSH configuration for node %q in the %s group. Set the SSH configuration of the node in each group instead", n.Host, g.name))
+			} else if !ok {
+				seenNodes[n.Host] = sshConfig
+			}
+		}
+	}
+	return errs
+}
+
 func (ng *NodeGroup) validate() (bool, []error) {
 	v := newValidator()
 	if ng == nil || len(ng.Nodes) <= 0 {
-		v.addError(fmt.Errorf("At least one node is required"))
+		v.addError(fieldError("nodes", ValidationCodeRequired, "At least one node is required"))
 	}
 	if ng.ExpectedCount <= 0 {
-		v.addError(fmt.Errorf("Node count must be greater than 0"))
+		v.addError(fieldError("expected_count", ValidationCodeInvalid, "Node count must be greater than 0"))
 	}
 	if len(ng.Nodes) != ng.ExpectedCount && (len(ng.Nodes) > 0 && ng.ExpectedCount > 0) {
-		v.addError(fmt.Errorf("Expected node count (%d) does not match the number of nodes provided (%d)", ng.ExpectedCount, len(ng.Nodes)))
+		v.addError(fieldError("expected_count", ValidationCodeInvalid, "Expected node count (%d) does not match the number of nodes provided (%d)", ng.ExpectedCount, len(ng.Nodes)))
 	}
 	for i, n := range ng.Nodes {
-		v.validateWithErrPrefix(fmt.Sprintf("Node #%d", i+1), &n)
+		v.validateFieldWithErrPrefix(fmt.Sprintf("nodes[%d]", i), fmt.Sprintf("Node #%d", i+1), &n)
 	}
+	v.validateField("ssh", ng.SSH)
 
 	return v.valid()
 }
@@ -487,7 +720,7 @@ func (ong *OptionalNodeGroup) validate() (bool, []error) {
 		return true, nil
 	}
 	if len(ong.Nodes) != ong.ExpectedCount {
-		return false, []error{fmt.Errorf("Expected node count (%d) does not match the number of nodes provided (%d)", ong.ExpectedCount, len(ong.Nodes))}
+		return false, []error{fieldError("expected_count", ValidationCodeInvalid, "Expected node count (%d) does not match the number of nodes provided (%d)", ong.ExpectedCount, len(ong.Nodes))}
 	}
 	ng := NodeGroup(*ong)
 	return ng.validate()
@@ -497,24 +730,25 @@ func (mng *MasterNodeGroup) validate() (bool, []error) {
 	v := newValidator()
 
 	if len(mng.Nodes) <= 0 {
-		v.addError(fmt.Errorf("At least one node is required"))
+		v.addError(fieldError("nodes", ValidationCodeRequired, "At least one node is required"))
 	}
 	if mng.ExpectedCount <= 0 {
-		v.addError(fmt.Errorf("Node count must be greater than 0"))
+		v.addError(fieldError("expected_count", ValidationCodeInvalid, "Node count must be greater than 0"))
 	}
 	if len(mng.Nodes) != mng.ExpectedCount && (len(mng.Nodes) > 0 && mng.ExpectedCount > 0) {
-		v.addError(fmt.Errorf("Expected node count (%d) does not match the number of nodes provided (%d)", mng.ExpectedCount, len(mng.Nodes)))
+		v.addError(fieldError("expected_count", ValidationCodeInvalid, "Expected node count (%d) does not match the number of nodes provided (%d)", mng.ExpectedCount, len(mng.Nodes)))
 	}
 	for i, n := range mng.Nodes {
-		v.validateWithErrPrefix(fmt.Sprintf("Node #%d", i+1), &n)
+		v.validateFieldWithErrPrefix(fmt.Sprintf("nodes[%d]", i), fmt.Sprintf("Node #%d", i+1), &n)
 	}
+	v.validateField("ssh", mng.SSH)
 
 	if mng.LoadBalancedFQDN == "" {
-		v.addError(fmt.Errorf("Load balanced FQDN is required"))
+		v.addError(fieldError("load_balanced_fqdn", ValidationCodeRequired, "Load balanced FQDN is required"))
 	}
 
 	if mng.LoadBalancedShortName == "" {
-		v.addError(fmt.Errorf("Load balanced shortname is required"))
+		v.addError(fieldError("load_balanced_short_name", ValidationCodeRequired, "Load balanced shortname is required"))
 	}
 
 	return v.valid()
@@ -523,86 +757,90 @@ func (mng *MasterNodeGroup) validate() (bool, []error) {
 func (n *Node) validate() (bool, []error) {
 	v := newValidator()
 	if n.Host == "" {
-		v.addError(fmt.Errorf("Node host field is required"))
+		v.addError(fieldError("host", ValidationCodeRequired, "Node host field is required"))
 	}
 	if n.IP == "" {
-		v.addError(fmt.Errorf("Node IP field is required"))
+		v.addError(fieldError("ip", ValidationCodeRequired, "Node IP field is required"))
 	}
 	if ip := net.ParseIP(n.IP); ip == nil && n.IP != "" {
-		v.addError(fmt.Errorf("Invalid IP provided"))
+		v.addError(fieldError("ip", ValidationCodeInvalid, "Invalid IP provided"))
 	}
 	if ip := net.ParseIP(n.InternalIP); n.InternalIP != "" && ip == nil {
-		v.addError(fmt.Errorf("Invalid InternalIP provided"))
+		v.addError(fieldError("internalip", ValidationCodeInvalid, "Invalid InternalIP provided"))
 	}
 	// Validate node labels don't start with 'kismatic/' as that is reserved
 	for key, val := range n.Labels {
 		if strings.HasPrefix(key, "kismatic/") {
-			v.addError(fmt.Errorf("Node label %q cannot start with 'kismatic/'", key))
+			v.addError(fieldError("labels."+key, ValidationCodeInvalid, "Node label %q cannot start with 'kismatic/'", key))
 		}
 		errs := validation.IsQualifiedName(key)
 		for _, err := range errs {
-			v.addError(fmt.Errorf("Node label name %q is not valid %s", key, err))
+			v.addError(fieldError("labels."+key, ValidationCodeInvalid, "Node label name %q is not valid %s", key, err))
 		}
 		errs = validation.IsValidLabelValue(val)
 		for _, err := range errs {
-			v.addError(fmt.Errorf("Node label %q is not valid %s", val, err))
+			v.addError(fieldError("labels."+key, ValidationCodeInvalid, "Node label %q is not valid %s", val, err))
 		}
 	}
 	// Validate node taints don't start with 'kismatic/' as that is reserved
 	// Don't validate effects as those will likely change
-	for _, taint := range n.Taints {
+	for i, taint := range n.Taints {
 		if strings.HasPrefix(taint.Key, "kismatic/") {
-			v.addError(fmt.Errorf("Node taint %q cannot start with 'kismatic/'", taint.Key))
+			v.addError(fieldError(fmt.Sprintf("taints[%d].key", i), ValidationCodeInvalid, "Node taint %q cannot start with 'kismatic/'", taint.Key))
 		}
 		errs := validation.IsQualifiedName(taint.Key)
 		for _, err := range errs {
-			v.addError(fmt.Errorf("Node taint name %q is not valid %s", taint.Key, err))
+			v.addError(fieldError(fmt.Sprintf("taints[%d].key", i), ValidationCodeInvalid, "Node taint name %q is not valid %s", taint.Key, err))
 		}
 		errs = validation.IsValidLabelValue(taint.Value)
 		for _, err := range errs {
-			v.addError(fmt.Errorf("Node taint %q is not valid %s", taint.Value, err))
+			v.addError(fieldError(fmt.Sprintf("taints[%d].value", i), ValidationCodeInvalid, "Node taint %q is not valid %s", taint.Value, err))
 		}
 		if !util.Contains(taint.Effect, taintEffects()) {
-			v.addError(fmt.Errorf("Node taint effect %q is not valid. Valid effects are: %v", taint.Effect, taintEffects()))
+			v.addError(fieldError(fmt.Sprintf("taints[%d].effect", i), ValidationCodeNotSupported, "Node taint effect %q is not valid. Valid effects are: %v", taint.Effect, taintEffects()))
 		}
 	}
+	v.validateField("ssh", n.SSH)
 	return v.valid()
 }
 
 func (dr *DockerRegistry) validate() (bool, []error) {
 	v := newValidator()
 	if (dr.Server == "" && dr.Address == "") && (dr.CAPath != "") {
-		v.addError(fmt.Errorf("Docker Registry server cannot be empty when CA is provided"))
+		v.addError(fieldError("server", ValidationCodeRequired, "Docker Registry server cannot be empty when CA is provided"))
 	}
 	if (dr.Server == "" && dr.Address == "") && (dr.Username != "") {
-		v.addError(fmt.Errorf("Docker Registry server cannot be empty when a username is provided"))
+		v.addError(fieldError("server", ValidationCodeRequired, "Docker Registry server cannot be empty when a username is provided"))
 	}
 	if _, err := os.Stat(dr.CAPath); dr.CAPath != "" && os.IsNotExist(err) {
-		v.addError(fmt.Errorf("Docker Registry CA file was not found at %q", dr.CAPath))
+		v.addError(fieldError("CA", ValidationCodeFileNotFound, "Docker Registry CA file was not found at %q", dr.CAPath))
 	}
 	if dr.Username != "" && dr.Password == "" {
-		v.addError(fmt.Errorf("Docker Registry password cannot be blank for username %q", dr.Username))
+		v.addError(fieldError("password", ValidationCodeRequired, "Docker Registry password cannot be blank for username %q", dr.Username))
 	}
 	if dr.Password != "" && dr.Username == "" {
-		v.addError(fmt.Errorf("Docker Registry username cannot be blank when a password is provided"))
+		v.addError(fieldError("username", ValidationCodeRequired, "Docker Registry username cannot be blank when a password is provided"))
+	}
+	if err := validateSecretReference(dr.Password); err != nil {
+		v.addError(fieldError("password", ValidationCodeInvalid, "Docker Registry password is invalid: %v", err))
 	}
 	return v.valid()
 }
 
 func (d Docker) validate() (bool, []error) {
 	v := newValidator()
-	v.validateWithErrPrefix("Storage", d.Storage)
+	v.validateFieldWithErrPrefix("storage", "Storage", d.Storage)
 	return v.valid()
 }
 
 func (ds DockerStorage) validate() (bool, []error) {
 	v := newValidator()
-	v.validateWithErrPrefix("Direct LVM", ds.DirectLVM)
+	v.validateFieldWithErrPrefix("direct_lvm", "Direct LVM", ds.DirectLVM)
 	if ds.DirectLVMBlockDevice.Path != "" && ds.Driver != "devicemapper" {
-		v.addError(errors.New("DirectLVMBlockDevice Path can only be used with 'devicemapper' storage driver"))
+		v.addError(fieldError("direct_lvm_block_device.path", ValidationCodeInvalid, "DirectLVMBlockDevice Path can only be used with 'devicemapper' storage driver"))
 	}
 	if ds.DirectLVMBlockDevice.Path != "" && !filepath.IsAbs(ds.DirectLVMBlockDevice.Path) {
-		v.addError(errors.New("DirectLVMBlockDevice Path must be absolute"))
+		v.addError(fieldError("direct_lvm_block_device.path", ValidationCodeInvalid, "DirectLVMBlockDevice Path must be absolute"))
 	}
 	return v.valid()
 }
@@ -611,10 +849,10 @@ func (dlvm *DockerStorageDirectLVMDeprecated) validate() (bool, []error) {
 	v := newValidator()
 	if dlvm != nil && dlvm.Enabled {
 		if dlvm.BlockDevice == "" {
-			v.addError(errors.New("DirectLVM is enabled, but no block device was specified"))
+			v.addError(fieldError("block_device", ValidationCodeRequired, "DirectLVM is enabled, but no block device was specified"))
 		}
 		if !filepath.IsAbs(dlvm.BlockDevice) {
-			v.addError(errors.New("Path to the block device must be absolute"))
+			v.addError(fieldError("block_device", ValidationCodeInvalid, "Path to the block device must be absolute"))
 		}
 	}
 	return v.valid()
@@ -626,10 +864,10 @@ func (nfs *NFS) validate() (bool, []error) {
 		return v.valid()
 	}
 	uniqueVolumes := make(map[NFSVolume]bool)
-	for _, vol := range nfs.Volumes {
-		v.validate(vol)
+	for i, vol := range nfs.Volumes {
+		v.validateField(fmt.Sprintf("nfs_volume[%d]", i), vol)
 		if _, ok := uniqueVolumes[vol]; ok {
-			v.addError(fmt.Errorf("Duplicate NFS volume %v", vol))
+			v.addError(fieldError(fmt.Sprintf("nfs_volume[%d]", i), ValidationCodeDuplicate, "Duplicate NFS volume %v", vol))
 		} else {
 			uniqueVolumes[vol] = true
 		}
@@ -640,13 +878,13 @@ func (nfs *NFS) validate() (bool, []error) {
 func (nfsVol NFSVolume) validate() (bool, []error) {
 	v := newValidator()
 	if nfsVol.Host == "" {
-		v.addError(errors.New("NFS volume host cannot be empty"))
+		v.addError(fieldError("nfs_host", ValidationCodeRequired, "NFS volume host cannot be empty"))
 	}
 	if nfsVol.Path == "" {
-		v.addError(errors.New("NFS volume path cannot be empty"))
+		v.addError(fieldError("mount_path", ValidationCodeRequired, "NFS volume path cannot be empty"))
 	}
 	if len(nfsVol.Path) > 0 && nfsVol.Path[0] != '/' {
-		v.addError(errors.New("NFS volume path must be absolute"))
+		v.addError(fieldError("mount_path", ValidationCodeInvalid, "NFS volume path must be absolute"))
 	}
 	return v.valid()
 }
@@ -655,35 +893,35 @@ func (sv StorageVolume) validate() (bool, []error) {
 	v := newValidator()
 	notAllowed := ": / \\ & < > |"
 	if strings.ContainsAny(sv.Name, notAllowed) {
-		v.addError(fmt.Errorf("Volume name may not contain spaces or any of the following characters: %q", notAllowed))
+		v.addError(fieldError("name", ValidationCodeInvalid, "Volume name may not contain spaces or any of the following characters: %q", notAllowed))
 	}
 	if sv.SizeGB < 1 {
-		v.addError(errors.New("Volume size must be 1GB or larger"))
+		v.addError(fieldError("size", ValidationCodeInvalid, "Volume size must be 1GB or larger"))
 	}
 	if sv.DistributionCount < 1 {
-		v.addError(errors.New("Distribution count must be greater than zero"))
+		v.addError(fieldError("distribution_count", ValidationCodeInvalid, "Distribution count must be greater than zero"))
 	}
 	if sv.ReplicateCount < 1 {
-		v.addError(errors.New("Replication count must be greater than zero"))
+		v.addError(fieldError("replica_count", ValidationCodeInvalid, "Replication count must be greater than zero"))
 	}
 	for _, a := range sv.AllowAddresses {
 		if ok := validateAllowedAddress(a); !ok {
-			v.addError(fmt.Errorf("Invalid address %q in the list of allowed addresses", a))
+			v.addError(fieldError("allow_addresses", ValidationCodeInvalid, "Invalid address %q in the list of allowed addresses", a))
 		}
 	}
 	reclaimPolicies := []string{"Retain", "Recycle", "Delete"} // API is case-sensitive
 	if !util.Contains(sv.ReclaimPolicy, reclaimPolicies) {
-		v.addError(fmt.Errorf("%q is not a valid reclaim policy. Valid reclaim policies are: %v", sv.ReclaimPolicy, reclaimPolicies))
+		v.addError(fieldError("reclaim_policy", ValidationCodeNotSupported, "%q is not a valid reclaim policy. Valid reclaim policies are: %v", sv.ReclaimPolicy, reclaimPolicies))
 	}
 
 	if len(sv.AccessModes) < 1 {
-		v.addError(errors.New("Access mode was not provided"))
+		v.addError(fieldError("access_modes", ValidationCodeRequired, "Access mode was not provided"))
 	}
 
 	accessModes := []string{"ReadWriteOnce", "ReadOnlyMany", "ReadWriteMany"} // API is case-sensitive
 	for _, m := range sv.AccessModes {
 		if !util.Contains(m, accessModes) {
-			v.addError(fmt.Errorf("%q is not a valid access mode. Valid access modes are: %v", m, accessModes))
+			v.addError(fieldError("access_modes", ValidationCodeNotSupported, "%q is not a valid access mode. Valid access modes are: %v", m, accessModes))
 		}
 	}
 	return v.valid()
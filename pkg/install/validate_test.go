@@ -2,7 +2,10 @@ package install
 
 import (
 	"fmt"
+	"os"
 	"testing"
+
+	"github.com/apprenda/kismatic/pkg/ssh"
 )
 
 func validPlan() Plan {
@@ -359,6 +362,45 @@ func TestValidatePlanNonExistentSSHKey(t *testing.T) {
 	assertInvalidPlan(t, p)
 }
 
+func TestValidatePlanSSHAgent(t *testing.T) {
+	defer os.Setenv(ssh.AgentSocketEnvVar, os.Getenv(ssh.AgentSocketEnvVar))
+	tests := []struct {
+		key   string
+		sock  string
+		valid bool
+	}{
+		{
+			key:   "",
+			sock:  "/tmp/agent.sock",
+			valid: true,
+		},
+		{
+			key:   "/bin/sh",
+			sock:  "/tmp/agent.sock",
+			valid: true,
+		},
+		{
+			key:   "",
+			sock:  "",
+			valid: false,
+		},
+		{
+			key:   "/foo",
+			sock:  "/tmp/agent.sock",
+			valid: false,
+		},
+	}
+	for i, test := range tests {
+		os.Setenv(ssh.AgentSocketEnvVar, test.sock)
+		p := validPlan()
+		p.Cluster.SSH.Key = test.key
+		p.Cluster.SSH.UseAgent = true
+		if valid, errs := ValidatePlan(&p); valid != test.valid {
+			t.Errorf("test %d: expected valid = %t, but got %t: %v", i, test.valid, valid, errs)
+		}
+	}
+}
+
 func TestValidatePlanNegativeSSHPort(t *testing.T) {
 	p := validPlan()
 	p.Cluster.SSH.Port = -1
@@ -2203,3 +2245,46 @@ func TestValidateFile(t *testing.T) {
 		}
 	}
 }
+
+func TestValidatePlanSSHOverrides(t *testing.T) {
+	tests := []struct {
+		modify func(p *Plan)
+		valid  bool
+	}{
+		{
+			modify: func(p *Plan) { p.Master.SSH = &SSHOverrides{User: "master", Key: "/bin/sh", Port: 2222} },
+			valid:  true,
+		},
+		{
+			modify: func(p *Plan) { p.Worker.Nodes[0].SSH = &SSHOverrides{Port: 2222} },
+			valid:  true,
+		},
+		{
+			modify: func(p *Plan) { p.Master.SSH = &SSHOverrides{Key: "relative.key"} },
+			valid:  false,
+		},
+		{
+			modify: func(p *Plan) { p.Worker.Nodes[0].SSH = &SSHOverrides{Port: 70000} },
+			valid:  false,
+		},
+		{
+			// etcd01 is also an ingress node, and must use the same SSH configuration in both groups
+			modify: func(p *Plan) { p.Ingress.SSH = &SSHOverrides{User: "ingress"} },
+			valid:  false,
+		},
+		{
+			modify: func(p *Plan) {
+				p.Ingress.SSH = &SSHOverrides{User: "ingress"}
+				p.Etcd.Nodes[0].SSH = &SSHOverrides{User: "ingress"}
+			},
+			valid: true,
+		},
+	}
+	for i, test := range tests {
+		p := validPlan()
+		test.modify(&p)
+		if valid, errs := ValidatePlan(&p); valid != test.valid {
+			t.Errorf("test %d: expected valid = %t, but got %t: %v", i, test.valid, valid, errs)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package install
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severities of validation errors. Warnings do not prevent the
+// installation from proceeding.
+const (
+	ValidationSeverityError   = "error"
+	ValidationSeverityWarning = "warning"
+)
+
+// Codes of validation errors
+const (
+	// The field is required, but was not set
+	ValidationCodeRequired = "FieldValueRequired"
+	// The value of the field is not valid
+	ValidationCodeInvalid = "FieldValueInvalid"
+	// The value of the field is not one of the supported options
+	ValidationCodeNotSupported = "FieldValueNotSupported"
+	// The value of the field is used more than once
+	ValidationCodeDuplicate = "FieldValueDuplicate"
+	// The file referenced by the field does not exist
+	ValidationCodeFileNotFound = "FileNotFound"
+	// The field is deprecated
+	ValidationCodeDeprecated = "FieldDeprecated"
+	// The node could not be reached
+	ValidationCodeConnectionFailed = "ConnectionFailed"
+	// The certificate is not valid for the cluster
+	ValidationCodeCertificateInvalid = "CertificateInvalid"
+	// The pre-flight checks failed on one or more nodes
+	ValidationCodePreFlightFailed = "PreFlightCheckFailed"
+)
+
+// A ValidationError is an error found when validating the plan, or any
+// other object provided by the user.
+type ValidationError struct {
+	// Path of the field that is invalid, such as "master.nodes[1].ip".
+	// Empty if the error does not apply to a specific field.
+	Field string `json:"field"`
+	// Severity of the error
+	Severity string `json:"severity"`
+	// Code that identifies the type of error
+	Code string `json:"code"`
+	// Message that describes the error
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func fieldError(field string, code string, format string, a ...interface{}) *ValidationError {
+	return &ValidationError{
+		Field:    field,
+		Severity: ValidationSeverityError,
+		Code:     code,
+		Message:  fmt.Sprintf(format, a...),
+	}
+}
+
+func fieldWarning(field string, code string, format string, a ...interface{}) *ValidationError {
+	return &ValidationError{
+		Field:    field,
+		Severity: ValidationSeverityWarning,
+		Code:     code,
+		Message:  fmt.Sprintf(format, a...),
+	}
+}
+
+// IsValidationWarning returns true if the error is a validation warning
+func IsValidationWarning(err error) bool {
+	verr, ok := err.(*ValidationError)
+	return ok && verr.Severity == ValidationSeverityWarning
+}
+
+// ValidationErrors returns the structured form of the errors returned by validation.
+// Errors that do not apply to a specific field are returned with an empty field path.
+func ValidationErrors(errs []error) []ValidationError {
+	verrs := make([]ValidationError, 0, len(errs))
+	for _, err := range errs {
+		if verr, ok := err.(*ValidationError); ok {
+			verrs = append(verrs, *verr)
+			continue
+		}
+		verrs = append(verrs, ValidationError{
+			Severity: ValidationSeverityError,
+			Code:     ValidationCodeInvalid,
+			Message:  err.Error(),
+		})
+	}
+	return verrs
+}
+
+// SplitValidationWarnings splits the errors returned by validation into
+// errors and warnings.
+func SplitValidationWarnings(errs []error) (errors []error, warnings []error) {
+	for _, err := range errs {
+		if IsValidationWarning(err) {
+			warnings = append(warnings, err)
+		} else {
+			errors = append(errors, err)
+		}
+	}
+	return errors, warnings
+}
+
+// fieldPath joins the path of a field to the path of its parent
+func fieldPath(parent string, field string) string {
+	switch {
+	case parent == "":
+		return field
+	case field == "":
+		return parent
+	case strings.HasPrefix(field, "["):
+		return parent + field
+	}
+	return parent + "." + field
+}
+
+// withField returns the error found under the given field, with its path
+// prefixed by the field and its message prefixed by the message prefix.
+func withField(field string, msgPrefix string, err error) error {
+	verr, ok := err.(*ValidationError)
+	if !ok {
+		verr = fieldError("", ValidationCodeInvalid, "%s", err.Error())
+	} else {
+		copied := *verr
+		verr = &copied
+	}
+	verr.Field = fieldPath(field, verr.Field)
+	if msgPrefix != "" {
+		verr.Message = fmt.Sprintf("%s: %s", msgPrefix, verr.Message)
+	}
+	return verr
+}
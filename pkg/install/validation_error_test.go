@@ -0,0 +1,74 @@
+package install
+
+import "testing"
+
+func TestValidatePlanFieldPaths(t *testing.T) {
+	p := validPlan()
+	p.Cluster.Networking.Type = ""
+	p.Master.Nodes[0].IP = "not-an-ip"
+	p.Worker.Nodes[0].IP = p.Etcd.Nodes[0].IP
+	valid, errs := ValidatePlan(&p)
+	if valid {
+		t.Fatalf("expected invalid, but got valid")
+	}
+	expected := []struct {
+		field string
+		code  string
+	}{
+		{field: "master.nodes[0].ip", code: ValidationCodeInvalid},
+		{field: "worker.nodes[0].ip", code: ValidationCodeDuplicate},
+	}
+	verrs := ValidationErrors(errs)
+	for _, e := range expected {
+		found := false
+		for _, verr := range verrs {
+			if verr.Field == e.field && verr.Code == e.code && verr.Severity == ValidationSeverityError {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("expected a %s error for field %q, but got %v", e.code, e.field, verrs)
+		}
+	}
+}
+
+func TestValidatePlanDeprecatedFieldWarning(t *testing.T) {
+	p := validPlan()
+	p.Cluster.Networking.Type = "overlay"
+	valid, errs := ValidatePlan(&p)
+	if !valid {
+		t.Fatalf("expected valid, but got invalid: %v", errs)
+	}
+	errs, warnings := SplitValidationWarnings(errs)
+	if len(errs) != 0 {
+		t.Errorf("expected no errors, but got %v", errs)
+	}
+	found := false
+	for _, w := range ValidationErrors(warnings) {
+		if w.Field == "cluster.networking.type" && w.Code == ValidationCodeDeprecated {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected a deprecation warning for cluster.networking.type, but got %v", warnings)
+	}
+}
+
+func TestFieldPath(t *testing.T) {
+	tests := []struct {
+		parent   string
+		field    string
+		expected string
+	}{
+		{parent: "", field: "ip", expected: "ip"},
+		{parent: "master", field: "", expected: "master"},
+		{parent: "master", field: "nodes", expected: "master.nodes"},
+		{parent: "master.nodes", field: "[0].ip", expected: "master.nodes[0].ip"},
+	}
+	for _, test := range tests {
+		if got := fieldPath(test.parent, test.field); got != test.expected {
+			t.Errorf("expected %q, but got %q", test.expected, got)
+		}
+	}
+}
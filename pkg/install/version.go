@@ -12,12 +12,10 @@ import (
 )
 
 var (
-	httpTimeout                  = 5 * time.Second
-	kubeReleaseRegex             = regexp.MustCompile(`^v1\.10\.(0|[1-9][0-9]*)$`)
-	kubernetesReleaseURL         = "https://storage.googleapis.com/kubernetes-release/release/stable-1.10.txt"
-	kubernetesVersionString      = "v1.10.0"
-	kubernetesMinorVersionString = "v1.10.x"
-	kubernetesVersion            = semver.Version{Major: 1, Minor: 10, Patch: 0} // build the struct directly to not get an error
+	httpTimeout      = 5 * time.Second
+	kubeReleaseRegex = regexp.MustCompile(`^v(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)$`)
+	// the version installed when the plan does not set one
+	kubernetesVersionString = versionCatalog.DefaultRelease().Version
 )
 
 func parseVersion(versionString string) (semver.Version, error) {
@@ -33,14 +31,19 @@ func parseVersion(versionString string) (semver.Version, error) {
 	return v, nil
 }
 
-// kubernetesStableVersion fetches the latest stable version
+// kubernetesLatestStableVersion fetches the latest stable version of the release's minor version
 // if an error occurs it will return the tested version
-func kubernetesLatestStableVersion() (semver.Version, string, error) {
+func kubernetesLatestStableVersion(release KubernetesRelease) (semver.Version, string, error) {
+	kubernetesVersionString := release.Version
+	kubernetesVersion, err := parseVersion(kubernetesVersionString)
+	if err != nil {
+		return kubernetesVersion, kubernetesVersionString, err
+	}
 	timeout := time.Duration(httpTimeout)
 	client := http.Client{
 		Timeout: timeout,
 	}
-	resp, err := client.Get(kubernetesReleaseURL)
+	resp, err := client.Get(kubernetesReleaseURL(release.Minor))
 	if err != nil {
 		return kubernetesVersion, kubernetesVersionString, fmt.Errorf("Error getting latest version from %v", err)
 	}
@@ -53,7 +56,7 @@ func kubernetesLatestStableVersion() (semver.Version, string, error) {
 		return kubernetesVersion, kubernetesVersionString, fmt.Errorf("Error reading response %v", err)
 	}
 	latest := strings.Trim(string(body), " \t\n")
-	if !kubeReleaseRegex.MatchString(latest) {
+	if minor, err := kubernetesMinorVersion(latest); err != nil || minor != release.Minor {
 		return kubernetesVersion, kubernetesVersionString, fmt.Errorf("Invalid version format %q", latest)
 	}
 	parsedLatest, err := parseVersion(latest)
@@ -63,18 +66,23 @@ func kubernetesLatestStableVersion() (semver.Version, string, error) {
 	return parsedLatest, latest, nil
 }
 
-// validates that the version is the expected Minor version
-func kubernetesVersionValid(version string) bool {
-	return kubeReleaseRegex.MatchString(version)
-}
-
 // VersionOverrides returns a map of all image names and their versions that can be modified by the user
 func VersionOverrides() map[string]string {
+	return imageVersions(kubernetesVersionString)
+}
+
+// imageVersions returns the versions of the images that depend on the Kubernetes version
+func imageVersions(kubernetesVersion string) map[string]string {
 	versions := make(map[string]string, 0)
-	versions["kube_proxy"] = kubernetesVersionString
-	versions["kube_controller_manager"] = kubernetesVersionString
-	versions["kube_scheduler"] = kubernetesVersionString
-	versions["kube_apiserver"] = kubernetesVersionString
+	if r, ok := versionCatalog.Release(kubernetesVersion); ok {
+		for name, v := range r.Images {
+			versions[name] = v
+		}
+	}
+	versions["kube_proxy"] = kubernetesVersion
+	versions["kube_controller_manager"] = kubernetesVersion
+	versions["kube_scheduler"] = kubernetesVersion
+	versions["kube_apiserver"] = kubernetesVersion
 
 	return versions
 }
@@ -0,0 +1,194 @@
+package install
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// versionCatalogYAML lists the Kubernetes minor versions supported by this release
+// of Kismatic, along with the versions of the components installed with them.
+// The images are the versions of the entries of the images manifest that depend
+// on the Kubernetes minor version. The Kubernetes component images always match
+// the cluster version.
+const versionCatalogYAML = `
+kubernetes:
+- minor: v1.9
+  version: v1.9.8
+  packages:
+    yum_release: "0"
+    deb_release: "00"
+  images:
+    etcd: v3.1.11
+    pause: "3.0"
+    kubedns: 1.14.7
+    kube_dnsmasq: 1.14.7
+    kubedns_sidecar: 1.14.7
+    coredns: 1.0.6
+- minor: v1.10
+  version: v1.10.0
+  default: true
+  packages:
+    yum_release: "0"
+    deb_release: "00"
+  images:
+    etcd: v3.1.13
+    pause: "3.0"
+    kubedns: 1.14.9
+    kube_dnsmasq: 1.14.9
+    kubedns_sidecar: 1.14.9
+    coredns: 1.1.1
+`
+
+// VersionCatalog lists the Kubernetes versions that can be installed
+type VersionCatalog struct {
+	Kubernetes []KubernetesRelease
+}
+
+// KubernetesRelease is a supported Kubernetes minor version
+type KubernetesRelease struct {
+	// The minor version, such as v1.10
+	Minor string
+	// The tested patch release of the minor version, used when the plan
+	// does not set a version
+	Version string
+	// Whether this is the version installed by default
+	Default bool
+	// The release of the kubelet and kubectl packages
+	Packages struct {
+		YumRelease string `yaml:"yum_release"`
+		DebRelease string `yaml:"deb_release"`
+	}
+	// The versions of the images that depend on the minor version
+	Images map[string]string
+}
+
+// YumVersion returns the version of the RPM packages for the Kubernetes version
+func (r KubernetesRelease) YumVersion(version string) string {
+	return strings.TrimPrefix(version, "v") + "-" + r.Packages.YumRelease
+}
+
+// DebVersion returns the version of the deb packages for the Kubernetes version
+func (r KubernetesRelease) DebVersion(version string) string {
+	return strings.TrimPrefix(version, "v") + "-" + r.Packages.DebRelease
+}
+
+// versionCatalog is the catalog embedded in the binary
+var versionCatalog = mustParseVersionCatalog(versionCatalogYAML)
+
+func mustParseVersionCatalog(b string) VersionCatalog {
+	c := VersionCatalog{}
+	if err := yaml.Unmarshal([]byte(b), &c); err != nil {
+		panic("failed to parse version catalog: " + err.Error())
+	}
+	versions := map[string]semver.Version{}
+	for _, r := range c.Kubernetes {
+		v, err := parseVersion(r.Version)
+		if err != nil {
+			panic("failed to parse version catalog: " + err.Error())
+		}
+		versions[r.Minor] = v
+	}
+	sort.Slice(c.Kubernetes, func(i, j int) bool {
+		return versions[c.Kubernetes[i].Minor].LT(versions[c.Kubernetes[j].Minor])
+	})
+	return c
+}
+
+// GetVersionCatalog returns the catalog of the Kubernetes versions supported by this release
+func GetVersionCatalog() VersionCatalog {
+	return versionCatalog
+}
+
+// DefaultRelease returns the release that is installed when the plan does not set a version
+func (c VersionCatalog) DefaultRelease() KubernetesRelease {
+	for _, r := range c.Kubernetes {
+		if r.Default {
+			return r
+		}
+	}
+	return c.Kubernetes[len(c.Kubernetes)-1]
+}
+
+// Release returns the release of the minor version of the Kubernetes version
+func (c VersionCatalog) Release(version string) (KubernetesRelease, bool) {
+	minor, err := kubernetesMinorVersion(version)
+	if err != nil {
+		return KubernetesRelease{}, false
+	}
+	for _, r := range c.Kubernetes {
+		if r.Minor == minor {
+			return r, true
+		}
+	}
+	return KubernetesRelease{}, false
+}
+
+// Minors returns the supported minor versions, from oldest to newest
+func (c VersionCatalog) Minors() []string {
+	minors := []string{}
+	for _, r := range c.Kubernetes {
+		minors = append(minors, r.Minor)
+	}
+	return minors
+}
+
+// UpgradableFrom returns the minor versions from which a cluster can be upgraded
+// to the release: the same minor version, and the previous supported minor version,
+// as Kubernetes does not support skipping minor versions
+func (c VersionCatalog) UpgradableFrom(r KubernetesRelease) []string {
+	from := []string{}
+	for i, cr := range c.Kubernetes {
+		if cr.Minor != r.Minor {
+			continue
+		}
+		if i > 0 && isNextMinor(c.Kubernetes[i-1].Minor, cr.Minor) {
+			from = append(from, c.Kubernetes[i-1].Minor)
+		}
+		from = append(from, cr.Minor)
+	}
+	return from
+}
+
+// ValidateUpgrade returns an error if a cluster cannot be upgraded from the
+// Kubernetes version to the target version
+func (c VersionCatalog) ValidateUpgrade(from, to string) error {
+	r, ok := c.Release(to)
+	if !ok {
+		return fmt.Errorf("Kubernetes version %q is not supported, the supported versions are %v", to, c.Minors())
+	}
+	fromMinor, err := kubernetesMinorVersion(from)
+	if err != nil {
+		return err
+	}
+	if !contains(fromMinor, c.UpgradableFrom(r)) {
+		return fmt.Errorf("cannot upgrade from Kubernetes %s to %s: Kubernetes %s can only be upgraded to from %s", from, to, r.Minor, strings.Join(c.UpgradableFrom(r), " or "))
+	}
+	return nil
+}
+
+// kubernetesMinorVersion returns the minor version of the Kubernetes version, such as v1.10
+func kubernetesMinorVersion(version string) (string, error) {
+	if !kubeReleaseRegex.MatchString(version) {
+		return "", fmt.Errorf("invalid Kubernetes version %q", version)
+	}
+	v, err := parseVersion(version)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("v%d.%d", v.Major, v.Minor), nil
+}
+
+func isNextMinor(minor, next string) bool {
+	a, errA := parseVersion(minor + ".0")
+	b, errB := parseVersion(next + ".0")
+	return errA == nil && errB == nil && a.Major == b.Major && a.Minor+1 == b.Minor
+}
+
+// kubernetesReleaseURL returns the URL of the latest stable release of the minor version
+func kubernetesReleaseURL(minor string) string {
+	return fmt.Sprintf("https://storage.googleapis.com/kubernetes-release/release/stable-%s.txt", strings.TrimPrefix(minor, "v"))
+}
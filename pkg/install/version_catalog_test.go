@@ -0,0 +1,93 @@
+package install
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVersionCatalogRelease(t *testing.T) {
+	tests := []struct {
+		version string
+		minor   string
+		valid   bool
+	}{
+		{version: "v1.10.0", minor: "v1.10", valid: true},
+		{version: "v1.10.5", minor: "v1.10", valid: true},
+		{version: "v1.9.8", minor: "v1.9", valid: true},
+		{version: "v1.8.4", valid: false},
+		{version: "1.10.0", valid: false},
+		{version: "v1.10", valid: false},
+		{version: "v1.10.0-beta.1", valid: false},
+		{version: "", valid: false},
+	}
+	for _, test := range tests {
+		r, ok := versionCatalog.Release(test.version)
+		if ok != test.valid {
+			t.Errorf("version %q: expected valid to be %v, but got %v", test.version, test.valid, ok)
+			continue
+		}
+		if ok && r.Minor != test.minor {
+			t.Errorf("version %q: expected minor %q, but got %q", test.version, test.minor, r.Minor)
+		}
+	}
+}
+
+func TestVersionCatalogIsSorted(t *testing.T) {
+	c := mustParseVersionCatalog(`
+kubernetes:
+- minor: v1.10
+  version: v1.10.0
+- minor: v1.9
+  version: v1.9.8
+`)
+	if expected := []string{"v1.9", "v1.10"}; !reflect.DeepEqual(c.Minors(), expected) {
+		t.Errorf("expected the minor versions to be %v, but got %v", expected, c.Minors())
+	}
+	// with no default, the newest release is installed
+	if c.DefaultRelease().Minor != "v1.10" {
+		t.Errorf("expected the default release to be v1.10, but got %q", c.DefaultRelease().Minor)
+	}
+}
+
+func TestVersionCatalogDefaultRelease(t *testing.T) {
+	r := versionCatalog.DefaultRelease()
+	if r.Version != kubernetesVersionString {
+		t.Errorf("expected the default version to be %q, but got %q", kubernetesVersionString, r.Version)
+	}
+	for _, name := range []string{"etcd", "pause", "kubedns", "kube_dnsmasq", "kubedns_sidecar", "coredns"} {
+		if r.Images[name] == "" {
+			t.Errorf("expected the default release to set the %q image version", name)
+		}
+	}
+}
+
+func TestVersionCatalogValidateUpgrade(t *testing.T) {
+	tests := []struct {
+		from, to string
+		valid    bool
+	}{
+		{from: "v1.10.0", to: "v1.10.3", valid: true},
+		{from: "v1.9.8", to: "v1.10.0", valid: true},
+		{from: "v1.9.2", to: "v1.9.8", valid: true},
+		{from: "v1.8.4", to: "v1.10.0", valid: false},
+		{from: "v1.10.0", to: "v1.9.8", valid: false},
+		{from: "v1.10.0", to: "v1.11.0", valid: false},
+		{from: "foo", to: "v1.10.0", valid: false},
+	}
+	for _, test := range tests {
+		err := versionCatalog.ValidateUpgrade(test.from, test.to)
+		if (err == nil) != test.valid {
+			t.Errorf("upgrade from %s to %s: expected valid to be %v, but got error %v", test.from, test.to, test.valid, err)
+		}
+	}
+}
+
+func TestKubernetesReleasePackageVersions(t *testing.T) {
+	r, _ := versionCatalog.Release("v1.10.0")
+	if v := r.YumVersion("v1.10.2"); v != "1.10.2-0" {
+		t.Errorf("expected yum version 1.10.2-0, but got %q", v)
+	}
+	if v := r.DebVersion("v1.10.2"); v != "1.10.2-00" {
+		t.Errorf("expected deb version 1.10.2-00, but got %q", v)
+	}
+}
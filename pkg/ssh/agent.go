@@ -0,0 +1,169 @@
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// AgentSocketEnvVar is the environment variable that holds the socket of the ssh-agent
+const AgentSocketEnvVar = "SSH_AUTH_SOCK"
+
+// dialAgent connects to the ssh-agent listening on the SSH_AUTH_SOCK socket
+func dialAgent() (net.Conn, error) {
+	sock := os.Getenv(AgentSocketEnvVar)
+	if sock == "" {
+		return nil, fmt.Errorf("%s is not set: start an ssh-agent to authenticate with its keys", AgentSocketEnvVar)
+	}
+	return dialAgentSocket(sock)
+}
+
+func dialAgentSocket(sock string) (net.Conn, error) {
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to the ssh-agent: %v", err)
+	}
+	return conn, nil
+}
+
+// Agent is an ssh-agent that holds the decrypted private keys used by Ansible,
+// so that the passphrases of the keys are only asked for once per run.
+// Requests for other keys are forwarded to the ssh-agent of the user, if any.
+type Agent struct {
+	dir      string
+	listener net.Listener
+	keyring  agent.Agent
+	// the socket of the ssh-agent of the user, empty if none is running
+	upstream string
+	wg       sync.WaitGroup
+}
+
+// StartAgent starts an ssh-agent holding the private keys in the files
+func StartAgent(keyFiles []string) (*Agent, error) {
+	keyring := agent.NewKeyring()
+	for _, f := range keyFiles {
+		key, err := loadPrivateKey(f)
+		if err != nil {
+			return nil, err
+		}
+		if err = keyring.Add(agent.AddedKey{PrivateKey: key, Comment: f}); err != nil {
+			return nil, fmt.Errorf("error adding SSH key %q to the agent: %v", f, err)
+		}
+	}
+	dir, err := ioutil.TempDir("", "kismatic-agent")
+	if err != nil {
+		return nil, fmt.Errorf("error creating directory for the agent socket: %v", err)
+	}
+	l, err := net.Listen("unix", filepath.Join(dir, "agent.sock"))
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("error listening on the agent socket: %v", err)
+	}
+	a := &Agent{dir: dir, listener: l, keyring: keyring, upstream: os.Getenv(AgentSocketEnvVar)}
+	a.wg.Add(1)
+	go a.serve()
+	return a, nil
+}
+
+// Socket returns the path to the socket the agent listens on,
+// which is to be set as SSH_AUTH_SOCK
+func (a *Agent) Socket() string {
+	return a.listener.Addr().String()
+}
+
+// Close stops the agent and removes its socket
+func (a *Agent) Close() error {
+	err := a.listener.Close()
+	a.wg.Wait()
+	os.RemoveAll(a.dir)
+	return err
+}
+
+func (a *Agent) serve() {
+	defer a.wg.Done()
+	for {
+		conn, err := a.listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			fa := &forwardingAgent{keyring: a.keyring}
+			if a.upstream != "" {
+				if upstream, err := dialAgentSocket(a.upstream); err == nil {
+					defer upstream.Close()
+					fa.upstream = agent.NewClient(upstream)
+				}
+			}
+			agent.ServeAgent(fa, conn)
+		}()
+	}
+}
+
+// forwardingAgent signs with the keys of the keyring, and forwards
+// the requests for other keys to the upstream agent if not nil
+type forwardingAgent struct {
+	keyring  agent.Agent
+	upstream agent.Agent
+}
+
+func (a *forwardingAgent) List() ([]*agent.Key, error) {
+	keys, err := a.keyring.List()
+	if err != nil || a.upstream == nil {
+		return keys, err
+	}
+	upstreamKeys, err := a.upstream.List()
+	if err != nil {
+		return keys, nil
+	}
+	return append(keys, upstreamKeys...), nil
+}
+
+func (a *forwardingAgent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	keys, err := a.keyring.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		if bytes.Equal(k.Marshal(), key.Marshal()) {
+			return a.keyring.Sign(key, data)
+		}
+	}
+	if a.upstream == nil {
+		return nil, fmt.Errorf("key not found")
+	}
+	return a.upstream.Sign(key, data)
+}
+
+func (a *forwardingAgent) Signers() ([]ssh.Signer, error) {
+	return nil, errAgentReadOnly
+}
+
+func (a *forwardingAgent) Add(key agent.AddedKey) error {
+	return errAgentReadOnly
+}
+
+func (a *forwardingAgent) Remove(key ssh.PublicKey) error {
+	return errAgentReadOnly
+}
+
+func (a *forwardingAgent) RemoveAll() error {
+	return errAgentReadOnly
+}
+
+func (a *forwardingAgent) Lock(passphrase []byte) error {
+	return errAgentReadOnly
+}
+
+func (a *forwardingAgent) Unlock(passphrase []byte) error {
+	return errAgentReadOnly
+}
+
+var errAgentReadOnly = fmt.Errorf("the agent does not support modifying its keys")
@@ -0,0 +1,92 @@
+package ssh
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestNativeClientUsesAgent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ssh-agent-test")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv(PassphraseEnvVar, os.Getenv(PassphraseEnvVar))
+	defer os.Setenv(AgentSocketEnvVar, os.Getenv(AgentSocketEnvVar))
+
+	keyFile := filepath.Join(dir, "id_rsa")
+	pub := writeEncryptedTestKey(t, keyFile, "secret")
+	os.Setenv(PassphraseEnvVar, "secret")
+	os.Unsetenv(AgentSocketEnvVar)
+	a, err := StartAgent([]string{keyFile})
+	if err != nil {
+		t.Fatalf("error starting agent: %v", err)
+	}
+	defer a.Close()
+	os.Setenv(AgentSocketEnvVar, a.Socket())
+
+	server := newTestServer(t, pub)
+	defer server.close()
+	pool := NewPool(DefaultClientOptions)
+	defer pool.Close()
+	client, err := pool.NewClient("127.0.0.1", server.port(), "alice", Auth{UseAgent: true}, nil)
+	if err != nil {
+		t.Fatalf("error creating client: %v", err)
+	}
+	out, err := client.Output(false, "echo hello")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "hello\n" {
+		t.Errorf("expected output %q, but got %q", "hello\n", out)
+	}
+}
+
+func TestAgentForwardsToUpstream(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ssh-agent-test")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv(AgentSocketEnvVar, os.Getenv(AgentSocketEnvVar))
+
+	// the upstream agent holds a key that is not served by the kismatic agent
+	upstreamKeyFile, upstreamPub := writeTestKey(t, dir)
+	os.Unsetenv(AgentSocketEnvVar)
+	upstream, err := StartAgent([]string{upstreamKeyFile})
+	if err != nil {
+		t.Fatalf("error starting upstream agent: %v", err)
+	}
+	defer upstream.Close()
+	os.Setenv(AgentSocketEnvVar, upstream.Socket())
+
+	a, err := StartAgent(nil)
+	if err != nil {
+		t.Fatalf("error starting agent: %v", err)
+	}
+	defer a.Close()
+	conn, err := dialAgentSocket(a.Socket())
+	if err != nil {
+		t.Fatalf("error connecting to agent: %v", err)
+	}
+	defer conn.Close()
+	c := agent.NewClient(conn)
+	keys, err := c.List()
+	if err != nil {
+		t.Fatalf("error listing keys: %v", err)
+	}
+	if len(keys) != 1 || string(keys[0].Marshal()) != string(upstreamPub.Marshal()) {
+		t.Fatalf("expected the upstream key to be listed, but got %v", keys)
+	}
+	sig, err := c.Sign(upstreamPub, []byte("data"))
+	if err != nil {
+		t.Fatalf("error signing with the upstream key: %v", err)
+	}
+	if err = upstreamPub.Verify([]byte("data"), sig); err != nil {
+		t.Errorf("invalid signature: %v", err)
+	}
+}
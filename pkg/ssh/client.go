@@ -0,0 +1,584 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// ClientOptions configure the connections established by the SSH clients
+type ClientOptions struct {
+	// The maximum time to wait for a connection to be established,
+	// including the SSH handshake. Connections do not time out when zero.
+	DialTimeout time.Duration
+	// The interval at which keepalive requests are sent to the hosts.
+	// Keepalives are not sent when zero.
+	KeepAliveInterval time.Duration
+	// The maximum time that a command is allowed to run. Interactive shells
+	// are not subject to this timeout. Commands do not time out when zero.
+	CommandTimeout time.Duration
+	// The maximum number of concurrent sessions on each connection.
+	// SSH servers usually refuse more than 10 sessions per connection.
+	MaxSessions int
+	// The known_hosts file that the host keys are verified against. The key
+	// of a host is recorded in the file the first time the host is contacted.
+	// Host keys are not verified when empty.
+	KnownHostsFile string
+}
+
+// DefaultClientOptions are the options used by the clients returned by NewClient
+var DefaultClientOptions = ClientOptions{
+	DialTimeout:       10 * time.Second,
+	KeepAliveInterval: 30 * time.Second,
+	MaxSessions:       10,
+}
+
+var defaultPool = NewPool(DefaultClientOptions)
+
+// SetKnownHostsFile sets the known_hosts file that the clients returned by
+// NewClient verify the host keys against. It applies to the connections
+// established after it is called.
+func SetKnownHostsFile(file string) {
+	defaultPool.mu.Lock()
+	defer defaultPool.mu.Unlock()
+	defaultPool.knownHosts = NewKnownHosts(file)
+}
+
+// A Pool keeps a single connection open to each host, which is shared
+// by the clients of the host. Each command runs in its own session,
+// so that clients can be used concurrently.
+type Pool struct {
+	options ClientOptions
+
+	mu         sync.Mutex
+	knownHosts *KnownHosts
+	conns      map[string]*pooledConn
+	dialing    map[string]*pendingConn
+}
+
+// pendingConn is a connection that is being established
+type pendingConn struct {
+	done chan struct{}
+	conn *pooledConn
+	err  error
+}
+
+type pooledConn struct {
+	client   *ssh.Client
+	sessions chan struct{}
+	closed   chan struct{}
+}
+
+// endpoint is a host, along with the credentials used to connect to it
+type endpoint struct {
+	host string
+	port int
+	user string
+	auth Auth
+}
+
+func (e endpoint) addr() string {
+	return net.JoinHostPort(e.host, strconv.Itoa(e.port))
+}
+
+func (e endpoint) String() string {
+	return fmt.Sprintf("%s@%s", e.user, e.addr())
+}
+
+// NewPool returns a pool of connections established with the given options
+func NewPool(options ClientOptions) *Pool {
+	if options.MaxSessions <= 0 {
+		options.MaxSessions = DefaultClientOptions.MaxSessions
+	}
+	p := &Pool{
+		options: options,
+		conns:   map[string]*pooledConn{},
+		dialing: map[string]*pendingConn{},
+	}
+	if options.KnownHostsFile != "" {
+		p.knownHosts = NewKnownHosts(options.KnownHostsFile)
+	}
+	return p
+}
+
+// NewClient returns a client that runs commands on the host, connecting through
+// the bastion when it is not nil. The connection is established when the first
+// command is run.
+func (p *Pool) NewClient(host string, port int, user string, auth Auth, bastion *Bastion) (*NativeClient, error) {
+	return p.NewClientContext(context.Background(), host, port, user, auth, bastion)
+}
+
+// NewClientContext returns a client like NewClient, whose commands are bound to the
+// context. Connecting to the host is aborted, and the running commands are killed,
+// when the context is cancelled.
+func (p *Pool) NewClientContext(ctx context.Context, host string, port int, user string, auth Auth, bastion *Bastion) (*NativeClient, error) {
+	if err := auth.validate(); err != nil {
+		return nil, err
+	}
+	c := &NativeClient{
+		ctx:    ctx,
+		pool:   p,
+		target: endpoint{host: host, port: port, user: user, auth: auth},
+	}
+	if bastion != nil {
+		if err := bastion.Auth.validate(); err != nil {
+			return nil, fmt.Errorf("bastion: %v", err)
+		}
+		c.bastion = &endpoint{host: bastion.Host, port: bastion.Port, user: bastion.User, auth: bastion.Auth}
+	}
+	return c, nil
+}
+
+// Close closes all the connections of the pool
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	conns := p.conns
+	p.conns = map[string]*pooledConn{}
+	p.mu.Unlock()
+	for _, c := range conns {
+		c.client.Close()
+	}
+	return nil
+}
+
+// conn returns the pooled connection to the target, establishing it if required
+func (p *Pool) conn(ctx context.Context, target endpoint, bastion *endpoint) (*pooledConn, error) {
+	key := fmt.Sprintf("%s|%v", target, target.auth)
+	if bastion != nil {
+		key = fmt.Sprintf("%s|%s|%v", key, *bastion, bastion.auth)
+	}
+	p.mu.Lock()
+	if c, ok := p.conns[key]; ok {
+		p.mu.Unlock()
+		return c, nil
+	}
+	// clients that need the connection while it is being established wait for it
+	if pending, ok := p.dialing[key]; ok {
+		p.mu.Unlock()
+		select {
+		case <-pending.done:
+			return pending.conn, pending.err
+		case <-ctx.Done():
+			return nil, fmt.Errorf("error connecting to %s: %v", target, ctx.Err())
+		}
+	}
+	pending := &pendingConn{done: make(chan struct{})}
+	p.dialing[key] = pending
+	p.mu.Unlock()
+
+	// connections are established without holding the lock, so that
+	// connecting to a host does not block the clients of other hosts
+	pending.conn, pending.err = p.connect(ctx, key, target, bastion)
+	p.mu.Lock()
+	delete(p.dialing, key)
+	if pending.err == nil {
+		select {
+		case <-pending.conn.closed:
+			// closed by the host before it could be pooled
+		default:
+			p.conns[key] = pending.conn
+		}
+	}
+	p.mu.Unlock()
+	close(pending.done)
+	return pending.conn, pending.err
+}
+
+func (p *Pool) connect(ctx context.Context, key string, target endpoint, bastion *endpoint) (*pooledConn, error) {
+	var via *ssh.Client
+	if bastion != nil {
+		b, err := p.conn(ctx, *bastion, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error connecting to bastion %s: %v", *bastion, err)
+		}
+		via = b.client
+	}
+	config, closeAgent, err := p.clientConfig(target)
+	if err != nil {
+		return nil, err
+	}
+	client, err := p.dial(ctx, target, config, via)
+	// the agent is only needed to authenticate
+	closeAgent()
+	if err != nil {
+		return nil, err
+	}
+	c := &pooledConn{
+		client:   client,
+		sessions: make(chan struct{}, p.options.MaxSessions),
+		closed:   make(chan struct{}),
+	}
+	go func() {
+		client.Wait()
+		close(c.closed)
+		p.remove(key, c)
+	}()
+	if p.options.KeepAliveInterval > 0 {
+		go p.keepAlive(c)
+	}
+	return c, nil
+}
+
+// remove the connection from the pool, if it is still the pooled connection
+func (p *Pool) remove(key string, c *pooledConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conns[key] == c {
+		delete(p.conns, key)
+	}
+}
+
+// keepAlive sends keepalive requests until the connection is closed.
+// The connection is closed when a request fails.
+func (p *Pool) keepAlive(c *pooledConn) {
+	ticker := time.NewTicker(p.options.KeepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-ticker.C:
+			if _, _, err := c.client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+				c.client.Close()
+				return
+			}
+		}
+	}
+}
+
+type dialResult struct {
+	client *ssh.Client
+	err    error
+}
+
+// ScanHostKey returns the key presented by the host, connecting through the
+// bastion when it is not nil. The key is not verified, and the host is not
+// authenticated with.
+func (p *Pool) ScanHostKey(host string, port int, bastion *Bastion) (ssh.PublicKey, error) {
+	return p.ScanHostKeyContext(context.Background(), host, port, bastion)
+}
+
+// ScanHostKeyContext returns the key presented by the host like ScanHostKey,
+// and is aborted when the context is cancelled.
+func (p *Pool) ScanHostKeyContext(ctx context.Context, host string, port int, bastion *Bastion) (ssh.PublicKey, error) {
+	target := endpoint{host: host, port: port, user: "kismatic"}
+	var via *ssh.Client
+	if bastion != nil {
+		b, err := p.conn(ctx, endpoint{host: bastion.Host, port: bastion.Port, user: bastion.User, auth: bastion.Auth}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error connecting to bastion %s@%s:%d: %v", bastion.User, bastion.Host, bastion.Port, err)
+		}
+		via = b.client
+	}
+	keys := make(chan ssh.PublicKey, 1)
+	config := &ssh.ClientConfig{
+		User: target.user,
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			keys <- key
+			return errHostKeyScanned
+		},
+	}
+	client, err := p.dial(ctx, target, config, via)
+	if err == nil {
+		client.Close()
+	}
+	select {
+	case key := <-keys:
+		return key, nil
+	default:
+		return nil, err
+	}
+}
+
+// errHostKeyScanned aborts the handshake once the host key has been received
+var errHostKeyScanned = fmt.Errorf("host key scanned")
+
+// dial connects to the target, through the given client if not nil.
+// Connecting and the SSH handshake are bounded by the dial timeout,
+// and are aborted when the context is cancelled.
+func (p *Pool) dial(ctx context.Context, target endpoint, config *ssh.ClientConfig, via *ssh.Client) (*ssh.Client, error) {
+	result := make(chan dialResult, 1)
+	go func() {
+		var conn net.Conn
+		var err error
+		if via != nil {
+			conn, err = via.Dial("tcp", target.addr())
+		} else {
+			d := net.Dialer{Timeout: p.options.DialTimeout}
+			conn, err = d.DialContext(ctx, "tcp", target.addr())
+		}
+		if err != nil {
+			result <- dialResult{err: err}
+			return
+		}
+		c, chans, reqs, err := ssh.NewClientConn(conn, target.addr(), config)
+		if err != nil {
+			conn.Close()
+			result <- dialResult{err: err}
+			return
+		}
+		result <- dialResult{client: ssh.NewClient(c, chans, reqs)}
+	}()
+
+	var timeout <-chan time.Time
+	if p.options.DialTimeout > 0 {
+		timer := time.NewTimer(p.options.DialTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case r := <-result:
+		if r.err != nil {
+			return nil, fmt.Errorf("error connecting to %s: %v", target, r.err)
+		}
+		return r.client, nil
+	case <-timeout:
+		closeLateClient(result)
+		return nil, fmt.Errorf("error connecting to %s: timed out after %v", target, p.options.DialTimeout)
+	case <-ctx.Done():
+		closeLateClient(result)
+		return nil, fmt.Errorf("error connecting to %s: %v", target, ctx.Err())
+	}
+}
+
+// closeLateClient closes the connection if it is established after
+// dialing was given up on
+func closeLateClient(result <-chan dialResult) {
+	go func() {
+		if r := <-result; r.client != nil {
+			r.client.Close()
+		}
+	}()
+}
+
+// clientConfig returns the configuration used to connect to the target.
+// The returned func closes the connection to the ssh-agent, if any.
+func (p *Pool) clientConfig(target endpoint) (*ssh.ClientConfig, func(), error) {
+	closeAgent := func() {}
+	signers := []ssh.Signer{}
+	if target.auth.Key != "" {
+		signer, err := loadSigner(target.auth.Key)
+		if err != nil {
+			return nil, nil, err
+		}
+		signers = append(signers, signer)
+	}
+	if target.auth.UseAgent {
+		conn, err := dialAgent()
+		if err != nil {
+			return nil, nil, err
+		}
+		agentSigners, err := agent.NewClient(conn).Signers()
+		if err != nil {
+			conn.Close()
+			return nil, nil, fmt.Errorf("error getting the keys of the ssh-agent: %v", err)
+		}
+		signers = append(signers, agentSigners...)
+		closeAgent = func() { conn.Close() }
+	}
+	config := &ssh.ClientConfig{
+		User: target.user,
+		// only the first public key method is attempted, so all the keys are offered by the same method
+		Auth: []ssh.AuthMethod{ssh.PublicKeys(signers...)},
+	}
+	p.mu.Lock()
+	knownHosts := p.knownHosts
+	p.mu.Unlock()
+	if knownHosts != nil {
+		algos, err := knownHosts.hostKeyAlgorithms(target.host, target.port)
+		if err != nil {
+			closeAgent()
+			return nil, nil, err
+		}
+		config.HostKeyAlgorithms = algos
+		config.HostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			return knownHosts.Check(target.host, target.port, key)
+		}
+	}
+	return config, closeAgent, nil
+}
+
+// NativeClient runs commands on a host over a connection of its pool
+type NativeClient struct {
+	ctx     context.Context
+	pool    *Pool
+	target  endpoint
+	bastion *endpoint
+}
+
+// session opens a new session, waiting until the connection allows it.
+// The returned func must be called once the session is no longer used.
+func (c *NativeClient) session() (*ssh.Session, func(), error) {
+	// a pooled connection might have been closed by the host,
+	// in which case a new connection is established
+	for attempt := 0; ; attempt++ {
+		conn, err := c.pool.conn(c.ctx, c.target, c.bastion)
+		if err != nil {
+			return nil, nil, err
+		}
+		select {
+		case conn.sessions <- struct{}{}:
+		case <-c.ctx.Done():
+			return nil, nil, fmt.Errorf("error opening session on %s: %v", c.target, c.ctx.Err())
+		}
+		s, err := conn.client.NewSession()
+		if err != nil {
+			<-conn.sessions
+			conn.client.Close()
+			if attempt == 0 {
+				// wait for the connection to be removed from the pool
+				<-conn.closed
+				continue
+			}
+			return nil, nil, fmt.Errorf("error opening session on %s: %v", c.target, err)
+		}
+		release := func() {
+			s.Close()
+			<-conn.sessions
+		}
+		return s, release, nil
+	}
+}
+
+// commandResult is the output and the error of a command run on a session
+type commandResult struct {
+	out []byte
+	err error
+}
+
+// run runs the function, killing the session if the command timeout expires
+// or if the context of the client is cancelled. The output of the function is
+// returned once it completes, and is discarded when the session is killed.
+func (c *NativeClient) run(s *ssh.Session, f func() ([]byte, error)) ([]byte, error) {
+	result := make(chan commandResult, 1)
+	go func() {
+		out, err := f()
+		result <- commandResult{out: out, err: err}
+	}()
+	var timeout <-chan time.Time
+	if c.pool.options.CommandTimeout > 0 {
+		timer := time.NewTimer(c.pool.options.CommandTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case r := <-result:
+		return r.out, r.err
+	case <-timeout:
+		s.Signal(ssh.SIGKILL)
+		s.Close()
+		return nil, fmt.Errorf("command timed out after %v", c.pool.options.CommandTimeout)
+	case <-c.ctx.Done():
+		s.Signal(ssh.SIGKILL)
+		s.Close()
+		return nil, fmt.Errorf("command was cancelled: %v", c.ctx.Err())
+	}
+}
+
+// Output runs the command and returns its combined stdout and stderr
+func (c *NativeClient) Output(pty bool, args ...string) (string, error) {
+	s, release, err := c.session()
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	if pty {
+		if err = s.RequestPty("xterm", 40, 80, ssh.TerminalModes{ssh.ECHO: 0}); err != nil {
+			return "", fmt.Errorf("error requesting pty: %v", err)
+		}
+	}
+	out, err := c.run(s, func() ([]byte, error) {
+		return s.CombinedOutput(strings.Join(args, " "))
+	})
+	return string(out), err
+}
+
+// Shell runs the command, binding Stdin, Stdout and Stderr. An interactive
+// shell is started when no command is given.
+func (c *NativeClient) Shell(pty bool, args ...string) error {
+	s, release, err := c.session()
+	if err != nil {
+		return err
+	}
+	defer release()
+	s.Stdout = os.Stdout
+	s.Stderr = os.Stderr
+	// stdin is copied without waiting for it to be closed, as the session
+	// would otherwise not complete until the user closes stdin
+	stdin, err := s.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("error binding stdin: %v", err)
+	}
+	go io.Copy(stdin, os.Stdin)
+
+	fd := int(os.Stdin.Fd())
+	if pty {
+		width, height := 80, 40
+		if terminal.IsTerminal(fd) {
+			state, err := terminal.MakeRaw(fd)
+			if err != nil {
+				return fmt.Errorf("error configuring terminal: %v", err)
+			}
+			defer terminal.Restore(fd, state)
+			if w, h, err := terminal.GetSize(fd); err == nil {
+				width, height = w, h
+			}
+		}
+		term := os.Getenv("TERM")
+		if term == "" {
+			term = "xterm"
+		}
+		if err = s.RequestPty(term, height, width, ssh.TerminalModes{}); err != nil {
+			return fmt.Errorf("error requesting pty: %v", err)
+		}
+	}
+	if len(args) == 0 {
+		if err = s.Shell(); err != nil {
+			return err
+		}
+		return s.Wait()
+	}
+	_, err = c.run(s, func() ([]byte, error) {
+		return nil, s.Run(strings.Join(args, " "))
+	})
+	return err
+}
+
+// Upload copies the local file to the remote path, preserving its permissions
+func (c *NativeClient) Upload(localPath string, remotePath string) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("error opening %q: %v", localPath, err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("error reading %q: %v", localPath, err)
+	}
+
+	s, release, err := c.session()
+	if err != nil {
+		return err
+	}
+	defer release()
+	s.Stdin = f
+	dest := shellQuote(remotePath)
+	cmd := fmt.Sprintf("cat > %s && chmod %o %s", dest, fi.Mode().Perm(), dest)
+	out, err := c.run(s, func() ([]byte, error) {
+		return s.CombinedOutput(cmd)
+	})
+	if err != nil {
+		return fmt.Errorf("error uploading %q to %s:%s: %v %s", localPath, c.target.host, remotePath, err, out)
+	}
+	return nil
+}
@@ -0,0 +1,445 @@
+package ssh
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// testServer is an in-process SSH server that runs commands with the handler
+type testServer struct {
+	listener net.Listener
+	config   *ssh.ServerConfig
+	handler  func(cmd string, ch ssh.Channel) int
+	hostKey  ssh.PublicKey
+
+	mu             sync.Mutex
+	connections    int
+	sessions       int
+	maxSessions    int
+	conns          []*ssh.ServerConn
+	receivedStdins map[string][]byte
+}
+
+func newTestServer(t *testing.T, authorizedKey ssh.PublicKey) *testServer {
+	hostKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("error generating host key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatalf("error creating signer: %v", err)
+	}
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(authorizedKey.Marshal()) {
+				return nil, fmt.Errorf("unauthorized key")
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	s := &testServer{listener: l, config: config, hostKey: signer.PublicKey(), receivedStdins: map[string][]byte{}}
+	s.handler = s.defaultHandler
+	go s.serve()
+	return s
+}
+
+func (s *testServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *testServer) close() {
+	s.listener.Close()
+	s.closeConnections()
+}
+
+func (s *testServer) closeConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.conns {
+		c.Close()
+	}
+	s.conns = nil
+}
+
+func (s *testServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			sconn, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.connections++
+			s.conns = append(s.conns, sconn)
+			s.mu.Unlock()
+			go ssh.DiscardRequests(reqs)
+			for newChannel := range chans {
+				if newChannel.ChannelType() != "session" {
+					newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+					continue
+				}
+				ch, requests, err := newChannel.Accept()
+				if err != nil {
+					continue
+				}
+				go s.session(ch, requests)
+			}
+		}()
+	}
+}
+
+func (s *testServer) session(ch ssh.Channel, requests <-chan *ssh.Request) {
+	s.mu.Lock()
+	s.sessions++
+	if s.sessions > s.maxSessions {
+		s.maxSessions = s.sessions
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.sessions--
+		s.mu.Unlock()
+	}()
+	for req := range requests {
+		switch req.Type {
+		case "pty-req":
+			req.Reply(true, nil)
+		case "exec":
+			req.Reply(true, nil)
+			var payload struct{ Command string }
+			ssh.Unmarshal(req.Payload, &payload)
+			status := s.handler(payload.Command, ch)
+			ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{uint32(status)}))
+			ch.Close()
+			return
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+func (s *testServer) defaultHandler(cmd string, ch ssh.Channel) int {
+	switch {
+	case cmd == "exit":
+		return 0
+	case strings.HasPrefix(cmd, "echo "):
+		fmt.Fprintln(ch, strings.TrimPrefix(cmd, "echo "))
+		return 0
+	case strings.HasPrefix(cmd, "sleep"):
+		time.Sleep(100 * time.Millisecond)
+		return 0
+	case strings.HasPrefix(cmd, "cat > "):
+		b, _ := ioutil.ReadAll(ch)
+		s.mu.Lock()
+		s.receivedStdins[cmd] = b
+		s.mu.Unlock()
+		return 0
+	}
+	fmt.Fprintf(ch.Stderr(), "%s: command not found\n", cmd)
+	return 127
+}
+
+// writeTestKey writes a new private key to the directory, returning its path and public key
+func writeTestKey(t *testing.T, dir string) (string, ssh.PublicKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	file := filepath.Join(dir, "id_rsa")
+	b := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err = ioutil.WriteFile(file, b, 0600); err != nil {
+		t.Fatalf("error writing key: %v", err)
+	}
+	pub, err := ssh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("error reading public key: %v", err)
+	}
+	return file, pub
+}
+
+func newTestClient(t *testing.T, options ClientOptions) (*testServer, *NativeClient, func()) {
+	dir, err := ioutil.TempDir("", "ssh-client-test")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	keyFile, pub := writeTestKey(t, dir)
+	server := newTestServer(t, pub)
+	pool := NewPool(options)
+	client, err := pool.NewClient("127.0.0.1", server.port(), "alice", Auth{Key: keyFile}, nil)
+	if err != nil {
+		t.Fatalf("error creating client: %v", err)
+	}
+	cleanup := func() {
+		pool.Close()
+		server.close()
+		os.RemoveAll(dir)
+	}
+	return server, client, cleanup
+}
+
+func TestNativeClientOutput(t *testing.T) {
+	server, client, cleanup := newTestClient(t, DefaultClientOptions)
+	defer cleanup()
+
+	out, err := client.Output(false, "echo", "hello")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "hello\n" {
+		t.Errorf("expected output %q, but got %q", "hello\n", out)
+	}
+
+	out, err = client.Output(true, "unknown")
+	if err == nil {
+		t.Errorf("expected an error when the command fails")
+	}
+	if !strings.Contains(out, "command not found") {
+		t.Errorf("expected the output to contain stderr, but got %q", out)
+	}
+	if server.connections != 1 {
+		t.Errorf("expected a single connection, but got %d", server.connections)
+	}
+}
+
+func TestNativeClientConcurrentSessions(t *testing.T) {
+	options := DefaultClientOptions
+	options.MaxSessions = 3
+	server, client, cleanup := newTestClient(t, options)
+	defer cleanup()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Output(false, "sleep"); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.connections != 1 {
+		t.Errorf("expected the connection to be shared, but got %d connections", server.connections)
+	}
+	if server.maxSessions > options.MaxSessions {
+		t.Errorf("expected at most %d concurrent sessions, but got %d", options.MaxSessions, server.maxSessions)
+	}
+}
+
+func TestNativeClientReconnects(t *testing.T) {
+	server, client, cleanup := newTestClient(t, DefaultClientOptions)
+	defer cleanup()
+
+	if _, err := client.Output(false, "exit"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server.closeConnections()
+	if _, err := client.Output(false, "exit"); err != nil {
+		t.Fatalf("expected the client to reconnect, but got: %v", err)
+	}
+	if server.connections != 2 {
+		t.Errorf("expected 2 connections, but got %d", server.connections)
+	}
+}
+
+func TestNativeClientCommandTimeout(t *testing.T) {
+	options := DefaultClientOptions
+	options.CommandTimeout = 10 * time.Millisecond
+	_, client, cleanup := newTestClient(t, options)
+	defer cleanup()
+
+	_, err := client.Output(false, "sleep")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout error, but got %v", err)
+	}
+}
+
+func TestNativeClientCommandCancelled(t *testing.T) {
+	server, client, cleanup := newTestClient(t, DefaultClientOptions)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c, err := client.pool.NewClientContext(ctx, "127.0.0.1", server.port(), "alice", client.target.auth, nil)
+	if err != nil {
+		t.Fatalf("error creating client: %v", err)
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err = c.Output(false, "sleep")
+	if err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Errorf("expected a cancellation error, but got %v", err)
+	}
+	// commands are not run once the context is cancelled
+	if _, err = c.Output(false, "exit"); err == nil {
+		t.Errorf("expected an error running a command after the context was cancelled")
+	}
+}
+
+func TestNativeClientDialCancelled(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ssh-client-test")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	keyFile, _ := writeTestKey(t, dir)
+
+	// a listener that never completes the SSH handshake
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer l.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	client, err := NewPool(DefaultClientOptions).NewClientContext(ctx, "127.0.0.1", l.Addr().(*net.TCPAddr).Port, "alice", Auth{Key: keyFile}, nil)
+	if err != nil {
+		t.Fatalf("error creating client: %v", err)
+	}
+	start := time.Now()
+	_, err = client.Output(false, "exit")
+	if err == nil || !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Errorf("expected a cancellation error, but got %v", err)
+	}
+	if time.Since(start) >= DefaultClientOptions.DialTimeout {
+		t.Errorf("expected connecting to be aborted before the dial timeout")
+	}
+}
+
+func TestNativeClientDialTimeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ssh-client-test")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	keyFile, _ := writeTestKey(t, dir)
+
+	// a listener that never completes the SSH handshake
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer l.Close()
+
+	options := DefaultClientOptions
+	options.DialTimeout = 50 * time.Millisecond
+	client, err := NewPool(options).NewClient("127.0.0.1", l.Addr().(*net.TCPAddr).Port, "alice", Auth{Key: keyFile}, nil)
+	if err != nil {
+		t.Fatalf("error creating client: %v", err)
+	}
+	_, err = client.Output(false, "exit")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout error, but got %v", err)
+	}
+}
+
+func TestNativeClientUpload(t *testing.T) {
+	server, client, cleanup := newTestClient(t, DefaultClientOptions)
+	defer cleanup()
+
+	f, err := ioutil.TempFile("", "upload")
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("contents")
+	f.Close()
+	os.Chmod(f.Name(), 0755)
+
+	if err = client.Upload(f.Name(), "/tmp/it's here"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cmd := `cat > '/tmp/it'\''s here' && chmod 755 '/tmp/it'\''s here'`
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if got := string(server.receivedStdins[cmd]); got != "contents" {
+		t.Errorf("expected the file to be uploaded with %q, but got %v", cmd, server.receivedStdins)
+	}
+}
+
+func TestNativeClientVerifiesHostKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ssh-client-test")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	keyFile, pub := writeTestKey(t, dir)
+	server := newTestServer(t, pub)
+	defer server.close()
+
+	options := DefaultClientOptions
+	options.KnownHostsFile = filepath.Join(dir, "known_hosts")
+	pool := NewPool(options)
+	defer pool.Close()
+	client, err := pool.NewClient("127.0.0.1", server.port(), "alice", Auth{Key: keyFile}, nil)
+	if err != nil {
+		t.Fatalf("error creating client: %v", err)
+	}
+	if _, err = client.Output(false, "exit"); err != nil {
+		t.Fatalf("unexpected error on first contact: %v", err)
+	}
+	keys, err := NewKnownHosts(options.KnownHostsFile).Lookup("127.0.0.1", server.port())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(keys) != 1 || Fingerprint(keys[0]) != Fingerprint(server.hostKey) {
+		t.Fatalf("expected the host key to be recorded, but got %v", keys)
+	}
+
+	// the host presents a different key
+	pool.Close()
+	if err = NewKnownHosts(options.KnownHostsFile).Replace("127.0.0.1", server.port(), newTestPublicKey(t)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = client.Output(false, "exit")
+	if err == nil || !strings.Contains(err.Error(), "host key verification failed") {
+		t.Errorf("expected a host key verification error, but got %v", err)
+	}
+}
+
+func TestScanHostKey(t *testing.T) {
+	server, _, cleanup := newTestClient(t, DefaultClientOptions)
+	defer cleanup()
+
+	key, err := NewPool(DefaultClientOptions).ScanHostKey("127.0.0.1", server.port(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if Fingerprint(key) != Fingerprint(server.hostKey) {
+		t.Errorf("expected the key %s, but got %s", Fingerprint(server.hostKey), Fingerprint(key))
+	}
+	if server.connections != 0 {
+		t.Errorf("expected the scan not to authenticate, but got %d connections", server.connections)
+	}
+}
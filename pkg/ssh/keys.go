@@ -0,0 +1,183 @@
+package ssh
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// PassphraseEnvVar is the environment variable that holds the passphrase of encrypted private keys
+const PassphraseEnvVar = "KISMATIC_SSH_KEY_PASSPHRASE"
+
+// PassphrasePrompt returns the passphrase of the encrypted private key file
+type PassphrasePrompt func(file string) ([]byte, error)
+
+var keys = struct {
+	sync.Mutex
+	prompt PassphrasePrompt
+	// the decrypted keys, so that the passphrase of a key is only asked for once
+	parsed map[string]interface{}
+}{parsed: map[string]interface{}{}}
+
+// SetPassphrasePrompt sets the prompt used to ask for the passphrase of encrypted
+// private keys, when the PassphraseEnvVar environment variable is not set
+func SetPassphrasePrompt(prompt PassphrasePrompt) {
+	keys.Lock()
+	defer keys.Unlock()
+	keys.prompt = prompt
+}
+
+// loadPrivateKey returns the private key in the file, decrypting it with its
+// passphrase when it is encrypted
+func loadPrivateKey(file string) (interface{}, error) {
+	keys.Lock()
+	defer keys.Unlock()
+	if key, ok := keys.parsed[file]; ok {
+		return key, nil
+	}
+	buffer, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading SSH key: %v", err)
+	}
+	block, _ := pem.Decode(buffer)
+	if block == nil {
+		return nil, fmt.Errorf("Parse SSH key error")
+	}
+	if isEncryptedOpenSSHKey(block) {
+		return nil, encryptedOpenSSHKeyError(file)
+	}
+	if x509.IsEncryptedPEMBlock(block) {
+		passphrase, err := keyPassphrase(file)
+		if err != nil {
+			return nil, err
+		}
+		der, err := x509.DecryptPEMBlock(block, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting SSH key %q: %v", file, err)
+		}
+		buffer = pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der})
+	}
+	key, err := ssh.ParseRawPrivateKey(buffer)
+	if err != nil {
+		return nil, fmt.Errorf("Parse SSH key error: %v", err)
+	}
+	keys.parsed[file] = key
+	return key, nil
+}
+
+func keyPassphrase(file string) ([]byte, error) {
+	if passphrase := os.Getenv(PassphraseEnvVar); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+	if keys.prompt == nil {
+		return nil, fmt.Errorf("SSH key %q is encrypted: set its passphrase in the %s environment variable", file, PassphraseEnvVar)
+	}
+	return keys.prompt(file)
+}
+
+// loadSigner returns the signer of the private key in the file
+func loadSigner(file string) (ssh.Signer, error) {
+	key, err := loadPrivateKey(file)
+	if err != nil {
+		return nil, err
+	}
+	return ssh.NewSignerFromKey(key)
+}
+
+// ValidPrivateKey verifies that the file is a private key that is only accessible
+// by its owner. Encrypted keys are valid, and are only decrypted when used.
+func ValidPrivateKey(file string) error {
+	// Check private key before use it
+	fi, err := os.Stat(file)
+	if err != nil {
+		// Abort if key not accessible
+		return err
+	}
+
+	buffer, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	isEncrypted, err := isEncrypted(buffer)
+	if err != nil {
+		return fmt.Errorf("Parse SSH key error")
+	}
+	if block, _ := pem.Decode(buffer); isEncryptedOpenSSHKey(block) {
+		return encryptedOpenSSHKeyError(file)
+	}
+
+	if !isEncrypted {
+		if _, err = ssh.ParsePrivateKey(buffer); err != nil {
+			return fmt.Errorf("Parse SSH key error: %v", err)
+		}
+	}
+
+	if runtime.GOOS != "windows" {
+		mode := fi.Mode()
+
+		// Private key file should have strict permissions
+		perm := mode.Perm()
+		if perm&0400 == 0 {
+			return fmt.Errorf("'%s' is not readable", file)
+		}
+		if perm&0077 != 0 {
+			return fmt.Errorf("permissions %#o for '%s' are too open. Permissions should be set to 0600.", perm, file)
+		}
+	}
+
+	return nil
+}
+
+// EncryptedPrivateKey returns true if the file is an encrypted private key
+func EncryptedPrivateKey(file string) bool {
+	buffer, err := ioutil.ReadFile(file)
+	if err != nil {
+		return false
+	}
+	encrypted, _ := isEncrypted(buffer)
+	return encrypted
+}
+
+func isEncrypted(buffer []byte) (bool, error) {
+	// There is no error, just a nil block
+	block, _ := pem.Decode(buffer)
+	// File cannot be decoded, maybe it's some unexpected format
+	if block == nil {
+		return false, fmt.Errorf("Parse SSH key error")
+	}
+
+	return x509.IsEncryptedPEMBlock(block) || isEncryptedOpenSSHKey(block), nil
+}
+
+// isEncryptedOpenSSHKey returns true if the block is a private key in the OpenSSH
+// format that is encrypted. These keys, written by default by ssh-keygen since
+// OpenSSH 7.8, cannot be decrypted.
+func isEncryptedOpenSSHKey(block *pem.Block) bool {
+	const magic = "openssh-key-v1\x00"
+	if block.Type != "OPENSSH PRIVATE KEY" || !bytes.HasPrefix(block.Bytes, []byte(magic)) {
+		return false
+	}
+	// the key starts with the name of the cipher it is encrypted with
+	rest := block.Bytes[len(magic):]
+	if len(rest) < 4 {
+		return false
+	}
+	n := binary.BigEndian.Uint32(rest)
+	if uint64(n) > uint64(len(rest)-4) {
+		return false
+	}
+	return string(rest[4:4+n]) != "none"
+}
+
+func encryptedOpenSSHKeyError(file string) error {
+	return fmt.Errorf("SSH key %q is encrypted in the OpenSSH format, which is not supported: add the key to an ssh-agent and set use_agent instead of ssh_key, or convert the key to the PEM format with \"ssh-keygen -p -m PEM -f %s\"", file, file)
+}
@@ -0,0 +1,151 @@
+package ssh
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// writeEncryptedTestKey writes a new private key encrypted with the passphrase
+// to the file, returning its public key
+func writeEncryptedTestKey(t *testing.T, file string, passphrase string) ssh.PublicKey {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	block, err := x509.EncryptPEMBlock(rand.Reader, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key), []byte(passphrase), x509.PEMCipherAES128)
+	if err != nil {
+		t.Fatalf("error encrypting key: %v", err)
+	}
+	if err = ioutil.WriteFile(file, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatalf("error writing key: %v", err)
+	}
+	pub, err := ssh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("error reading public key: %v", err)
+	}
+	return pub
+}
+
+func TestLoadEncryptedPrivateKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ssh-keys-test")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv(PassphraseEnvVar, os.Getenv(PassphraseEnvVar))
+	defer SetPassphrasePrompt(nil)
+
+	tests := []struct {
+		name        string
+		envVar      string
+		prompt      PassphrasePrompt
+		expectError bool
+	}{
+		{
+			name:   "passphrase from the environment",
+			envVar: "secret",
+		},
+		{
+			name:   "passphrase from the prompt",
+			prompt: func(string) ([]byte, error) { return []byte("secret"), nil },
+		},
+		{
+			name:   "environment preferred over the prompt",
+			envVar: "secret",
+			prompt: func(string) ([]byte, error) { return nil, fmt.Errorf("unexpected prompt") },
+		},
+		{
+			name:        "wrong passphrase",
+			envVar:      "not the passphrase",
+			expectError: true,
+		},
+		{
+			name:        "no passphrase",
+			expectError: true,
+		},
+	}
+	for i, test := range tests {
+		file := filepath.Join(dir, fmt.Sprintf("id_rsa_%d", i))
+		pub := writeEncryptedTestKey(t, file, "secret")
+		if err = ValidPrivateKey(file); err != nil {
+			t.Errorf("%s: expected the encrypted key to be valid, but got: %v", test.name, err)
+		}
+		if !EncryptedPrivateKey(file) {
+			t.Errorf("%s: expected the key to be reported as encrypted", test.name)
+		}
+		os.Setenv(PassphraseEnvVar, test.envVar)
+		SetPassphrasePrompt(test.prompt)
+		signer, err := loadSigner(file)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("%s: expected an error, but got none", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if string(signer.PublicKey().Marshal()) != string(pub.Marshal()) {
+			t.Errorf("%s: the loaded key does not match the written key", test.name)
+		}
+	}
+}
+
+func TestEncryptedPrivateKeyUnencrypted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ssh-keys-test")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	file, _ := writeTestKey(t, dir)
+	if EncryptedPrivateKey(file) {
+		t.Errorf("expected the key not to be reported as encrypted")
+	}
+	if EncryptedPrivateKey(filepath.Join(dir, "missing")) {
+		t.Errorf("expected a missing key not to be reported as encrypted")
+	}
+}
+
+func TestEncryptedOpenSSHPrivateKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ssh-keys-test")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	// openssh keys start with the name of their cipher, followed by the key
+	// derivation function, its options and the keys
+	openSSHKey := func(cipher string) []byte {
+		b := []byte("openssh-key-v1\x00")
+		b = append(b, 0, 0, 0, byte(len(cipher)))
+		return append(append(b, cipher...), "bcrypt..."...)
+	}
+	file := filepath.Join(dir, "id_ed25519")
+	block := &pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: openSSHKey("aes256-ctr")}
+	if err = ioutil.WriteFile(file, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatalf("error writing key: %v", err)
+	}
+	if !EncryptedPrivateKey(file) {
+		t.Errorf("expected the key to be reported as encrypted")
+	}
+	if err = ValidPrivateKey(file); err == nil || !strings.Contains(err.Error(), "ssh-keygen -p -m PEM") {
+		t.Errorf("expected an error explaining how to use the key, but got %v", err)
+	}
+	if _, err = loadPrivateKey(file); err == nil || !strings.Contains(err.Error(), "ssh-agent") {
+		t.Errorf("expected an error explaining how to use the key, but got %v", err)
+	}
+
+	if isEncryptedOpenSSHKey(&pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: openSSHKey("none")}) {
+		t.Errorf("expected an openssh key without a cipher not to be reported as encrypted")
+	}
+}
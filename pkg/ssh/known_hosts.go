@@ -0,0 +1,206 @@
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// KnownHosts is a known_hosts file in the OpenSSH format. The key of a host
+// is recorded the first time the host is contacted, and the host must present
+// the same key on the following connections.
+type KnownHosts struct {
+	file string
+	mu   sync.Mutex
+}
+
+// NewKnownHosts returns the known hosts recorded in the file.
+// The file is created when the first host is recorded.
+func NewKnownHosts(file string) *KnownHosts {
+	return &KnownHosts{file: file}
+}
+
+// File returns the path to the known_hosts file
+func (k *KnownHosts) File() string {
+	return k.file
+}
+
+// HostKeyMismatchError is returned when a host presents a key that does not
+// match the key recorded for it
+type HostKeyMismatchError struct {
+	Host           string
+	Port           int
+	Fingerprint    string
+	KnownHostsFile string
+}
+
+func (e HostKeyMismatchError) Error() string {
+	return fmt.Sprintf("host key verification failed for %s: the host presented the key %s, which does not match the key recorded in %q. If the host key was changed deliberately, accept the new key with \"kismatic ssh-keys rescan\"", knownHostsAddress(e.Host, e.Port), e.Fingerprint, e.KnownHostsFile)
+}
+
+// Check verifies the key presented by the host against the recorded keys.
+// The key is recorded if no key is known for the host.
+func (k *KnownHosts) Check(host string, port int, key ssh.PublicKey) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	known, err := k.lookup(host, port)
+	if err != nil {
+		return err
+	}
+	if len(known) == 0 {
+		return k.append(host, port, key)
+	}
+	for _, kk := range known {
+		if bytes.Equal(kk.Marshal(), key.Marshal()) {
+			return nil
+		}
+	}
+	return HostKeyMismatchError{Host: host, Port: port, Fingerprint: Fingerprint(key), KnownHostsFile: k.file}
+}
+
+// Lookup returns the keys recorded for the host
+func (k *KnownHosts) Lookup(host string, port int) ([]ssh.PublicKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.lookup(host, port)
+}
+
+// Replace records the key as the only key of the host
+func (k *KnownHosts) Replace(host string, port int, key ssh.PublicKey) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	b, err := k.read()
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	addr := knownHostsAddress(host, port)
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := s.Bytes()
+		if _, hosts, _, _, _, err := ssh.ParseKnownHosts(line); err == nil && containsString(hosts, addr) {
+			continue
+		}
+		out.Write(line)
+		out.WriteByte('\n')
+	}
+	if err = s.Err(); err != nil {
+		return fmt.Errorf("error reading %q: %v", k.file, err)
+	}
+	out.WriteString(knownHostsLine(host, port, key))
+	if err = os.MkdirAll(filepath.Dir(k.file), 0777); err != nil {
+		return fmt.Errorf("error creating directory for %q: %v", k.file, err)
+	}
+	if err = ioutil.WriteFile(k.file, out.Bytes(), 0600); err != nil {
+		return fmt.Errorf("error writing %q: %v", k.file, err)
+	}
+	return nil
+}
+
+func (k *KnownHosts) lookup(host string, port int) ([]ssh.PublicKey, error) {
+	b, err := k.read()
+	if err != nil {
+		return nil, err
+	}
+	addr := knownHostsAddress(host, port)
+	keys := []ssh.PublicKey{}
+	for len(b) > 0 {
+		var (
+			marker string
+			hosts  []string
+			key    ssh.PublicKey
+		)
+		marker, hosts, key, _, b, err = ssh.ParseKnownHosts(b)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %q: %v", k.file, err)
+		}
+		// certificate authorities and revoked keys are not supported
+		if marker == "" && containsString(hosts, addr) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (k *KnownHosts) read() ([]byte, error) {
+	b, err := ioutil.ReadFile(k.file)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error reading %q: %v", k.file, err)
+	}
+	return b, nil
+}
+
+func (k *KnownHosts) append(host string, port int, key ssh.PublicKey) error {
+	if err := os.MkdirAll(filepath.Dir(k.file), 0777); err != nil {
+		return fmt.Errorf("error creating directory for %q: %v", k.file, err)
+	}
+	f, err := os.OpenFile(k.file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening %q: %v", k.file, err)
+	}
+	defer f.Close()
+	if _, err = f.WriteString(knownHostsLine(host, port, key)); err != nil {
+		return fmt.Errorf("error writing %q: %v", k.file, err)
+	}
+	return nil
+}
+
+// hostKeyAlgorithms returns the host key algorithms accepted from the host,
+// preferring the algorithms of the keys recorded for it, as OpenSSH does
+func (k *KnownHosts) hostKeyAlgorithms(host string, port int) ([]string, error) {
+	known, err := k.Lookup(host, port)
+	if err != nil || len(known) == 0 {
+		return nil, err
+	}
+	algos := []string{}
+	for _, key := range known {
+		algos = append(algos, key.Type())
+	}
+	for _, algo := range []string{ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521, ssh.KeyAlgoRSA, ssh.KeyAlgoDSA} {
+		if !containsString(algos, algo) {
+			algos = append(algos, algo)
+		}
+	}
+	return algos, nil
+}
+
+// Fingerprint returns the SHA256 fingerprint of the key, in the format used by OpenSSH
+func Fingerprint(key ssh.PublicKey) string {
+	sum := sha256.Sum256(key.Marshal())
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// knownHostsAddress returns the address of the host, as written in known_hosts files
+func knownHostsAddress(host string, port int) string {
+	if port == 22 {
+		return host
+	}
+	return "[" + host + "]:" + strconv.Itoa(port)
+}
+
+func knownHostsLine(host string, port int, key ssh.PublicKey) string {
+	// MarshalAuthorizedKey includes the trailing newline
+	return knownHostsAddress(host, port) + " " + string(ssh.MarshalAuthorizedKey(key))
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package ssh
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestPublicKey(t *testing.T) ssh.PublicKey {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	pub, err := ssh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("error reading public key: %v", err)
+	}
+	return pub
+}
+
+func TestKnownHosts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "known-hosts-test")
+	if err != nil {
+		t.Fatalf("error creating tmp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "generated", "known_hosts")
+	kh := NewKnownHosts(file)
+	key := newTestPublicKey(t)
+	otherKey := newTestPublicKey(t)
+
+	// the key is trusted on first use
+	if err = kh.Check("10.0.0.1", 22, key); err != nil {
+		t.Fatalf("unexpected error recording the key: %v", err)
+	}
+	if err = kh.Check("10.0.0.1", 22, key); err != nil {
+		t.Errorf("unexpected error verifying the recorded key: %v", err)
+	}
+	err = kh.Check("10.0.0.1", 22, otherKey)
+	if _, ok := err.(HostKeyMismatchError); !ok {
+		t.Errorf("expected a host key mismatch error, but got %v", err)
+	}
+	// hosts are identified by their port too
+	if err = kh.Check("10.0.0.1", 2222, otherKey); err != nil {
+		t.Errorf("unexpected error recording the key of another port: %v", err)
+	}
+
+	if err = kh.Replace("10.0.0.1", 22, otherKey); err != nil {
+		t.Fatalf("unexpected error replacing the key: %v", err)
+	}
+	if err = kh.Check("10.0.0.1", 22, otherKey); err != nil {
+		t.Errorf("unexpected error verifying the replaced key: %v", err)
+	}
+	keys, err := kh.Lookup("10.0.0.1", 22)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(keys) != 1 {
+		t.Errorf("expected the replaced key to be the only key of the host, but got %d keys", len(keys))
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("error reading known_hosts file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	expected := []string{
+		"[10.0.0.1]:2222 " + strings.TrimSpace(string(ssh.MarshalAuthorizedKey(otherKey))),
+		"10.0.0.1 " + strings.TrimSpace(string(ssh.MarshalAuthorizedKey(otherKey))),
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines in the known_hosts file, but got:\n%s", len(expected), b)
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("expected line %d to be %q, but got %q", i, expected[i], lines[i])
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	fp := Fingerprint(newTestPublicKey(t))
+	// base64 of a sha256 sum, without padding
+	if !strings.HasPrefix(fp, "SHA256:") || len(fp) != len("SHA256:")+43 {
+		t.Errorf("unexpected fingerprint %q", fp)
+	}
+}
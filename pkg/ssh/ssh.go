@@ -1,13 +1,9 @@
 package ssh
 
 import (
-	"crypto/x509"
-	"encoding/pem"
+	"context"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"os/exec"
-	"runtime"
+	"strings"
 
 	"golang.org/x/crypto/ssh"
 )
@@ -15,8 +11,6 @@ import (
 var baseSSHArgs = []string{
 	"-F", "/dev/null",
 	"-o", "PasswordAuthentication=no",
-	"-o", "StrictHostKeyChecking=no",
-	"-o", "UserKnownHostsFile=/dev/null",
 	"-o", "LogLevel=quiet", // suppress "Warning: Permanently added '[localhost]:2022' (ECDSA) to the list of known hosts."
 	"-o", "ConnectionAttempts=3", // retry 3 times if SSH connection fails
 	"-o", "ConnectTimeout=10", // timeout after 10 seconds
@@ -24,137 +18,125 @@ var baseSSHArgs = []string{
 	"-o", "ControlPath=none",
 }
 
+// Client runs commands on a remote host
 type Client interface {
+	// Output runs the command and returns its combined stdout and stderr
 	Output(pty bool, args ...string) (string, error)
+	// Shell runs the command, or an interactive shell when no command is given,
+	// binding Stdin, Stdout and Stderr
 	Shell(pty bool, args ...string) error
+	// Upload copies the local file to the remote path, preserving its permissions
+	Upload(localPath string, remotePath string) error
 }
 
-type ExternalClient struct {
-	BaseArgs   []string
-	BinaryPath string
-	cmd        *exec.Cmd
+// IsCommandError returns true if the error was returned because the command
+// ran on the host and exited with a non-zero status, as opposed to the host
+// not being reachable
+func IsCommandError(err error) bool {
+	_, ok := err.(*ssh.ExitError)
+	return ok
 }
 
-// TestConnection connects to ip:port as user with key and immediately exits.
-func TestConnection(ip string, port int, user, key string) error {
-	client, err := NewClient(ip, port, user, key)
-	if err != nil {
-		return err
-	}
-
-	return client.Shell(false, "exit")
+// Auth are the credentials used to authenticate with a host
+type Auth struct {
+	// The path to the private key. Encrypted keys are decrypted with their passphrase.
+	// Optional when UseAgent is set.
+	Key string
+	// Authenticate with the keys of the ssh-agent found with the SSH_AUTH_SOCK
+	// environment variable, in addition to the private key
+	UseAgent bool
 }
 
-// NewClient verifies ssh is available in the PATH and returns an SSH client
-func NewClient(host string, port int, user string, key string) (Client, error) {
-	if err := ValidUnencryptedPrivateKey(key); err != nil {
-		return nil, err
-	}
-
-	sshBinaryPath, err := exec.LookPath("ssh")
-	if err != nil {
-		return nil, fmt.Errorf("command not found: ssh")
+func (a Auth) validate() error {
+	if a.Key == "" {
+		if !a.UseAgent {
+			return fmt.Errorf("an SSH key is required when the ssh-agent is not used")
+		}
+		return nil
 	}
-
-	return newExternalClient(sshBinaryPath, user, host, port, key)
+	return ValidPrivateKey(a.Key)
 }
 
-func newExternalClient(sshBinaryPath string, user string, host string, port int, key string) (*ExternalClient, error) {
-	// Get defailt args with user and host
-	args := append(baseSSHArgs, fmt.Sprintf("%s@%s", user, host))
-	// set port
-	args = append(args, "-p", fmt.Sprintf("%d", port))
-	// set key
-	args = append(args, "-i", key)
+// Bastion is a host through which the connection to the target host is established
+type Bastion struct {
+	Host string
+	Port int
+	User string
+	Auth
+}
 
-	client := &ExternalClient{
-		BinaryPath: sshBinaryPath,
-		BaseArgs:   args,
+// ProxyCommand returns the command that connects to the target host through the bastion,
+// to be used as the ProxyCommand option of the ssh binary found at sshBinaryPath.
+// The key of the bastion is verified against the knownHostsFile.
+func (b Bastion) ProxyCommand(sshBinaryPath string, knownHostsFile string) string {
+	args := []string{shellQuote(sshBinaryPath)}
+	args = append(args, baseSSHArgs...)
+	args = append(args, HostKeyCheckingArgs(knownHostsFile)...)
+	// encrypted keys are served by the ssh-agent, so that ssh does not prompt for their passphrase
+	if b.Key != "" && !EncryptedPrivateKey(b.Key) {
+		args = append(args, "-i", shellQuote(b.Key))
 	}
-
-	return client, nil
+	args = append(args, "-p", fmt.Sprintf("%d", b.Port), "-W", "%h:%p", shellQuote(fmt.Sprintf("%s@%s", b.User, b.Host)))
+	return strings.Join(args, " ")
 }
 
-// Output runs the ssh command and returns the output
-func (client *ExternalClient) Output(pty bool, args ...string) (string, error) {
-	args = append(client.BaseArgs, args...)
-	cmd := getSSHCmd(client.BinaryPath, pty, args...)
-	// for pseudo-tty and sudo to work correctly Stdin must be set to os.Stdin
-	if pty {
-		cmd.Stdin = os.Stdin
+// HostKeyCheckingArgs returns the arguments of the ssh binary that verify the host key
+// against the knownHostsFile. Host keys are not verified when the file is empty.
+func HostKeyCheckingArgs(knownHostsFile string) []string {
+	if knownHostsFile == "" {
+		return []string{"-o", "StrictHostKeyChecking=no", "-o", "UserKnownHostsFile=/dev/null"}
 	}
-	output, err := cmd.CombinedOutput()
-	return string(output), err
+	return []string{"-o", "StrictHostKeyChecking=yes", "-o", "UserKnownHostsFile=" + shellQuote(knownHostsFile)}
 }
 
-// Shell runs the ssh command, binding Stdin, Stdout and Stderr
-func (client *ExternalClient) Shell(pty bool, args ...string) error {
-	args = append(client.BaseArgs, args...)
-	cmd := getSSHCmd(client.BinaryPath, pty, args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+// shellQuote quotes the argument so that it is not interpreted by the shell
+func shellQuote(arg string) string {
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
 }
 
-func getSSHCmd(binaryPath string, pty bool, args ...string) *exec.Cmd {
-	if pty {
-		args = append([]string{"-t"}, args...)
-	}
-	return exec.Command(binaryPath, args...)
+// TestConnection connects to ip:port as user with key and immediately exits.
+func TestConnection(ip string, port int, user, key string) error {
+	return TestConnectionWithBastion(ip, port, user, Auth{Key: key}, nil)
 }
 
-// ValidUnencryptedPrivateKey parses SSH private key
-func ValidUnencryptedPrivateKey(file string) error {
-	// Check private key before use it
-	fi, err := os.Stat(file)
+// TestConnectionWithBastion connects to ip:port through the bastion as user with auth and immediately exits.
+func TestConnectionWithBastion(ip string, port int, user string, auth Auth, bastion *Bastion) error {
+	client, err := NewClientWithBastion(ip, port, user, auth, bastion)
 	if err != nil {
-		// Abort if key not accessible
 		return err
 	}
-
-	buffer, err := ioutil.ReadFile(file)
-	if err != nil {
-		return err
-	}
-
-	isEncrypted, err := isEncrypted(buffer)
-	if err != nil {
-		return fmt.Errorf("Parse SSH key error")
-	}
-
-	if isEncrypted {
-		return fmt.Errorf("Encrypted SSH key is not permitted")
-	}
-
-	_, err = ssh.ParsePrivateKey(buffer)
-	if err != nil {
-		return fmt.Errorf("Parse SSH key error: %v", err)
+	if out, err := client.Output(false, "exit"); err != nil {
+		return fmt.Errorf("%v %s", err, out)
 	}
+	return nil
+}
 
-	if runtime.GOOS != "windows" {
-		mode := fi.Mode()
+// NewClient returns an SSH client for the host. The connection to the host
+// is shared with the other clients of the host.
+func NewClient(host string, port int, user string, key string) (Client, error) {
+	return NewClientWithBastion(host, port, user, Auth{Key: key}, nil)
+}
 
-		// Private key file should have strict permissions
-		perm := mode.Perm()
-		if perm&0400 == 0 {
-			return fmt.Errorf("'%s' is not readable", file)
-		}
-		if perm&0077 != 0 {
-			return fmt.Errorf("permissions %#o for '%s' are too open. Permissions should be set to 0600.", perm, file)
-		}
-	}
+// NewClientWithBastion returns an SSH client that authenticates with auth, and connects
+// to the host through the bastion. The host is connected to directly when the bastion is nil.
+func NewClientWithBastion(host string, port int, user string, auth Auth, bastion *Bastion) (Client, error) {
+	return defaultPool.NewClient(host, port, user, auth, bastion)
+}
 
-	return nil
+// NewClientContext returns an SSH client like NewClientWithBastion, whose commands are
+// bound to the context. Its commands are killed when the context is cancelled.
+func NewClientContext(ctx context.Context, host string, port int, user string, auth Auth, bastion *Bastion) (Client, error) {
+	return defaultPool.NewClientContext(ctx, host, port, user, auth, bastion)
 }
 
-func isEncrypted(buffer []byte) (bool, error) {
-	// There is no error, just a nil block
-	block, _ := pem.Decode(buffer)
-	// File cannot be decoded, maybe it's some unexpected format
-	if block == nil {
-		return false, fmt.Errorf("Parse SSH key error")
-	}
+// ScanHostKey returns the key presented by the host, connecting through the bastion
+// when it is not nil
+func ScanHostKey(host string, port int, bastion *Bastion) (ssh.PublicKey, error) {
+	return defaultPool.ScanHostKey(host, port, bastion)
+}
 
-	return x509.IsEncryptedPEMBlock(block), nil
+// ScanHostKeyContext returns the key presented by the host like ScanHostKey,
+// and is aborted when the context is cancelled
+func ScanHostKeyContext(ctx context.Context, host string, port int, bastion *Bastion) (ssh.PublicKey, error) {
+	return defaultPool.ScanHostKeyContext(ctx, host, port, bastion)
 }
@@ -1,6 +1,9 @@
 package ssh
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestIsEncrypted(t *testing.T) {
 	for _, data := range testData {
@@ -14,6 +17,23 @@ func TestIsEncrypted(t *testing.T) {
 	}
 }
 
+func TestBastionProxyCommand(t *testing.T) {
+	b := Bastion{Host: "bastion.example.com", Port: 2222, User: "jump", Auth: Auth{Key: "/home/o'neil/key"}}
+	cmd := b.ProxyCommand("/usr/bin/ssh", "/tmp/generated/known_hosts")
+	if !strings.HasPrefix(cmd, "'/usr/bin/ssh' ") {
+		t.Errorf("expected the proxy command to start with the ssh binary, but got %q", cmd)
+	}
+	expectedSuffix := `-i '/home/o'\''neil/key' -p 2222 -W %h:%p 'jump@bastion.example.com'`
+	if !strings.HasSuffix(cmd, expectedSuffix) {
+		t.Errorf("expected the proxy command to end with %q, but got %q", expectedSuffix, cmd)
+	}
+	for _, opt := range []string{"-o StrictHostKeyChecking=yes", "-o UserKnownHostsFile='/tmp/generated/known_hosts'"} {
+		if !strings.Contains(cmd, opt) {
+			t.Errorf("expected the proxy command to contain %q, but got %q", opt, cmd)
+		}
+	}
+}
+
 var testData = []struct {
 	encrypted bool
 	pemData   []byte
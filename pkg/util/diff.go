@@ -0,0 +1,102 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DiffLine is a single line of a line-based diff
+type DiffLine struct {
+	// Op is "-" when the line was removed, "+" when the line was added,
+	// and " " when the line is unchanged.
+	Op   string
+	Text string
+}
+
+// DiffLines returns the line-based diff required to turn a into b
+func DiffLines(a, b string) []DiffLine {
+	al := splitLines(a)
+	bl := splitLines(b)
+	// lcs[i][j] is the length of the longest common subsequence of al[i:] and bl[j:]
+	lcs := make([][]int, len(al)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bl)+1)
+	}
+	for i := len(al) - 1; i >= 0; i-- {
+		for j := len(bl) - 1; j >= 0; j-- {
+			if al[i] == bl[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	diff := []DiffLine{}
+	i, j := 0, 0
+	for i < len(al) && j < len(bl) {
+		switch {
+		case al[i] == bl[j]:
+			diff = append(diff, DiffLine{Op: " ", Text: al[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{Op: "-", Text: al[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: "+", Text: bl[j]})
+			j++
+		}
+	}
+	for ; i < len(al); i++ {
+		diff = append(diff, DiffLine{Op: "-", Text: al[i]})
+	}
+	for ; j < len(bl); j++ {
+		diff = append(diff, DiffLine{Op: "+", Text: bl[j]})
+	}
+	return diff
+}
+
+// PrintDiff prints the lines that differ between a and b, along with the given
+// number of unchanged lines surrounding each change. Removed lines are printed
+// in red, and added lines in green.
+func PrintDiff(out io.Writer, a, b string, context int) {
+	diff := DiffLines(a, b)
+	// figure out which lines should be printed
+	show := make([]bool, len(diff))
+	for i, l := range diff {
+		if l.Op == " " {
+			continue
+		}
+		for j := i - context; j <= i+context; j++ {
+			if j >= 0 && j < len(diff) {
+				show[j] = true
+			}
+		}
+	}
+	for i, l := range diff {
+		if !show[i] {
+			continue
+		}
+		if i > 0 && !show[i-1] {
+			fmt.Fprintln(out, "...")
+		}
+		switch l.Op {
+		case "-":
+			PrintColor(out, Red, "- %s\n", l.Text)
+		case "+":
+			PrintColor(out, Green, "+ %s\n", l.Text)
+		default:
+			fmt.Fprintf(out, "  %s\n", l.Text)
+		}
+	}
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,46 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected []DiffLine
+	}{
+		{
+			a:        "",
+			b:        "",
+			expected: []DiffLine{},
+		},
+		{
+			a:        "foo\nbar\n",
+			b:        "foo\nbar\n",
+			expected: []DiffLine{{" ", "foo"}, {" ", "bar"}},
+		},
+		{
+			a:        "foo\nbar\n",
+			b:        "foo\nbaz\n",
+			expected: []DiffLine{{" ", "foo"}, {"-", "bar"}, {"+", "baz"}},
+		},
+		{
+			a:        "foo\nbar\nbaz",
+			b:        "bar\nbaz\nqux",
+			expected: []DiffLine{{"-", "foo"}, {" ", "bar"}, {" ", "baz"}, {"+", "qux"}},
+		},
+		{
+			a:        "",
+			b:        "foo",
+			expected: []DiffLine{{"+", "foo"}},
+		},
+	}
+	for i, test := range tests {
+		diff := DiffLines(test.a, test.b)
+		if !reflect.DeepEqual(diff, test.expected) {
+			t.Errorf("test %d: expected %v, got %v", i, test.expected, diff)
+		}
+	}
+}
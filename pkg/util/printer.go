@@ -143,3 +143,10 @@ func PrintValidationErrors(out io.Writer, errors []error) {
 		PrintColor(out, Red, "- %v\n", err)
 	}
 }
+
+// PrintValidationWarnings loops through the warnings
+func PrintValidationWarnings(out io.Writer, warnings []error) {
+	for _, w := range warnings {
+		PrintColor(out, Orange, "- %v\n", w)
+	}
+}